@@ -458,6 +458,17 @@ func RemoveMutePersistency(guildID string, userID string) (err error) {
 	return persistencyRemoveCachedRole(guildID, userID, muteRole.ID)
 }
 
+// AddMutePersistency adds the mute role to the cached roles of the member,
+// so the persistency module applies it again if the member leaves before their role update got cached
+func AddMutePersistency(guildID string, userID string) (err error) {
+	muteRole, err := GetMuteRole(guildID)
+	if err != nil {
+		return err
+	}
+
+	return persistencyAddCachedRole(guildID, userID, muteRole.ID)
+}
+
 func UnmuteUser(guildID string, userID string) (err error) {
 	errRole := RemoveMuteRole(guildID, userID)
 	errDatabase := RemoveMuteDatabase(guildID, userID)
@@ -549,6 +560,37 @@ func persistencyRemoveCachedRole(GuildID string, UserID string, roleID string) (
 	return err
 }
 
+func persistencyAddCachedRole(GuildID string, UserID string, roleID string) (err error) {
+	key := "robyul2-discord:persistency:" + GuildID + ":" + UserID + ":roles"
+	var redisRoleIDs []string
+
+	marshalled, err := cache.GetRedisClient().Get(key).Bytes()
+	if err != nil && !strings.Contains(err.Error(), "redis: nil") {
+		return err
+	}
+	if err == nil {
+		err = msgpack.Unmarshal(marshalled, &redisRoleIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, redisRoleID := range redisRoleIDs {
+		if redisRoleID == roleID {
+			return nil
+		}
+	}
+	redisRoleIDs = append(redisRoleIDs, roleID)
+
+	marshalled, err = msgpack.Marshal(redisRoleIDs)
+	if err != nil {
+		return
+	}
+	err = cache.GetRedisClient().Set(key, marshalled, 0).Err()
+
+	return err
+}
+
 func LogMachineryError(errorMessage string) (err error) {
 	cache.GetLogger().WithField("module", "machinery").Error("Task Failed: ", errorMessage)
 	raven.CaptureError(errors.New(errorMessage), map[string]string{})
@@ -737,6 +779,51 @@ func GetAllPermissions(guild *discordgo.Guild, member *discordgo.Member) int64 {
 	return perms
 }
 
+var permissionNames = []struct {
+	Permission int
+	Name       string
+}{
+	{discordgo.PermissionCreateInstantInvite, "Create Instant Invite"},
+	{discordgo.PermissionKickMembers, "Kick Members"},
+	{discordgo.PermissionBanMembers, "Ban Members"},
+	{discordgo.PermissionAdministrator, "Administrator"},
+	{discordgo.PermissionManageChannels, "Manage Channels"},
+	{discordgo.PermissionManageServer, "Manage Server"},
+	{discordgo.PermissionAddReactions, "Add Reactions"},
+	{discordgo.PermissionViewAuditLogs, "View Audit Log"},
+	{discordgo.PermissionReadMessages, "Read Messages"},
+	{discordgo.PermissionSendMessages, "Send Messages"},
+	{discordgo.PermissionSendTTSMessages, "Send TTS Messages"},
+	{discordgo.PermissionManageMessages, "Manage Messages"},
+	{discordgo.PermissionEmbedLinks, "Embed Links"},
+	{discordgo.PermissionAttachFiles, "Attach Files"},
+	{discordgo.PermissionReadMessageHistory, "Read Message History"},
+	{discordgo.PermissionMentionEveryone, "Mention Everyone"},
+	{discordgo.PermissionUseExternalEmojis, "Use External Emojis"},
+	{discordgo.PermissionVoiceConnect, "Connect"},
+	{discordgo.PermissionVoiceSpeak, "Speak"},
+	{discordgo.PermissionVoiceMuteMembers, "Mute Members"},
+	{discordgo.PermissionVoiceDeafenMembers, "Deafen Members"},
+	{discordgo.PermissionVoiceMoveMembers, "Move Members"},
+	{discordgo.PermissionVoiceUseVAD, "Use Voice Activity"},
+	{discordgo.PermissionChangeNickname, "Change Nickname"},
+	{discordgo.PermissionManageNicknames, "Manage Nicknames"},
+	{discordgo.PermissionManageRoles, "Manage Roles"},
+	{discordgo.PermissionManageWebhooks, "Manage Webhooks"},
+	{discordgo.PermissionManageEmojis, "Manage Emojis"},
+}
+
+// GetPermissionNames returns the readable names of all permissions set in the given permission bitfield
+func GetPermissionNames(permissions int) (names []string) {
+	names = make([]string, 0)
+	for _, permission := range permissionNames {
+		if permissions&permission.Permission == permission.Permission {
+			names = append(names, permission.Name)
+		}
+	}
+	return names
+}
+
 func Pagify(text string, delimiter string) []string {
 	result := make([]string, 0)
 	textParts := strings.Split(text, delimiter)
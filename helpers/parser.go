@@ -34,3 +34,16 @@ func ParseKeyValueString(text string) (data map[string]string) {
 	}
 	return data
 }
+
+// TextAfterFields returns the text after the first n whitespace separated fields, keeping line breaks inside the text
+func TextAfterFields(text string, n int) string {
+	for i := 0; i < n; i++ {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		text = text[end:]
+	}
+	return strings.TrimSpace(text)
+}
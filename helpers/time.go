@@ -144,3 +144,15 @@ func HumanizeDuration(d time.Duration) (result string) {
 	}
 	return result
 }
+
+// ParseDuration parses durations like 90m, 12h or 7d
+func ParseDuration(text string) (duration time.Duration, err error) {
+	if strings.HasSuffix(text, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(text)
+}
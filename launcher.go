@@ -281,9 +281,10 @@ func main() {
 	}
 	log.WithField("module", "launcher").Info("started machinery server, default queue: robyul_tasks")
 	machineryServer.RegisterTasks(map[string]interface{}{
-		"unmute_user":    helpers.UnmuteUser,
-		"apply_autorole": plugins.AutoroleApply,
-		"log_error":      helpers.LogMachineryError,
+		"unmute_user":       helpers.UnmuteUser,
+		"apply_autorole":    plugins.AutoroleApply,
+		"verification_kick": plugins.VerificationKick,
+		"log_error":         helpers.LogMachineryError,
 	})
 	cache.SetMachineryServer(machineryServer)
 	worker := machineryServer.NewWorker("robyul_worker_1", 1)
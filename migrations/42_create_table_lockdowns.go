@@ -0,0 +1,12 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m42_create_table_lockdowns() {
+	CreateTableIfNotExists("lockdowns")
+
+	rethink.Table("lockdowns").IndexCreate("guild_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m43_create_table_mod_notes() {
+	CreateTableIfNotExists("mod_notes")
+
+	rethink.Table("mod_notes").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("mod_notes").IndexCreate("user_id").Run(helpers.GetDB())
+}
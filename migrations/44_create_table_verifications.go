@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m44_create_table_verifications() {
+	CreateTableIfNotExists("verifications")
+
+	rethink.Table("verifications").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("verifications").IndexCreate("user_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,12 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m45_create_table_guild_backups() {
+	CreateTableIfNotExists("guild_backups")
+
+	rethink.Table("guild_backups").IndexCreate("guild_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m46_create_table_audit_log_entries() {
+	CreateTableIfNotExists("audit_log_entries")
+
+	rethink.Table("audit_log_entries").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("audit_log_entries").IndexCreate("user_id").Run(helpers.GetDB())
+	rethink.Table("audit_log_entries").IndexCreate("target_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m47_create_table_evasion_bans() {
+	CreateTableIfNotExists("evasion_bans")
+
+	rethink.Table("evasion_bans").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("evasion_bans").IndexCreate("user_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m48_create_table_evasion_alerts() {
+	CreateTableIfNotExists("evasion_alerts")
+
+	rethink.Table("evasion_alerts").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("evasion_alerts").IndexCreate("message_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m49_create_table_modmail_threads() {
+	CreateTableIfNotExists("modmail_threads")
+
+	rethink.Table("modmail_threads").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("modmail_threads").IndexCreate("user_id").Run(helpers.GetDB())
+	rethink.Table("modmail_threads").IndexCreate("channel_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m50_create_table_ban_cases() {
+	CreateTableIfNotExists("ban_cases")
+
+	rethink.Table("ban_cases").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("ban_cases").IndexCreate("user_id").Run(helpers.GetDB())
+	rethink.Table("ban_cases").IndexCreate("appeal_code").Run(helpers.GetDB())
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m51_create_table_ban_appeals() {
+	CreateTableIfNotExists("ban_appeals")
+
+	rethink.Table("ban_appeals").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("ban_appeals").IndexCreate("case_id").Run(helpers.GetDB())
+	rethink.Table("ban_appeals").IndexCreate("message_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,12 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m52_create_table_levels_multiplier_events() {
+	CreateTableIfNotExists("levels_multiplier_events")
+
+	rethink.Table("levels_multiplier_events").IndexCreate("guild_id").Run(helpers.GetDB())
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m53_create_table_levels_exp_history() {
+	CreateTableIfNotExists("levels_exp_history")
+
+	rethink.Table("levels_exp_history").IndexCreate("day").Run(helpers.GetDB())
+	rethink.Table("levels_exp_history").IndexCreateFunc("guild_id_day", func(row rethink.Term) interface{} {
+		return []interface{}{row.Field("guild_id"), row.Field("day")}
+	}).Run(helpers.GetDB())
+}
@@ -0,0 +1,12 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m54_create_table_levels_seasons() {
+	CreateTableIfNotExists("levels_seasons")
+
+	rethink.Table("levels_seasons").IndexCreate("guild_id").Run(helpers.GetDB())
+}
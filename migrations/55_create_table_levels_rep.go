@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m55_create_table_levels_rep() {
+	CreateTableIfNotExists("levels_rep")
+
+	rethink.Table("levels_rep").IndexCreate("guild_id").Run(helpers.GetDB())
+	rethink.Table("levels_rep").IndexCreate("giver_user_id").Run(helpers.GetDB())
+	rethink.Table("levels_rep").IndexCreate("receiver_user_id").Run(helpers.GetDB())
+}
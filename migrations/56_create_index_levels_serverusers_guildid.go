@@ -0,0 +1,10 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m56_create_index_levels_serverusers_guildid() {
+	rethink.Table("levels_serverusers").IndexCreate("guildid").Run(helpers.GetDB())
+}
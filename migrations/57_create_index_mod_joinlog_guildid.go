@@ -0,0 +1,10 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	rethink "github.com/gorethink/gorethink"
+)
+
+func m57_create_index_mod_joinlog_guildid() {
+	rethink.Table("mod_joinlog").IndexCreate("guildid").Run(helpers.GetDB())
+}
@@ -51,6 +51,22 @@ var migrations = []helpers.Callback{
 	m39_create_table_donators,
 	m40_create_table_bot_config,
 	m41_create_table_bot_status,
+	m42_create_table_lockdowns,
+	m43_create_table_mod_notes,
+	m44_create_table_verifications,
+	m45_create_table_guild_backups,
+	m46_create_table_audit_log_entries,
+	m47_create_table_evasion_bans,
+	m48_create_table_evasion_alerts,
+	m49_create_table_modmail_threads,
+	m50_create_table_ban_cases,
+	m51_create_table_ban_appeals,
+	m52_create_table_levels_multiplier_events,
+	m53_create_table_levels_exp_history,
+	m54_create_table_levels_seasons,
+	m55_create_table_levels_rep,
+	m56_create_index_levels_serverusers_guildid,
+	m57_create_index_mod_joinlog_guildid,
 }
 
 // Run executes all registered migrations
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+const (
+	AuditLogTable = "audit_log_entries"
+)
+
+// AuditLogActionTypes maps the discord audit log action types to readable names
+var AuditLogActionTypes = map[int]string{
+	1:  "guild-update",
+	10: "channel-create",
+	11: "channel-update",
+	12: "channel-delete",
+	13: "overwrite-create",
+	14: "overwrite-update",
+	15: "overwrite-delete",
+	20: "member-kick",
+	21: "member-prune",
+	22: "member-ban",
+	23: "member-unban",
+	24: "member-update",
+	25: "member-role-update",
+	30: "role-create",
+	31: "role-update",
+	32: "role-delete",
+	40: "invite-create",
+	41: "invite-update",
+	42: "invite-delete",
+	50: "webhook-create",
+	51: "webhook-update",
+	52: "webhook-delete",
+	60: "emoji-create",
+	61: "emoji-update",
+	62: "emoji-delete",
+	72: "message-delete",
+}
+
+type AuditLogEntry struct {
+	ID         string           `rethink:"id"` // the discord audit log entry id
+	GuildID    string           `rethink:"guild_id"`
+	UserID     string           `rethink:"user_id"`
+	TargetID   string           `rethink:"target_id"`
+	ActionType int              `rethink:"action_type"`
+	Reason     string           `rethink:"reason"`
+	Changes    []AuditLogChange `rethink:"changes"`
+	Text       string           `rethink:"text"`
+	CreatedAt  time.Time        `rethink:"created_at"`
+}
+
+type AuditLogChange struct {
+	Key      string `rethink:"key"`
+	OldValue string `rethink:"old_value"`
+	NewValue string `rethink:"new_value"`
+}
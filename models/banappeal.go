@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	BanCasesTable   = "ban_cases"
+	BanAppealsTable = "ban_appeals"
+)
+
+// BanCaseEntry is a ban issued through the ban command
+type BanCaseEntry struct {
+	ID          string    `rethink:"id,omitempty"`
+	GuildID     string    `rethink:"guild_id"`
+	UserID      string    `rethink:"user_id"`
+	ModeratorID string    `rethink:"moderator_id"`
+	Reason      string    `rethink:"reason"`
+	AppealCode  string    `rethink:"appeal_code"` // empty if appeals were disabled when the ban happened
+	CreatedAt   time.Time `rethink:"created_at"`
+}
+
+const (
+	BanAppealStatusPending  = "pending"
+	BanAppealStatusApproved = "approved"
+	BanAppealStatusDenied   = "denied"
+)
+
+type BanAppealEntry struct {
+	ID              string    `rethink:"id,omitempty"`
+	CaseID          string    `rethink:"case_id"`
+	GuildID         string    `rethink:"guild_id"`
+	UserID          string    `rethink:"user_id"`
+	Message         string    `rethink:"message"`
+	ChannelID       string    `rethink:"channel_id"`
+	MessageID       string    `rethink:"message_id"`
+	Status          string    `rethink:"status"`
+	HandledByUserID string    `rethink:"handled_by_user_id"`
+	HandledAt       time.Time `rethink:"handled_at"`
+	CreatedAt       time.Time `rethink:"created_at"`
+}
@@ -34,6 +34,17 @@ type Config struct {
 		UserMultipleJoins        bool
 	} `rethink:"inspect_triggers_enabled"`
 	InspectsChannel string `rethink:"inspects_channel"`
+	// InspectScoring replaces the inspect triggers with weighted signals if enabled
+	InspectScoring struct {
+		Enabled                 bool
+		BannedOnOtherServers    int // per server
+		NewlyCreatedAccount     int
+		NewlyCreatedAccountDays int
+		NoCommonServers         int
+		Reported                int // per report
+		MultipleJoins           int // per join after the first one
+		Actions                 []InspectScoreAction
+	} `rethink:"inspect_scoring"`
 
 	NukeIsParticipating bool   `rethink:"nuke_participation"`
 	NukeLogChannel      string `rethink:"nuke_channel"`
@@ -48,6 +59,30 @@ type Config struct {
 
 	LevelsMaxBadges int `rethink:"levels_maxbadges"`
 
+	LevelsCurve            string  `rethink:"levels_curve"`            // quadratic (default), linear, exponential or custom
+	LevelsCurveThresholds  []int64 `rethink:"levels_curve_thresholds"` // EXP required for each level, used by the custom curve
+	LevelsExpPerMessageMin int     `rethink:"levels_exp_per_message_min"`
+	LevelsExpPerMessageMax int     `rethink:"levels_exp_per_message_max"`
+
+	LevelsMultipliers []LevelsMultiplier `rethink:"levels_multipliers"`
+
+	LevelsVoiceExpPerMinute int `rethink:"levels_voice_exp_per_minute"` // 0 disables voice EXP
+	LevelsVoiceExpDailyCap  int `rethink:"levels_voice_exp_daily_cap"`  // 0 for no cap
+
+	LevelsAnnouncementsDestination string `rethink:"levels_announcements_destination"` // empty (disabled), current, channel or dm
+	LevelsAnnouncementsChannelID   string `rethink:"levels_announcements_channel_id"`
+	LevelsAnnouncementsTemplate    string `rethink:"levels_announcements_template"`
+	LevelsAnnouncementsImage       bool   `rethink:"levels_announcements_image"`
+	LevelsAnnouncementsEvery       int    `rethink:"levels_announcements_every"` // only announce every n levels
+
+	LevelsSeasonRewardRoleID string `rethink:"levels_season_reward_role_id"`
+	LevelsSeasonRewardTopN   int    `rethink:"levels_season_reward_top_n"`
+
+	LevelsAntiFarming                string `rethink:"levels_anti_farming"` // empty (disabled), low, medium or high
+	LevelsAntiFarmingReportChannelID string `rethink:"levels_anti_farming_report_channel_id"`
+
+	LevelsRepCooldownHours int `rethink:"levels_rep_cooldown_hours"` // 0 for the default cooldown
+
 	AutoRoleIDs      []string          `rethink:"autorole_roleids"`
 	DelayedAutoRoles []DelayedAutoRole `rethink:"delayed_autoroles"`
 
@@ -62,8 +97,80 @@ type Config struct {
 
 	RandomPicturesPicDelay                  int      `rethink:"randompictures_pic_delay"`
 	RandomPicturesPicDelayIgnoredChannelIDs []string `rethink:"randompictures_pic_delay_ignored_channelids"`
+
+	RaidDetectionEnabled       bool `rethink:"raid_detection_enabled"`
+	RaidDetectionJoins         int  `rethink:"raid_detection_joins"`
+	RaidDetectionSeconds       int  `rethink:"raid_detection_seconds"`
+	RaidDetectionMaxAccountAge int  `rethink:"raid_detection_max_account_age"` // in days, 0 counts every account
+
+	RaidModeEnabled                   bool      `rethink:"raid_mode_enabled"`
+	RaidModeEnabledAt                 time.Time `rethink:"raid_mode_enabled_at"`
+	RaidModeAutoEnable                bool      `rethink:"raid_mode_auto_enable"`
+	RaidModeAction                    string    `rethink:"raid_mode_action"` // "kick", "mute" or ""
+	RaidModeRaiseVerification         bool      `rethink:"raid_mode_raise_verification"`
+	RaidModeLockChannels              bool      `rethink:"raid_mode_lock_channels"`
+	RaidModePreviousVerificationLevel int       `rethink:"raid_mode_previous_verification_level"`
+	RaidModeVerificationRaised        bool      `rethink:"raid_mode_verification_raised"` // true if raid mode changed the verification level
+
+	ModLogChannelID string `rethink:"mod_log_channel_id"`
+
+	VerificationEnabled   bool          `rethink:"verification_enabled"`
+	VerificationMode      string        `rethink:"verification_mode"` // "reaction", "captcha" or "code"
+	VerificationRoleID    string        `rethink:"verification_role_id"`
+	VerificationChannelID string        `rethink:"verification_channel_id"`
+	VerificationMessageID string        `rethink:"verification_message_id"`
+	VerificationEmoji     string        `rethink:"verification_emoji"`
+	VerificationKickAfter time.Duration `rethink:"verification_kick_after"` // 0 never kicks
+
+	MessageLogChannelID         string   `rethink:"message_log_channel_id"`
+	MessageLogIgnoredChannelIDs []string `rethink:"message_log_ignored_channel_ids"`
+	MessageLogIgnoredUserIDs    []string `rethink:"message_log_ignored_user_ids"`
+
+	AuditLogChannelID string `rethink:"audit_log_channel_id"`
+
+	EvasionDetectionEnabled bool `rethink:"evasion_detection_enabled"`
+	EvasionDetectionSignals struct {
+		SimilarNames   bool
+		SameAvatar     bool
+		CreatedNearBan bool
+		SameInvite     bool
+	} `rethink:"evasion_detection_signals"`
+	EvasionDetectionThreshold int `rethink:"evasion_detection_threshold"` // minimum confidence in percent, 0 uses the default
+
+	ModmailEnabled        bool             `rethink:"modmail_enabled"`
+	ModmailCategoryID     string           `rethink:"modmail_category_id"`
+	ModmailLogChannelID   string           `rethink:"modmail_log_channel_id"`
+	ModmailAnonymous      bool             `rethink:"modmail_anonymous"` // hide the names of staff members in replies
+	ModmailBlockedUserIDs []string         `rethink:"modmail_blocked_user_ids"`
+	ModmailSnippets       []ModmailSnippet `rethink:"modmail_snippets"`
+
+	BanAppealsChannelID string `rethink:"ban_appeals_channel_id"` // empty disables appeals
+}
+
+const (
+	InspectScoreActionNotify = "notify"
+	InspectScoreActionMute   = "mute"
+	InspectScoreActionKick   = "kick"
+)
+
+// InspectScoreAction is run if the inspect score of a joining member reaches the threshold
+type InspectScoreAction struct {
+	Threshold int
+	Action    string
 }
 
+// LevelsMultiplier multiplies the EXP members get for messages with the role or in the channel
+type LevelsMultiplier struct {
+	Type       string // role or channel
+	TargetID   string
+	Multiplier float64
+}
+
+const (
+	LevelsMultiplierTypeRole    = "role"
+	LevelsMultiplierTypeChannel = "channel"
+)
+
 type DelayedAutoRole struct {
 	RoleID string
 	Delay  time.Duration
@@ -11,4 +11,5 @@ type DonatorEntry struct {
 	Name          string    `gorethink:"name"`
 	HeartOverride string    `gorethink:"heart_override"`
 	AddedAt       time.Time `gorethink:"added_at"`
+	UserID        string    `gorethink:"user_id"` // optional, used for donator badges
 }
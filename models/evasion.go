@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	EvasionBansTable   = "evasion_bans"
+	EvasionAlertsTable = "evasion_alerts"
+)
+
+// EvasionBanEntry is a snapshot of a banned user, taken when the ban happened
+type EvasionBanEntry struct {
+	ID         string    `rethink:"id,omitempty"`
+	GuildID    string    `rethink:"guild_id"`
+	UserID     string    `rethink:"user_id"`
+	BannedAt   time.Time `rethink:"banned_at"`
+	Names      []string  `rethink:"names"` // usernames and nicknames
+	AvatarHash string    `rethink:"avatar_hash"`
+	InviteCode string    `rethink:"invite_code"`
+}
+
+const (
+	EvasionAlertStatusOpen    = "open"
+	EvasionAlertStatusBanned  = "banned"
+	EvasionAlertStatusIgnored = "ignored"
+)
+
+type EvasionAlertEntry struct {
+	ID              string    `rethink:"id,omitempty"`
+	GuildID         string    `rethink:"guild_id"`
+	UserID          string    `rethink:"user_id"`
+	BannedUserID    string    `rethink:"banned_user_id"`
+	Confidence      int       `rethink:"confidence"`
+	ChannelID       string    `rethink:"channel_id"`
+	MessageID       string    `rethink:"message_id"`
+	Status          string    `rethink:"status"`
+	HandledByUserID string    `rethink:"handled_by_user_id"`
+	CreatedAt       time.Time `rethink:"created_at"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+const (
+	GuildBackupsTable = "guild_backups"
+)
+
+type GuildBackupEntry struct {
+	ID              string              `rethink:"id,omitempty"`
+	GuildID         string              `rethink:"guild_id"`
+	CreatedByUserID string              `rethink:"created_by_userid"`
+	CreatedAt       time.Time           `rethink:"created_at"`
+	Version         int                 `rethink:"version"` // version of the snapshot format
+	Snapshot        GuildBackupSnapshot `rethink:"snapshot"`
+}
+
+type GuildBackupSnapshot struct {
+	Name              string               `rethink:"name"`
+	Region            string               `rethink:"region"`
+	VerificationLevel int                  `rethink:"verification_level"`
+	Roles             []GuildBackupRole    `rethink:"roles"`
+	Channels          []GuildBackupChannel `rethink:"channels"` // includes categories
+	Emoji             []GuildBackupEmoji   `rethink:"emoji"`
+	Settings          Config               `rethink:"settings"`
+}
+
+type GuildBackupRole struct {
+	ID          string `rethink:"id"`
+	Name        string `rethink:"name"`
+	Color       int    `rethink:"color"`
+	Hoist       bool   `rethink:"hoist"`
+	Mentionable bool   `rethink:"mentionable"`
+	Managed     bool   `rethink:"managed"`
+	Permissions int    `rethink:"permissions"`
+	Position    int    `rethink:"position"`
+}
+
+type GuildBackupChannel struct {
+	ID         string                 `rethink:"id"`
+	Name       string                 `rethink:"name"`
+	Type       int                    `rethink:"type"`
+	Topic      string                 `rethink:"topic"`
+	NSFW       bool                   `rethink:"nsfw"`
+	Position   int                    `rethink:"position"`
+	Bitrate    int                    `rethink:"bitrate"`
+	ParentID   string                 `rethink:"parent_id"`
+	Overwrites []GuildBackupOverwrite `rethink:"overwrites"`
+}
+
+type GuildBackupOverwrite struct {
+	ID    string `rethink:"id"`
+	Type  string `rethink:"type"` // "role" or "member"
+	Allow int    `rethink:"allow"`
+	Deny  int    `rethink:"deny"`
+}
+
+type GuildBackupEmoji struct {
+	ID            string   `rethink:"id"`
+	Name          string   `rethink:"name"`
+	Roles         []string `rethink:"roles"`
+	Managed       bool     `rethink:"managed"`
+	RequireColons bool     `rethink:"require_colons"`
+	Animated      bool     `rethink:"animated"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	LevelsMultiplierEventsTable = "levels_multiplier_events"
+)
+
+type LevelsMultiplierEventEntry struct {
+	ID              string    `rethink:"id,omitempty"`
+	GuildID         string    `rethink:"guild_id"`
+	Name            string    `rethink:"name"`
+	Multiplier      float64   `rethink:"multiplier"`
+	ChannelID       string    `rethink:"channel_id"` // announcement channel, can be empty
+	CreatedByUserID string    `rethink:"created_by_user_id"`
+	StartsAt        time.Time `rethink:"starts_at"`
+	EndsAt          time.Time `rethink:"ends_at"`
+	Started         bool      `rethink:"started"`
+	Ended           bool      `rethink:"ended"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	LevelsRepTable = "levels_rep"
+)
+
+// LevelsRepEntry is a reputation point a user gave to another user
+type LevelsRepEntry struct {
+	ID              string    `rethink:"id,omitempty"`
+	GuildID         string    `rethink:"guild_id"`
+	GiverUserID     string    `rethink:"giver_user_id"`
+	ReceiverUserID  string    `rethink:"receiver_user_id"`
+	Reason          string    `rethink:"reason"`
+	GivenAt         time.Time `rethink:"given_at"`
+	Revoked         bool      `rethink:"revoked"`
+	RevokedByUserID string    `rethink:"revoked_by_user_id"`
+	RevokedAt       time.Time `rethink:"revoked_at"`
+}
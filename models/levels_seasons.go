@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	LevelsExpHistoryTable = "levels_exp_history"
+	LevelsSeasonsTable    = "levels_seasons"
+)
+
+// LevelsExpHistoryEntry is the EXP a user gained on a guild on one UTC day
+type LevelsExpHistoryEntry struct {
+	ID      string `rethink:"id,omitempty"` // <guild id>-<user id>-<day>
+	GuildID string `rethink:"guild_id"`
+	UserID  string `rethink:"user_id"`
+	Day     string `rethink:"day"` // 2006-01-02
+	Exp     int64  `rethink:"exp"`
+}
+
+type LevelsSeasonEntry struct {
+	ID              string               `rethink:"id,omitempty"`
+	GuildID         string               `rethink:"guild_id"`
+	Name            string               `rethink:"name"`
+	StartedByUserID string               `rethink:"started_by_user_id"`
+	StartedAt       time.Time            `rethink:"started_at"`
+	FirstDayExp     map[string]int64     `rethink:"first_day_exp"` // EXP per user gained on the day the season started before it started
+	EndsAt          time.Time            `rethink:"ends_at"`       // zero if the season has to be ended manually
+	EndedAt         time.Time            `rethink:"ended_at"`
+	Active          bool                 `rethink:"active"`
+	RewardRoleID    string               `rethink:"reward_role_id"`
+	RewardTopN      int                  `rethink:"reward_top_n"`
+	RewardsPending  bool                 `rethink:"rewards_pending"` // the season ended before all members of the guild were cached
+	Results         []LevelsSeasonResult `rethink:"results"`
+}
+
+type LevelsSeasonResult struct {
+	UserID  string `rethink:"user_id"`
+	Exp     int64  `rethink:"exp"`
+	Ranking int    `rethink:"ranking"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	LockdownsTable = "lockdowns"
+)
+
+type LockdownEntry struct {
+	ID              string            `rethink:"id,omitempty"`
+	GuildID         string            `rethink:"guild_id"`
+	CreatedByUserID string            `rethink:"created_by_userid"`
+	CreatedAt       time.Time         `rethink:"created_at"`
+	RaidMode        bool              `rethink:"raid_mode"`
+	Channels        []LockdownChannel `rethink:"channels"`
+}
+
+type LockdownChannel struct {
+	ChannelID  string              `rethink:"channel_id"`
+	Overwrites []LockdownOverwrite `rethink:"overwrites"`
+}
+
+type LockdownOverwrite struct {
+	ID    string `rethink:"id"`
+	Type  string `rethink:"type"` // "role" or "member"
+	Allow int    `rethink:"allow"`
+	Deny  int    `rethink:"deny"`
+}
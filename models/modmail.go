@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	ModmailThreadsTable = "modmail_threads"
+)
+
+type ModmailThreadEntry struct {
+	ID        string           `rethink:"id,omitempty"`
+	GuildID   string           `rethink:"guild_id"`
+	UserID    string           `rethink:"user_id"`
+	ChannelID string           `rethink:"channel_id"`
+	Open      bool             `rethink:"open"`
+	CreatedAt time.Time        `rethink:"created_at"`
+	ClosedAt  time.Time        `rethink:"closed_at"`
+	ClosedBy  string           `rethink:"closed_by"`
+	Messages  []ModmailMessage `rethink:"messages"`
+}
+
+type ModmailMessage struct {
+	AuthorID    string    `rethink:"author_id"`
+	AuthorName  string    `rethink:"author_name"`
+	FromStaff   bool      `rethink:"from_staff"`
+	Anonymous   bool      `rethink:"anonymous"`
+	Content     string    `rethink:"content"`
+	Attachments []string  `rethink:"attachments"`
+	CreatedAt   time.Time `rethink:"created_at"`
+}
+
+// ModmailSnippet is a canned reply staff can send in modmail threads
+type ModmailSnippet struct {
+	Name string `rethink:"name"`
+	Text string `rethink:"text"`
+}
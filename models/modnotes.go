@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+const (
+	ModNotesTable = "mod_notes"
+)
+
+type ModNoteEntry struct {
+	ID           string    `rethink:"id,omitempty"`
+	GuildID      string    `rethink:"guild_id"`
+	UserID       string    `rethink:"user_id"`
+	AuthorUserID string    `rethink:"author_user_id"`
+	CreatedAt    time.Time `rethink:"created_at"`
+	Text         string    `rethink:"text"`
+}
@@ -134,6 +134,27 @@ type Rest_Chatlog_Message struct {
 	Embeds         int
 }
 
+type Rest_ModNote struct {
+	ID           string
+	GuildID      string
+	UserID       string
+	AuthorUserID string
+	CreatedAt    time.Time
+	Text         string
+}
+
+type Rest_AuditLogEntry struct {
+	ID         string
+	GuildID    string
+	UserID     string
+	TargetID   string
+	ActionType int
+	Action     string
+	Reason     string
+	Text       string
+	CreatedAt  time.Time
+}
+
 const (
 	Redis_Key_Feature_Levels_Badges  = "robyul2-discord:feature:levels-badges:server:%s"
 	Redis_Key_Feature_RandomPictures = "robyul2-discord:feature:randompictures:server:%s"
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+const (
+	VerificationsTable = "verifications"
+)
+
+type VerificationEntry struct {
+	ID              string    `rethink:"id,omitempty"`
+	GuildID         string    `rethink:"guild_id"`
+	UserID          string    `rethink:"user_id"`
+	Mode            string    `rethink:"mode"`
+	Code            string    `rethink:"code"`
+	PromptChannelID string    `rethink:"prompt_channel_id"`
+	PromptMessageID string    `rethink:"prompt_message_id"`
+	CreatedAt       time.Time `rethink:"created_at"`
+}
@@ -70,6 +70,10 @@ var (
 		&plugins.Ping{},
 		&google.Handler{},
 		&plugins.BotStatus{},
+		&plugins.Backup{},
+		&plugins.AuditLog{},
+		&plugins.Permissions{},
+		&plugins.MassBan{},
 	}
 
 	// PluginList is the list of active plugins
@@ -89,6 +93,12 @@ var (
 		&plugins.Persistency{},
 		&plugins.DM{},
 		&plugins.Twitter{},
+		&plugins.Raid{},
+		&plugins.Verification{},
+		&plugins.MessageLog{},
+		&plugins.Evasion{},
+		&plugins.Modmail{},
+		&plugins.BanAppeals{},
 	}
 
 	// TriggerPluginList is the list of plugins that activate on normal chat
@@ -0,0 +1,678 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type auditLogAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next auditLogAction)
+
+type AuditLog struct{}
+
+type auditLogResponse struct {
+	Users   []*discordgo.User  `json:"users"`
+	Entries []auditLogRawEntry `json:"audit_log_entries"`
+}
+
+type auditLogRawEntry struct {
+	ID         string              `json:"id"`
+	UserID     string              `json:"user_id"`
+	TargetID   string              `json:"target_id"`
+	ActionType int                 `json:"action_type"`
+	Reason     string              `json:"reason"`
+	Changes    []auditLogRawChange `json:"changes"`
+	Options    *auditLogRawOptions `json:"options"`
+}
+
+type auditLogRawChange struct {
+	Key      string          `json:"key"`
+	OldValue json.RawMessage `json:"old_value"`
+	NewValue json.RawMessage `json:"new_value"`
+}
+
+type auditLogRawOptions struct {
+	DeleteMemberDays string `json:"delete_member_days"`
+	MembersRemoved   string `json:"members_removed"`
+	ChannelID        string `json:"channel_id"`
+	Count            string `json:"count"`
+	ID               string `json:"id"`
+	Type             string `json:"type"`
+	RoleName         string `json:"role_name"`
+}
+
+type auditLogRawRole struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+const (
+	auditLogPollInterval = 60 * time.Second
+	// entries older than this are stored, but not posted, to avoid flooding the channel after enabling the watcher or downtimes
+	auditLogPostWindow   = 15 * time.Minute
+	auditLogSearchLimit  = 50
+	auditLogRecentLimit  = 10
+	auditLogFetchLimit   = 100
+	auditLogColorCreate  = 0x2ECC71
+	auditLogColorUpdate  = 0xF1C40F
+	auditLogColorDelete  = 0xE74C3C
+	auditLogColorNeutral = 0x3498DB
+)
+
+var (
+	auditLogLastSeen      map[string]string
+	auditLogLastSeenMutex sync.Mutex
+)
+
+func (a *AuditLog) Commands() []string {
+	return []string{
+		"audit-log",
+	}
+}
+
+func (a *AuditLog) Init(session *discordgo.Session) {
+	auditLogLastSeenMutex.Lock()
+	auditLogLastSeen = make(map[string]string, 0)
+	auditLogLastSeenMutex.Unlock()
+
+	go a.watchLoop(session)
+	a.logger().Info("Started audit log loop (60s)")
+}
+
+func (a *AuditLog) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := a.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (a *AuditLog) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) auditLogAction {
+	if len(args) < 1 {
+		return a.actionRecent
+	}
+
+	switch args[0] {
+	case "channel":
+		return a.actionChannel
+	case "disable":
+		return a.actionDisable
+	case "search":
+		return a.actionSearch
+	}
+
+	*out = a.newMsg("bot.arguments.invalid")
+	return a.actionFinish
+}
+
+// [p]audit-log
+func (a *AuditLog) actionRecent(args []string, in *discordgo.Message, out **discordgo.MessageSend) auditLogAction {
+	if !helpers.IsMod(in) {
+		*out = a.newMsg("mod.no_permission")
+		return a.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	response, err := a.fetch(channel.GuildID, auditLogRecentLimit)
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok && errD.Message != nil && errD.Message.Code == discordgo.ErrCodeMissingPermissions {
+			*out = a.newMsg("plugins.auditlog.no-permission")
+			return a.actionFinish
+		}
+	}
+	helpers.Relax(err)
+
+	if len(response.Entries) <= 0 {
+		*out = a.newMsg("plugins.auditlog.recent-none")
+		return a.actionFinish
+	}
+
+	var message string
+	for _, entry := range response.Entries {
+		stored := a.render(channel.GuildID, entry, response.Users)
+		message += a.formatStored(stored) + "\n"
+	}
+
+	for _, page := range helpers.Pagify(message, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.Relax(err)
+	}
+	return nil
+}
+
+// [p]audit-log channel <channel>
+func (a *AuditLog) actionChannel(args []string, in *discordgo.Message, out **discordgo.MessageSend) auditLogAction {
+	if !helpers.IsAdmin(in) {
+		*out = a.newMsg("admin.no_permission")
+		return a.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = a.newMsg("bot.arguments.too-few")
+		return a.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+		*out = a.newMsg("bot.arguments.invalid")
+		return a.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.AuditLogChannelID = targetChannel.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = a.newMsg("plugins.auditlog.channel-set", targetChannel.ID)
+	return a.actionFinish
+}
+
+// [p]audit-log disable
+func (a *AuditLog) actionDisable(args []string, in *discordgo.Message, out **discordgo.MessageSend) auditLogAction {
+	if !helpers.IsAdmin(in) {
+		*out = a.newMsg("admin.no_permission")
+		return a.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.AuditLogChannelID = ""
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = a.newMsg("plugins.auditlog.disabled")
+	return a.actionFinish
+}
+
+// [p]audit-log search [user=<user>] [action=<action type>] [since=<duration>] [until=<duration>]
+func (a *AuditLog) actionSearch(args []string, in *discordgo.Message, out **discordgo.MessageSend) auditLogAction {
+	if !helpers.IsMod(in) {
+		*out = a.newMsg("mod.no_permission")
+		return a.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	query := rethink.Table(models.AuditLogTable).GetAllByIndex("guild_id", channel.GuildID)
+
+	for _, arg := range args[1:] {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) < 2 {
+			*out = a.newMsg("bot.arguments.invalid")
+			return a.actionFinish
+		}
+
+		switch strings.ToLower(parts[0]) {
+		case "user":
+			targetUser, err := helpers.GetUserFromMention(parts[1])
+			if err != nil || targetUser == nil || targetUser.ID == "" {
+				*out = a.newMsg("bot.arguments.invalid")
+				return a.actionFinish
+			}
+			query = query.Filter(rethink.Row.Field("user_id").Eq(targetUser.ID).Or(rethink.Row.Field("target_id").Eq(targetUser.ID)))
+		case "action":
+			actionType, ok := a.parseActionType(parts[1])
+			if !ok {
+				*out = a.newMsg("plugins.auditlog.search-invalid-action", a.actionTypeList())
+				return a.actionFinish
+			}
+			query = query.Filter(rethink.Row.Field("action_type").Eq(actionType))
+		case "since", "until":
+			duration, err := helpers.ParseDuration(parts[1])
+			if err != nil {
+				*out = a.newMsg("plugins.auditlog.search-invalid-duration")
+				return a.actionFinish
+			}
+			if strings.ToLower(parts[0]) == "since" {
+				query = query.Filter(rethink.Row.Field("created_at").Ge(time.Now().Add(-duration)))
+			} else {
+				query = query.Filter(rethink.Row.Field("created_at").Le(time.Now().Add(-duration)))
+			}
+		default:
+			*out = a.newMsg("bot.arguments.invalid")
+			return a.actionFinish
+		}
+	}
+
+	cursor, err := query.OrderBy(rethink.Desc("created_at")).Limit(auditLogSearchLimit).Run(helpers.GetDB())
+	helpers.Relax(err)
+	defer cursor.Close()
+
+	var entries []models.AuditLogEntry
+	err = cursor.All(&entries)
+	helpers.Relax(err)
+
+	if len(entries) <= 0 {
+		*out = a.newMsg("plugins.auditlog.search-none")
+		return a.actionFinish
+	}
+
+	message := helpers.GetTextF("plugins.auditlog.search-result", len(entries)) + "\n"
+	for _, entry := range entries {
+		message += a.formatStored(entry) + "\n"
+	}
+
+	for _, page := range helpers.Pagify(message, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.Relax(err)
+	}
+	return nil
+}
+
+func (a *AuditLog) watchLoop(session *discordgo.Session) {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			a.logger().Info("The watchLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			a.watchLoop(session)
+		}()
+	}()
+
+	for {
+		for _, guild := range session.State.Guilds {
+			settings := helpers.GuildSettingsGetCached(guild.ID)
+			if settings.AuditLogChannelID == "" {
+				continue
+			}
+
+			err := a.poll(guild.ID, settings.AuditLogChannelID)
+			if err != nil {
+				a.logger().WithField("GuildID", guild.ID).Warn("polling audit log failed: " + err.Error())
+			}
+		}
+
+		time.Sleep(auditLogPollInterval)
+	}
+}
+
+// poll fetches the latest audit log entries of the guild, stores new entries and posts them to the log channel
+func (a *AuditLog) poll(guildID string, channelID string) (err error) {
+	lastSeenID, err := a.getLastSeen(guildID)
+	if err != nil {
+		return err
+	}
+
+	response, err := a.fetch(guildID, auditLogFetchLimit)
+	if err != nil {
+		return err
+	}
+
+	// entries are sorted newest first
+	for i := len(response.Entries) - 1; i >= 0; i-- {
+		rawEntry := response.Entries[i]
+		if !a.isNewer(rawEntry.ID, lastSeenID) {
+			continue
+		}
+
+		entry := a.render(guildID, rawEntry, response.Users)
+		_, err = rethink.Table(models.AuditLogTable).Insert(entry, rethink.InsertOpts{Conflict: "replace"}).RunWrite(helpers.GetDB())
+		if err != nil {
+			return err
+		}
+		lastSeenID = entry.ID
+		a.setLastSeen(guildID, lastSeenID)
+
+		if time.Since(entry.CreatedAt) > auditLogPostWindow {
+			continue
+		}
+
+		_, err = helpers.SendEmbed(channelID, a.getEmbed(entry))
+		if err != nil {
+			a.logger().WithField("ChannelID", channelID).Warn("posting audit log entry failed: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *AuditLog) fetch(guildID string, limit int) (response auditLogResponse, err error) {
+	result, err := cache.GetSession().Request("GET",
+		fmt.Sprintf(discordgo.EndpointAPI+"guilds/%s/audit-logs?limit=%d", guildID, limit), nil)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.Unmarshal(result, &response)
+	return response, err
+}
+
+func (a *AuditLog) getLastSeen(guildID string) (lastSeenID string, err error) {
+	auditLogLastSeenMutex.Lock()
+	lastSeenID, ok := auditLogLastSeen[guildID]
+	auditLogLastSeenMutex.Unlock()
+	if ok {
+		return lastSeenID, nil
+	}
+
+	cursor, err := rethink.Table(models.AuditLogTable).GetAllByIndex("guild_id", guildID).
+		OrderBy(rethink.Desc("created_at")).Limit(1).Run(helpers.GetDB())
+	if err != nil {
+		return "", err
+	}
+	defer cursor.Close()
+
+	var entry models.AuditLogEntry
+	err = cursor.One(&entry)
+	if err != nil && err != rethink.ErrEmptyResult {
+		return "", err
+	}
+
+	a.setLastSeen(guildID, entry.ID)
+	return entry.ID, nil
+}
+
+func (a *AuditLog) setLastSeen(guildID string, entryID string) {
+	auditLogLastSeenMutex.Lock()
+	defer auditLogLastSeenMutex.Unlock()
+
+	auditLogLastSeen[guildID] = entryID
+}
+
+// isNewer returns true if the snowflake id is newer than the other snowflake id
+func (a *AuditLog) isNewer(id string, otherID string) bool {
+	if len(id) != len(otherID) {
+		return len(id) > len(otherID)
+	}
+	return id > otherID
+}
+
+func (a *AuditLog) parseActionType(text string) (actionType int, ok bool) {
+	actionType, err := strconv.Atoi(text)
+	if err == nil {
+		_, ok = models.AuditLogActionTypes[actionType]
+		return actionType, ok
+	}
+
+	for key, name := range models.AuditLogActionTypes {
+		if name == strings.ToLower(text) {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+func (a *AuditLog) actionTypeList() string {
+	names := make([]string, 0)
+	for _, name := range models.AuditLogActionTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "`" + strings.Join(names, "`, `") + "`"
+}
+
+// render turns a raw audit log entry into a readable entry
+func (a *AuditLog) render(guildID string, rawEntry auditLogRawEntry, users []*discordgo.User) (entry models.AuditLogEntry) {
+	entry = models.AuditLogEntry{
+		ID:         rawEntry.ID,
+		GuildID:    guildID,
+		UserID:     rawEntry.UserID,
+		TargetID:   rawEntry.TargetID,
+		ActionType: rawEntry.ActionType,
+		Reason:     rawEntry.Reason,
+		Changes:    make([]models.AuditLogChange, 0),
+		CreatedAt:  helpers.GetTimeFromSnowflake(rawEntry.ID),
+	}
+
+	userName := func(userID string) string {
+		if userID == "" {
+			return "N/A"
+		}
+		for _, user := range users {
+			if user.ID == userID {
+				return user.Username + "#" + user.Discriminator
+			}
+		}
+		user, err := helpers.GetUser(userID)
+		if err == nil && user != nil {
+			return user.Username + "#" + user.Discriminator
+		}
+		return "#" + userID
+	}
+	// the name of the target, taken from the changes if available
+	targetName := func() string {
+		for _, change := range rawEntry.Changes {
+			if change.Key == "name" || change.Key == "code" {
+				if value := a.rawValue(change.NewValue); value != "" {
+					return value
+				}
+				return a.rawValue(change.OldValue)
+			}
+		}
+		if channel, err := helpers.GetChannelWithoutApi(rawEntry.TargetID); err == nil && channel != nil {
+			return channel.Name
+		}
+		if role, err := cache.GetSession().State.Role(guildID, rawEntry.TargetID); err == nil && role != nil {
+			return role.Name
+		}
+		return "#" + rawEntry.TargetID
+	}
+	channelName := func(channelID string) string {
+		if channel, err := helpers.GetChannelWithoutApi(channelID); err == nil && channel != nil {
+			return "#" + channel.Name
+		}
+		return "#" + channelID
+	}
+
+	var verb string
+	switch rawEntry.ActionType % 10 {
+	case 0:
+		verb = "created"
+	case 1:
+		verb = "updated"
+	case 2:
+		verb = "deleted"
+	}
+
+	by := userName(rawEntry.UserID)
+	var summary string
+	switch rawEntry.ActionType {
+	case 1:
+		summary = fmt.Sprintf("Server settings updated by **%s**", by)
+	case 10, 11, 12:
+		summary = fmt.Sprintf("Channel **#%s** %s by **%s**", targetName(), verb, by)
+	case 13, 14, 15:
+		overwriteTarget := "N/A"
+		if rawEntry.Options != nil {
+			if rawEntry.Options.Type == "role" {
+				overwriteTarget = "role **" + rawEntry.Options.RoleName + "**"
+			} else {
+				overwriteTarget = "member **" + userName(rawEntry.Options.ID) + "**"
+			}
+		}
+		verb = map[int]string{13: "created", 14: "updated", 15: "deleted"}[rawEntry.ActionType]
+		summary = fmt.Sprintf("Permission overwrite for %s in **%s** %s by **%s**",
+			overwriteTarget, channelName(rawEntry.TargetID), verb, by)
+	case 20:
+		summary = fmt.Sprintf("**%s** kicked by **%s**", userName(rawEntry.TargetID), by)
+	case 21:
+		if rawEntry.Options != nil {
+			summary = fmt.Sprintf("Pruned %s members inactive for %s days by **%s**",
+				rawEntry.Options.MembersRemoved, rawEntry.Options.DeleteMemberDays, by)
+		} else {
+			summary = fmt.Sprintf("Members pruned by **%s**", by)
+		}
+	case 22:
+		summary = fmt.Sprintf("**%s** banned by **%s**", userName(rawEntry.TargetID), by)
+	case 23:
+		summary = fmt.Sprintf("**%s** unbanned by **%s**", userName(rawEntry.TargetID), by)
+	case 24:
+		summary = fmt.Sprintf("Member **%s** updated by **%s**", userName(rawEntry.TargetID), by)
+	case 25:
+		summary = fmt.Sprintf("Roles of **%s** changed by **%s**", userName(rawEntry.TargetID), by)
+	case 30, 31, 32:
+		summary = fmt.Sprintf("Role **%s** %s by **%s**", targetName(), verb, by)
+	case 40, 41, 42:
+		summary = fmt.Sprintf("Invite **%s** %s by **%s**", targetName(), verb, by)
+	case 50, 51, 52:
+		summary = fmt.Sprintf("Webhook **%s** %s by **%s**", targetName(), verb, by)
+	case 60, 61, 62:
+		summary = fmt.Sprintf("Emoji **%s** %s by **%s**", targetName(), verb, by)
+	case 72:
+		if rawEntry.Options != nil {
+			summary = fmt.Sprintf("%s message(s) by **%s** deleted in **%s** by **%s**",
+				rawEntry.Options.Count, userName(rawEntry.TargetID), channelName(rawEntry.Options.ChannelID), by)
+		} else {
+			summary = fmt.Sprintf("Message(s) by **%s** deleted by **%s**", userName(rawEntry.TargetID), by)
+		}
+	default:
+		summary = fmt.Sprintf("Action `%d` by **%s**", rawEntry.ActionType, by)
+	}
+
+	lines := []string{summary}
+	for _, change := range rawEntry.Changes {
+		entry.Changes = append(entry.Changes, models.AuditLogChange{
+			Key:      change.Key,
+			OldValue: string(change.OldValue),
+			NewValue: string(change.NewValue),
+		})
+
+		if line := a.renderChange(change); line != "" {
+			lines = append(lines, "• "+line)
+		}
+	}
+	if rawEntry.Reason != "" {
+		lines = append(lines, "Reason: "+rawEntry.Reason)
+	}
+
+	entry.Text = strings.Join(lines, "\n")
+	return entry
+}
+
+func (a *AuditLog) renderChange(change auditLogRawChange) string {
+	switch change.Key {
+	case "id", "type":
+		return ""
+	case "permissions", "allow", "deny":
+		oldPermissions, _ := strconv.Atoi(a.rawValue(change.OldValue))
+		newPermissions, _ := strconv.Atoi(a.rawValue(change.NewValue))
+		diff := make([]string, 0)
+		for _, name := range helpers.GetPermissionNames(newPermissions &^ oldPermissions) {
+			diff = append(diff, "+"+name)
+		}
+		for _, name := range helpers.GetPermissionNames(oldPermissions &^ newPermissions) {
+			diff = append(diff, "-"+name)
+		}
+		if len(diff) <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("%s changed: %s", change.Key, strings.Join(diff, ", "))
+	case "$add", "$remove":
+		var roles []auditLogRawRole
+		json.Unmarshal(change.NewValue, &roles)
+		names := make([]string, 0)
+		for _, role := range roles {
+			names = append(names, "**"+role.Name+"**")
+		}
+		if change.Key == "$add" {
+			return "added roles: " + strings.Join(names, ", ")
+		}
+		return "removed roles: " + strings.Join(names, ", ")
+	case "color":
+		oldColor, _ := strconv.Atoi(a.rawValue(change.OldValue))
+		newColor, _ := strconv.Atoi(a.rawValue(change.NewValue))
+		return fmt.Sprintf("color: `#%s` → `#%s`", helpers.GetHexFromDiscordColor(oldColor), helpers.GetHexFromDiscordColor(newColor))
+	}
+
+	oldValue := a.rawValue(change.OldValue)
+	newValue := a.rawValue(change.NewValue)
+	switch {
+	case oldValue == "" && newValue == "":
+		return ""
+	case oldValue == "":
+		return fmt.Sprintf("%s: `%s`", change.Key, newValue)
+	case newValue == "":
+		return fmt.Sprintf("%s: `%s` removed", change.Key, oldValue)
+	}
+	return fmt.Sprintf("%s: `%s` → `%s`", change.Key, oldValue, newValue)
+}
+
+// rawValue returns a json value as plain text
+func (a *AuditLog) rawValue(value json.RawMessage) string {
+	if len(value) <= 0 || string(value) == "null" {
+		return ""
+	}
+	var text string
+	if json.Unmarshal(value, &text) == nil {
+		return text
+	}
+	return string(value)
+}
+
+func (a *AuditLog) formatStored(entry models.AuditLogEntry) string {
+	return fmt.Sprintf("`%s` %s", entry.CreatedAt.UTC().Format("2006-01-02 15:04"), entry.Text)
+}
+
+func (a *AuditLog) getEmbed(entry models.AuditLogEntry) *discordgo.MessageEmbed {
+	color := auditLogColorNeutral
+	switch entry.ActionType {
+	case 10, 13, 30, 40, 50, 60:
+		color = auditLogColorCreate
+	case 1, 11, 14, 24, 25, 31, 41, 51, 61:
+		color = auditLogColorUpdate
+	case 12, 15, 20, 21, 22, 32, 42, 52, 62, 72:
+		color = auditLogColorDelete
+	}
+
+	actionName := models.AuditLogActionTypes[entry.ActionType]
+	if actionName == "" {
+		actionName = strconv.Itoa(entry.ActionType)
+	}
+
+	description := entry.Text
+	if len([]rune(description)) > 2048 {
+		description = string([]rune(description)[:2047]) + "…"
+	}
+
+	return &discordgo.MessageEmbed{
+		Description: description,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s | User ID: %s | Target ID: %s", actionName, entry.UserID, entry.TargetID),
+		},
+		Timestamp: entry.CreatedAt.Format(time.RFC3339),
+		Color:     color,
+	}
+}
+
+func (a *AuditLog) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) auditLogAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (a *AuditLog) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (a *AuditLog) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "auditlog")
+}
@@ -338,22 +338,36 @@ func (a *AutoRoles) OnGuildMemberAdd(member *discordgo.Member, session *discordg
 	go func() {
 		defer helpers.Recover()
 
-		settings := helpers.GuildSettingsGetCached(member.GuildID)
-		for _, roleID := range settings.AutoRoleIDs {
-			err := AutoroleApply(member.GuildID, member.User.ID, roleID)
-			helpers.RelaxLog(err)
+		// members have to pass the verification first, the verification plugin applies the autoroles afterwards
+		if helpers.GuildSettingsGetCached(member.GuildID).VerificationEnabled && !member.User.Bot {
+			return
 		}
-		for _, delayedAutorole := range settings.DelayedAutoRoles {
-			signature := AutoroleApplySignature(member.GuildID, member.User.ID, delayedAutorole.RoleID)
-			applyAt := time.Now().Add(delayedAutorole.Delay)
-			signature.ETA = &applyAt
 
-			_, err := cache.GetMachineryServer().SendTask(signature)
-			helpers.Relax(err)
-		}
+		err := AutoroleApplyAll(member.GuildID, member.User.ID)
+		helpers.Relax(err)
 	}()
 }
 
+// AutoroleApplyAll applies all autoroles of the guild to the user and schedules the delayed autoroles
+func AutoroleApplyAll(guildID string, userID string) (err error) {
+	settings := helpers.GuildSettingsGetCached(guildID)
+	for _, roleID := range settings.AutoRoleIDs {
+		err = AutoroleApply(guildID, userID, roleID)
+		helpers.RelaxLog(err)
+	}
+	for _, delayedAutorole := range settings.DelayedAutoRoles {
+		signature := AutoroleApplySignature(guildID, userID, delayedAutorole.RoleID)
+		applyAt := time.Now().Add(delayedAutorole.Delay)
+		signature.ETA = &applyAt
+
+		_, err = cache.GetMachineryServer().SendTask(signature)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func AutoroleApply(guildID string, userID string, roleID string) (err error) {
 	err = cache.GetSession().GuildMemberRoleAdd(guildID, userID, roleID)
 	if err != nil {
@@ -0,0 +1,803 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type backupAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next backupAction)
+
+type Backup struct{}
+
+const (
+	// backupVersion is the version of the snapshot format, increase it when GuildBackupSnapshot changes
+	backupVersion     = 1
+	backupMaxPerGuild = 20
+	backupShortIDLen  = 8
+)
+
+// backupDiff describes the differences between a snapshot and the current state of a guild
+type backupDiff struct {
+	RolesMissing      []models.GuildBackupRole
+	RolesAdded        []*discordgo.Role
+	RolesChanged      []string
+	ChannelsMissing   []models.GuildBackupChannel
+	ChannelsAdded     []*discordgo.Channel
+	ChannelsChanged   []string
+	OverwritesChanged []models.GuildBackupChannel
+	EmojiMissing      []models.GuildBackupEmoji
+	EmojiAdded        []*discordgo.Emoji
+	SettingsChanged   bool
+}
+
+// backupRestoreParts are the parts of a snapshot to restore
+type backupRestoreParts struct {
+	Roles      bool
+	Channels   bool
+	Overwrites bool
+	Settings   bool
+}
+
+func (b *Backup) Commands() []string {
+	return []string{
+		"backup",
+		"backups",
+	}
+}
+
+func (b *Backup) Init(session *discordgo.Session) {
+
+}
+
+func (b *Backup) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := b.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (b *Backup) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) backupAction {
+	if !helpers.IsAdmin(in) {
+		*out = b.newMsg("admin.no_permission")
+		return b.actionFinish
+	}
+
+	if len(args) < 1 {
+		return b.actionList
+	}
+
+	switch args[0] {
+	case "create", "new":
+		return b.actionCreate
+	case "list":
+		return b.actionList
+	case "diff":
+		return b.actionDiff
+	case "restore":
+		return b.actionRestore
+	}
+
+	*out = b.newMsg("bot.arguments.invalid")
+	return b.actionFinish
+}
+
+// [p]backup create
+func (b *Backup) actionCreate(args []string, in *discordgo.Message, out **discordgo.MessageSend) backupAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	guild, err := helpers.GetGuild(channel.GuildID)
+	helpers.Relax(err)
+
+	backup := models.GuildBackupEntry{
+		GuildID:         guild.ID,
+		CreatedByUserID: in.Author.ID,
+		CreatedAt:       time.Now(),
+		Version:         backupVersion,
+		Snapshot:        b.snapshot(guild),
+	}
+
+	backup.ID, err = b.insertBackup(backup)
+	helpers.Relax(err)
+
+	err = b.pruneBackups(guild.ID)
+	helpers.RelaxLog(err)
+
+	b.logger().WithField("GuildID", guild.ID).WithField("UserID", in.Author.ID).Info("created backup " + backup.ID)
+
+	*out = b.newMsg("plugins.backup.create-success", b.shortID(backup.ID),
+		len(backup.Snapshot.Roles), len(backup.Snapshot.Channels), len(backup.Snapshot.Emoji))
+	return b.actionFinish
+}
+
+// [p]backup list
+func (b *Backup) actionList(args []string, in *discordgo.Message, out **discordgo.MessageSend) backupAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	backups, err := b.getBackups(channel.GuildID)
+	helpers.Relax(err)
+
+	if len(backups) <= 0 {
+		*out = b.newMsg("plugins.backup.list-empty", helpers.GetPrefixForServer(channel.GuildID))
+		return b.actionFinish
+	}
+
+	var message string
+	for _, backup := range backups {
+		author, err := helpers.GetUser(backup.CreatedByUserID)
+		if err != nil || author == nil {
+			author = new(discordgo.User)
+			author.ID = backup.CreatedByUserID
+			author.Username = "N/A"
+		}
+		message += fmt.Sprintf("`%s`: %s by `%s (#%s)`: %d roles, %d channels, %d emoji\n",
+			b.shortID(backup.ID), humanize.Time(backup.CreatedAt), author.Username, author.ID,
+			len(backup.Snapshot.Roles), len(backup.Snapshot.Channels), len(backup.Snapshot.Emoji))
+	}
+	message += fmt.Sprintf("_found %d backups in total_\n", len(backups))
+
+	*out = b.newMsg(message)
+	return b.actionFinish
+}
+
+// [p]backup diff <backup id>
+func (b *Backup) actionDiff(args []string, in *discordgo.Message, out **discordgo.MessageSend) backupAction {
+	if len(args) < 2 {
+		*out = b.newMsg("bot.arguments.too-few")
+		return b.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	guild, err := helpers.GetGuild(channel.GuildID)
+	helpers.Relax(err)
+
+	backup, err := b.findBackup(guild.ID, args[1])
+	if err != nil {
+		*out = b.newMsg("plugins.backup.error-not-found")
+		return b.actionFinish
+	}
+
+	diff := b.diff(backup.Snapshot, guild)
+
+	message := helpers.GetTextF("plugins.backup.diff-title", b.shortID(backup.ID), humanize.Time(backup.CreatedAt)) + "\n"
+	changes := b.diffText(diff)
+	if changes == "" {
+		message += helpers.GetText("plugins.backup.diff-no-changes")
+	} else {
+		message += changes
+	}
+
+	*out = b.newMsg(message)
+	return b.actionFinish
+}
+
+// [p]backup restore <backup id> [roles] [channels] [overwrites] [settings]
+func (b *Backup) actionRestore(args []string, in *discordgo.Message, out **discordgo.MessageSend) backupAction {
+	if len(args) < 2 {
+		*out = b.newMsg("bot.arguments.too-few")
+		return b.actionFinish
+	}
+
+	var parts backupRestoreParts
+	if len(args) < 3 {
+		parts = backupRestoreParts{Roles: true, Channels: true, Overwrites: true}
+	}
+	for _, part := range args[2:] {
+		switch strings.ToLower(part) {
+		case "roles":
+			parts.Roles = true
+		case "channels":
+			parts.Channels = true
+		case "overwrites", "permissions":
+			parts.Overwrites = true
+		case "settings":
+			parts.Settings = true
+		case "all":
+			parts = backupRestoreParts{Roles: true, Channels: true, Overwrites: true, Settings: true}
+		default:
+			*out = b.newMsg("bot.arguments.invalid")
+			return b.actionFinish
+		}
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	guild, err := helpers.GetGuild(channel.GuildID)
+	helpers.Relax(err)
+
+	backup, err := b.findBackup(guild.ID, args[1])
+	if err != nil {
+		*out = b.newMsg("plugins.backup.error-not-found")
+		return b.actionFinish
+	}
+	if backup.Version > backupVersion {
+		*out = b.newMsg("plugins.backup.error-version", backup.Version)
+		return b.actionFinish
+	}
+
+	diff := b.diff(backup.Snapshot, guild)
+
+	preview := b.restorePreview(diff, parts)
+	if preview == "" {
+		*out = b.newMsg("plugins.backup.restore-nothing")
+		return b.actionFinish
+	}
+
+	if !helpers.ConfirmEmbed(in.ChannelID, in.Author,
+		helpers.GetTextF("plugins.backup.restore-confirm", b.shortID(backup.ID), preview), "✅", "🚫") {
+		return nil
+	}
+
+	_, err = helpers.SendMessage(in.ChannelID, helpers.GetText("plugins.backup.restore-started"))
+	helpers.Relax(err)
+
+	restored, failed := b.restore(guild, backup, diff, parts)
+
+	b.logger().WithField("GuildID", guild.ID).WithField("UserID", in.Author.ID).Infof(
+		"restored backup %s: %d restored, %d failed", backup.ID, restored, failed)
+
+	*out = b.newMsg("plugins.backup.restore-done", in.Author.ID, restored, failed)
+	return b.actionFinish
+}
+
+// snapshot creates a snapshot of the guild structure and the bot settings of the guild
+func (b *Backup) snapshot(guild *discordgo.Guild) (snapshot models.GuildBackupSnapshot) {
+	snapshot = models.GuildBackupSnapshot{
+		Name:              guild.Name,
+		Region:            guild.Region,
+		VerificationLevel: int(guild.VerificationLevel),
+		Roles:             make([]models.GuildBackupRole, 0),
+		Channels:          make([]models.GuildBackupChannel, 0),
+		Emoji:             make([]models.GuildBackupEmoji, 0),
+		Settings:          helpers.GuildSettingsGetCached(guild.ID),
+	}
+
+	for _, role := range guild.Roles {
+		snapshot.Roles = append(snapshot.Roles, models.GuildBackupRole{
+			ID:          role.ID,
+			Name:        role.Name,
+			Color:       role.Color,
+			Hoist:       role.Hoist,
+			Mentionable: role.Mentionable,
+			Managed:     role.Managed,
+			Permissions: role.Permissions,
+			Position:    role.Position,
+		})
+	}
+
+	for _, channel := range guild.Channels {
+		backupChannel := models.GuildBackupChannel{
+			ID:         channel.ID,
+			Name:       channel.Name,
+			Type:       int(channel.Type),
+			Topic:      channel.Topic,
+			NSFW:       channel.NSFW,
+			Position:   channel.Position,
+			Bitrate:    channel.Bitrate,
+			ParentID:   channel.ParentID,
+			Overwrites: make([]models.GuildBackupOverwrite, 0),
+		}
+		for _, overwrite := range channel.PermissionOverwrites {
+			backupChannel.Overwrites = append(backupChannel.Overwrites, models.GuildBackupOverwrite{
+				ID:    overwrite.ID,
+				Type:  overwrite.Type,
+				Allow: overwrite.Allow,
+				Deny:  overwrite.Deny,
+			})
+		}
+		snapshot.Channels = append(snapshot.Channels, backupChannel)
+	}
+
+	for _, emoji := range guild.Emojis {
+		snapshot.Emoji = append(snapshot.Emoji, models.GuildBackupEmoji{
+			ID:            emoji.ID,
+			Name:          emoji.Name,
+			Roles:         emoji.Roles,
+			Managed:       emoji.Managed,
+			RequireColons: emoji.RequireColons,
+			Animated:      emoji.Animated,
+		})
+	}
+
+	return snapshot
+}
+
+func (b *Backup) diff(snapshot models.GuildBackupSnapshot, guild *discordgo.Guild) (diff backupDiff) {
+	currentRoles := make(map[string]*discordgo.Role)
+	for _, role := range guild.Roles {
+		currentRoles[role.ID] = role
+	}
+	backupRoles := make(map[string]models.GuildBackupRole)
+	for _, role := range snapshot.Roles {
+		backupRoles[role.ID] = role
+
+		currentRole, ok := currentRoles[role.ID]
+		if !ok {
+			if !role.Managed {
+				diff.RolesMissing = append(diff.RolesMissing, role)
+			}
+			continue
+		}
+
+		changes := make([]string, 0)
+		if currentRole.Name != role.Name {
+			changes = append(changes, fmt.Sprintf("name `%s` ➡ `%s`", role.Name, currentRole.Name))
+		}
+		if currentRole.Color != role.Color {
+			changes = append(changes, "color")
+		}
+		if currentRole.Permissions != role.Permissions {
+			changes = append(changes, "permissions")
+		}
+		if currentRole.Hoist != role.Hoist || currentRole.Mentionable != role.Mentionable {
+			changes = append(changes, "display")
+		}
+		if len(changes) > 0 {
+			diff.RolesChanged = append(diff.RolesChanged, fmt.Sprintf("`%s`: %s", role.Name, strings.Join(changes, ", ")))
+		}
+	}
+	for _, role := range guild.Roles {
+		if _, ok := backupRoles[role.ID]; !ok {
+			diff.RolesAdded = append(diff.RolesAdded, role)
+		}
+	}
+
+	currentChannels := make(map[string]*discordgo.Channel)
+	for _, channel := range guild.Channels {
+		currentChannels[channel.ID] = channel
+	}
+	backupChannels := make(map[string]models.GuildBackupChannel)
+	for _, channel := range snapshot.Channels {
+		backupChannels[channel.ID] = channel
+
+		currentChannel, ok := currentChannels[channel.ID]
+		if !ok {
+			diff.ChannelsMissing = append(diff.ChannelsMissing, channel)
+			continue
+		}
+
+		changes := make([]string, 0)
+		if currentChannel.Name != channel.Name {
+			changes = append(changes, fmt.Sprintf("name `%s` ➡ `%s`", channel.Name, currentChannel.Name))
+		}
+		if currentChannel.Topic != channel.Topic {
+			changes = append(changes, "topic")
+		}
+		if currentChannel.NSFW != channel.NSFW {
+			changes = append(changes, "nsfw")
+		}
+		if currentChannel.ParentID != channel.ParentID {
+			changes = append(changes, "category")
+		}
+		if !b.overwritesEqual(channel.Overwrites, currentChannel.PermissionOverwrites) {
+			changes = append(changes, "permission overwrites")
+			diff.OverwritesChanged = append(diff.OverwritesChanged, channel)
+		}
+		if len(changes) > 0 {
+			diff.ChannelsChanged = append(diff.ChannelsChanged, fmt.Sprintf("`%s`: %s", channel.Name, strings.Join(changes, ", ")))
+		}
+	}
+	for _, channel := range guild.Channels {
+		if _, ok := backupChannels[channel.ID]; !ok {
+			diff.ChannelsAdded = append(diff.ChannelsAdded, channel)
+		}
+	}
+
+	currentEmoji := make(map[string]bool)
+	for _, emoji := range guild.Emojis {
+		currentEmoji[emoji.ID] = true
+	}
+	backupEmoji := make(map[string]bool)
+	for _, emoji := range snapshot.Emoji {
+		backupEmoji[emoji.ID] = true
+		if !currentEmoji[emoji.ID] {
+			diff.EmojiMissing = append(diff.EmojiMissing, emoji)
+		}
+	}
+	for _, emoji := range guild.Emojis {
+		if !backupEmoji[emoji.ID] {
+			diff.EmojiAdded = append(diff.EmojiAdded, emoji)
+		}
+	}
+
+	currentSettings := helpers.GuildSettingsGetCached(guild.ID)
+	backupSettings := b.settingsForRestore(snapshot.Settings, currentSettings)
+	currentSettingsJson, errCurrent := json.Marshal(currentSettings)
+	backupSettingsJson, errBackup := json.Marshal(backupSettings)
+	if errCurrent == nil && errBackup == nil && string(currentSettingsJson) != string(backupSettingsJson) {
+		diff.SettingsChanged = true
+	}
+
+	return diff
+}
+
+func (b *Backup) overwritesEqual(backupOverwrites []models.GuildBackupOverwrite, currentOverwrites []*discordgo.PermissionOverwrite) bool {
+	if len(backupOverwrites) != len(currentOverwrites) {
+		return false
+	}
+	for _, backupOverwrite := range backupOverwrites {
+		found := false
+		for _, currentOverwrite := range currentOverwrites {
+			if currentOverwrite.ID == backupOverwrite.ID {
+				found = currentOverwrite.Allow == backupOverwrite.Allow && currentOverwrite.Deny == backupOverwrite.Deny
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Backup) diffText(diff backupDiff) (text string) {
+	for _, role := range diff.RolesMissing {
+		text += fmt.Sprintf("➖ Role `%s` (#%s) has been deleted\n", role.Name, role.ID)
+	}
+	for _, role := range diff.RolesAdded {
+		text += fmt.Sprintf("➕ Role `%s` (#%s) has been created\n", role.Name, role.ID)
+	}
+	for _, change := range diff.RolesChanged {
+		text += fmt.Sprintf("✏ Role %s\n", change)
+	}
+	for _, channel := range diff.ChannelsMissing {
+		text += fmt.Sprintf("➖ %s `%s` (#%s) has been deleted\n", b.channelTypeText(channel.Type), channel.Name, channel.ID)
+	}
+	for _, channel := range diff.ChannelsAdded {
+		text += fmt.Sprintf("➕ %s `%s` (#%s) has been created\n", b.channelTypeText(int(channel.Type)), channel.Name, channel.ID)
+	}
+	for _, change := range diff.ChannelsChanged {
+		text += fmt.Sprintf("✏ Channel %s\n", change)
+	}
+	for _, emoji := range diff.EmojiMissing {
+		text += fmt.Sprintf("➖ Emoji `:%s:` (#%s) has been deleted\n", emoji.Name, emoji.ID)
+	}
+	for _, emoji := range diff.EmojiAdded {
+		text += fmt.Sprintf("➕ Emoji `:%s:` (#%s) has been created\n", emoji.Name, emoji.ID)
+	}
+	if diff.SettingsChanged {
+		text += "✏ Robyul settings have been changed\n"
+	}
+	return text
+}
+
+func (b *Backup) restorePreview(diff backupDiff, parts backupRestoreParts) (text string) {
+	if parts.Roles {
+		for _, role := range diff.RolesMissing {
+			text += fmt.Sprintf("➕ Role `%s`\n", role.Name)
+		}
+	}
+	if parts.Channels {
+		for _, channel := range b.sortChannelsForRestore(diff.ChannelsMissing) {
+			text += fmt.Sprintf("➕ %s `%s`\n", b.channelTypeText(channel.Type), channel.Name)
+		}
+	}
+	if parts.Overwrites {
+		for _, channel := range diff.OverwritesChanged {
+			text += fmt.Sprintf("🔑 Permission overwrites of `%s`\n", channel.Name)
+		}
+	}
+	if parts.Settings && diff.SettingsChanged {
+		text += "⚙ Robyul settings\n"
+	}
+	if len(diff.EmojiMissing) > 0 && text != "" {
+		text += fmt.Sprintf("_%d deleted emoji can't be restored_\n", len(diff.EmojiMissing))
+	}
+
+	// keep the preview short enough for the confirm embed
+	if len(text) > 1800 {
+		text = text[:strings.LastIndex(text[:1800], "\n")+1] + "…\n"
+	}
+	return text
+}
+
+// restore recreates the selected parts of the snapshot, returns the number of restored and failed items
+func (b *Backup) restore(guild *discordgo.Guild, backup models.GuildBackupEntry, diff backupDiff, parts backupRestoreParts) (restored int, failed int) {
+	// maps ids in the snapshot to ids of recreated roles and channels
+	newIDs := make(map[string]string)
+
+	existingRoles := make(map[string]bool)
+	for _, role := range guild.Roles {
+		existingRoles[role.ID] = true
+	}
+
+	if parts.Roles {
+		recreatedRoles := make([]*discordgo.Role, 0)
+		for _, backupRole := range diff.RolesMissing {
+			newRole, err := cache.GetSession().GuildRoleCreate(guild.ID)
+			if err == nil {
+				newRole, err = cache.GetSession().GuildRoleEdit(guild.ID, newRole.ID,
+					backupRole.Name, backupRole.Color, backupRole.Hoist, backupRole.Permissions, backupRole.Mentionable)
+			}
+			if err != nil {
+				b.logger().WithField("GuildID", guild.ID).Warn("unable to restore role: " + err.Error())
+				failed++
+				continue
+			}
+			newIDs[backupRole.ID] = newRole.ID
+			existingRoles[newRole.ID] = true
+			newRole.Position = backupRole.Position
+			recreatedRoles = append(recreatedRoles, newRole)
+			restored++
+		}
+		if len(recreatedRoles) > 0 {
+			_, err := cache.GetSession().GuildRoleReorder(guild.ID, recreatedRoles)
+			if err != nil {
+				b.logger().WithField("GuildID", guild.ID).Warn("unable to reorder restored roles: " + err.Error())
+			}
+		}
+	}
+
+	mapOverwrites := func(backupOverwrites []models.GuildBackupOverwrite) (overwrites []*discordgo.PermissionOverwrite) {
+		overwrites = make([]*discordgo.PermissionOverwrite, 0)
+		for _, backupOverwrite := range backupOverwrites {
+			id := backupOverwrite.ID
+			if newID, ok := newIDs[id]; ok {
+				id = newID
+			}
+			if backupOverwrite.Type == "role" && !existingRoles[id] {
+				// the role doesn't exist anymore and hasn't been restored
+				continue
+			}
+			overwrites = append(overwrites, &discordgo.PermissionOverwrite{
+				ID:    id,
+				Type:  backupOverwrite.Type,
+				Allow: backupOverwrite.Allow,
+				Deny:  backupOverwrite.Deny,
+			})
+		}
+		return overwrites
+	}
+
+	if parts.Channels {
+		for _, backupChannel := range b.sortChannelsForRestore(diff.ChannelsMissing) {
+			var channelType string
+			switch discordgo.ChannelType(backupChannel.Type) {
+			case discordgo.ChannelTypeGuildText:
+				channelType = "text"
+			case discordgo.ChannelTypeGuildVoice:
+				channelType = "voice"
+			case discordgo.ChannelTypeGuildCategory:
+				channelType = "category"
+			default:
+				continue
+			}
+
+			newChannel, err := cache.GetSession().GuildChannelCreate(guild.ID, backupChannel.Name, channelType)
+			if err != nil {
+				b.logger().WithField("GuildID", guild.ID).Warn("unable to restore channel: " + err.Error())
+				failed++
+				continue
+			}
+			newIDs[backupChannel.ID] = newChannel.ID
+
+			parentID := backupChannel.ParentID
+			if newParentID, ok := newIDs[parentID]; ok {
+				parentID = newParentID
+			}
+			channelEdit := &discordgo.ChannelEdit{
+				Name:                 backupChannel.Name,
+				Topic:                backupChannel.Topic,
+				NSFW:                 backupChannel.NSFW,
+				Position:             backupChannel.Position,
+				PermissionOverwrites: mapOverwrites(backupChannel.Overwrites),
+				ParentID:             parentID,
+			}
+			if discordgo.ChannelType(backupChannel.Type) == discordgo.ChannelTypeGuildVoice {
+				channelEdit.Bitrate = backupChannel.Bitrate
+			}
+			_, err = cache.GetSession().ChannelEditComplex(newChannel.ID, channelEdit)
+			if err != nil {
+				b.logger().WithField("GuildID", guild.ID).Warn("unable to restore channel details: " + err.Error())
+				failed++
+				continue
+			}
+			restored++
+		}
+	}
+
+	if parts.Overwrites {
+		for _, backupChannel := range diff.OverwritesChanged {
+			channel, err := helpers.GetChannel(backupChannel.ID)
+			if err != nil || channel == nil || channel.ID == "" {
+				failed++
+				continue
+			}
+
+			channelFailed := false
+			overwrites := mapOverwrites(backupChannel.Overwrites)
+			for _, overwrite := range overwrites {
+				err = cache.GetSession().ChannelPermissionSet(channel.ID, overwrite.ID, overwrite.Type, overwrite.Allow, overwrite.Deny)
+				if err != nil {
+					channelFailed = true
+				}
+			}
+			for _, currentOverwrite := range channel.PermissionOverwrites {
+				inBackup := false
+				for _, overwrite := range overwrites {
+					if overwrite.ID == currentOverwrite.ID {
+						inBackup = true
+						break
+					}
+				}
+				if inBackup {
+					continue
+				}
+				err = cache.GetSession().ChannelPermissionDelete(channel.ID, currentOverwrite.ID)
+				if err != nil {
+					channelFailed = true
+				}
+			}
+
+			if channelFailed {
+				failed++
+				continue
+			}
+			restored++
+		}
+	}
+
+	if parts.Settings && diff.SettingsChanged {
+		settings := b.settingsForRestore(backup.Snapshot.Settings, helpers.GuildSettingsGetCached(guild.ID))
+		err := helpers.GuildSettingsSet(guild.ID, settings)
+		if err != nil {
+			b.logger().WithField("GuildID", guild.ID).Warn("unable to restore settings: " + err.Error())
+			failed++
+		} else {
+			restored++
+		}
+	}
+
+	return restored, failed
+}
+
+// settingsForRestore returns the settings of the backup with the runtime state of the current settings,
+// so a restore doesn't end raid mode, reopen polls, unblock modmail users or similar
+func (b *Backup) settingsForRestore(settings models.Config, currentSettings models.Config) models.Config {
+	settings.Id = currentSettings.Id
+	settings.Guild = currentSettings.Guild
+	settings.Polls = currentSettings.Polls
+	settings.MutedMembers = currentSettings.MutedMembers
+	settings.RaidModeEnabled = currentSettings.RaidModeEnabled
+	settings.RaidModeEnabledAt = currentSettings.RaidModeEnabledAt
+	settings.RaidModePreviousVerificationLevel = currentSettings.RaidModePreviousVerificationLevel
+	settings.RaidModeVerificationRaised = currentSettings.RaidModeVerificationRaised
+	settings.VerificationMessageID = currentSettings.VerificationMessageID
+	settings.ModmailBlockedUserIDs = currentSettings.ModmailBlockedUserIDs
+	settings.LevelsRepCooldownHours = currentSettings.LevelsRepCooldownHours
+	return settings
+}
+
+// sortChannelsForRestore sorts categories before other channels so channels can be moved into restored categories
+func (b *Backup) sortChannelsForRestore(channels []models.GuildBackupChannel) []models.GuildBackupChannel {
+	sorted := make([]models.GuildBackupChannel, len(channels))
+	copy(sorted, channels)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iIsCategory := discordgo.ChannelType(sorted[i].Type) == discordgo.ChannelTypeGuildCategory
+		jIsCategory := discordgo.ChannelType(sorted[j].Type) == discordgo.ChannelTypeGuildCategory
+		if iIsCategory != jIsCategory {
+			return iIsCategory
+		}
+		return sorted[i].Position < sorted[j].Position
+	})
+	return sorted
+}
+
+func (b *Backup) channelTypeText(channelType int) string {
+	switch discordgo.ChannelType(channelType) {
+	case discordgo.ChannelTypeGuildVoice:
+		return "Voice Channel"
+	case discordgo.ChannelTypeGuildCategory:
+		return "Category"
+	}
+	return "Channel"
+}
+
+func (b *Backup) shortID(id string) string {
+	if len(id) > backupShortIDLen {
+		return id[:backupShortIDLen]
+	}
+	return id
+}
+
+// findBackup finds a backup of the guild by its (short) id
+func (b *Backup) findBackup(guildID string, id string) (backup models.GuildBackupEntry, err error) {
+	backups, err := b.getBackups(guildID)
+	if err != nil {
+		return backup, err
+	}
+	for _, backup := range backups {
+		if strings.HasPrefix(backup.ID, strings.ToLower(id)) {
+			return backup, nil
+		}
+	}
+	return backup, errors.New("backup not found")
+}
+
+// getBackups returns all backups of the guild, newest first
+func (b *Backup) getBackups(guildID string) (backups []models.GuildBackupEntry, err error) {
+	listCursor, err := rethink.Table(models.GuildBackupsTable).GetAllByIndex(
+		"guild_id", guildID,
+	).OrderBy(rethink.Desc("created_at")).Run(helpers.GetDB())
+	if err != nil {
+		return backups, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&backups)
+	return backups, err
+}
+
+func (b *Backup) insertBackup(entry models.GuildBackupEntry) (id string, err error) {
+	insert := rethink.Table(models.GuildBackupsTable).Insert(entry)
+	response, err := insert.RunWrite(helpers.GetDB())
+	if err != nil {
+		return "", err
+	}
+	if len(response.GeneratedKeys) > 0 {
+		return response.GeneratedKeys[0], nil
+	}
+	return "", nil
+}
+
+// pruneBackups deletes the oldest backups of the guild if there are more than backupMaxPerGuild
+func (b *Backup) pruneBackups(guildID string) (err error) {
+	backups, err := b.getBackups(guildID)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= backupMaxPerGuild {
+		return nil
+	}
+	for _, backup := range backups[backupMaxPerGuild:] {
+		_, err = rethink.Table(models.GuildBackupsTable).Get(backup.ID).Delete().RunWrite(helpers.GetDB())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backup) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) backupAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (b *Backup) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (b *Backup) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "backup")
+}
+
+func (b *Backup) Uninit(session *discordgo.Session) {
+
+}
@@ -0,0 +1,575 @@
+package plugins
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type banAppealsAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next banAppealsAction)
+
+type BanAppeals struct{}
+
+const (
+	banAppealsApproveEmoji = "✅"
+	banAppealsDenyEmoji    = "❌"
+
+	banAppealsCodeLength   = 8
+	banAppealsCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	banAppealsMaxLength    = 1000
+)
+
+var (
+	banAppealsRand      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	banAppealsRandMutex sync.Mutex
+)
+
+func (ba *BanAppeals) Commands() []string {
+	return []string{
+		"appeals",
+	}
+}
+
+func (ba *BanAppeals) Init(session *discordgo.Session) {
+
+}
+
+func (ba *BanAppeals) Uninit(session *discordgo.Session) {
+
+}
+
+func (ba *BanAppeals) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := ba.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (ba *BanAppeals) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) banAppealsAction {
+	if !helpers.IsMod(in) {
+		*out = ba.newMsg("mod.no_permission")
+		return ba.actionFinish
+	}
+
+	if len(args) < 1 {
+		return ba.actionStatus
+	}
+
+	switch args[0] {
+	case "status":
+		return ba.actionStatus
+	case "channel", "disable":
+		if !helpers.IsAdmin(in) {
+			*out = ba.newMsg("admin.no_permission")
+			return ba.actionFinish
+		}
+		if args[0] == "channel" {
+			return ba.actionChannel
+		}
+		return ba.actionDisable
+	case "history":
+		return ba.actionHistory
+	}
+
+	*out = ba.newMsg("bot.arguments.invalid")
+	return ba.actionFinish
+}
+
+// [p]appeals [status]
+func (ba *BanAppeals) actionStatus(args []string, in *discordgo.Message, out **discordgo.MessageSend) banAppealsAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	if settings.BanAppealsChannelID == "" {
+		*out = ba.newMsg("plugins.banappeals.status-disabled", helpers.GetPrefixForServer(channel.GuildID))
+		return ba.actionFinish
+	}
+
+	var pendingAppeals []models.BanAppealEntry
+	listCursor, err := rethink.Table(models.BanAppealsTable).GetAllByIndex("guild_id", channel.GuildID).Filter(
+		rethink.Row.Field("status").Eq(models.BanAppealStatusPending),
+	).Run(helpers.GetDB())
+	helpers.Relax(err)
+	defer listCursor.Close()
+	err = listCursor.All(&pendingAppeals)
+	helpers.Relax(err)
+
+	message := helpers.GetTextF("plugins.banappeals.status-enabled", settings.BanAppealsChannelID, len(pendingAppeals))
+	for _, appeal := range pendingAppeals {
+		message += fmt.Sprintf("\n`#%s` <https://discordapp.com/channels/%s/%s/%s>",
+			appeal.UserID, appeal.GuildID, appeal.ChannelID, appeal.MessageID)
+	}
+
+	for _, page := range helpers.Pagify(message, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.Relax(err)
+	}
+	return nil
+}
+
+// [p]appeals channel <#channel>
+func (ba *BanAppeals) actionChannel(args []string, in *discordgo.Message, out **discordgo.MessageSend) banAppealsAction {
+	if len(args) < 2 {
+		*out = ba.newMsg("bot.arguments.too-few")
+		return ba.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.GuildID != channel.GuildID {
+		*out = ba.newMsg("bot.arguments.invalid")
+		return ba.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.BanAppealsChannelID = targetChannel.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = ba.newMsg("plugins.banappeals.channel-set", targetChannel.ID)
+	return ba.actionFinish
+}
+
+// [p]appeals disable
+func (ba *BanAppeals) actionDisable(args []string, in *discordgo.Message, out **discordgo.MessageSend) banAppealsAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.BanAppealsChannelID = ""
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = ba.newMsg("plugins.banappeals.disabled")
+	return ba.actionFinish
+}
+
+// [p]appeals history <user>
+func (ba *BanAppeals) actionHistory(args []string, in *discordgo.Message, out **discordgo.MessageSend) banAppealsAction {
+	if len(args) < 2 {
+		*out = ba.newMsg("bot.arguments.too-few")
+		return ba.actionFinish
+	}
+
+	targetUser, err := helpers.GetUserFromMention(args[1])
+	if err != nil || targetUser == nil || targetUser.ID == "" {
+		*out = ba.newMsg("bot.arguments.invalid")
+		return ba.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	cases, err := ba.getCases(targetUser.ID)
+	helpers.Relax(err)
+
+	message := helpers.GetTextF("plugins.banappeals.history-title", targetUser.Username, targetUser.Discriminator, targetUser.ID) + "\n"
+	var found bool
+	for _, banCase := range cases {
+		if banCase.GuildID != channel.GuildID {
+			continue
+		}
+		found = true
+
+		message += helpers.GetTextF("plugins.banappeals.history-case",
+			banCase.CreatedAt.UTC().Format(time.ANSIC), banCase.ModeratorID, banCase.Reason) + "\n"
+		appeal, err := ba.getAppealByCaseID(banCase.ID)
+		if err != nil || appeal.ID == "" {
+			message += helpers.GetText("plugins.banappeals.history-no-appeal") + "\n"
+			continue
+		}
+		message += helpers.GetTextF("plugins.banappeals.history-appeal", appeal.Status,
+			appeal.CreatedAt.UTC().Format(time.ANSIC), appeal.Message) + "\n"
+	}
+	if !found {
+		*out = ba.newMsg("plugins.banappeals.history-none")
+		return ba.actionFinish
+	}
+
+	for _, page := range helpers.Pagify(message, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.Relax(err)
+	}
+	return nil
+}
+
+func (ba *BanAppeals) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) banAppealsAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (ba *BanAppeals) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (ba *BanAppeals) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "banappeals")
+}
+
+// banAppealsSendCode sends the appeal code to a user that is about to be banned,
+// the user has to be notified before the ban because we won't share a server after it.
+// If the ban fails the code has to be retracted using banAppealsRetractCode.
+func banAppealsSendCode(guild *discordgo.Guild, user *discordgo.User) (code string, codeMessages []*discordgo.Message) {
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+	if settings.BanAppealsChannelID == "" {
+		return "", nil
+	}
+
+	banAppealsRandMutex.Lock()
+	for i := 0; i < banAppealsCodeLength; i++ {
+		code += string(banAppealsCodeAlphabet[banAppealsRand.Intn(len(banAppealsCodeAlphabet))])
+	}
+	banAppealsRandMutex.Unlock()
+
+	dmChannel, err := cache.GetSession().UserChannelCreate(user.ID)
+	if err == nil {
+		codeMessages, err = helpers.SendMessage(dmChannel.ID, helpers.GetTextF("plugins.banappeals.code-dm",
+			guild.Name, helpers.GetPrefixForServer(""), code))
+	}
+	if err != nil {
+		cache.GetLogger().WithField("module", "banappeals").WithField("UserID", user.ID).Warn(
+			"unable to send appeal code: " + err.Error())
+	}
+	return code, codeMessages
+}
+
+// banAppealsRetractCode deletes the appeal code DM of a ban that failed
+func banAppealsRetractCode(codeMessages []*discordgo.Message) {
+	for _, codeMessage := range codeMessages {
+		if codeMessage == nil {
+			continue
+		}
+		err := cache.GetSession().ChannelMessageDelete(codeMessage.ChannelID, codeMessage.ID)
+		if err != nil {
+			cache.GetLogger().WithField("module", "banappeals").WithField("ChannelID", codeMessage.ChannelID).Warn(
+				"unable to retract appeal code: " + err.Error())
+		}
+	}
+}
+
+// banAppealsCreateCase stores a ban issued through the ban command
+func banAppealsCreateCase(guildID string, userID string, moderatorID string, reason string, code string) (err error) {
+	_, err = rethink.Table(models.BanCasesTable).Insert(models.BanCaseEntry{
+		GuildID:     guildID,
+		UserID:      userID,
+		ModeratorID: moderatorID,
+		Reason:      reason,
+		AppealCode:  code,
+		CreatedAt:   time.Now(),
+	}).RunWrite(helpers.GetDB())
+	return err
+}
+
+// banAppealsIsAppeal returns true if a DM is meant for the appeals, with or without the default prefix
+func banAppealsIsAppeal(content string) bool {
+	content = strings.TrimPrefix(content, helpers.GetPrefixForServer(""))
+	fields := strings.Fields(content)
+	return len(fields) > 0 && strings.ToLower(fields[0]) == "appeal"
+}
+
+func (ba *BanAppeals) getCases(userID string) (cases []models.BanCaseEntry, err error) {
+	listCursor, err := rethink.Table(models.BanCasesTable).GetAllByIndex("user_id", userID).OrderBy(
+		rethink.Desc("created_at"),
+	).Run(helpers.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&cases)
+	return cases, err
+}
+
+func (ba *BanAppeals) getAppealByCaseID(caseID string) (appeal models.BanAppealEntry, err error) {
+	listCursor, err := rethink.Table(models.BanAppealsTable).GetAllByIndex("case_id", caseID).Run(helpers.GetDB())
+	if err != nil {
+		return appeal, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&appeal)
+	return appeal, err
+}
+
+func (ba *BanAppeals) getAppealByMessageID(messageID string) (appeal models.BanAppealEntry, err error) {
+	listCursor, err := rethink.Table(models.BanAppealsTable).GetAllByIndex("message_id", messageID).Run(helpers.GetDB())
+	if err != nil {
+		return appeal, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&appeal)
+	return appeal, err
+}
+
+// findCase finds the ban case of the user matching the appeal code, the server ID, or the server name
+func (ba *BanAppeals) findCase(userID string, server string) (banCase models.BanCaseEntry, found bool) {
+	cases, err := ba.getCases(userID)
+	if err != nil {
+		return banCase, false
+	}
+
+	for _, possibleCase := range cases {
+		if possibleCase.AppealCode != "" && possibleCase.AppealCode == strings.ToUpper(server) {
+			return possibleCase, true
+		}
+	}
+	for _, possibleCase := range cases {
+		if possibleCase.AppealCode == "" {
+			continue
+		}
+		if possibleCase.GuildID == server {
+			return possibleCase, true
+		}
+		guild, err := helpers.GetGuild(possibleCase.GuildID)
+		if err == nil && strings.ToLower(guild.Name) == strings.ToLower(server) {
+			return possibleCase, true
+		}
+	}
+	return banCase, false
+}
+
+func (ba *BanAppeals) sendDM(userID string, message string) (err error) {
+	dmChannel, err := cache.GetSession().UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+	_, err = helpers.SendMessage(dmChannel.ID, message)
+	return err
+}
+
+func (ba *BanAppeals) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+	if msg.Author == nil || msg.Author.Bot || !banAppealsIsAppeal(content) {
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	if err != nil || channel.Type != discordgo.ChannelTypeDM {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		ba.handleAppeal(content, msg)
+	}()
+}
+
+// handleAppeal handles appeal <appeal code or server> <message> sent by DM
+func (ba *BanAppeals) handleAppeal(content string, msg *discordgo.Message) {
+	args := strings.Fields(strings.TrimPrefix(content, helpers.GetPrefixForServer("")))
+	if len(args) < 3 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.banappeals.appeal-usage"))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	banCase, found := ba.findCase(msg.Author.ID, args[1])
+	if !found {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.banappeals.appeal-not-found"))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(banCase.GuildID)
+	if settings.BanAppealsChannelID == "" {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.banappeals.appeal-disabled"))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	existingAppeal, err := ba.getAppealByCaseID(banCase.ID)
+	if err == nil && existingAppeal.ID != "" {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.banappeals.appeal-exists", existingAppeal.Status))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	appealText := strings.TrimSpace(strings.TrimPrefix(content, helpers.GetPrefixForServer("")))
+	appealText = strings.TrimSpace(appealText[len(args[0]):])
+	appealText = strings.TrimSpace(appealText[len(args[1]):])
+	if len(appealText) > banAppealsMaxLength {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.banappeals.appeal-too-long", banAppealsMaxLength))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	guild, err := helpers.GetGuild(banCase.GuildID)
+	helpers.Relax(err)
+
+	appealEmbed := &discordgo.MessageEmbed{
+		Title: helpers.GetText("plugins.banappeals.embed-title"),
+		Description: helpers.GetTextF("plugins.banappeals.embed-description",
+			msg.Author.ID, msg.Author.Username, msg.Author.Discriminator, msg.Author.ID),
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: helpers.GetAvatarUrl(msg.Author)},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Banned at", Value: banCase.CreatedAt.UTC().Format(time.ANSIC) + " UTC", Inline: true},
+			{Name: "Banned by", Value: "<@" + banCase.ModeratorID + ">", Inline: true},
+			{Name: "Ban reason", Value: banCase.Reason, Inline: false},
+			{Name: "Appeal", Value: appealText, Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.banappeals.embed-footer",
+			banAppealsApproveEmoji, banAppealsDenyEmoji)},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Color:     0xF1C40F,
+	}
+	messages, err := helpers.SendEmbed(settings.BanAppealsChannelID, appealEmbed)
+	if err != nil || len(messages) <= 0 {
+		ba.logger().WithField("GuildID", guild.ID).Warn(fmt.Sprintf("unable to post appeal: %v", err))
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.banappeals.appeal-failed"))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	_, err = rethink.Table(models.BanAppealsTable).Insert(models.BanAppealEntry{
+		CaseID:    banCase.ID,
+		GuildID:   banCase.GuildID,
+		UserID:    msg.Author.ID,
+		Message:   appealText,
+		ChannelID: messages[0].ChannelID,
+		MessageID: messages[0].ID,
+		Status:    models.BanAppealStatusPending,
+		CreatedAt: time.Now(),
+	}).RunWrite(helpers.GetDB())
+	helpers.Relax(err)
+
+	cache.GetSession().MessageReactionAdd(messages[0].ChannelID, messages[0].ID, banAppealsApproveEmoji)
+	cache.GetSession().MessageReactionAdd(messages[0].ChannelID, messages[0].ID, banAppealsDenyEmoji)
+
+	_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.banappeals.appeal-submitted", guild.Name))
+	helpers.RelaxLog(err)
+}
+
+func (ba *BanAppeals) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
+
+}
+
+func (ba *BanAppeals) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (ba *BanAppeals) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (ba *BanAppeals) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+	if reaction.UserID == session.State.User.ID {
+		return
+	}
+	if reaction.Emoji.Name != banAppealsApproveEmoji && reaction.Emoji.Name != banAppealsDenyEmoji {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		appeal, err := ba.getAppealByMessageID(reaction.MessageID)
+		if err != nil || appeal.ID == "" || appeal.Status != models.BanAppealStatusPending {
+			return
+		}
+
+		if !helpers.IsModByID(appeal.GuildID, reaction.UserID) {
+			return
+		}
+
+		moderator, err := helpers.GetUser(reaction.UserID)
+		helpers.Relax(err)
+		guild, err := helpers.GetGuild(appeal.GuildID)
+		helpers.Relax(err)
+
+		status := models.BanAppealStatusDenied
+		if reaction.Emoji.Name == banAppealsApproveEmoji {
+			status = models.BanAppealStatusApproved
+		}
+
+		// claim the appeal first, so only one decision applies if multiple mods react at the same time
+		result, err := rethink.Table(models.BanAppealsTable).GetAll(appeal.ID).Filter(
+			rethink.Row.Field("status").Eq(models.BanAppealStatusPending),
+		).Update(map[string]interface{}{
+			"status":             status,
+			"handled_by_user_id": moderator.ID,
+			"handled_at":         time.Now(),
+		}).RunWrite(helpers.GetDB())
+		helpers.Relax(err)
+		if result.Replaced != 1 {
+			return
+		}
+		appeal.Status = status
+
+		var footerText string
+		if appeal.Status == models.BanAppealStatusApproved {
+			err = session.GuildBanDelete(appeal.GuildID, appeal.UserID)
+			if err != nil {
+				// the user might have been unbanned manually already
+				if errD, ok := err.(*discordgo.RESTError); !ok || errD.Response == nil || errD.Response.StatusCode != http.StatusNotFound {
+					ba.logger().WithField("GuildID", appeal.GuildID).WithField("UserID", appeal.UserID).Warn("unable to unban: " + err.Error())
+					// release the appeal again, so it can be retried
+					_, err = rethink.Table(models.BanAppealsTable).Get(appeal.ID).Update(map[string]interface{}{
+						"status":             models.BanAppealStatusPending,
+						"handled_by_user_id": "",
+						"handled_at":         time.Time{},
+					}).RunWrite(helpers.GetDB())
+					helpers.RelaxLog(err)
+					helpers.SendMessage(appeal.ChannelID, helpers.GetTextF("plugins.banappeals.unban-failed", appeal.UserID))
+					return
+				}
+			}
+			footerText = helpers.GetTextF("plugins.banappeals.embed-footer-approved", moderator.Username, moderator.Discriminator)
+			err = ba.sendDM(appeal.UserID, helpers.GetTextF("plugins.banappeals.approved-dm", guild.Name))
+		} else {
+			footerText = helpers.GetTextF("plugins.banappeals.embed-footer-denied", moderator.Username, moderator.Discriminator)
+			err = ba.sendDM(appeal.UserID, helpers.GetTextF("plugins.banappeals.denied-dm", guild.Name))
+		}
+		if err != nil {
+			footerText += " | " + helpers.GetText("plugins.banappeals.embed-footer-dm-failed")
+		}
+
+		message, err := helpers.GetMessage(appeal.ChannelID, appeal.MessageID)
+		if err != nil || len(message.Embeds) <= 0 {
+			return
+		}
+		appealEmbed := message.Embeds[0]
+		appealEmbed.Footer = &discordgo.MessageEmbedFooter{Text: footerText}
+		if appeal.Status == models.BanAppealStatusApproved {
+			appealEmbed.Color = 0x2ECC71
+		} else {
+			appealEmbed.Color = 0xE74C3C
+		}
+		helpers.EditEmbed(appeal.ChannelID, appeal.MessageID, appealEmbed)
+		session.MessageReactionsRemoveAll(appeal.ChannelID, appeal.MessageID)
+	}()
+}
+
+func (ba *BanAppeals) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+
+}
+
+func (ba *BanAppeals) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
+
+}
+
+func (ba *BanAppeals) OnGuildBanRemove(user *discordgo.GuildBanRemove, session *discordgo.Session) {
+
+}
@@ -57,6 +57,34 @@ func (d *Donators) Action(command string, content string, msg *discordgo.Message
 				return
 			})
 			return
+		case "link": // [p]donators link <user> <name>
+			helpers.RequireRobyulMod(msg, func() {
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
+					return
+				}
+
+				targetUser, err := helpers.GetUserFromMention(args[1])
+				if err != nil || targetUser == nil || targetUser.ID == "" {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+					return
+				}
+
+				name := strings.TrimSpace(strings.Replace(content, strings.Join(args[:2], " "), "", 1))
+
+				err = d.LinkDonator(name, targetUser.ID)
+				if err == rethink.ErrEmptyResult {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.donators.link-not-found", name))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+				helpers.Relax(err)
+
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.donators.link-success", name, targetUser.Username))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			})
+			return
 		}
 	}
 
@@ -101,6 +129,22 @@ func (d *Donators) InsertDonator(Name string, HeartOverride string) (err error)
 	return err
 }
 
+// LinkDonator sets the user of the donator with the name, the user is used for donator badges
+func (d *Donators) LinkDonator(Name string, UserID string) (err error) {
+	result, err := rethink.Table(models.DonatorsTable).Filter(
+		rethink.Row.Field("name").Eq(Name),
+	).Update(map[string]interface{}{
+		"user_id": UserID,
+	}).RunWrite(helpers.GetDB())
+	if err != nil {
+		return err
+	}
+	if result.Replaced <= 0 && result.Unchanged <= 0 {
+		return rethink.ErrEmptyResult
+	}
+	return nil
+}
+
 func (d *Donators) GetDonators() (entries []models.DonatorEntry, err error) {
 	listCursor, err := rethink.Table(models.DonatorsTable).OrderBy(rethink.Asc("added_at")).Run(helpers.GetDB())
 	if err != nil {
@@ -0,0 +1,716 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type evasionAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next evasionAction)
+
+type Evasion struct{}
+
+type evasionSuspect struct {
+	Ban        models.EvasionBanEntry
+	Confidence int
+	Signals    []string
+}
+
+const (
+	evasionDefaultThreshold = 50
+	evasionBanEmoji         = "🔨"
+	evasionIgnoreEmoji      = "❎"
+	// give the mod plugin time to store the join, so we know the invite used
+	evasionJoinDelay = 5 * time.Second
+
+	evasionWeightSameAvatar      = 50
+	evasionWeightSimilarNames    = 35
+	evasionWeightCreatedNearBan  = 30
+	evasionWeightCreatedAfterBan = 15
+	evasionWeightSameInvite      = 15
+	evasionMinimumNameSimilarity = 0.75
+	evasionMinimumNameLength     = 3
+)
+
+func (e *Evasion) Commands() []string {
+	return []string{
+		"evasion",
+	}
+}
+
+func (e *Evasion) Init(session *discordgo.Session) {
+
+}
+
+func (e *Evasion) Uninit(session *discordgo.Session) {
+
+}
+
+func (e *Evasion) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := e.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (e *Evasion) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) evasionAction {
+	if len(args) < 1 || args[0] == "status" {
+		if !helpers.IsMod(in) {
+			*out = e.newMsg("mod.no_permission")
+			return e.actionFinish
+		}
+		return e.actionStatus
+	}
+
+	if !helpers.IsAdmin(in) {
+		*out = e.newMsg("admin.no_permission")
+		return e.actionFinish
+	}
+
+	switch args[0] {
+	case "enable":
+		return e.actionEnable
+	case "disable":
+		return e.actionDisable
+	case "signal":
+		return e.actionSignal
+	case "threshold":
+		return e.actionThreshold
+	}
+
+	*out = e.newMsg("bot.arguments.invalid")
+	return e.actionFinish
+}
+
+// [p]evasion [status]
+func (e *Evasion) actionStatus(args []string, in *discordgo.Message, out **discordgo.MessageSend) evasionAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	statusEmbed := &discordgo.MessageEmbed{
+		Title: helpers.GetText("plugins.evasion.status-title"),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Enabled", Value: e.boolText(settings.EvasionDetectionEnabled), Inline: true},
+			{Name: "Threshold", Value: fmt.Sprintf("%d%%", e.getThreshold(settings)), Inline: true},
+			{Name: "Signals", Value: fmt.Sprintf("Similar names (`names`): %s\nSame avatar (`avatar`): %s\nCreated near a ban (`created`): %s\nSame invite (`invite`): %s",
+				e.boolText(settings.EvasionDetectionSignals.SimilarNames),
+				e.boolText(settings.EvasionDetectionSignals.SameAvatar),
+				e.boolText(settings.EvasionDetectionSignals.CreatedNearBan),
+				e.boolText(settings.EvasionDetectionSignals.SameInvite)), Inline: false},
+		},
+	}
+	if settings.InspectsChannel == "" {
+		statusEmbed.Description = helpers.GetTextF("plugins.evasion.status-no-inspects-channel", helpers.GetPrefixForServer(channel.GuildID))
+	}
+
+	*out = &discordgo.MessageSend{Embed: statusEmbed}
+	return e.actionFinish
+}
+
+// [p]evasion enable
+func (e *Evasion) actionEnable(args []string, in *discordgo.Message, out **discordgo.MessageSend) evasionAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.EvasionDetectionEnabled = true
+	if !settings.EvasionDetectionSignals.SimilarNames && !settings.EvasionDetectionSignals.SameAvatar &&
+		!settings.EvasionDetectionSignals.CreatedNearBan && !settings.EvasionDetectionSignals.SameInvite {
+		settings.EvasionDetectionSignals.SimilarNames = true
+		settings.EvasionDetectionSignals.SameAvatar = true
+		settings.EvasionDetectionSignals.CreatedNearBan = true
+		settings.EvasionDetectionSignals.SameInvite = true
+	}
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.InspectsChannel == "" {
+		*out = e.newMsg("plugins.evasion.enabled-no-inspects-channel", helpers.GetPrefixForServer(channel.GuildID))
+		return e.actionFinish
+	}
+
+	*out = e.newMsg("plugins.evasion.enabled", settings.InspectsChannel)
+	return e.actionFinish
+}
+
+// [p]evasion disable
+func (e *Evasion) actionDisable(args []string, in *discordgo.Message, out **discordgo.MessageSend) evasionAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.EvasionDetectionEnabled = false
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = e.newMsg("plugins.evasion.disabled")
+	return e.actionFinish
+}
+
+// [p]evasion signal <names|avatar|created|invite>
+func (e *Evasion) actionSignal(args []string, in *discordgo.Message, out **discordgo.MessageSend) evasionAction {
+	if len(args) < 2 {
+		*out = e.newMsg("bot.arguments.too-few")
+		return e.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	var enabled bool
+	switch strings.ToLower(args[1]) {
+	case "names":
+		settings.EvasionDetectionSignals.SimilarNames = !settings.EvasionDetectionSignals.SimilarNames
+		enabled = settings.EvasionDetectionSignals.SimilarNames
+	case "avatar":
+		settings.EvasionDetectionSignals.SameAvatar = !settings.EvasionDetectionSignals.SameAvatar
+		enabled = settings.EvasionDetectionSignals.SameAvatar
+	case "created":
+		settings.EvasionDetectionSignals.CreatedNearBan = !settings.EvasionDetectionSignals.CreatedNearBan
+		enabled = settings.EvasionDetectionSignals.CreatedNearBan
+	case "invite":
+		settings.EvasionDetectionSignals.SameInvite = !settings.EvasionDetectionSignals.SameInvite
+		enabled = settings.EvasionDetectionSignals.SameInvite
+	default:
+		*out = e.newMsg("bot.arguments.invalid")
+		return e.actionFinish
+	}
+
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if enabled {
+		*out = e.newMsg("plugins.evasion.signal-enabled", strings.ToLower(args[1]))
+	} else {
+		*out = e.newMsg("plugins.evasion.signal-disabled", strings.ToLower(args[1]))
+	}
+	return e.actionFinish
+}
+
+// [p]evasion threshold <percent>
+func (e *Evasion) actionThreshold(args []string, in *discordgo.Message, out **discordgo.MessageSend) evasionAction {
+	if len(args) < 2 {
+		*out = e.newMsg("bot.arguments.too-few")
+		return e.actionFinish
+	}
+
+	threshold, err := strconv.Atoi(strings.TrimSuffix(args[1], "%"))
+	if err != nil || threshold < 1 || threshold > 100 {
+		*out = e.newMsg("bot.arguments.invalid")
+		return e.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.EvasionDetectionThreshold = threshold
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = e.newMsg("plugins.evasion.threshold-set", threshold)
+	return e.actionFinish
+}
+
+func (e *Evasion) getThreshold(settings models.Config) int {
+	if settings.EvasionDetectionThreshold <= 0 {
+		return evasionDefaultThreshold
+	}
+	return settings.EvasionDetectionThreshold
+}
+
+// getBans returns all known bans of the guild, bans recorded before the detection was added only contain the last known username
+func (e *Evasion) getBans(guildID string) (bans []models.EvasionBanEntry, err error) {
+	listCursor, err := rethink.Table(models.EvasionBansTable).GetAllByIndex("guild_id", guildID).Run(helpers.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&bans)
+	if err != nil {
+		return nil, err
+	}
+
+	var guildBans []*discordgo.GuildBan
+	err = cache.GetRedisCacheCodec().Get(fmt.Sprintf("robyul2-discord:api:bans:%s", guildID), &guildBans)
+	if err != nil {
+		// ban list is not cached, the bot might not have the permission to read it
+		return bans, nil
+	}
+
+	for _, guildBan := range guildBans {
+		if guildBan.User == nil {
+			continue
+		}
+		known := false
+		for _, ban := range bans {
+			if ban.UserID == guildBan.User.ID {
+				known = true
+				break
+			}
+		}
+		if !known {
+			bans = append(bans, models.EvasionBanEntry{
+				GuildID:    guildID,
+				UserID:     guildBan.User.ID,
+				Names:      []string{guildBan.User.Username},
+				AvatarHash: guildBan.User.Avatar,
+			})
+		}
+	}
+	return bans, nil
+}
+
+// getNames returns the current and previous usernames and nicknames of the user on the guild
+func (e *Evasion) getNames(guildID string, user *discordgo.User, nickname string) (names []string) {
+	names = []string{user.Username}
+	if nickname != "" {
+		names = append(names, nickname)
+	}
+
+	namesPlugin := &Names{}
+	usernames, err := namesPlugin.GetUsernames(user.ID)
+	if err == nil {
+		names = append(names, usernames...)
+	}
+	nicknames, err := namesPlugin.GetNicknames(guildID, user.ID)
+	if err == nil {
+		names = append(names, nicknames...)
+	}
+
+	uniqueNames := make([]string, 0)
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		seen := false
+		for _, uniqueName := range uniqueNames {
+			if uniqueName == name {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			uniqueNames = append(uniqueNames, name)
+		}
+	}
+	return uniqueNames
+}
+
+// getLastInviteCode returns the invite code used for the last join of the user on the guild
+func (e *Evasion) getLastInviteCode(guildID string, userID string) string {
+	joins, err := (&Mod{}).GetJoins(userID, guildID)
+	if err != nil {
+		return ""
+	}
+	var lastJoin DB_Mod_JoinLog
+	for _, join := range joins {
+		if join.JoinedAt.After(lastJoin.JoinedAt) {
+			lastJoin = join
+		}
+	}
+	return lastJoin.InviteCodeUsed
+}
+
+// score calculates how likely the member is an alt account of the banned user
+func (e *Evasion) score(settings models.Config, ban models.EvasionBanEntry,
+	user *discordgo.User, names []string, inviteCode string) (suspect evasionSuspect) {
+	suspect = evasionSuspect{Ban: ban, Signals: make([]string, 0)}
+
+	if settings.EvasionDetectionSignals.SameAvatar && user.Avatar != "" && user.Avatar == ban.AvatarHash {
+		suspect.Confidence += evasionWeightSameAvatar
+		suspect.Signals = append(suspect.Signals, "🖼 Same avatar")
+	}
+
+	if settings.EvasionDetectionSignals.SimilarNames {
+		var bestSimilarity float64
+		var bestName, bestBannedName string
+		for _, name := range names {
+			for _, bannedName := range ban.Names {
+				similarity := e.nameSimilarity(name, bannedName)
+				if similarity > bestSimilarity {
+					bestSimilarity = similarity
+					bestName = name
+					bestBannedName = bannedName
+				}
+			}
+		}
+		if bestSimilarity >= evasionMinimumNameSimilarity {
+			suspect.Confidence += int(float64(evasionWeightSimilarNames) * bestSimilarity)
+			suspect.Signals = append(suspect.Signals, fmt.Sprintf("🏷 Similar name: `%s` ~ `%s` (%d%%)",
+				bestName, bestBannedName, int(bestSimilarity*100)))
+		}
+	}
+
+	if settings.EvasionDetectionSignals.CreatedNearBan && !ban.BannedAt.IsZero() {
+		createdAt := helpers.GetTimeFromSnowflake(user.ID)
+		if createdAt.After(ban.BannedAt) {
+			createdAfter := createdAt.Sub(ban.BannedAt)
+			if createdAfter <= 24*time.Hour {
+				suspect.Confidence += evasionWeightCreatedNearBan
+				suspect.Signals = append(suspect.Signals, fmt.Sprintf("🕑 Account created %s after the ban", e.durationText(createdAfter)))
+			} else if createdAfter <= 7*24*time.Hour {
+				suspect.Confidence += evasionWeightCreatedAfterBan
+				suspect.Signals = append(suspect.Signals, fmt.Sprintf("🕑 Account created %s after the ban", e.durationText(createdAfter)))
+			}
+		}
+	}
+
+	if settings.EvasionDetectionSignals.SameInvite && inviteCode != "" && inviteCode == ban.InviteCode {
+		suspect.Confidence += evasionWeightSameInvite
+		suspect.Signals = append(suspect.Signals, fmt.Sprintf("📨 Joined with the same invite `%s`", inviteCode))
+	}
+
+	if suspect.Confidence > 100 {
+		suspect.Confidence = 100
+	}
+	return suspect
+}
+
+func (e *Evasion) durationText(duration time.Duration) string {
+	if duration < time.Hour {
+		return fmt.Sprintf("%d minutes", int(duration.Minutes()))
+	}
+	if duration < 48*time.Hour {
+		return fmt.Sprintf("%d hours", int(duration.Hours()))
+	}
+	return fmt.Sprintf("%d days", int(duration.Hours()/24))
+}
+
+// nameSimilarity returns the similarity of two names between 0 and 1, ignoring case, spaces and symbols
+func (e *Evasion) nameSimilarity(a string, b string) float64 {
+	normalize := func(name string) []rune {
+		result := make([]rune, 0)
+		for _, char := range strings.ToLower(name) {
+			if unicode.IsLetter(char) || unicode.IsNumber(char) {
+				result = append(result, char)
+			}
+		}
+		return result
+	}
+	runesA := normalize(a)
+	runesB := normalize(b)
+	if len(runesA) < evasionMinimumNameLength || len(runesB) < evasionMinimumNameLength {
+		return 0
+	}
+
+	// levenshtein distance
+	previous := make([]int, len(runesB)+1)
+	current := make([]int, len(runesB)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(runesA); i++ {
+		current[0] = i
+		for j := 1; j <= len(runesB); j++ {
+			cost := 1
+			if runesA[i-1] == runesB[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+	distance := previous[len(runesB)]
+
+	maxLength := len(runesA)
+	if len(runesB) > maxLength {
+		maxLength = len(runesB)
+	}
+	return 1 - float64(distance)/float64(maxLength)
+}
+
+func (e *Evasion) isIgnored(guildID string, userID string, bannedUserID string) bool {
+	listCursor, err := rethink.Table(models.EvasionAlertsTable).GetAllByIndex("guild_id", guildID).Filter(
+		rethink.Row.Field("user_id").Eq(userID),
+	).Filter(
+		rethink.Row.Field("banned_user_id").Eq(bannedUserID),
+	).Filter(
+		rethink.Row.Field("status").Eq(models.EvasionAlertStatusIgnored),
+	).Count().Run(helpers.GetDB())
+	if err != nil {
+		return false
+	}
+	defer listCursor.Close()
+
+	var count int
+	err = listCursor.One(&count)
+	return err == nil && count > 0
+}
+
+func (e *Evasion) getAlertByMessageID(messageID string) (alert models.EvasionAlertEntry, err error) {
+	listCursor, err := rethink.Table(models.EvasionAlertsTable).GetAllByIndex("message_id", messageID).Run(helpers.GetDB())
+	if err != nil {
+		return alert, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&alert)
+	return alert, err
+}
+
+func (e *Evasion) getAlertEmbed(member *discordgo.Member, suspect evasionSuspect) *discordgo.MessageEmbed {
+	bannedUserName := "N/A"
+	if len(suspect.Ban.Names) > 0 {
+		bannedUserName = suspect.Ban.Names[0]
+	}
+
+	alertEmbed := &discordgo.MessageEmbed{
+		Title: helpers.GetText("plugins.evasion.alert-title"),
+		Description: helpers.GetTextF("plugins.evasion.alert-description",
+			member.User.ID, member.User.Username, member.User.Discriminator, member.User.ID, bannedUserName, suspect.Ban.UserID),
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: helpers.GetAvatarUrl(member.User)},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Confidence", Value: fmt.Sprintf("**%d%%**", suspect.Confidence), Inline: true},
+			{Name: "Account created", Value: helpers.GetTimeFromSnowflake(member.User.ID).Format(time.ANSIC), Inline: true},
+			{Name: "Signals", Value: strings.Join(suspect.Signals, "\n"), Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: helpers.GetTextF("plugins.evasion.alert-footer", evasionBanEmoji, evasionIgnoreEmoji),
+		},
+		Color: 0xE67E22,
+	}
+	if !suspect.Ban.BannedAt.IsZero() {
+		alertEmbed.Fields = append(alertEmbed.Fields, &discordgo.MessageEmbedField{
+			Name: "Banned user banned at", Value: suspect.Ban.BannedAt.Format(time.ANSIC), Inline: true})
+	}
+	return alertEmbed
+}
+
+func (e *Evasion) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+
+}
+
+func (e *Evasion) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
+
+}
+
+func (e *Evasion) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+	if member.User == nil || member.User.Bot {
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(member.GuildID)
+	if !settings.EvasionDetectionEnabled || settings.InspectsChannel == "" {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		time.Sleep(evasionJoinDelay)
+
+		bans, err := e.getBans(member.GuildID)
+		if err != nil {
+			e.logger().WithField("GuildID", member.GuildID).Error("unable to get bans: " + err.Error())
+			return
+		}
+		if len(bans) <= 0 {
+			return
+		}
+
+		names := e.getNames(member.GuildID, member.User, member.Nick)
+		inviteCode := e.getLastInviteCode(member.GuildID, member.User.ID)
+
+		var bestSuspect evasionSuspect
+		for _, ban := range bans {
+			if ban.UserID == member.User.ID {
+				continue
+			}
+			suspect := e.score(settings, ban, member.User, names, inviteCode)
+			if suspect.Confidence > bestSuspect.Confidence {
+				bestSuspect = suspect
+			}
+		}
+
+		if bestSuspect.Confidence < e.getThreshold(settings) {
+			return
+		}
+		if e.isIgnored(member.GuildID, member.User.ID, bestSuspect.Ban.UserID) {
+			return
+		}
+
+		e.logger().WithField("GuildID", member.GuildID).WithField("UserID", member.User.ID).Info(fmt.Sprintf(
+			"suspected ban evasion of #%s with %d%% confidence", bestSuspect.Ban.UserID, bestSuspect.Confidence))
+
+		messages, err := helpers.SendEmbed(settings.InspectsChannel, e.getAlertEmbed(member, bestSuspect))
+		if err != nil || len(messages) <= 0 {
+			return
+		}
+
+		_, err = rethink.Table(models.EvasionAlertsTable).Insert(models.EvasionAlertEntry{
+			GuildID:      member.GuildID,
+			UserID:       member.User.ID,
+			BannedUserID: bestSuspect.Ban.UserID,
+			Confidence:   bestSuspect.Confidence,
+			ChannelID:    messages[0].ChannelID,
+			MessageID:    messages[0].ID,
+			Status:       models.EvasionAlertStatusOpen,
+			CreatedAt:    time.Now(),
+		}).RunWrite(helpers.GetDB())
+		helpers.Relax(err)
+
+		session.MessageReactionAdd(messages[0].ChannelID, messages[0].ID, evasionBanEmoji)
+		session.MessageReactionAdd(messages[0].ChannelID, messages[0].ID, evasionIgnoreEmoji)
+	}()
+}
+
+func (e *Evasion) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (e *Evasion) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+	if reaction.UserID == session.State.User.ID {
+		return
+	}
+	if reaction.Emoji.Name != evasionBanEmoji && reaction.Emoji.Name != evasionIgnoreEmoji {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		alert, err := e.getAlertByMessageID(reaction.MessageID)
+		if err != nil || alert.ID == "" || alert.Status != models.EvasionAlertStatusOpen {
+			return
+		}
+
+		if !helpers.IsModByID(alert.GuildID, reaction.UserID) {
+			return
+		}
+
+		moderator, err := helpers.GetUser(reaction.UserID)
+		helpers.Relax(err)
+
+		var footerText string
+		if reaction.Emoji.Name == evasionBanEmoji {
+			err = session.GuildBanCreateWithReason(alert.GuildID, alert.UserID,
+				fmt.Sprintf("Ban evasion of #%s, confirmed by %s#%s", alert.BannedUserID, moderator.Username, moderator.Discriminator), 0)
+			if err != nil {
+				e.logger().WithField("GuildID", alert.GuildID).WithField("UserID", alert.UserID).Warn("unable to ban: " + err.Error())
+				helpers.SendMessage(alert.ChannelID, helpers.GetTextF("plugins.evasion.ban-failed", alert.UserID))
+				return
+			}
+			alert.Status = models.EvasionAlertStatusBanned
+			footerText = helpers.GetTextF("plugins.evasion.alert-footer-banned", moderator.Username, moderator.Discriminator)
+		} else {
+			alert.Status = models.EvasionAlertStatusIgnored
+			footerText = helpers.GetTextF("plugins.evasion.alert-footer-ignored", moderator.Username, moderator.Discriminator)
+		}
+
+		alert.HandledByUserID = moderator.ID
+		_, err = rethink.Table(models.EvasionAlertsTable).Get(alert.ID).Update(alert).RunWrite(helpers.GetDB())
+		helpers.Relax(err)
+
+		message, err := helpers.GetMessage(alert.ChannelID, alert.MessageID)
+		if err != nil || len(message.Embeds) <= 0 {
+			return
+		}
+		alertEmbed := message.Embeds[0]
+		alertEmbed.Footer = &discordgo.MessageEmbedFooter{Text: footerText}
+		if alert.Status == models.EvasionAlertStatusBanned {
+			alertEmbed.Color = 0xE74C3C
+		} else {
+			alertEmbed.Color = 0x95A5A6
+		}
+		helpers.EditEmbed(alert.ChannelID, alert.MessageID, alertEmbed)
+		session.MessageReactionsRemoveAll(alert.ChannelID, alert.MessageID)
+	}()
+}
+
+func (e *Evasion) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+
+}
+
+func (e *Evasion) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
+	if user.User == nil {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		// store a snapshot of the banned user to compare new members against
+		ban := models.EvasionBanEntry{
+			GuildID:    user.GuildID,
+			UserID:     user.User.ID,
+			BannedAt:   time.Now(),
+			Names:      e.getNames(user.GuildID, user.User, ""),
+			AvatarHash: user.User.Avatar,
+			InviteCode: e.getLastInviteCode(user.GuildID, user.User.ID),
+		}
+
+		_, err := rethink.Table(models.EvasionBansTable).GetAllByIndex("user_id", user.User.ID).Filter(
+			rethink.Row.Field("guild_id").Eq(user.GuildID),
+		).Delete().RunWrite(helpers.GetDB())
+		helpers.Relax(err)
+
+		_, err = rethink.Table(models.EvasionBansTable).Insert(ban).RunWrite(helpers.GetDB())
+		helpers.Relax(err)
+	}()
+}
+
+func (e *Evasion) OnGuildBanRemove(user *discordgo.GuildBanRemove, session *discordgo.Session) {
+	if user.User == nil {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		_, err := rethink.Table(models.EvasionBansTable).GetAllByIndex("user_id", user.User.ID).Filter(
+			rethink.Row.Field("guild_id").Eq(user.GuildID),
+		).Delete().RunWrite(helpers.GetDB())
+		helpers.Relax(err)
+	}()
+}
+
+func (e *Evasion) boolText(value bool) string {
+	if value {
+		return "✅"
+	}
+	return "❌"
+}
+
+func (e *Evasion) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) evasionAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (e *Evasion) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (e *Evasion) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "evasion")
+}
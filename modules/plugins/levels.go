@@ -2,7 +2,9 @@ package plugins
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,19 +13,20 @@ import (
 	"image/color"
 	"image/draw"
 	"image/gif"
+	_ "image/jpeg"
 	"image/png"
 	"io/ioutil"
 	"math"
 	"math/rand"
 	"net/http"
 	"net/url"
-	"os"
-	"os/exec"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/Seklfreak/Robyul2/cache"
 	"github.com/Seklfreak/Robyul2/helpers"
@@ -33,11 +36,15 @@ import (
 	"github.com/andybons/gogif"
 	"github.com/bradfitz/slice"
 	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
 	"github.com/getsentry/raven-go"
 	redisCache "github.com/go-redis/cache"
+	"github.com/golang/freetype/truetype"
 	rethink "github.com/gorethink/gorethink"
 	"github.com/lucasb-eyer/go-colorful"
 	"github.com/nfnt/resize"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 	"gopkg.in/oleiade/lane.v1"
 )
 
@@ -48,8 +55,11 @@ type Levels struct {
 }
 
 type ProcessExpInfo struct {
-	GuildID string
-	UserID  string
+	GuildID      string
+	ChannelID    string
+	UserID       string
+	VoiceMinutes int     // EXP for time spent in voice instead of a message if set
+	Quality      float64 // scales the message EXP if set, see scoreMessageQuality
 }
 
 var (
@@ -70,6 +80,18 @@ var (
 	temporaryIgnoredGuilds []string
 
 	expStack = lane.NewStack()
+
+	levelsImportIDRegex   = regexp.MustCompile(`^[0-9]{15,20}$`)
+	levelsImportIDKeys    = []string{"userid", "id", "discordid", "memberid", "user"}
+	levelsImportExpKeys   = []string{"xp", "exp", "experience", "totalxp", "totalexp", "points"}
+	levelsImportLevelKeys = []string{"level", "lvl"}
+
+	ErrLevelsInvalidPeriod  = errors.New("invalid leaderboard period")
+	ErrLevelsNoActiveSeason = errors.New("no active season")
+
+	levelsMultiplierEvents           []models.LevelsMultiplierEventEntry
+	levelsMultiplierEventsLock       sync.RWMutex
+	levelsMultiplierEventsUpdateLock sync.Mutex
 )
 
 func (m *Levels) Commands() []string {
@@ -87,10 +109,12 @@ func (m *Levels) Commands() []string {
 }
 
 type DB_Levels_ServerUser struct {
-	ID      string `gorethink:"id,omitempty"`
-	UserID  string `gorethink:"userid"`
-	GuildID string `gorethink:"guildid"`
-	Exp     int64  `gorethink:"exp"`
+	ID            string `gorethink:"id,omitempty"`
+	UserID        string `gorethink:"userid"`
+	GuildID       string `gorethink:"guildid"`
+	Exp           int64  `gorethink:"exp"`
+	VoiceExpDay   string `gorethink:"voice_exp_day"` // UTC day of VoiceExpToday, 2006-01-02
+	VoiceExpToday int64  `gorethink:"voice_exp_today"`
 }
 
 type DB_Profile_Background struct {
@@ -116,6 +140,7 @@ type DB_Profile_Userdata struct {
 	DetailOpacity     string    `gorethink:"detail_opacity"`
 	Timezone          string    `gorethink:"timezone"`
 	Birthday          string    `gorethink:"birthday"`
+	LevelUpOptOut     bool      `gorethink:"levelup_optout"`
 }
 
 type DB_Badge struct {
@@ -130,6 +155,17 @@ type DB_Badge struct {
 	LevelRequirement int       `gorethink:"levelrequirement"`
 	AllowedUserIDs   []string  `gorethinK:"allowed_userids"`
 	DeniedUserIDs    []string  `gorethinK:"allowed_userids"`
+
+	Rules         []DB_Badge_Rule `gorethink:"rules"`
+	RuleUserIDs   []string        `gorethink:"rule_userids"`
+	AnnounceRules bool            `gorethink:"announce_rules"`
+}
+
+// DB_Badge_Rule is a requirement for automatically awarded badges, a badge is awarded if all of its rules are met
+type DB_Badge_Rule struct {
+	Type     string `gorethink:"type"`
+	TargetID string `gorethink:"targetid"` // role or season ID
+	Value    int64  `gorethink:"value"`    // starboard entries, days on the server, rep, or top n of a season
 }
 
 type Cache_Levels_top struct {
@@ -148,10 +184,25 @@ var (
 	cachePath                string
 	assetsPath               string
 	htmlTemplateString       string
-	levelsEnv                = os.Environ()
-	webshotBinary            string
 	topCache                 []Cache_Levels_top
 	activeBadgePickerUserIDs map[string]string
+
+	levelsAntiFarmingUserHistory    = make(map[string][]string)
+	levelsAntiFarmingChannelHistory = make(map[string][]levelsAntiFarmingChannelMessage)
+	levelsAntiFarmingSuspects       = make(map[string]map[string]*levelsAntiFarmingSuspect)
+	levelsAntiFarmingLock           sync.Mutex
+	levelsAntiFarmingCleanRegex     = regexp.MustCompile(`<a?:[A-Za-z0-9_]+:[0-9]+>|<[@#&!]+[0-9]+>|https?://[^\s]+`)
+	levelsAntiFarmingCommandRegex   = regexp.MustCompile(`^[!?.$%;~&+=\-]{1,2}[a-z]`)
+
+	levelsPeriodRankingsCache     = make(map[string]levelsPeriodRankingsCacheEntry)
+	levelsPeriodRankingsCacheLock sync.Mutex
+
+	levelsBadgeRuleGuildIDs     map[string]bool
+	levelsBadgeRuleGuildIDsLock sync.Mutex
+
+	levelsProfileFontRegular  *truetype.Font
+	levelsProfileFontBold     *truetype.Font
+	levelsProfileFontFallback *truetype.Font
 )
 
 const (
@@ -160,6 +211,97 @@ const (
 	TimeBirthdayFormat = "01/02"
 )
 
+const (
+	levelsCurveQuadratic   = "quadratic"
+	levelsCurveLinear      = "linear"
+	levelsCurveExponential = "exponential"
+	levelsCurveCustom      = "custom"
+
+	levelsCurveLinearExpPerLevel = 1000
+	levelsCurveExponentialBase   = 1.15
+	levelsCurveExponentialFactor = 500
+	levelsCurveCustomMaxLevels   = 500
+	levelsCurvePreviewLevels     = 50
+	// levelsExpMax is the most EXP a user can have, levels needing more EXP can't be reached
+	levelsExpMax = math.MaxInt64 / 2
+
+	levelsExpPerMessageDefaultMin = 10
+	levelsExpPerMessageDefaultMax = 14
+	levelsExpPerMessageLimit      = 1000
+
+	levelsVoiceExpDailyCapLimit = 1000000
+
+	levelsAnnouncementsDestinationCurrent = "current"
+	levelsAnnouncementsDestinationChannel = "channel"
+	levelsAnnouncementsDestinationDM      = "dm"
+	levelsAnnouncementsTemplateMaxLength  = 1000
+
+	levelsPeriodWeekly       = "weekly"
+	levelsPeriodMonthly      = "monthly"
+	levelsPeriodSeason       = "season"
+	levelsDayFormat          = "2006-01-02"
+	levelsSeasonArchiveSize  = 100
+	levelsSeasonResultsShown = 25
+	levelsSeasonMaxDuration  = 365 * 24 * time.Hour
+	levelsPeriodCacheTime    = 5 * time.Minute
+
+	levelsImportMaxEntries = 100000
+	levelsImportMaxLevel   = 10000
+	levelsImportPreview    = 10
+
+	levelsAntiFarmingLow                    = "low"
+	levelsAntiFarmingMedium                 = "medium"
+	levelsAntiFarmingHigh                   = "high"
+	levelsAntiFarmingSignalShort            = "short"
+	levelsAntiFarmingSignalRepetitive       = "repetitive"
+	levelsAntiFarmingSignalDuplicate        = "duplicate"
+	levelsAntiFarmingSignalConversationless = "conversationless"
+	levelsAntiFarmingSignalCommand          = "command"
+	levelsAntiFarmingHistorySize            = 5
+	levelsAntiFarmingChannelHistorySize     = 8
+	levelsAntiFarmingConversationWindow     = 2 * time.Minute
+	levelsAntiFarmingFastAlternationWindow  = 90 * time.Second
+	levelsAntiFarmingUniqueRatioMinLength   = 8
+	levelsAntiFarmingSimilarityMaxLength    = 500
+	levelsAntiFarmingSuspectWindow          = 24 * time.Hour
+	levelsAntiFarmingReportThreshold        = 25
+	levelsAntiFarmingSuspectsShown          = 20
+
+	levelsRepCooldownDefaultHours = 12
+	levelsRepCooldownMaxHours     = 168
+	levelsRepReasonMaxLength      = 200
+	levelsRepHistorySize          = 10
+	levelsRepTopSize              = 10
+	levelsRepRingWindow           = 30 * 24 * time.Hour
+	levelsRepRingLimit            = 3
+
+	levelsBadgeRuleStarboard     = "starboard"
+	levelsBadgeRuleMember        = "member"
+	levelsBadgeRuleDonator       = "donator"
+	levelsBadgeRuleRep           = "rep"
+	levelsBadgeRuleSeason        = "season"
+	levelsBadgeRuleRole          = "role"
+	levelsBadgeRulesMax          = 5
+	levelsBadgeRulesInterval     = 30 * time.Minute
+	levelsBadgeRuleAnnounceLimit = 10
+
+	levelsProfileCardWidth            = 400
+	levelsProfileCardHeight           = 300
+	levelsProfileBadgesPerRow         = 4
+	levelsProfileCardCacheExpiration  = time.Hour
+	levelsProfileImageCacheExpiration = 24 * time.Hour
+
+	levelsMultiplierLimit            = 10
+	levelsMultiplierEventMaxStartsIn = 365 * 24 * time.Hour
+	levelsMultiplierEventMaxDuration = 30 * 24 * time.Hour
+)
+
+// levelsCurve describes how much EXP is required for a level, Thresholds are only used by the custom curve
+type levelsCurve struct {
+	Type       string
+	Thresholds []int64
+}
+
 func (m *Levels) Init(session *discordgo.Session) {
 	m.BucketInit()
 
@@ -170,7 +312,11 @@ func (m *Levels) Init(session *discordgo.Session) {
 	htmlTemplate, err := ioutil.ReadFile(assetsPath + "profile.html")
 	helpers.Relax(err)
 	htmlTemplateString = string(htmlTemplate)
-	webshotBinary, err = exec.LookPath("webshot")
+	levelsProfileFontRegular, err = m.loadProfileFont("Roboto/Roboto-Regular.ttf")
+	helpers.Relax(err)
+	levelsProfileFontBold, err = m.loadProfileFont("Roboto/Roboto-Bold.ttf")
+	helpers.Relax(err)
+	levelsProfileFontFallback, err = m.loadProfileFont("2593-UnDotum.ttf")
 	helpers.Relax(err)
 
 	go m.processExpStackLoop()
@@ -179,6 +325,23 @@ func (m *Levels) Init(session *discordgo.Session) {
 	go m.cacheTopLoop()
 	log.WithField("module", "levels").Info("Started processCacheTopLoop")
 
+	go m.multiplierEventsLoop()
+	log.WithField("module", "levels").Info("Started multiplierEventsLoop")
+
+	go m.voiceExpLoop()
+	log.WithField("module", "levels").Info("Started voiceExpLoop")
+
+	go m.seasonsLoop()
+	log.WithField("module", "levels").Info("Started seasonsLoop")
+
+	go m.badgeRulesLoop()
+	log.WithField("module", "levels").Info("Started badgeRulesLoop")
+
+	go m.antiFarmingCleanupLoop()
+	log.WithField("module", "levels").Info("Started antiFarmingCleanupLoop")
+
+	session.AddHandler(m.OnGuildMemberUpdate)
+
 	activeBadgePickerUserIDs = make(map[string]string, 0)
 
 	go m.setServerFeaturesLoop()
@@ -326,7 +489,7 @@ func (m *Levels) cacheTopLoop() {
 					rankData = Levels_Cache_Ranking_Item{
 						UserID:  level.Key,
 						EXP:     level.Value,
-						Level:   m.getLevelFromExp(level.Value),
+						Level:   m.getLevelFromExp(guildCache.GuildID, level.Value),
 						Ranking: i,
 					}
 
@@ -388,39 +551,459 @@ func (m *Levels) processExpStackLoop() {
 	for {
 		if !expStack.Empty() {
 			expItem := expStack.Pop().(ProcessExpInfo)
+
+			baseExp := m.getRandomExpForMessage(expItem.GuildID)
+			if expItem.VoiceMinutes > 0 {
+				baseExp = int64(helpers.GuildSettingsGetCached(expItem.GuildID).LevelsVoiceExpPerMinute * expItem.VoiceMinutes)
+			} else if expItem.Quality > 0 && expItem.Quality < 1 {
+				baseExp = int64(math.Floor(float64(baseExp)*expItem.Quality + 0.5))
+			}
+
+			multiplier, _ := m.getExpMultiplier(expItem.GuildID, expItem.ChannelID, expItem.UserID)
+			expGainedFloat := math.Floor(float64(baseExp)*multiplier + 0.5)
+			if expGainedFloat > levelsExpMax {
+				expGainedFloat = levelsExpMax
+			}
+			expGained := int64(expGainedFloat)
+			if expGained <= 0 {
+				continue
+			}
+
 			levelsServerUser := m.getLevelsServerUserOrCreateNew(expItem.GuildID, expItem.UserID)
 
+			if expItem.VoiceMinutes > 0 {
+				expGained = m.capVoiceExp(&levelsServerUser, expGained)
+				if expGained <= 0 {
+					continue
+				}
+			}
+
 			expBefore := levelsServerUser.Exp
-			levelBefore := m.getLevelFromExp(levelsServerUser.Exp)
+			levelBefore := m.getLevelFromExp(expItem.GuildID, levelsServerUser.Exp)
 
-			levelsServerUser.Exp += m.getRandomExpForMessage()
+			levelsServerUser.Exp = levelsAddExp(levelsServerUser.Exp, expGained)
 
-			levelAfter := m.getLevelFromExp(levelsServerUser.Exp)
+			levelAfter := m.getLevelFromExp(expItem.GuildID, levelsServerUser.Exp)
 
 			m.setLevelsServerUser(levelsServerUser)
 
+			err := m.addExpHistory(expItem.GuildID, expItem.UserID, expGained)
+			helpers.RelaxLog(err)
+
 			if expBefore <= 0 || levelBefore != levelAfter {
 				err := m.applyLevelsRoles(expItem.GuildID, expItem.UserID, levelAfter)
 				if errD, ok := err.(*discordgo.RESTError); !ok || errD.Message.Message != "404: Not Found" {
 					helpers.RelaxLog(err)
 				}
 			}
+
+			if levelAfter > levelBefore {
+				go m.announceLevelUp(expItem, levelBefore, levelAfter)
+			}
 		} else {
 			time.Sleep(1 * time.Second)
 		}
 	}
 }
 
+func (m *Levels) voiceExpLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "levels").Error("The voiceExpLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.voiceExpLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(1 * time.Minute)
+
+	NextGuild:
+		for _, guild := range cache.GetSession().State.Guilds {
+			settings := helpers.GuildSettingsGetCached(guild.ID)
+			if settings.LevelsVoiceExpPerMinute <= 0 {
+				continue
+			}
+			for _, temporaryIgnoredGuild := range temporaryIgnoredGuilds {
+				if temporaryIgnoredGuild == guild.ID {
+					continue NextGuild
+				}
+			}
+
+			for userID, channelID := range m.getVoiceExpMembers(guild, settings) {
+				expStack.Push(ProcessExpInfo{UserID: userID, GuildID: guild.ID, ChannelID: channelID, VoiceMinutes: 1})
+			}
+		}
+	}
+}
+
+// getVoiceExpMembers returns the members earning voice EXP right now mapped to their voice channel
+// members muted or deafened, in the AFK channel, in an ignored channel or without another eligible member in the channel don't earn EXP
+func (m *Levels) getVoiceExpMembers(guild *discordgo.Guild, settings models.Config) (channelIDs map[string]string) {
+	channelIDs = make(map[string]string)
+
+	eligibleInChannel := make(map[string][]string)
+	for _, voiceState := range guild.VoiceStates {
+		if voiceState.ChannelID == "" || voiceState.ChannelID == guild.AfkChannelID {
+			continue
+		}
+		member, err := helpers.GetGuildMemberWithoutApi(guild.ID, voiceState.UserID)
+		if err != nil || member == nil || member.User == nil || member.User.Bot {
+			continue
+		}
+		if voiceState.Mute || voiceState.Deaf || voiceState.SelfMute || voiceState.SelfDeaf || voiceState.Suppress {
+			continue
+		}
+		ignored := false
+		for _, ignoredChannelID := range settings.LevelsIgnoredChannelIDs {
+			if ignoredChannelID == voiceState.ChannelID {
+				ignored = true
+			}
+		}
+		for _, ignoredUserID := range settings.LevelsIgnoredUserIDs {
+			if ignoredUserID == voiceState.UserID {
+				ignored = true
+			}
+		}
+		if ignored {
+			continue
+		}
+		eligibleInChannel[voiceState.ChannelID] = append(eligibleInChannel[voiceState.ChannelID], voiceState.UserID)
+	}
+
+	// muted, deafened or ignored members, like a deafened alt, don't count towards the two members needed
+	for channelID, channelUserIDs := range eligibleInChannel {
+		if len(channelUserIDs) < 2 {
+			continue
+		}
+		for _, userID := range channelUserIDs {
+			channelIDs[userID] = channelID
+		}
+	}
+	return channelIDs
+}
+
+// capVoiceExp limits the voice EXP to the daily voice EXP cap of the guild and updates the voice EXP counter of the user
+func (m *Levels) capVoiceExp(levelsServerUser *DB_Levels_ServerUser, exp int64) int64 {
+	today := time.Now().UTC().Format("2006-01-02")
+	if levelsServerUser.VoiceExpDay != today {
+		levelsServerUser.VoiceExpDay = today
+		levelsServerUser.VoiceExpToday = 0
+	}
+
+	dailyCap := int64(helpers.GuildSettingsGetCached(levelsServerUser.GuildID).LevelsVoiceExpDailyCap)
+	if dailyCap > 0 && levelsServerUser.VoiceExpToday+exp > dailyCap {
+		exp = dailyCap - levelsServerUser.VoiceExpToday
+	}
+	if exp <= 0 {
+		return 0
+	}
+
+	levelsServerUser.VoiceExpToday += exp
+	return exp
+}
+
+// announceLevelUp sends the level up announcement of the guild for the member if enabled
+func (m *Levels) announceLevelUp(expItem ProcessExpInfo, levelBefore, levelAfter int) {
+	defer helpers.Recover()
+
+	settings := helpers.GuildSettingsGetCached(expItem.GuildID)
+	if settings.LevelsAnnouncementsDestination == "" {
+		return
+	}
+	// announce if any multiple of every has been reached, a single gain can skip levels
+	if settings.LevelsAnnouncementsEvery > 1 &&
+		levelAfter/settings.LevelsAnnouncementsEvery <= levelBefore/settings.LevelsAnnouncementsEvery {
+		return
+	}
+
+	member, err := helpers.GetGuildMember(expItem.GuildID, expItem.UserID)
+	if err != nil || member == nil || member.User == nil {
+		return
+	}
+	if m.GetUserUserdata(member.User).LevelUpOptOut {
+		return
+	}
+
+	var channelID string
+	switch settings.LevelsAnnouncementsDestination {
+	case levelsAnnouncementsDestinationCurrent:
+		// voice EXP has no text channel to announce in
+		if expItem.VoiceMinutes <= 0 {
+			channelID = expItem.ChannelID
+		}
+	case levelsAnnouncementsDestinationChannel:
+		channelID = settings.LevelsAnnouncementsChannelID
+	case levelsAnnouncementsDestinationDM:
+		dmChannel, err := cache.GetSession().UserChannelCreate(expItem.UserID)
+		if err != nil {
+			return
+		}
+		channelID = dmChannel.ID
+	}
+	if channelID == "" {
+		return
+	}
+
+	guild, err := helpers.GetGuild(expItem.GuildID)
+	if err != nil {
+		return
+	}
+
+	_, err = helpers.SendComplex(channelID, m.getLevelUpMessage(guild, member, levelBefore, levelAfter, settings))
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok && errD.Message != nil {
+			switch errD.Message.Code {
+			case discordgo.ErrCodeUnknownChannel, discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions,
+				discordgo.ErrCodeCannotSendMessagesToThisUser:
+				return
+			}
+		}
+		helpers.RelaxLog(err)
+	}
+}
+
+// getLevelUpMessage fills the level up template of the guild and attaches the profile card if enabled
+func (m *Levels) getLevelUpMessage(guild *discordgo.Guild, member *discordgo.Member, levelBefore, levelAfter int, settings models.Config) (messageSend *discordgo.MessageSend) {
+	template := settings.LevelsAnnouncementsTemplate
+	if template == "" {
+		template = helpers.GetText("plugins.levels.announcements-default-template")
+	}
+
+	rolesBefore, _ := m.getLevelsRoles(guild.ID, levelBefore)
+	rolesAfter, _ := m.getLevelsRoles(guild.ID, levelAfter)
+	rolesGained := make([]string, 0)
+	for _, roleAfter := range rolesAfter {
+		hadRoleBefore := false
+		for _, roleBefore := range rolesBefore {
+			if roleBefore.ID == roleAfter.ID {
+				hadRoleBefore = true
+			}
+		}
+		if !hadRoleBefore {
+			rolesGained = append(rolesGained, roleAfter.Name)
+		}
+	}
+
+	rankText := "N/A"
+	if strings.Contains(template, "{rank}") {
+		rank, err := m.getRankForUser(guild.ID, member.User.ID)
+		if err == nil {
+			rankText = strconv.Itoa(rank)
+		}
+	}
+
+	messageSend = &discordgo.MessageSend{
+		Content: strings.NewReplacer(
+			"{user}", "<@"+member.User.ID+">",
+			"{username}", member.User.Username,
+			"{level}", strconv.Itoa(levelAfter),
+			"{rank}", rankText,
+			"{roles}", strings.Join(rolesGained, ", "),
+			"{server}", guild.Name,
+		).Replace(template),
+	}
+
+	if settings.LevelsAnnouncementsImage {
+		imageBytes, ext, err := m.GetProfile(member, guild, false)
+		if err == nil {
+			messageSend.Files = []*discordgo.File{
+				{
+					Name:   fmt.Sprintf("%s-level-%d.%s", member.User.ID, levelAfter, ext),
+					Reader: bytes.NewReader(imageBytes),
+				},
+			}
+		} else {
+			cache.GetLogger().WithField("module", "levels").Error(fmt.Sprintf("level up card generation failed: %s", err.Error()))
+		}
+	}
+
+	return messageSend
+}
+
+// getRankForUser returns the current rank of the user on the guild by EXP
+func (m *Levels) getRankForUser(guildID, userID string) (rank int, err error) {
+	exp := m.getLevelsServerUserExp(guildID, userID)
+
+	cursor, err := rethink.Table("levels_serverusers").GetAllByIndex(
+		"guildid", guildID,
+	).Filter(
+		rethink.Row.Field("exp").Gt(exp),
+	).Count().Run(helpers.GetDB())
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close()
+
+	var higherRanked int
+	err = cursor.One(&higherRanked)
+	if err != nil {
+		return 0, err
+	}
+	return higherRanked + 1, nil
+}
+
 func (m *Levels) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
 	switch command {
-	case "rep": // [p]rep <user id/mention>
+	case "rep": // [p]rep <user id/mention> [<reason>]
 		session.ChannelTyping(msg.ChannelID)
 		args := strings.Fields(content)
+		channel, err := helpers.GetChannel(msg.ChannelID)
+		helpers.Relax(err)
+
+		// rep is given and listed per server, in DMs only bot admins can look at the rep of all servers or revoke rep
+		if channel.GuildID == "" {
+			isAllHistory := len(args) >= 3 && args[0] == "history" && args[2] == "all" && helpers.IsBotAdmin(msg.Author.ID)
+			if !isAllHistory && (len(args) <= 0 || args[0] != "revoke") {
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-dm"))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+		}
+
+		if len(args) >= 1 {
+			switch args[0] {
+			case "top", "leaderboard": // [p]rep top
+				rankings, err := m.getRepRankings(channel.GuildID)
+				helpers.Relax(err)
+				if len(rankings) <= 0 {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-top-none"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				resultText := helpers.GetText("plugins.levels.rep-top-title") + "\n"
+				for i, ranking := range rankings {
+					username := "N/A"
+					if user, err := helpers.GetUser(ranking.UserID); err == nil {
+						username = user.Username + "#" + user.Discriminator
+					}
+					resultText += helpers.GetTextF("plugins.levels.rep-top-entry", i+1, username, ranking.Count) + "\n"
+				}
+				_, err = helpers.SendMessage(msg.ChannelID, resultText)
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			case "history": // [p]rep history [<user id/mention>] [all]
+				targetUser := msg.Author
+				if len(args) >= 2 {
+					targetUser, err = helpers.GetUserFromMention(args[1])
+					if err != nil || targetUser == nil || targetUser.ID == "" {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+				}
+
+				// only bot admins can see the rep of other servers
+				guildID := channel.GuildID
+				if len(args) >= 3 && args[2] == "all" && helpers.IsBotAdmin(msg.Author.ID) {
+					guildID = ""
+				}
+
+				received, err := m.getRepEntries("receiver_user_id", targetUser.ID, guildID, levelsRepHistorySize)
+				helpers.Relax(err)
+				given, err := m.getRepEntries("giver_user_id", targetUser.ID, guildID, levelsRepHistorySize)
+				helpers.Relax(err)
+
+				resultText := helpers.GetTextF("plugins.levels.rep-history-received-title", targetUser.Username) + "\n"
+				if len(received) <= 0 {
+					resultText += helpers.GetText("plugins.levels.rep-history-none") + "\n"
+				}
+				for _, repEntry := range received {
+					resultText += m.getRepEntryText(repEntry, repEntry.GiverUserID) + "\n"
+				}
+				resultText += "\n" + helpers.GetTextF("plugins.levels.rep-history-given-title", targetUser.Username) + "\n"
+				if len(given) <= 0 {
+					resultText += helpers.GetText("plugins.levels.rep-history-none") + "\n"
+				}
+				for _, repEntry := range given {
+					resultText += m.getRepEntryText(repEntry, repEntry.ReceiverUserID) + "\n"
+				}
+
+				_, err = helpers.SendMessage(msg.ChannelID, resultText)
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			case "revoke": // [p]rep revoke <rep id or user id/mention>
+				helpers.RequireBotAdmin(msg, func() {
+					if len(args) < 2 {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+
+					repEntries := make([]models.LevelsRepEntry, 0)
+					if repEntry, err := m.getRepEntry(args[1]); err == nil {
+						repEntries = append(repEntries, repEntry)
+					} else {
+						targetUser, err := helpers.GetUserFromMention(args[1])
+						if err != nil || targetUser == nil || targetUser.ID == "" {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-revoke-not-found"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						repEntries, err = m.getRepEntries("giver_user_id", targetUser.ID, "", 0)
+						helpers.Relax(err)
+
+						if len(repEntries) > 0 && !helpers.ConfirmEmbed(msg.ChannelID, msg.Author,
+							helpers.GetTextF("plugins.levels.rep-revoke-all-confirm", len(repEntries), targetUser.Username), "✅", "🚫") {
+							return
+						}
+					}
+
+					var revoked int
+					for _, repEntry := range repEntries {
+						if repEntry.Revoked {
+							continue
+						}
+						err = m.revokeRepEntry(repEntry, msg.Author.ID)
+						helpers.Relax(err)
+						revoked++
+					}
+
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-revoke-success", revoked))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			case "cooldown": // [p]rep cooldown [<hours>]
+				if len(args) < 2 {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-cooldown-status",
+						int(m.getRepCooldown(channel.GuildID).Hours())))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				helpers.RequireAdmin(msg, func() {
+					hours, err := strconv.Atoi(args[1])
+					// rep is global, a lower cooldown on one server would allow farming rep for every server
+					if err != nil || hours < levelsRepCooldownDefaultHours || hours > levelsRepCooldownMaxHours {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-cooldown-invalid",
+							levelsRepCooldownDefaultHours, levelsRepCooldownMaxHours))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+
+					settings := helpers.GuildSettingsGetCached(channel.GuildID)
+					settings.LevelsRepCooldownHours = hours
+					err = helpers.GuildSettingsSet(channel.GuildID, settings)
+					helpers.Relax(err)
+
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-cooldown-set", hours))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			}
+		}
+
 		userData := m.GetUserUserdata(msg.Author)
+		nextRepAt, err := m.getNextRepAt(channel.GuildID, userData)
+		helpers.Relax(err)
 
 		if len(args) <= 0 {
-			if time.Since(userData.LastRepped).Hours() < 12 {
-				timeUntil := time.Until(userData.LastRepped.Add(time.Hour * 12))
+			if time.Now().Before(nextRepAt) {
+				timeUntil := time.Until(nextRepAt)
 				_, err := helpers.SendMessage(msg.ChannelID,
 					helpers.GetTextF("plugins.levels.rep-next-rep",
 						int(math.Floor(timeUntil.Hours())),
@@ -433,8 +1016,8 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 			return
 		}
 
-		if time.Since(userData.LastRepped).Hours() < 12 {
-			timeUntil := time.Until(userData.LastRepped.Add(time.Hour * 12))
+		if time.Now().Before(nextRepAt) {
+			timeUntil := time.Until(nextRepAt)
 			_, err := helpers.SendMessage(msg.ChannelID,
 				helpers.GetTextF("plugins.levels.rep-error-timelimit",
 					int(math.Floor(timeUntil.Hours())),
@@ -467,6 +1050,21 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 			return
 		}
 
+		reason := strings.Join(args[1:], " ")
+		if len([]rune(reason)) > levelsRepReasonMaxLength {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-error-reason-too-long", levelsRepReasonMaxLength))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
+		isRing, err := m.isRepRing(msg.Author.ID, targetUser.ID)
+		helpers.Relax(err)
+		if isRing {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-ring"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
 		targetUserData := m.GetUserUserdata(targetUser)
 		targetUserData.Rep += 1
 		m.setUserUserdata(targetUserData)
@@ -474,8 +1072,22 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 		userData.LastRepped = time.Now()
 		m.setUserUserdata(userData)
 
-		_, err = helpers.SendMessage(msg.ChannelID,
-			helpers.GetTextF("plugins.levels.rep-success", targetUser.Username))
+		_, err = rethink.Table(models.LevelsRepTable).Insert(models.LevelsRepEntry{
+			GuildID:        channel.GuildID,
+			GiverUserID:    msg.Author.ID,
+			ReceiverUserID: targetUser.ID,
+			Reason:         reason,
+			GivenAt:        time.Now(),
+		}).RunWrite(helpers.GetDB())
+		helpers.RelaxLog(err)
+
+		go m.checkBadgeRulesForUser(channel.GuildID, targetUser.ID)
+
+		successText := helpers.GetTextF("plugins.levels.rep-success", targetUser.Username)
+		if reason != "" {
+			successText += "\n" + helpers.GetTextF("plugins.levels.rep-success-reason", m.getRepReasonText(reason))
+		}
+		_, err = helpers.SendMessage(msg.ChannelID, successText)
 		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 		return
 	case "profile", "gif-profile": // [p]profile
@@ -799,8 +1411,8 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 								if badge.GuildID == "global" {
 									globalText = "GLOBAL "
 								}
-								resultText += fmt.Sprintf("**%s%s**: URL: <%s>, Border Color: #%s, Requirement: %d, Allowed Users: %d, Denied Users %d\n",
-									globalText, badge.Name, badge.URL, badge.BorderColor, badge.LevelRequirement, len(badge.AllowedUserIDs), len(badge.DeniedUserIDs),
+								resultText += fmt.Sprintf("**%s%s**: URL: <%s>, Border Color: #%s, Requirement: %d, Allowed Users: %d, Denied Users %d, Rules: %d\n",
+									globalText, badge.Name, badge.URL, badge.BorderColor, badge.LevelRequirement, len(badge.AllowedUserIDs), len(badge.DeniedUserIDs), len(badge.Rules),
 								)
 							}
 							resultText += fmt.Sprintf("I found %d badges in this category.\n",
@@ -979,18 +1591,145 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 							}
 						})
 						return
-					case "move": // [p]profile badge move <category name> <badge name> <#>
-						session.ChannelTyping(msg.ChannelID)
-						if len(args) < 5 {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
-							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-							return
-						}
-						categoryName := args[2]
-						badgeName := args[3]
-						newSpot, err := strconv.Atoi(args[4])
-						if err != nil {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					case "rule", "rules": // [p]profile badge rule <add|remove|list|announce> <category name> <badge name> [<rule>]
+						helpers.RequireAdmin(msg, func() {
+							session.ChannelTyping(msg.ChannelID)
+							if len(args) < 5 {
+								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
+
+							channel, err := helpers.GetChannel(msg.ChannelID)
+							helpers.Relax(err)
+
+							badge := m.GetBadge(args[3], args[4], channel.GuildID)
+							if badge.ID == "" {
+								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.badge-error-not-found"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
+							if badge.GuildID == "global" && !helpers.IsBotAdmin(msg.Author.ID) {
+								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.edit-badge-error-not-allowed"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
+
+							switch args[2] {
+							case "add": // [p]profile badge rule add <category name> <badge name> <rule type> [<rule arguments>]
+								if len(badge.Rules) >= levelsBadgeRulesMax {
+									_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-rule-too-many", levelsBadgeRulesMax))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+
+								rule, err := m.parseBadgeRule(badge.GuildID, args[5:])
+								if err != nil {
+									_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.badge-rule-invalid"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+
+								badge.Rules = append(badge.Rules, rule)
+								m.UpdateBadge(badge)
+
+								levelsBadgeRuleGuildIDsLock.Lock()
+								if levelsBadgeRuleGuildIDs == nil {
+									levelsBadgeRuleGuildIDs = make(map[string]bool)
+								}
+								levelsBadgeRuleGuildIDs[badge.GuildID] = true
+								levelsBadgeRuleGuildIDsLock.Unlock()
+
+								go func() {
+									defer helpers.Recover()
+									helpers.RelaxLog(m.updateBadgeRuleUsers(badge))
+								}()
+
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-rule-added",
+									m.getBadgeRuleText(badge.GuildID, rule), badge.Name, badge.Category))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							case "remove", "delete": // [p]profile badge rule remove <category name> <badge name> <#>
+								if len(args) < 6 {
+									_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+								ruleNumber, err := strconv.Atoi(args[5])
+								if err != nil || ruleNumber < 1 || ruleNumber > len(badge.Rules) {
+									_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+
+								removedRule := badge.Rules[ruleNumber-1]
+								badge.Rules = append(badge.Rules[:ruleNumber-1], badge.Rules[ruleNumber:]...)
+								if len(badge.Rules) <= 0 {
+									badge.RuleUserIDs = make([]string, 0)
+								}
+								m.UpdateBadge(badge)
+
+								if len(badge.Rules) > 0 {
+									go func() {
+										defer helpers.Recover()
+										helpers.RelaxLog(m.updateBadgeRuleUsers(badge))
+									}()
+								}
+
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-rule-removed",
+									m.getBadgeRuleText(badge.GuildID, removedRule), badge.Name, badge.Category))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							case "list": // [p]profile badge rule list <category name> <badge name>
+								if len(badge.Rules) <= 0 {
+									_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.badge-rule-list-none"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+
+								announceText := helpers.GetText("plugins.levels.badge-rule-announce-off")
+								if badge.AnnounceRules {
+									announceText = helpers.GetText("plugins.levels.badge-rule-announce-on")
+								}
+								resultText := helpers.GetTextF("plugins.levels.badge-rule-list-title", badge.Name, badge.Category) + "\n"
+								for i, rule := range badge.Rules {
+									resultText += fmt.Sprintf("`#%d` %s\n", i+1, m.getBadgeRuleText(badge.GuildID, rule))
+								}
+								resultText += helpers.GetTextF("plugins.levels.badge-rule-list-footer", len(badge.RuleUserIDs), announceText)
+
+								for _, page := range helpers.Pagify(resultText, "\n") {
+									_, err = helpers.SendMessage(msg.ChannelID, page)
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								}
+								return
+							case "announce": // [p]profile badge rule announce <category name> <badge name>
+								badge.AnnounceRules = !badge.AnnounceRules
+								m.UpdateBadge(badge)
+
+								if badge.AnnounceRules {
+									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-rule-announce-enabled", badge.Name, badge.Category))
+								} else {
+									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-rule-announce-disabled", badge.Name, badge.Category))
+								}
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						})
+						return
+					case "move": // [p]profile badge move <category name> <badge name> <#>
+						session.ChannelTyping(msg.ChannelID)
+						if len(args) < 5 {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						categoryName := args[2]
+						badgeName := args[3]
+						newSpot, err := strconv.Atoi(args[4])
+						if err != nil {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
@@ -1404,7 +2143,11 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 		if len(args) >= 1 && args[0] != "" {
 			switch args[0] {
 			case "leaderboard", "top":
-				// [p]level top
+				// [p]level top [<weekly, monthly or season>]
+				if len(args) >= 2 {
+					m.sendPeriodLeaderboard(msg, guild, args[1])
+					return
+				}
 				// TODO: use cached top list
 				var levelsServersUsers []DB_Levels_ServerUser
 				listCursor, err := rethink.Table("levels_serverusers").Filter(
@@ -1461,7 +2204,7 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 
 					topLevelEmbed.Fields = append(topLevelEmbed.Fields, &discordgo.MessageEmbedField{
 						Name:   fmt.Sprintf("%d. %s", displayRanking, fullUsername),
-						Value:  fmt.Sprintf("Level: %d", m.getLevelFromExp(levelsServersUsers[i-offset].Exp)),
+						Value:  fmt.Sprintf("Level: %d", m.getLevelFromExp(channel.GuildID, levelsServersUsers[i-offset].Exp)),
 						Inline: false,
 					})
 					displayRanking++
@@ -1490,7 +2233,7 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 
 				topLevelEmbed.Fields = append(topLevelEmbed.Fields, &discordgo.MessageEmbedField{
 					Name:   "Your Rank: " + serverRank,
-					Value:  fmt.Sprintf("Level: %d", m.getLevelFromExp(thislevelUser.Exp)),
+					Value:  fmt.Sprintf("Level: %d", m.getLevelFromExp(channel.GuildID, thislevelUser.Exp)),
 					Inline: false,
 				})
 
@@ -1502,6 +2245,12 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 				return
 			case "global-leaderboard", "global-top", "globaltop":
+				// [p]level global-top [<weekly or monthly>]
+				if len(args) >= 2 {
+					m.sendPeriodLeaderboard(msg, nil, args[1])
+					return
+				}
+
 				var rankedTotalExpMap PairList
 				for _, serverCache := range topCache {
 					if serverCache.GuildID == "global" {
@@ -1537,7 +2286,7 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 					fullUsername := currentUser.Username
 					globalTopLevelEmbed.Fields = append(globalTopLevelEmbed.Fields, &discordgo.MessageEmbedField{
 						Name:   fmt.Sprintf("%d. %s", i+1, fullUsername),
-						Value:  fmt.Sprintf("Global Level: %d", m.getLevelFromExp(userRanked.Value)),
+						Value:  fmt.Sprintf("Global Level: %d", m.getLevelFromExp("global", userRanked.Value)),
 						Inline: false,
 					})
 					i++
@@ -1572,7 +2321,7 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 
 				globalTopLevelEmbed.Fields = append(globalTopLevelEmbed.Fields, &discordgo.MessageEmbedField{
 					Name:   "Your Rank: " + globalRank,
-					Value:  fmt.Sprintf("Global Level: %d", m.getLevelFromExp(totalExp)),
+					Value:  fmt.Sprintf("Global Level: %d", m.getLevelFromExp("global", totalExp)),
 					Inline: false,
 				})
 
@@ -1609,1825 +2358,5364 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 					}
 				}
 				return
-			case "ignore":
-				if len(args) >= 2 {
-					switch args[1] {
-					case "list": // [p]levels ignore list
-						helpers.RequireMod(msg, func() {
-							settings := helpers.GuildSettingsGetCached(channel.GuildID)
+			case "curve":
+				if len(args) < 2 {
+					// [p]levels curve
+					helpers.RequireMod(msg, func() {
+						min, max := m.getExpPerMessageRange(channel.GuildID)
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.curve-status",
+							m.getLevelsCurve(channel.GuildID).Type, min, max, helpers.GetPrefixForServer(channel.GuildID)))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				}
 
-							ignoredMessage := "**Ignored Users:**"
-							if len(settings.LevelsIgnoredUserIDs) > 0 {
-								for i, ignoredUserID := range settings.LevelsIgnoredUserIDs {
-									ignoredUser, err := helpers.GetGuildMember(channel.GuildID, ignoredUserID)
-									if err != nil {
-										ignoredMessage += " N/A"
-									} else {
-										ignoredMessage += " " + ignoredUser.User.Username + "#" + ignoredUser.User.Discriminator
-									}
-									if i+1 < len(settings.LevelsIgnoredUserIDs) {
-										ignoredMessage += ","
-									}
-								}
-							} else {
-								ignoredMessage += " None"
+				switch args[1] {
+				case "preview": // [p]levels curve preview [<curve>]
+					helpers.RequireMod(msg, func() {
+						curve := m.getLevelsCurve(channel.GuildID)
+						if len(args) >= 3 {
+							switch args[2] {
+							case levelsCurveQuadratic, levelsCurveLinear, levelsCurveExponential:
+								curve = levelsCurve{Type: args[2]}
+							default:
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.curve-invalid"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
 							}
+						}
 
-							ignoredMessage += "\n**Ignored Channels:**"
-							if len(settings.LevelsIgnoredChannelIDs) > 0 {
-								for i, ignoredChannelID := range settings.LevelsIgnoredChannelIDs {
-									ignoredMessage += " <#" + ignoredChannelID + ">"
-									if i+1 < len(settings.LevelsIgnoredChannelIDs) {
-										ignoredMessage += ","
-									}
-								}
-							} else {
-								ignoredMessage += " None"
-							}
+						previewText := helpers.GetTextF("plugins.levels.curve-preview-title", curve.Type) + "\n```\n"
+						for level := 1; level <= levelsCurvePreviewLevels; level++ {
+							previewText += fmt.Sprintf("Level %2d: %10s EXP\n", level, humanize.Comma(curve.expForLevel(level)))
+						}
+						previewText += "```"
 
-							for _, page := range helpers.Pagify(ignoredMessage, " ") {
-								_, err = helpers.SendMessage(msg.ChannelID, page)
-								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-							}
-							return
-						})
-						return
-					case "user": // [p]levels ignore user <user>
+						_, err = helpers.SendMessage(msg.ChannelID, previewText)
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				case levelsCurveQuadratic, levelsCurveLinear, levelsCurveExponential: // [p]levels curve <quadratic, linear or exponential>
+					helpers.RequireAdmin(msg, func() {
+						settings := helpers.GuildSettingsGetCached(channel.GuildID)
+						settings.LevelsCurve = args[1]
+						settings.LevelsCurveThresholds = nil
+						err = helpers.GuildSettingsSet(channel.GuildID, settings)
+						helpers.Relax(err)
+
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.curve-set", args[1], helpers.GetPrefixForServer(channel.GuildID)))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				case levelsCurveCustom: // [p]levels curve custom <exp for level 1> <exp for level 2> …
+					helpers.RequireAdmin(msg, func() {
 						if len(args) < 3 {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						if len(args)-2 > levelsCurveCustomMaxLevels {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.curve-custom-too-many", levelsCurveCustomMaxLevels))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
 
-						helpers.RequireAdmin(msg, func() {
-							targetUser, err = helpers.GetUserFromMention(args[2])
-							if targetUser == nil || targetUser.ID == "" {
-								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						thresholds := make([]int64, 0)
+						for _, arg := range args[2:] {
+							threshold, err := strconv.ParseInt(strings.Replace(arg, ",", "", -1), 10, 64)
+							if err != nil || threshold <= 0 || threshold > levelsExpMax ||
+								(len(thresholds) > 0 && threshold <= thresholds[len(thresholds)-1]) {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.curve-custom-invalid"))
 								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 								return
 							}
+							thresholds = append(thresholds, threshold)
+						}
 
-							settings := helpers.GuildSettingsGetCached(channel.GuildID)
+						settings := helpers.GuildSettingsGetCached(channel.GuildID)
+						settings.LevelsCurve = levelsCurveCustom
+						settings.LevelsCurveThresholds = thresholds
+						err = helpers.GuildSettingsSet(channel.GuildID, settings)
+						helpers.Relax(err)
 
-							for i, ignoredUserID := range settings.LevelsIgnoredUserIDs {
-								if ignoredUserID == targetUser.ID {
-									settings.LevelsIgnoredUserIDs = append(settings.LevelsIgnoredUserIDs[:i], settings.LevelsIgnoredUserIDs[i+1:]...)
-									err = helpers.GuildSettingsSet(channel.GuildID, settings)
-									helpers.Relax(err)
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.curve-set", levelsCurveCustom, helpers.GetPrefixForServer(channel.GuildID)))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				default:
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.curve-invalid"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+			case "xp-range", "exp-range": // [p]levels xp-range [<min> <max>]
+				helpers.RequireAdmin(msg, func() {
+					if len(args) < 3 {
+						min, max := m.getExpPerMessageRange(channel.GuildID)
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.xp-range-status", min, max))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
 
-									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.ignore-user-removed"))
-									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-									return
-								}
-							}
+					min, errMin := strconv.Atoi(args[1])
+					max, errMax := strconv.Atoi(args[2])
+					if errMin != nil || errMax != nil || min <= 0 || max < min || max > levelsExpPerMessageLimit {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.xp-range-invalid", levelsExpPerMessageLimit))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
 
-							settings.LevelsIgnoredUserIDs = append(settings.LevelsIgnoredUserIDs, targetUser.ID)
-							err = helpers.GuildSettingsSet(channel.GuildID, settings)
-							helpers.Relax(err)
+					settings := helpers.GuildSettingsGetCached(channel.GuildID)
+					settings.LevelsExpPerMessageMin = min
+					settings.LevelsExpPerMessageMax = max
+					err = helpers.GuildSettingsSet(channel.GuildID, settings)
+					helpers.Relax(err)
 
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.ignore-user-added", helpers.GetPrefixForServer(channel.GuildID)))
-							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-							return
-						})
-						return
-					case "channel": // [p]levels ignore channel <channel>
-						if len(args) < 3 {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-							return
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.xp-range-set", min, max))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			case "multiplier", "multipliers":
+				if len(args) < 2 || args[1] == "list" {
+					// [p]levels multipliers
+					helpers.RequireMod(msg, func() {
+						settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+						var roleMultipliersText, channelMultipliersText string
+						for _, levelsMultiplier := range settings.LevelsMultipliers {
+							switch levelsMultiplier.Type {
+							case models.LevelsMultiplierTypeRole:
+								roleMultipliersText += fmt.Sprintf("<@&%s> %s\n", levelsMultiplier.TargetID, m.formatMultiplier(levelsMultiplier.Multiplier))
+							case models.LevelsMultiplierTypeChannel:
+								channelMultipliersText += fmt.Sprintf("<#%s> %s\n", levelsMultiplier.TargetID, m.formatMultiplier(levelsMultiplier.Multiplier))
+							}
+						}
+						if roleMultipliersText == "" {
+							roleMultipliersText = "None\n"
+						}
+						if channelMultipliersText == "" {
+							channelMultipliersText = "None\n"
 						}
 
-						helpers.RequireAdmin(msg, func() {
-							targetChannel, err := helpers.GetChannelFromMention(msg, args[2])
-							helpers.Relax(err)
-							if targetChannel == nil || targetChannel.ID == "" {
-								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-								return
+						events, err := m.getMultiplierEvents(channel.GuildID)
+						helpers.Relax(err)
+						var eventsText string
+						for _, event := range events {
+							announcementText := ""
+							if event.ChannelID != "" {
+								announcementText = fmt.Sprintf(", announced in <#%s>", event.ChannelID)
 							}
+							eventsText += fmt.Sprintf("`%s` %s %s from %s UTC until %s UTC%s\n",
+								event.ID, event.Name, m.formatMultiplier(event.Multiplier),
+								event.StartsAt.UTC().Format(time.ANSIC), event.EndsAt.UTC().Format(time.ANSIC), announcementText)
+						}
+						if eventsText == "" {
+							eventsText = "None\n"
+						}
 
-							settings := helpers.GuildSettingsGetCached(channel.GuildID)
+						multipliersText := helpers.GetTextF("plugins.levels.multiplier-list",
+							roleMultipliersText, channelMultipliersText, eventsText)
+						for _, page := range helpers.Pagify(multipliersText, "\n") {
+							_, err = helpers.SendMessage(msg.ChannelID, page)
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						}
+					})
+					return
+				}
 
-							for i, ignoredChannelID := range settings.LevelsIgnoredChannelIDs {
-								if ignoredChannelID == targetChannel.ID {
-									settings.LevelsIgnoredChannelIDs = append(settings.LevelsIgnoredChannelIDs[:i], settings.LevelsIgnoredChannelIDs[i+1:]...)
-									err = helpers.GuildSettingsSet(channel.GuildID, settings)
-									helpers.Relax(err)
+				switch args[1] {
+				case "role": // [p]levels multiplier role <role name or id> <multiplier or remove>
+					helpers.RequireAdmin(msg, func() {
+						if len(args) < 4 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
 
-									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.ignore-channel-removed"))
-									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-									return
-								}
+						roleNameToMatch := strings.Join(args[2:len(args)-1], " ")
+						var targetRole *discordgo.Role
+						for _, role := range guild.Roles {
+							if strings.ToLower(role.Name) == strings.ToLower(roleNameToMatch) || role.ID == roleNameToMatch {
+								targetRole = role
 							}
+						}
+						if targetRole == nil || targetRole.ID == "" {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.multiplier-role-not-found"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
 
-							settings.LevelsIgnoredChannelIDs = append(settings.LevelsIgnoredChannelIDs, targetChannel.ID)
-							err = helpers.GuildSettingsSet(channel.GuildID, settings)
+						if args[len(args)-1] == "remove" {
+							err = m.setLevelsMultiplier(channel.GuildID, models.LevelsMultiplierTypeRole, targetRole.ID, -1)
 							helpers.Relax(err)
 
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.ignore-channel-added"))
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-role-removed", targetRole.Name))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
-						})
-						return
-					}
-				}
-				return
-				// [p]level process-history
-			case "process-history":
-				helpers.RequireBotAdmin(msg, func() {
-					dmChannel, err := session.UserChannelCreate(msg.Author.ID)
-					helpers.Relax(err)
-					session.ChannelTyping(msg.ChannelID)
-					channel, err := helpers.GetChannel(msg.ChannelID)
-					helpers.Relax(err)
-					guild, err := helpers.GetGuild(channel.GuildID)
-					helpers.Relax(err)
-					_, err = helpers.SendMessage(msg.ChannelID, fmt.Sprintf("<@%s> Check your DMs.", msg.Author.ID))
-					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-					// pause new message processing for that guild
-					temporaryIgnoredGuilds = append(temporaryIgnoredGuilds, channel.GuildID)
-					_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Temporary disabled EXP Processing for `%s` while processing the Message History.", guild.Name))
-					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-					// reset accounts on this server
-					var levelsServersUsers []DB_Levels_ServerUser
-					listCursor, err := rethink.Table("levels_serverusers").Filter(
-						rethink.Row.Field("guildid").Eq(channel.GuildID),
-					).Run(helpers.GetDB())
-					helpers.Relax(err)
-					defer listCursor.Close()
-					err = listCursor.All(&levelsServersUsers)
-					helpers.Relax(err)
-					for _, levelsServerUser := range levelsServersUsers {
-						levelsServerUser.Exp = 0
-						m.setLevelsServerUser(levelsServerUser)
-					}
-					_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Resetted the EXP for every User on `%s`.", guild.Name))
-					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-					// process history
-					//var wg sync.WaitGroup
-					//wg.Add(len(guild.Channels))
-					for _, guildChannel := range guild.Channels {
-						guildChannelCurrent := guildChannel
-						//go func() {
-						prefix := helpers.GetPrefixForServer(guildChannelCurrent.GuildID)
-						expForUsers := make(map[string]int64)
-						//defer wg.Done()
-						if guildChannelCurrent.Type == discordgo.ChannelTypeGuildVoice {
-							continue
 						}
 
-						cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("Started processing of Channel #%s (#%s) on Guild %s (#%s)",
-							guildChannelCurrent.Name, guildChannelCurrent.ID, guild.Name, guild.ID))
-						// (asynchronous)
-						_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Started processing Messages for Channel <#%s>.", guildChannelCurrent.ID))
-						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-						lastBefore := ""
-						for {
-							messages, err := session.ChannelMessages(guildChannelCurrent.ID, 100, lastBefore, "", "")
-							if err != nil {
-								cache.GetLogger().WithField("module", "levels").Error(err.Error())
-								break
-							}
-							cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("Processing %d messages for Channel #%s (#%s) from before \"%s\" on Guild %s (#%s)",
-								len(messages), guildChannelCurrent.Name, guildChannelCurrent.ID, lastBefore, guild.Name, guild.ID))
-							if len(messages) <= 0 {
-								break
-							}
-							for _, message := range messages {
-								// ignore bot messages
-								if message.Author.Bot == true {
-									continue
-								}
-								// ignore commands
-								if prefix != "" {
-									if strings.HasPrefix(message.Content, prefix) {
-										continue
-									}
-								}
-								if _, ok := expForUsers[message.Author.ID]; ok {
-									expForUsers[message.Author.ID] += 5
-								} else {
-									expForUsers[message.Author.ID] = 5
-								}
-
-							}
-							lastBefore = messages[len(messages)-1].ID
+						multiplier, err := m.parseMultiplier(args[len(args)-1])
+						if err != nil {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-invalid", levelsMultiplierLimit))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
 						}
 
-						for userId, expForuser := range expForUsers {
-							levelsServerUser := m.getLevelsServerUserOrCreateNew(guildChannelCurrent.GuildID, userId)
-							levelsServerUser.Exp += expForuser
-							m.setLevelsServerUser(levelsServerUser)
-						}
+						err = m.setLevelsMultiplier(channel.GuildID, models.LevelsMultiplierTypeRole, targetRole.ID, multiplier)
+						helpers.Relax(err)
 
-						cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("Completed processing of Channel #%s (#%s) on Guild %s (#%s)",
-							guildChannelCurrent.Name, guildChannelCurrent.ID, guild.Name, guild.ID))
-						_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Completed processing Messages for Channel <#%s>.", guildChannelCurrent.ID))
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-role-set", targetRole.Name, m.formatMultiplier(multiplier)))
 						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-						//}()
-					}
-					//fmt.Println("Waiting for all channels")
-					//wg.Wait()
-					// enable new message processing again
-					var newTemporaryIgnoredGuilds []string
-					for _, temporaryIgnoredGuild := range temporaryIgnoredGuilds {
-						if temporaryIgnoredGuild != channel.GuildID {
-							newTemporaryIgnoredGuilds = append(newTemporaryIgnoredGuilds, temporaryIgnoredGuild)
-						}
-					}
-					temporaryIgnoredGuilds = newTemporaryIgnoredGuilds
-					_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Enabled EXP Processing for `%s` again.", guild.Name))
-					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-					_, err = helpers.SendMessage(dmChannel.ID, "Done!")
-					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-					return
-				})
-				return
-			case "role", "roles":
-				if len(args) < 2 {
-					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
-					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
 					return
-				}
-
-				switch args[1] {
-				case "add":
-					helpers.RequireMod(msg, func() {
-						// [p]levels role add <role name or id> <start level> [<last level>]
+				case "channel": // [p]levels multiplier channel <channel> <multiplier or remove>
+					helpers.RequireAdmin(msg, func() {
 						if len(args) < 4 {
 							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
-						if _, err = strconv.Atoi(args[len(args)-1]); err != nil {
+
+						targetChannel, err := helpers.GetChannelFromMention(msg, args[2])
+						if err != nil || targetChannel == nil || targetChannel.ID == "" {
 							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
 
-						serverRoles, err := session.GuildRoles(channel.GuildID)
-						if err != nil {
-							if errD, ok := err.(*discordgo.RESTError); ok && errD.Message.Code == discordgo.ErrCodeMissingPermissions {
-								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.permissions.required", "Manage Roles"))
-								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-								return
-							}
-						}
-						helpers.Relax(err)
-
-						var targetRole *discordgo.Role
-
-						roleNameToMatch := strings.TrimSpace(strings.Replace(content, strings.Join(args[:2], " "), "", 1))
-
-						var startLevel int
-						lastLevel := -1
-						if _, err = strconv.Atoi(args[len(args)-2]); len(args) > 4 && err == nil {
-							startLevel, err = strconv.Atoi(args[len(args)-2])
+						if args[3] == "remove" {
+							err = m.setLevelsMultiplier(channel.GuildID, models.LevelsMultiplierTypeChannel, targetChannel.ID, -1)
 							helpers.Relax(err)
-							lastLevel, err = strconv.Atoi(args[len(args)-1])
-							helpers.Relax(err)
-
-							roleNameToMatch = strings.TrimSpace(strings.Replace(roleNameToMatch, " "+strings.Join(args[len(args)-2:], " "), "", 1))
-						} else {
-							startLevel, err = strconv.Atoi(args[len(args)-1])
-							helpers.Relax(err)
-
-							roleNameToMatch = strings.TrimSpace(strings.Replace(roleNameToMatch, " "+strings.Join(args[len(args)-1:], " "), "", 1))
-						}
 
-						for _, role := range serverRoles {
-							if strings.ToLower(role.Name) == strings.ToLower(roleNameToMatch) || role.ID == roleNameToMatch {
-								targetRole = role
-							}
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-channel-removed", targetChannel.ID))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
 						}
 
-						if targetRole == nil || targetRole.ID == "" || startLevel < 0 || (lastLevel < 0 && lastLevel != -1) || (lastLevel != -1 && startLevel > lastLevel) {
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						multiplier, err := m.parseMultiplier(args[3])
+						if err != nil {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-invalid", levelsMultiplierLimit))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
 
-						_, err = m.createLevelsRoleEntry(channel.GuildID, targetRole.ID, startLevel, lastLevel)
+						err = m.setLevelsMultiplier(channel.GuildID, models.LevelsMultiplierTypeChannel, targetChannel.ID, multiplier)
 						helpers.Relax(err)
 
-						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.levels-role-add-success", targetRole.Name))
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-channel-set", targetChannel.ID, m.formatMultiplier(multiplier)))
 						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 					})
 					return
-				case "apply":
-					// [p]levels role apply
-					helpers.RequireMod(msg, func() {
-						if helpers.ConfirmEmbed(msg.ChannelID, msg.Author, helpers.GetText("plugins.levels.levels-role-apply-confirm"), "✅", "🚫") {
-							errors := make([]error, 0)
-							var success int
+				case "event":
+					if len(args) < 3 {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
 
-							guild, err := helpers.GetGuild(channel.GuildID)
-							helpers.Relax(err)
+					switch args[2] {
+					case "add", "schedule": // [p]levels multiplier event add <multiplier> <starts in or now> <duration> [<#announcement channel>] [<name>]
+						helpers.RequireAdmin(msg, func() {
+							if len(args) < 6 {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
 
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.levels-role-apply-start"))
+							multiplier, err := m.parseMultiplier(args[3])
+							if err != nil || multiplier <= 0 {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-invalid", levelsMultiplierLimit))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
 
-							for _, member := range guild.Members {
-								if member.User.Bot == true {
-									continue
+							var startsIn time.Duration
+							if args[4] != "now" {
+								startsIn, err = helpers.ParseDuration(args[4])
+								if err != nil || startsIn < 0 || startsIn > levelsMultiplierEventMaxStartsIn {
+									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.multiplier-event-invalid-time"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
 								}
+							}
+							duration, err := helpers.ParseDuration(args[5])
+							if err != nil || duration <= 0 || duration > levelsMultiplierEventMaxDuration {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.multiplier-event-invalid-time"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
 
-								errRole := m.applyLevelsRoles(guild.ID, member.User.ID, m.GetLevelForUser(member.User.ID, guild.ID))
-								if errRole == nil {
-									success++
-								} else {
-									errors = append(errors, errRole)
+							nameArgs := args[6:]
+							var announcementChannelID string
+							if len(nameArgs) > 0 {
+								announcementChannel, err := helpers.GetChannelFromMention(msg, nameArgs[0])
+								if err == nil && announcementChannel != nil && announcementChannel.GuildID == channel.GuildID {
+									announcementChannelID = announcementChannel.ID
+									nameArgs = nameArgs[1:]
 								}
 							}
+							name := strings.Join(nameArgs, " ")
+							if name == "" {
+								name = helpers.GetText("plugins.levels.multiplier-event-default-name")
+							}
 
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.levels-role-apply-result", msg.Author.ID, success, len(errors)))
+							startsAt := time.Now().Add(startsIn)
+							entry := models.LevelsMultiplierEventEntry{
+								GuildID:         channel.GuildID,
+								Name:            name,
+								Multiplier:      multiplier,
+								ChannelID:       announcementChannelID,
+								CreatedByUserID: msg.Author.ID,
+								StartsAt:        startsAt,
+								EndsAt:          startsAt.Add(duration),
+							}
+							entry.ID, err = m.createMultiplierEvent(entry)
+							helpers.Relax(err)
+
+							err = m.updateMultiplierEvents()
+							helpers.RelaxLog(err)
+
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-event-added",
+								entry.Name, m.formatMultiplier(entry.Multiplier),
+								entry.StartsAt.UTC().Format(time.ANSIC), entry.EndsAt.UTC().Format(time.ANSIC), entry.ID))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-							return
-						}
+						})
 						return
-					})
-					return
-				case "list":
-					// [p]levels role list
-					helpers.RequireMod(msg, func() {
-						entries, err := m.getLevelsRoleEntriesBy("guild_id", channel.GuildID)
-						if err != nil {
-							if !strings.Contains(err.Error(), "no levels role entries") {
-								helpers.Relax(err)
+					case "remove", "delete": // [p]levels multiplier event remove <event id>
+						helpers.RequireAdmin(msg, func() {
+							if len(args) < 4 {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
 							}
-						}
 
-						if len(entries) <= 0 {
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.levels-role-list-empty"))
-							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-						}
+							events, err := m.getMultiplierEvents(channel.GuildID)
+							helpers.Relax(err)
 
-						var message string
-						for _, entry := range entries {
-							role, err := session.State.Role(entry.GuildID, entry.RoleID)
-							if err != nil {
-								role = new(discordgo.Role)
-								role.ID = "N/A"
-								role.Name = "N/A"
-							}
+							for _, event := range events {
+								if event.ID != args[3] {
+									continue
+								}
 
-							lastLevelText := strconv.Itoa(entry.LastLevel)
-							if lastLevelText == "-1" {
-								lastLevelText = "∞"
+								err = m.deleteMultiplierEvent(event.ID)
+								helpers.Relax(err)
+
+								err = m.updateMultiplierEvents()
+								helpers.RelaxLog(err)
+
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.multiplier-event-removed", event.Name))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
 							}
 
-							message += fmt.Sprintf("`%s`: Role `%s` (`#%s`) from level %d to level %s\n",
-								entry.ID, role.Name, role.ID, entry.StartLevel, lastLevelText)
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.multiplier-event-not-found"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						})
+						return
+					}
+				}
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			case "voice":
+				if len(args) < 3 {
+					// [p]levels voice
+					helpers.RequireMod(msg, func() {
+						settings := helpers.GuildSettingsGetCached(channel.GuildID)
+						if settings.LevelsVoiceExpPerMinute <= 0 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.voice-status-disabled", helpers.GetPrefixForServer(channel.GuildID)))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
 						}
-						message += fmt.Sprintf("_found %d role(s) in total_", len(entries))
 
-						overwrites := m.getLevelsRolesGuildOverwrites(channel.GuildID)
+						capText := helpers.GetText("plugins.levels.voice-no-cap")
+						if settings.LevelsVoiceExpDailyCap > 0 {
+							capText = helpers.GetTextF("plugins.levels.voice-cap", settings.LevelsVoiceExpDailyCap)
+						}
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.voice-status", settings.LevelsVoiceExpPerMinute, capText))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				}
 
-						if len(overwrites) > 0 {
-							message += "\n**Overwrites:**\n"
-
-							for _, overwrite := range overwrites {
-								overwriteRole, err := session.State.Role(channel.GuildID, overwrite.RoleID)
-								if err != nil {
-									continue
-								}
-								overwriteUser, err := helpers.GetUser(overwrite.UserID)
-								if err != nil {
-									overwriteUser = new(discordgo.User)
-									overwriteUser.Username = "N/A"
-									overwriteUser.ID = overwrite.UserID
-								}
+				helpers.RequireAdmin(msg, func() {
+					value, err := strconv.Atoi(args[2])
+					if err != nil || value < 0 {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
 
-								message += fmt.Sprintf("%s user `%s` (`#%s`) role `%s` (`#%s`)\n",
-									strings.Title(overwrite.Type), overwriteUser.Username, overwriteUser.ID, overwriteRole.Name, overwriteRole.ID)
-							}
-							message += fmt.Sprintf("_found %d overwrite(s) in total_", len(overwrites))
+					settings := helpers.GuildSettingsGetCached(channel.GuildID)
+					switch args[1] {
+					case "rate": // [p]levels voice rate <exp per minute, 0 to disable>
+						if value > levelsExpPerMessageLimit {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.voice-rate-too-high", levelsExpPerMessageLimit))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
 						}
-
-						for _, page := range helpers.Pagify(message, "\n") {
-							_, err = helpers.SendMessage(msg.ChannelID, page)
+						settings.LevelsVoiceExpPerMinute = value
+					case "cap": // [p]levels voice cap <exp per day, 0 for no cap>
+						if value > levelsVoiceExpDailyCapLimit {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.voice-cap-too-high", levelsVoiceExpDailyCapLimit))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
 						}
+						settings.LevelsVoiceExpDailyCap = value
+					default:
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 						return
-					})
-					return
-				case "remove", "delete":
-					// levels role remove <connection id>
+					}
+					err = helpers.GuildSettingsSet(channel.GuildID, settings)
+					helpers.Relax(err)
+
+					switch {
+					case settings.LevelsVoiceExpPerMinute <= 0:
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.voice-disabled"))
+					case settings.LevelsVoiceExpDailyCap > 0:
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.voice-set",
+							settings.LevelsVoiceExpPerMinute, helpers.GetTextF("plugins.levels.voice-cap", settings.LevelsVoiceExpDailyCap)))
+					default:
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.voice-set",
+							settings.LevelsVoiceExpPerMinute, helpers.GetText("plugins.levels.voice-no-cap")))
+					}
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			case "announcements", "announcement", "levelup":
+				if len(args) < 2 {
+					// [p]levels announcements
 					helpers.RequireMod(msg, func() {
-						if len(args) < 3 {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+						settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+						var destinationText string
+						switch settings.LevelsAnnouncementsDestination {
+						case levelsAnnouncementsDestinationCurrent:
+							destinationText = helpers.GetText("plugins.levels.announcements-destination-current")
+						case levelsAnnouncementsDestinationChannel:
+							destinationText = helpers.GetTextF("plugins.levels.announcements-destination-channel", settings.LevelsAnnouncementsChannelID)
+						case levelsAnnouncementsDestinationDM:
+							destinationText = helpers.GetText("plugins.levels.announcements-destination-dm")
+						default:
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.announcements-status-disabled", helpers.GetPrefixForServer(channel.GuildID)))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
 
-						entry, err := m.getLevelsRoleEntryBy("id", args[2])
-						if err != nil {
-							if !strings.Contains(err.Error(), "no levels role entry") {
-								helpers.Relax(err)
-							}
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-							return
+						template := settings.LevelsAnnouncementsTemplate
+						if template == "" {
+							template = helpers.GetText("plugins.levels.announcements-default-template")
+						}
+						every := settings.LevelsAnnouncementsEvery
+						if every < 1 {
+							every = 1
 						}
 
-						err = m.deleteLevelsRoleEntry(entry)
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.announcements-status",
+							destinationText, every, settings.LevelsAnnouncementsImage, template))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				}
+
+				switch args[1] {
+				case "opt-out", "optout", "opt-in", "optin": // [p]levels announcements opt-out/opt-in
+					userData := m.GetUserUserdata(msg.Author)
+					userData.LevelUpOptOut = strings.Contains(args[1], "out")
+					m.setUserUserdata(userData)
+
+					if userData.LevelUpOptOut {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.announcements-opted-out"))
+					} else {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.announcements-opted-in"))
+					}
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				case "test": // [p]levels announcements test
+					helpers.RequireMod(msg, func() {
+						member, err := helpers.GetGuildMember(channel.GuildID, msg.Author.ID)
 						helpers.Relax(err)
 
-						role, err := session.State.Role(channel.GuildID, entry.RoleID)
-						if err != nil {
-							role = new(discordgo.Role)
-							role.Name = "N/A"
+						level := m.GetLevelForUser(msg.Author.ID, channel.GuildID)
+						levelBefore := level - 1
+						if levelBefore < 0 {
+							levelBefore = 0
 						}
 
-						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.levels-role-delete-success",
-							role.Name, entry.RoleID))
+						_, err = helpers.SendComplex(msg.ChannelID,
+							m.getLevelUpMessage(guild, member, levelBefore, level, helpers.GuildSettingsGetCached(channel.GuildID)))
 						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-						return
 					})
 					return
-				case "grant":
-					// TODO: apply roles on join, show overwrites in list
-					helpers.RequireMod(msg, func() {
-						if len(args) < 4 {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+				}
+
+				helpers.RequireAdmin(msg, func() {
+					settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+					var resultText string
+					switch args[1] {
+					case "disable": // [p]levels announcements disable
+						settings.LevelsAnnouncementsDestination = ""
+						resultText = helpers.GetText("plugins.levels.announcements-disabled")
+					case "here", "current": // [p]levels announcements here
+						settings.LevelsAnnouncementsDestination = levelsAnnouncementsDestinationCurrent
+						resultText = helpers.GetTextF("plugins.levels.announcements-destination-set",
+							helpers.GetText("plugins.levels.announcements-destination-current"))
+					case "dm": // [p]levels announcements dm
+						settings.LevelsAnnouncementsDestination = levelsAnnouncementsDestinationDM
+						resultText = helpers.GetTextF("plugins.levels.announcements-destination-set",
+							helpers.GetText("plugins.levels.announcements-destination-dm"))
+					case "channel": // [p]levels announcements channel <channel>
+						if len(args) < 3 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
-
-						guild, err := helpers.GetGuild(channel.GuildID)
-						helpers.Relax(err)
-
-						targetUser, err := helpers.GetUserFromMention(args[2])
-						if err != nil || targetUser == nil || targetUser.ID == "" {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						targetChannel, err := helpers.GetChannelFromMention(msg, args[2])
+						if err != nil || targetChannel == nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
-
-						var targetRole *discordgo.Role
-
-						roleNameToMatch := strings.TrimSpace(strings.Replace(content, strings.Join(args[:3], " "), "", 1))
-						for _, guildRole := range guild.Roles {
-							if strings.ToLower(guildRole.Name) == strings.ToLower(roleNameToMatch) || guildRole.ID == roleNameToMatch {
-								targetRole = guildRole
-							}
+						settings.LevelsAnnouncementsDestination = levelsAnnouncementsDestinationChannel
+						settings.LevelsAnnouncementsChannelID = targetChannel.ID
+						resultText = helpers.GetTextF("plugins.levels.announcements-destination-set",
+							helpers.GetTextF("plugins.levels.announcements-destination-channel", targetChannel.ID))
+					case "message", "template": // [p]levels announcements message <template or reset>
+						if len(args) < 3 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
 						}
-
-						if targetRole == nil || targetRole.ID == "" {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						template := strings.TrimSpace(strings.Replace(content, strings.Join(args[:2], " "), "", 1))
+						if template == "reset" {
+							template = ""
+						}
+						if len(template) > levelsAnnouncementsTemplateMaxLength {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.announcements-template-too-long", levelsAnnouncementsTemplateMaxLength))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
-
-						previousGrant, previousDeny, grant := m.getLevelsRolesUserRoleOverwrite(guild.ID, targetRole.ID, targetUser.ID)
-
-						if previousDeny {
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.roles-grant-error-denying"))
+						settings.LevelsAnnouncementsTemplate = template
+						resultText = helpers.GetText("plugins.levels.announcements-template-set")
+					case "image": // [p]levels announcements image <on or off>
+						if len(args) < 3 || (args[2] != "on" && args[2] != "off") {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						settings.LevelsAnnouncementsImage = args[2] == "on"
+						if settings.LevelsAnnouncementsImage {
+							resultText = helpers.GetText("plugins.levels.announcements-image-enabled")
+						} else {
+							resultText = helpers.GetText("plugins.levels.announcements-image-disabled")
+						}
+					case "every": // [p]levels announcements every <n levels>
+						if len(args) < 3 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						every, err := strconv.Atoi(args[2])
+						if err != nil || every < 1 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
+						settings.LevelsAnnouncementsEvery = every
+						resultText = helpers.GetTextF("plugins.levels.announcements-every-set", every)
+					default:
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
 
-						if previousGrant {
-							err = m.deleteLevelsRolesOverwriteEntry(grant)
+					err = helpers.GuildSettingsSet(channel.GuildID, settings)
+					helpers.Relax(err)
 
-							err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
-							helpers.Relax(err)
+					_, err = helpers.SendMessage(msg.ChannelID, resultText)
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			case "season", "seasons":
+				if len(args) < 2 {
+					// [p]levels season
+					season, err := m.getActiveSeason(channel.GuildID)
+					if err == ErrLevelsNoActiveSeason {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-none", helpers.GetPrefixForServer(channel.GuildID)))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+					helpers.Relax(err)
 
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-grant-remove-success",
-								targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+					endsText := helpers.GetText("plugins.levels.season-ends-manually")
+					if !season.EndsAt.IsZero() {
+						endsText = helpers.GetTextF("plugins.levels.season-ends-at", season.EndsAt.UTC().Format(time.ANSIC))
+					}
+					settings := helpers.GuildSettingsGetCached(channel.GuildID)
+					rewardsText := helpers.GetText("plugins.levels.season-rewards-none")
+					if settings.LevelsSeasonRewardRoleID != "" && settings.LevelsSeasonRewardTopN > 0 {
+						rewardsText = helpers.GetTextF("plugins.levels.season-rewards", settings.LevelsSeasonRewardTopN, settings.LevelsSeasonRewardRoleID)
+					}
+
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-status",
+						season.Name, season.StartedAt.UTC().Format(time.ANSIC), endsText, rewardsText, helpers.GetPrefixForServer(channel.GuildID)))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				switch args[1] {
+				case "start": // [p]levels season start [<duration>] [<name>]
+					helpers.RequireAdmin(msg, func() {
+						_, err := m.getActiveSeason(channel.GuildID)
+						if err == nil {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.season-already-running"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
+						} else if err != ErrLevelsNoActiveSeason {
+							helpers.Relax(err)
 						}
 
-						_, err = m.createLevelsRolesOverwriteEntry(guild.ID, targetRole.ID, targetUser.ID, "grant")
+						season := models.LevelsSeasonEntry{
+							GuildID:         channel.GuildID,
+							StartedByUserID: msg.Author.ID,
+							StartedAt:       time.Now(),
+							Active:          true,
+						}
+
+						nameArgs := args[2:]
+						if len(nameArgs) > 0 {
+							duration, err := helpers.ParseDuration(nameArgs[0])
+							if err == nil {
+								if duration <= 0 || duration > levelsSeasonMaxDuration {
+									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.season-invalid-duration"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+								// EXP history is stored per UTC day, so seasons end at UTC midnight
+								season.EndsAt = levelsDayStart(season.StartedAt.Add(duration + 24*time.Hour - 1))
+								nameArgs = nameArgs[1:]
+							}
+						}
+						season.Name = strings.Join(nameArgs, " ")
+						if season.Name == "" {
+							seasons, err := m.getSeasons(channel.GuildID)
+							helpers.Relax(err)
+							season.Name = helpers.GetTextF("plugins.levels.season-default-name", len(seasons)+1)
+						}
+
+						// EXP history is stored per UTC day, leave the EXP gained today before the season started out
+						season.FirstDayExp, err = m.getDayExp(channel.GuildID, season.StartedAt)
 						helpers.Relax(err)
 
-						err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
+						season.ID, err = m.createSeason(season)
 						helpers.Relax(err)
+						m.clearPeriodRankingsCache(channel.GuildID)
 
-						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-grant-create-success",
-							targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-started", season.Name, helpers.GetPrefixForServer(channel.GuildID)))
 						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-						return
 					})
 					return
-				case "deny":
-					// [p]levels roles deny <@user or user id> <role name or id>
-					helpers.RequireMod(msg, func() {
-						if len(args) < 4 {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+				case "end", "stop": // [p]levels season end
+					helpers.RequireAdmin(msg, func() {
+						season, err := m.getActiveSeason(channel.GuildID)
+						if err == ErrLevelsNoActiveSeason {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-none", helpers.GetPrefixForServer(channel.GuildID)))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
-
-						guild, err := helpers.GetGuild(channel.GuildID)
 						helpers.Relax(err)
 
-						targetUser, err := helpers.GetUserFromMention(args[2])
-						if err != nil || targetUser == nil || targetUser.ID == "" {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						if !helpers.ConfirmEmbed(msg.ChannelID, msg.Author, helpers.GetTextF("plugins.levels.season-end-confirm", season.Name), "✅", "🚫") {
 							return
 						}
 
-						var targetRole *discordgo.Role
+						season, err = m.endSeason(season)
+						helpers.Relax(err)
 
-						roleNameToMatch := strings.TrimSpace(strings.Replace(content, strings.Join(args[:3], " "), "", 1))
-						for _, guildRole := range guild.Roles {
-							if strings.ToLower(guildRole.Name) == strings.ToLower(roleNameToMatch) || guildRole.ID == roleNameToMatch {
-								targetRole = guildRole
-							}
+						for _, page := range helpers.Pagify(m.getSeasonResultsText(season), "\n") {
+							_, err = helpers.SendMessage(msg.ChannelID, page)
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 						}
-
-						if targetRole == nil || targetRole.ID == "" {
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					})
+					return
+				case "rewards", "reward": // [p]levels season rewards <top n> <role name or id> or [p]levels season rewards off
+					helpers.RequireAdmin(msg, func() {
+						if len(args) < 3 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
 
-						previousGrant, previousDeny, grant := m.getLevelsRolesUserRoleOverwrite(guild.ID, targetRole.ID, targetUser.ID)
+						settings := helpers.GuildSettingsGetCached(channel.GuildID)
+						if args[2] == "off" {
+							settings.LevelsSeasonRewardRoleID = ""
+							settings.LevelsSeasonRewardTopN = 0
+							err = helpers.GuildSettingsSet(channel.GuildID, settings)
+							helpers.Relax(err)
 
-						if previousGrant {
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.roles-deny-error-granting"))
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.season-rewards-disabled"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
 
-						if previousDeny {
-							err = m.deleteLevelsRolesOverwriteEntry(grant)
-
-							err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
-							helpers.Relax(err)
+						topN, err := strconv.Atoi(args[2])
+						if err != nil || topN <= 0 || topN > levelsSeasonArchiveSize || len(args) < 4 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-rewards-invalid", levelsSeasonArchiveSize))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
 
-							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-deny-remove-success",
-								targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+						roleNameToMatch := strings.Join(args[3:], " ")
+						var targetRole *discordgo.Role
+						for _, role := range guild.Roles {
+							if strings.ToLower(role.Name) == strings.ToLower(roleNameToMatch) || role.ID == roleNameToMatch {
+								targetRole = role
+							}
+						}
+						if targetRole == nil || targetRole.ID == "" {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.multiplier-role-not-found"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 							return
 						}
 
-						_, err = m.createLevelsRolesOverwriteEntry(guild.ID, targetRole.ID, targetUser.ID, "deny")
+						settings.LevelsSeasonRewardRoleID = targetRole.ID
+						settings.LevelsSeasonRewardTopN = topN
+						err = helpers.GuildSettingsSet(channel.GuildID, settings)
 						helpers.Relax(err)
 
-						err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
-						helpers.Relax(err)
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-rewards-set", targetRole.Name, topN))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				case "history": // [p]levels season history
+					seasons, err := m.getSeasons(channel.GuildID)
+					helpers.Relax(err)
 
-						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-deny-create-success",
-							targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+					var historyText string
+					for _, season := range seasons {
+						if season.Active {
+							historyText += fmt.Sprintf("`%s` **%s** since %s UTC (running)\n",
+								season.ID, season.Name, season.StartedAt.UTC().Format(time.ANSIC))
+							continue
+						}
+						historyText += fmt.Sprintf("`%s` **%s** from %s UTC until %s UTC\n",
+							season.ID, season.Name, season.StartedAt.UTC().Format(time.ANSIC), season.EndedAt.UTC().Format(time.ANSIC))
+					}
+					if historyText == "" {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-none", helpers.GetPrefixForServer(channel.GuildID)))
 						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 						return
-					})
+					}
+
+					for _, page := range helpers.Pagify(helpers.GetTextF("plugins.levels.season-history-title", helpers.GetPrefixForServer(channel.GuildID))+"\n"+historyText, "\n") {
+						_, err = helpers.SendMessage(msg.ChannelID, page)
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					}
 					return
-				}
+				case "view", "results": // [p]levels season view <season id>
+					if len(args) < 3 {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+
+					seasons, err := m.getSeasons(channel.GuildID)
+					helpers.Relax(err)
+
+					for _, season := range seasons {
+						if season.ID != args[2] {
+							continue
+						}
+						if season.Active {
+							m.sendPeriodLeaderboard(msg, guild, levelsPeriodSeason)
+							return
+						}
+
+						for _, page := range helpers.Pagify(m.getSeasonResultsText(season), "\n") {
+							_, err = helpers.SendMessage(msg.ChannelID, page)
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						}
+						return
+					}
 
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.season-not-found"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
 				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
 				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 				return
-			}
-			targetUser, err = helpers.GetUserFromMention(args[0])
-			if targetUser == nil || targetUser.ID == "" {
-				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+			case "import": // [p]levels import [level] [add] with an uploaded CSV or JSON file
+				helpers.RequireAdmin(msg, func() {
+					if len(msg.Attachments) <= 0 {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.import-no-file"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+
+					var valuesAreLevels, addExp bool
+					for _, arg := range args[1:] {
+						switch arg {
+						case "level", "levels":
+							valuesAreLevels = true
+						case "add":
+							addExp = true
+						}
+					}
+
+					data, err := helpers.NetGetUAWithError(msg.Attachments[0].URL, helpers.DEFAULT_UA)
+					helpers.Relax(err)
+
+					entries, err := m.parseLevelsImport(channel.GuildID, data, valuesAreLevels)
+					if err != nil {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.import-parse-error", err.Error()))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+					if len(entries) <= 0 {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.import-nothing-found"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+					if len(entries) > levelsImportMaxEntries {
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.import-too-many", levelsImportMaxEntries))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+
+					var onServer int
+					for _, entry := range entries {
+						if helpers.GetIsInGuild(channel.GuildID, entry.UserID) {
+							onServer++
+						}
+					}
+
+					modeText := helpers.GetText("plugins.levels.import-mode-replace")
+					if addExp {
+						modeText = helpers.GetText("plugins.levels.import-mode-add")
+					}
+					previewText := helpers.GetTextF("plugins.levels.import-preview", len(entries), onServer, modeText) + "\n"
+					for i, entry := range entries {
+						if i >= levelsImportPreview {
+							previewText += "…\n"
+							break
+						}
+						username := "N/A"
+						if member, err := helpers.GetGuildMemberWithoutApi(channel.GuildID, entry.UserID); err == nil && member.User != nil {
+							username = member.User.Username + "#" + member.User.Discriminator
+						}
+						newExp := entry.Exp
+						if addExp {
+							newExp = levelsAddExp(m.getLevelsServerUserExp(channel.GuildID, entry.UserID), newExp)
+						}
+						previewText += fmt.Sprintf("`%s` %s: %s EXP (Level %d)\n",
+							entry.UserID, username, humanize.Comma(newExp), m.getLevelFromExp(channel.GuildID, newExp))
+					}
+
+					if !helpers.ConfirmEmbed(msg.ChannelID, msg.Author, previewText, "✅", "🚫") {
+						return
+					}
+
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.import-started"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+
+					// pause new message processing for that guild
+					temporaryIgnoredGuilds = append(temporaryIgnoredGuilds, channel.GuildID)
+					defer func() {
+						var newTemporaryIgnoredGuilds []string
+						for _, temporaryIgnoredGuild := range temporaryIgnoredGuilds {
+							if temporaryIgnoredGuild != channel.GuildID {
+								newTemporaryIgnoredGuilds = append(newTemporaryIgnoredGuilds, temporaryIgnoredGuild)
+							}
+						}
+						temporaryIgnoredGuilds = newTemporaryIgnoredGuilds
+					}()
+
+					for _, entry := range entries {
+						levelsServerUser := m.getLevelsServerUserOrCreateNew(channel.GuildID, entry.UserID)
+						if addExp {
+							levelsServerUser.Exp = levelsAddExp(levelsServerUser.Exp, entry.Exp)
+						} else {
+							levelsServerUser.Exp = entry.Exp
+						}
+						m.setLevelsServerUser(levelsServerUser)
+					}
+
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.import-done",
+						msg.Author.ID, len(entries), helpers.GetPrefixForServer(channel.GuildID)))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			case "export": // [p]levels export
+				helpers.RequireAdmin(msg, func() {
+					var levelsServersUsers []DB_Levels_ServerUser
+					listCursor, err := rethink.Table("levels_serverusers").Filter(
+						rethink.Row.Field("guildid").Eq(channel.GuildID),
+					).OrderBy(rethink.Desc("exp")).Run(helpers.GetDB())
+					helpers.Relax(err)
+					defer listCursor.Close()
+					err = listCursor.All(&levelsServersUsers)
+					if err != nil && err != rethink.ErrEmptyResult {
+						helpers.Relax(err)
+					}
+
+					var exportBuffer bytes.Buffer
+					writer := csv.NewWriter(&exportBuffer)
+					err = writer.Write([]string{"user_id", "username", "exp", "level"})
+					helpers.Relax(err)
+					for _, levelsServerUser := range levelsServersUsers {
+						if levelsServerUser.Exp <= 0 {
+							continue
+						}
+						username := ""
+						if member, err := helpers.GetGuildMemberWithoutApi(channel.GuildID, levelsServerUser.UserID); err == nil && member.User != nil {
+							username = member.User.Username + "#" + member.User.Discriminator
+						}
+						err = writer.Write([]string{
+							levelsServerUser.UserID,
+							username,
+							strconv.FormatInt(levelsServerUser.Exp, 10),
+							strconv.Itoa(m.getLevelFromExp(channel.GuildID, levelsServerUser.Exp)),
+						})
+						helpers.Relax(err)
+					}
+					writer.Flush()
+					helpers.Relax(writer.Error())
+
+					_, err = helpers.SendComplex(msg.ChannelID, &discordgo.MessageSend{
+						Content: helpers.GetTextF("plugins.levels.export-done", msg.Author.ID),
+						Files: []*discordgo.File{
+							{
+								Name:   fmt.Sprintf("%s-levels-%s.csv", channel.GuildID, time.Now().UTC().Format("2006-01-02")),
+								Reader: bytes.NewReader(exportBuffer.Bytes()),
+							},
+						},
+					})
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			case "anti-farming", "antifarming", "farming":
+				if len(args) < 2 {
+					// [p]levels anti-farming
+					helpers.RequireMod(msg, func() {
+						settings := helpers.GuildSettingsGetCached(channel.GuildID)
+						if settings.LevelsAntiFarming == "" {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.anti-farming-status-disabled", helpers.GetPrefixForServer(channel.GuildID)))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						reportText := helpers.GetText("plugins.levels.anti-farming-no-report-channel")
+						if settings.LevelsAntiFarmingReportChannelID != "" {
+							reportText = helpers.GetTextF("plugins.levels.anti-farming-report-channel", settings.LevelsAntiFarmingReportChannelID)
+						}
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.anti-farming-status", settings.LevelsAntiFarming, reportText))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				}
+
+				switch args[1] {
+				case "suspects", "report": // [p]levels anti-farming suspects
+					helpers.RequireMod(msg, func() {
+						userIDs, suspects := m.getAntiFarmingSuspects(channel.GuildID)
+						if len(suspects) <= 0 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.anti-farming-suspects-none"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						resultText := helpers.GetText("plugins.levels.anti-farming-suspects-title") + "\n"
+						for i, suspect := range suspects {
+							if i >= levelsAntiFarmingSuspectsShown {
+								break
+							}
+							username := "N/A"
+							if member, err := helpers.GetGuildMemberWithoutApi(channel.GuildID, userIDs[i]); err == nil && member.User != nil {
+								username = member.User.Username + "#" + member.User.Discriminator
+							}
+							resultText += helpers.GetTextF("plugins.levels.anti-farming-suspects-entry",
+								username, userIDs[i], suspect.Flagged, suspect.Messages, levelsAntiFarmingSignalsText(suspect.Signals)) + "\n"
+						}
+
+						for _, page := range helpers.Pagify(resultText, "\n") {
+							_, err = helpers.SendMessage(msg.ChannelID, page)
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						}
+					})
+					return
+				}
+
+				helpers.RequireAdmin(msg, func() {
+					settings := helpers.GuildSettingsGetCached(channel.GuildID)
+					var resultText string
+					switch args[1] {
+					case "off", "disable": // [p]levels anti-farming off
+						settings.LevelsAntiFarming = ""
+						resultText = helpers.GetText("plugins.levels.anti-farming-disabled")
+					case levelsAntiFarmingLow, levelsAntiFarmingMedium, levelsAntiFarmingHigh: // [p]levels anti-farming <low|medium|high>
+						settings.LevelsAntiFarming = args[1]
+						resultText = helpers.GetTextF("plugins.levels.anti-farming-set", args[1])
+					case "report-channel", "channel": // [p]levels anti-farming report-channel <channel or off>
+						if len(args) < 3 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						if args[2] == "off" || args[2] == "disable" {
+							settings.LevelsAntiFarmingReportChannelID = ""
+							resultText = helpers.GetText("plugins.levels.anti-farming-report-channel-disabled")
+							break
+						}
+						targetChannel, err := helpers.GetChannelFromMention(msg, args[2])
+						if err != nil || targetChannel == nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						settings.LevelsAntiFarmingReportChannelID = targetChannel.ID
+						resultText = helpers.GetTextF("plugins.levels.anti-farming-report-channel-set", targetChannel.ID)
+					default:
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+					err = helpers.GuildSettingsSet(channel.GuildID, settings)
+					helpers.Relax(err)
+
+					_, err = helpers.SendMessage(msg.ChannelID, resultText)
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				})
+				return
+			case "ignore":
+				if len(args) >= 2 {
+					switch args[1] {
+					case "list": // [p]levels ignore list
+						helpers.RequireMod(msg, func() {
+							settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+							ignoredMessage := "**Ignored Users:**"
+							if len(settings.LevelsIgnoredUserIDs) > 0 {
+								for i, ignoredUserID := range settings.LevelsIgnoredUserIDs {
+									ignoredUser, err := helpers.GetGuildMember(channel.GuildID, ignoredUserID)
+									if err != nil {
+										ignoredMessage += " N/A"
+									} else {
+										ignoredMessage += " " + ignoredUser.User.Username + "#" + ignoredUser.User.Discriminator
+									}
+									if i+1 < len(settings.LevelsIgnoredUserIDs) {
+										ignoredMessage += ","
+									}
+								}
+							} else {
+								ignoredMessage += " None"
+							}
+
+							ignoredMessage += "\n**Ignored Channels:**"
+							if len(settings.LevelsIgnoredChannelIDs) > 0 {
+								for i, ignoredChannelID := range settings.LevelsIgnoredChannelIDs {
+									ignoredMessage += " <#" + ignoredChannelID + ">"
+									if i+1 < len(settings.LevelsIgnoredChannelIDs) {
+										ignoredMessage += ","
+									}
+								}
+							} else {
+								ignoredMessage += " None"
+							}
+
+							for _, page := range helpers.Pagify(ignoredMessage, " ") {
+								_, err = helpers.SendMessage(msg.ChannelID, page)
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							}
+							return
+						})
+						return
+					case "user": // [p]levels ignore user <user>
+						if len(args) < 3 {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						helpers.RequireAdmin(msg, func() {
+							targetUser, err = helpers.GetUserFromMention(args[2])
+							if targetUser == nil || targetUser.ID == "" {
+								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
+
+							settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+							for i, ignoredUserID := range settings.LevelsIgnoredUserIDs {
+								if ignoredUserID == targetUser.ID {
+									settings.LevelsIgnoredUserIDs = append(settings.LevelsIgnoredUserIDs[:i], settings.LevelsIgnoredUserIDs[i+1:]...)
+									err = helpers.GuildSettingsSet(channel.GuildID, settings)
+									helpers.Relax(err)
+
+									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.ignore-user-removed"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+							}
+
+							settings.LevelsIgnoredUserIDs = append(settings.LevelsIgnoredUserIDs, targetUser.ID)
+							err = helpers.GuildSettingsSet(channel.GuildID, settings)
+							helpers.Relax(err)
+
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.ignore-user-added", helpers.GetPrefixForServer(channel.GuildID)))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						})
+						return
+					case "channel": // [p]levels ignore channel <channel>
+						if len(args) < 3 {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						helpers.RequireAdmin(msg, func() {
+							targetChannel, err := helpers.GetChannelFromMention(msg, args[2])
+							helpers.Relax(err)
+							if targetChannel == nil || targetChannel.ID == "" {
+								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
+
+							settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+							for i, ignoredChannelID := range settings.LevelsIgnoredChannelIDs {
+								if ignoredChannelID == targetChannel.ID {
+									settings.LevelsIgnoredChannelIDs = append(settings.LevelsIgnoredChannelIDs[:i], settings.LevelsIgnoredChannelIDs[i+1:]...)
+									err = helpers.GuildSettingsSet(channel.GuildID, settings)
+									helpers.Relax(err)
+
+									_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.ignore-channel-removed"))
+									helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+									return
+								}
+							}
+
+							settings.LevelsIgnoredChannelIDs = append(settings.LevelsIgnoredChannelIDs, targetChannel.ID)
+							err = helpers.GuildSettingsSet(channel.GuildID, settings)
+							helpers.Relax(err)
+
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.ignore-channel-added"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						})
+						return
+					}
+				}
+				return
+				// [p]level process-history
+			case "process-history":
+				helpers.RequireBotAdmin(msg, func() {
+					dmChannel, err := session.UserChannelCreate(msg.Author.ID)
+					helpers.Relax(err)
+					session.ChannelTyping(msg.ChannelID)
+					channel, err := helpers.GetChannel(msg.ChannelID)
+					helpers.Relax(err)
+					guild, err := helpers.GetGuild(channel.GuildID)
+					helpers.Relax(err)
+					_, err = helpers.SendMessage(msg.ChannelID, fmt.Sprintf("<@%s> Check your DMs.", msg.Author.ID))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					// pause new message processing for that guild
+					temporaryIgnoredGuilds = append(temporaryIgnoredGuilds, channel.GuildID)
+					_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Temporary disabled EXP Processing for `%s` while processing the Message History.", guild.Name))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					// reset accounts on this server
+					var levelsServersUsers []DB_Levels_ServerUser
+					listCursor, err := rethink.Table("levels_serverusers").Filter(
+						rethink.Row.Field("guildid").Eq(channel.GuildID),
+					).Run(helpers.GetDB())
+					helpers.Relax(err)
+					defer listCursor.Close()
+					err = listCursor.All(&levelsServersUsers)
+					helpers.Relax(err)
+					for _, levelsServerUser := range levelsServersUsers {
+						levelsServerUser.Exp = 0
+						m.setLevelsServerUser(levelsServerUser)
+					}
+					_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Resetted the EXP for every User on `%s`.", guild.Name))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					// process history
+					//var wg sync.WaitGroup
+					//wg.Add(len(guild.Channels))
+					for _, guildChannel := range guild.Channels {
+						guildChannelCurrent := guildChannel
+						//go func() {
+						prefix := helpers.GetPrefixForServer(guildChannelCurrent.GuildID)
+						expForUsers := make(map[string]int64)
+						//defer wg.Done()
+						if guildChannelCurrent.Type == discordgo.ChannelTypeGuildVoice {
+							continue
+						}
+
+						cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("Started processing of Channel #%s (#%s) on Guild %s (#%s)",
+							guildChannelCurrent.Name, guildChannelCurrent.ID, guild.Name, guild.ID))
+						// (asynchronous)
+						_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Started processing Messages for Channel <#%s>.", guildChannelCurrent.ID))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						lastBefore := ""
+						for {
+							messages, err := session.ChannelMessages(guildChannelCurrent.ID, 100, lastBefore, "", "")
+							if err != nil {
+								cache.GetLogger().WithField("module", "levels").Error(err.Error())
+								break
+							}
+							cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("Processing %d messages for Channel #%s (#%s) from before \"%s\" on Guild %s (#%s)",
+								len(messages), guildChannelCurrent.Name, guildChannelCurrent.ID, lastBefore, guild.Name, guild.ID))
+							if len(messages) <= 0 {
+								break
+							}
+							for _, message := range messages {
+								// ignore bot messages
+								if message.Author.Bot == true {
+									continue
+								}
+								// ignore commands
+								if prefix != "" {
+									if strings.HasPrefix(message.Content, prefix) {
+										continue
+									}
+								}
+								if _, ok := expForUsers[message.Author.ID]; ok {
+									expForUsers[message.Author.ID] += 5
+								} else {
+									expForUsers[message.Author.ID] = 5
+								}
+
+							}
+							lastBefore = messages[len(messages)-1].ID
+						}
+
+						for userId, expForuser := range expForUsers {
+							levelsServerUser := m.getLevelsServerUserOrCreateNew(guildChannelCurrent.GuildID, userId)
+							levelsServerUser.Exp += expForuser
+							m.setLevelsServerUser(levelsServerUser)
+						}
+
+						cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("Completed processing of Channel #%s (#%s) on Guild %s (#%s)",
+							guildChannelCurrent.Name, guildChannelCurrent.ID, guild.Name, guild.ID))
+						_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Completed processing Messages for Channel <#%s>.", guildChannelCurrent.ID))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						//}()
+					}
+					//fmt.Println("Waiting for all channels")
+					//wg.Wait()
+					// enable new message processing again
+					var newTemporaryIgnoredGuilds []string
+					for _, temporaryIgnoredGuild := range temporaryIgnoredGuilds {
+						if temporaryIgnoredGuild != channel.GuildID {
+							newTemporaryIgnoredGuilds = append(newTemporaryIgnoredGuilds, temporaryIgnoredGuild)
+						}
+					}
+					temporaryIgnoredGuilds = newTemporaryIgnoredGuilds
+					_, err = helpers.SendMessage(dmChannel.ID, fmt.Sprintf("Enabled EXP Processing for `%s` again.", guild.Name))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					_, err = helpers.SendMessage(dmChannel.ID, "Done!")
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				})
+				return
+			case "role", "roles":
+				if len(args) < 2 {
+					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				switch args[1] {
+				case "add":
+					helpers.RequireMod(msg, func() {
+						// [p]levels role add <role name or id> <start level> [<last level>]
+						if len(args) < 4 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						if _, err = strconv.Atoi(args[len(args)-1]); err != nil {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						serverRoles, err := session.GuildRoles(channel.GuildID)
+						if err != nil {
+							if errD, ok := err.(*discordgo.RESTError); ok && errD.Message.Code == discordgo.ErrCodeMissingPermissions {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.permissions.required", "Manage Roles"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							}
+						}
+						helpers.Relax(err)
+
+						var targetRole *discordgo.Role
+
+						roleNameToMatch := strings.TrimSpace(strings.Replace(content, strings.Join(args[:2], " "), "", 1))
+
+						var startLevel int
+						lastLevel := -1
+						if _, err = strconv.Atoi(args[len(args)-2]); len(args) > 4 && err == nil {
+							startLevel, err = strconv.Atoi(args[len(args)-2])
+							helpers.Relax(err)
+							lastLevel, err = strconv.Atoi(args[len(args)-1])
+							helpers.Relax(err)
+
+							roleNameToMatch = strings.TrimSpace(strings.Replace(roleNameToMatch, " "+strings.Join(args[len(args)-2:], " "), "", 1))
+						} else {
+							startLevel, err = strconv.Atoi(args[len(args)-1])
+							helpers.Relax(err)
+
+							roleNameToMatch = strings.TrimSpace(strings.Replace(roleNameToMatch, " "+strings.Join(args[len(args)-1:], " "), "", 1))
+						}
+
+						for _, role := range serverRoles {
+							if strings.ToLower(role.Name) == strings.ToLower(roleNameToMatch) || role.ID == roleNameToMatch {
+								targetRole = role
+							}
+						}
+
+						if targetRole == nil || targetRole.ID == "" || startLevel < 0 || (lastLevel < 0 && lastLevel != -1) || (lastLevel != -1 && startLevel > lastLevel) {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						_, err = m.createLevelsRoleEntry(channel.GuildID, targetRole.ID, startLevel, lastLevel)
+						helpers.Relax(err)
+
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.levels-role-add-success", targetRole.Name))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					})
+					return
+				case "apply":
+					// [p]levels role apply
+					helpers.RequireMod(msg, func() {
+						if helpers.ConfirmEmbed(msg.ChannelID, msg.Author, helpers.GetText("plugins.levels.levels-role-apply-confirm"), "✅", "🚫") {
+							errors := make([]error, 0)
+							var success int
+
+							guild, err := helpers.GetGuild(channel.GuildID)
+							helpers.Relax(err)
+
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.levels-role-apply-start"))
+
+							for _, member := range guild.Members {
+								if member.User.Bot == true {
+									continue
+								}
+
+								errRole := m.applyLevelsRoles(guild.ID, member.User.ID, m.GetLevelForUser(member.User.ID, guild.ID))
+								if errRole == nil {
+									success++
+								} else {
+									errors = append(errors, errRole)
+								}
+							}
+
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.levels-role-apply-result", msg.Author.ID, success, len(errors)))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						return
+					})
+					return
+				case "list":
+					// [p]levels role list
+					helpers.RequireMod(msg, func() {
+						entries, err := m.getLevelsRoleEntriesBy("guild_id", channel.GuildID)
+						if err != nil {
+							if !strings.Contains(err.Error(), "no levels role entries") {
+								helpers.Relax(err)
+							}
+						}
+
+						if len(entries) <= 0 {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.levels-role-list-empty"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						}
+
+						var message string
+						for _, entry := range entries {
+							role, err := session.State.Role(entry.GuildID, entry.RoleID)
+							if err != nil {
+								role = new(discordgo.Role)
+								role.ID = "N/A"
+								role.Name = "N/A"
+							}
+
+							lastLevelText := strconv.Itoa(entry.LastLevel)
+							if lastLevelText == "-1" {
+								lastLevelText = "∞"
+							}
+
+							message += fmt.Sprintf("`%s`: Role `%s` (`#%s`) from level %d to level %s\n",
+								entry.ID, role.Name, role.ID, entry.StartLevel, lastLevelText)
+						}
+						message += fmt.Sprintf("_found %d role(s) in total_", len(entries))
+
+						overwrites := m.getLevelsRolesGuildOverwrites(channel.GuildID)
+
+						if len(overwrites) > 0 {
+							message += "\n**Overwrites:**\n"
+
+							for _, overwrite := range overwrites {
+								overwriteRole, err := session.State.Role(channel.GuildID, overwrite.RoleID)
+								if err != nil {
+									continue
+								}
+								overwriteUser, err := helpers.GetUser(overwrite.UserID)
+								if err != nil {
+									overwriteUser = new(discordgo.User)
+									overwriteUser.Username = "N/A"
+									overwriteUser.ID = overwrite.UserID
+								}
+
+								message += fmt.Sprintf("%s user `%s` (`#%s`) role `%s` (`#%s`)\n",
+									strings.Title(overwrite.Type), overwriteUser.Username, overwriteUser.ID, overwriteRole.Name, overwriteRole.ID)
+							}
+							message += fmt.Sprintf("_found %d overwrite(s) in total_", len(overwrites))
+						}
+
+						for _, page := range helpers.Pagify(message, "\n") {
+							_, err = helpers.SendMessage(msg.ChannelID, page)
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						}
+						return
+					})
+					return
+				case "remove", "delete":
+					// levels role remove <connection id>
+					helpers.RequireMod(msg, func() {
+						if len(args) < 3 {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						entry, err := m.getLevelsRoleEntryBy("id", args[2])
+						if err != nil {
+							if !strings.Contains(err.Error(), "no levels role entry") {
+								helpers.Relax(err)
+							}
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						err = m.deleteLevelsRoleEntry(entry)
+						helpers.Relax(err)
+
+						role, err := session.State.Role(channel.GuildID, entry.RoleID)
+						if err != nil {
+							role = new(discordgo.Role)
+							role.Name = "N/A"
+						}
+
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.levels-role-delete-success",
+							role.Name, entry.RoleID))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					})
+					return
+				case "grant":
+					// TODO: apply roles on join, show overwrites in list
+					helpers.RequireMod(msg, func() {
+						if len(args) < 4 {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						guild, err := helpers.GetGuild(channel.GuildID)
+						helpers.Relax(err)
+
+						targetUser, err := helpers.GetUserFromMention(args[2])
+						if err != nil || targetUser == nil || targetUser.ID == "" {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						var targetRole *discordgo.Role
+
+						roleNameToMatch := strings.TrimSpace(strings.Replace(content, strings.Join(args[:3], " "), "", 1))
+						for _, guildRole := range guild.Roles {
+							if strings.ToLower(guildRole.Name) == strings.ToLower(roleNameToMatch) || guildRole.ID == roleNameToMatch {
+								targetRole = guildRole
+							}
+						}
+
+						if targetRole == nil || targetRole.ID == "" {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						previousGrant, previousDeny, grant := m.getLevelsRolesUserRoleOverwrite(guild.ID, targetRole.ID, targetUser.ID)
+
+						if previousDeny {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.roles-grant-error-denying"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						if previousGrant {
+							err = m.deleteLevelsRolesOverwriteEntry(grant)
+
+							err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
+							helpers.Relax(err)
+
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-grant-remove-success",
+								targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						_, err = m.createLevelsRolesOverwriteEntry(guild.ID, targetRole.ID, targetUser.ID, "grant")
+						helpers.Relax(err)
+
+						err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
+						helpers.Relax(err)
+
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-grant-create-success",
+							targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					})
+					return
+				case "deny":
+					// [p]levels roles deny <@user or user id> <role name or id>
+					helpers.RequireMod(msg, func() {
+						if len(args) < 4 {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						guild, err := helpers.GetGuild(channel.GuildID)
+						helpers.Relax(err)
+
+						targetUser, err := helpers.GetUserFromMention(args[2])
+						if err != nil || targetUser == nil || targetUser.ID == "" {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						var targetRole *discordgo.Role
+
+						roleNameToMatch := strings.TrimSpace(strings.Replace(content, strings.Join(args[:3], " "), "", 1))
+						for _, guildRole := range guild.Roles {
+							if strings.ToLower(guildRole.Name) == strings.ToLower(roleNameToMatch) || guildRole.ID == roleNameToMatch {
+								targetRole = guildRole
+							}
+						}
+
+						if targetRole == nil || targetRole.ID == "" {
+							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						previousGrant, previousDeny, grant := m.getLevelsRolesUserRoleOverwrite(guild.ID, targetRole.ID, targetUser.ID)
+
+						if previousGrant {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.roles-deny-error-granting"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						if previousDeny {
+							err = m.deleteLevelsRolesOverwriteEntry(grant)
+
+							err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
+							helpers.Relax(err)
+
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-deny-remove-success",
+								targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+
+						_, err = m.createLevelsRolesOverwriteEntry(guild.ID, targetRole.ID, targetUser.ID, "deny")
+						helpers.Relax(err)
+
+						err = m.applyLevelsRoles(guild.ID, targetUser.ID, m.GetLevelForUser(targetUser.ID, guild.ID))
+						helpers.Relax(err)
+
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.roles-deny-create-success",
+							targetUser.Username, targetUser.ID, targetRole.Name, targetRole.ID))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					})
+					return
+				}
+
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+			targetUser, err = helpers.GetUserFromMention(args[0])
+			if targetUser == nil || targetUser.ID == "" {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
 				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 				return
 			}
 		}
 
-		var levelsServersUser []DB_Levels_ServerUser
-		listCursor, err := rethink.Table("levels_serverusers").Filter(
-			rethink.Row.Field("userid").Eq(targetUser.ID),
-		).Run(helpers.GetDB())
-		helpers.Relax(err)
-		defer listCursor.Close()
-		err = listCursor.All(&levelsServersUser)
+		var levelsServersUser []DB_Levels_ServerUser
+		listCursor, err := rethink.Table("levels_serverusers").Filter(
+			rethink.Row.Field("userid").Eq(targetUser.ID),
+		).Run(helpers.GetDB())
+		helpers.Relax(err)
+		defer listCursor.Close()
+		err = listCursor.All(&levelsServersUser)
+
+		if err == rethink.ErrEmptyResult {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.level-no-stats"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		} else if err != nil {
+			helpers.Relax(err)
+		}
+
+		var levelThisServerUser DB_Levels_ServerUser
+		var totalExp int64
+		for _, levelsServerUser := range levelsServersUser {
+			if levelsServerUser.GuildID == channel.GuildID {
+				levelThisServerUser = levelsServerUser
+			}
+			totalExp += levelsServerUser.Exp
+		}
+
+		if totalExp <= 0 {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.level-no-stats"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
+		currentMember, _ := helpers.GetGuildMember(channel.GuildID, levelThisServerUser.UserID)
+		if currentMember == nil || currentMember.User == nil || currentMember.User.ID == "" {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
+		fullUsername := currentMember.User.Username
+		if currentMember.Nick != "" {
+			fullUsername += " ~ " + currentMember.Nick
+		}
+
+		zeroWidthWhitespace, err := strconv.Unquote(`'\u200b'`)
+		helpers.Relax(err)
+
+		userLevelEmbed := &discordgo.MessageEmbed{
+			Color:       0x0FADED,
+			Title:       helpers.GetTextF("plugins.levels.user-embed-title", fullUsername),
+			Description: "View the leaderboard for this server [here](" + helpers.GetConfig().Path("website.ranking_base_url").Data().(string) + "/" + channel.GuildID + ").",
+			Footer: &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.levels.embed-footer",
+				len(session.State.Guilds),
+			)},
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Level",
+					Value:  strconv.Itoa(m.getLevelFromExp(channel.GuildID, levelThisServerUser.Exp)),
+					Inline: true,
+				},
+				{
+					Name:   "Level Progress",
+					Value:  strconv.Itoa(m.getProgressToNextLevelFromExp(channel.GuildID, levelThisServerUser.Exp)) + " %",
+					Inline: true,
+				},
+				{
+					Name:   zeroWidthWhitespace,
+					Value:  zeroWidthWhitespace,
+					Inline: true,
+				},
+				{
+					Name:   "Global Level",
+					Value:  strconv.Itoa(m.getLevelFromExp("global", totalExp)),
+					Inline: true,
+				},
+				{
+					Name:   "Global Level Progress",
+					Value:  strconv.Itoa(m.getProgressToNextLevelFromExp("global", totalExp)) + " %",
+					Inline: true,
+				},
+				{
+					Name:   zeroWidthWhitespace,
+					Value:  zeroWidthWhitespace,
+					Inline: true,
+				},
+			},
+		}
+
+		multiplier, multiplierSources := m.getExpMultiplier(channel.GuildID, msg.ChannelID, currentMember.User.ID)
+		if len(multiplierSources) > 0 {
+			userLevelEmbed.Fields = append(userLevelEmbed.Fields, &discordgo.MessageEmbedField{
+				Name:   helpers.GetTextF("plugins.levels.multiplier-embed-field", m.formatMultiplier(multiplier)),
+				Value:  strings.Join(multiplierSources, "\n"),
+				Inline: false,
+			})
+		}
+
+		_, err = helpers.SendEmbed(msg.ChannelID, userLevelEmbed)
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	case "leaderboard", "leaderboards", "ranking", "rankings":
+		channel, err := helpers.GetChannel(msg.ChannelID)
+		helpers.Relax(err)
+
+		link := helpers.GetConfig().Path("website.ranking_base_url").Data().(string) + "/" + channel.GuildID
+
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.ranking-text", link))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+}
+
+func (l *Levels) DeleteMessages(channelID string, messages []string) {
+	for _, message := range messages {
+		cache.GetSession().ChannelMessageDelete(channelID, message)
+	}
+}
+
+func (l *Levels) BadgePickerPrintCategories(user *discordgo.User, channeID string, availableBadges []DB_Badge, activeBadgeIDs []string, allBadges []DB_Badge) []string {
+	categoriesCount := make(map[string]int, 0)
+	for _, badge := range availableBadges {
+		if _, ok := categoriesCount[badge.Category]; ok {
+			categoriesCount[badge.Category] += 1
+		} else {
+			categoriesCount[badge.Category] = 1
+		}
+	}
+
+	sortedKeys := make([]string, len(categoriesCount))
+	i := 0
+	for k := range categoriesCount {
+		sortedKeys[i] = k
+		i++
+	}
+	sort.Strings(sortedKeys)
+
+	resultText := l.BadgePickerActiveText(user.Username, activeBadgeIDs, allBadges)
+	resultText += "Choose a category name:\n"
+	for _, key := range sortedKeys {
+		resultText += fmt.Sprintf("__%s__ (%d badges)\n", key, categoriesCount[key])
+	}
+	resultText += l.BadgePickerHelpText()
+
+	messageIDs := make([]string, 0)
+	for _, page := range helpers.Pagify(resultText, "\n") {
+		messages, err := helpers.SendMessage(channeID, page)
+		helpers.RelaxMessage(err, channeID, "")
+		for _, message := range messages {
+			messageIDs = append(messageIDs, message.ID)
+		}
+	}
+	return messageIDs
+}
+
+func (l *Levels) BadgePickerPrintBadges(user *discordgo.User, channeID string, availableBadges []DB_Badge, activeBadgeIDs []string, categoryName string, allBadges []DB_Badge) []string {
+	categoryName = strings.ToLower(categoryName)
+
+	resultText := l.BadgePickerActiveText(user.Username, activeBadgeIDs, allBadges)
+	resultText += "Choose a badge name:\n"
+	for _, badge := range availableBadges {
+		if badge.Category == categoryName {
+			isActive := false
+			for _, activeBadgeID := range activeBadgeIDs {
+				if activeBadgeID == badge.ID {
+					isActive = true
+				}
+			}
+			activeText := ""
+			if isActive {
+				activeText = " _**in use**_"
+			}
+			resultText += fmt.Sprintf("__%s__%s\n", badge.Name, activeText)
+		}
+	}
+	resultText += l.BadgePickerHelpText()
+
+	messageIDs := make([]string, 0)
+	for _, page := range helpers.Pagify(resultText, "\n") {
+		messages, err := helpers.SendMessage(channeID, page)
+		helpers.RelaxMessage(err, channeID, "")
+		for _, message := range messages {
+			messageIDs = append(messageIDs, message.ID)
+		}
+	}
+	return messageIDs
+}
+
+func (l *Levels) BadgePickerActiveText(username string, activeBadgeIDs []string, availableBadges []DB_Badge) string {
+	spaceLeft := BadgeLimt - len(activeBadgeIDs)
+	text := fmt.Sprintf("**@%s** You can pick %d more badge(s) to display on your profile.\nYou are currently displaying:", username, spaceLeft)
+	if len(activeBadgeIDs) > 0 {
+		for _, badgeID := range activeBadgeIDs {
+			for _, badge := range availableBadges {
+				if badge.ID == badgeID {
+					text += fmt.Sprintf(" `%s (%s)`", badge.Name, badge.Category)
+				}
+			}
+		}
+	} else {
+		text += " No badges"
+	}
+	text += ".\n\n"
+	return text
+}
+
+func (l *Levels) BadgePickerHelpText() string {
+	return "\nSay `categories` to display all categories, `category name` to choose a category, `badge name` to choose a badge, `reset` to remove all badges displayed on your profile, `exit` to exit and save. To remove a badge from your Profile pick the badge again.\n"
+}
+
+func (l *Levels) InsertNewProfileBackground(backgroundName string, backgroundUrl string, tags []string) error {
+	newEntry := new(DB_Profile_Background)
+	newEntry.Name = strings.ToLower(backgroundName)
+	newEntry.URL = backgroundUrl
+	newEntry.CreatedAt = time.Now()
+	newEntry.Tags = tags
+
+	insert := rethink.Table("profile_backgrounds").Insert(newEntry)
+	_, err := insert.RunWrite(helpers.GetDB())
+	return err
+}
+
+func (l *Levels) DeleteProfileBackground(backgroundName string) error {
+	if backgroundName != "" {
+		_, err := rethink.Table("profile_backgrounds").Filter(
+			rethink.Row.Field("id").Eq(backgroundName),
+		).Delete().RunWrite(helpers.GetDB())
+		return err
+	}
+	return nil
+}
+
+func (l *Levels) ProfileBackgroundSearch(searchText string) []DB_Profile_Background {
+	var entryBucket []DB_Profile_Background
+	listCursor, err := rethink.Table("profile_backgrounds").Filter(func(profile rethink.Term) rethink.Term {
+		return profile.Field("id").Match(fmt.Sprintf("(?i)%s", searchText))
+	}).Limit(5).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&entryBucket)
+
+	if err == rethink.ErrEmptyResult {
+		return entryBucket
+	} else if err != nil {
+		helpers.Relax(err)
+	}
+
+	return entryBucket
+}
+
+func (l *Levels) ProfileBackgroundNameExists(backgroundName string) bool {
+	var entryBucket DB_Profile_Background
+	listCursor, err := rethink.Table("profile_backgrounds").Filter(
+		rethink.Row.Field("id").Eq(strings.ToLower(backgroundName)),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&entryBucket)
+
+	if err == rethink.ErrEmptyResult {
+		var entryBucket DB_Profile_Background
+		listCursor, err := rethink.Table("profile_backgrounds").Filter(func(profile rethink.Term) rethink.Term {
+			return profile.Field("id").Match(fmt.Sprintf("(?i)^%s$", backgroundName))
+		}).Run(helpers.GetDB())
+		if err != nil {
+			panic(err)
+		}
+		defer listCursor.Close()
+		err = listCursor.One(&entryBucket)
+
+		if err == rethink.ErrEmptyResult {
+			return false
+		} else if err != nil {
+			helpers.Relax(err)
+		}
+
+		return true
+	} else if err != nil {
+		helpers.Relax(err)
+	}
+
+	return true
+}
+
+func (l *Levels) GetProfileBackgroundUrl(backgroundName string) string {
+	if backgroundName == "" {
+		return "http://i.imgur.com/I9b74U9.jpg"
+	}
+
+	var entryBucket DB_Profile_Background
+	listCursor, err := rethink.Table("profile_backgrounds").Filter(
+		rethink.Row.Field("id").Eq(strings.ToLower(backgroundName)),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&entryBucket)
+
+	if err == rethink.ErrEmptyResult {
+		var entryBucket DB_Profile_Background
+		listCursor, err := rethink.Table("profile_backgrounds").Filter(func(profile rethink.Term) rethink.Term {
+			return profile.Field("id").Match(fmt.Sprintf("(?i)^%s$", backgroundName))
+		}).Run(helpers.GetDB())
+		if err != nil {
+			panic(err)
+		}
+		defer listCursor.Close()
+		err = listCursor.One(&entryBucket)
+
+		if err == rethink.ErrEmptyResult {
+			return "http://i.imgur.com/I9b74U9.jpg" // Default Robyul Background
+		} else if err != nil {
+			helpers.Relax(err)
+		}
+
+		return entryBucket.URL
+	} else if err != nil {
+		helpers.Relax(err)
+	}
+
+	return entryBucket.URL
+}
+
+func (l *Levels) GetUserUserdata(user *discordgo.User) DB_Profile_Userdata {
+	var entryBucket DB_Profile_Userdata
+	listCursor, err := rethink.Table("profile_userdata").Filter(
+		rethink.Row.Field("userid").Eq(user.ID),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&entryBucket)
+
+	if err == rethink.ErrEmptyResult {
+		entryBucket.UserID = user.ID
+		insert := rethink.Table("profile_userdata").Insert(entryBucket)
+		_, err := insert.RunWrite(helpers.GetDB())
+		if err != nil {
+			helpers.Relax(err)
+		} else {
+			return l.GetUserUserdata(user)
+		}
+		return entryBucket
+	} else if err != nil {
+		helpers.Relax(err)
+	}
+
+	return entryBucket
+}
+
+func (l *Levels) GetServerOnlyBadges(guildID string) []DB_Badge {
+	var entryBucket []DB_Badge
+	listCursor, err := rethink.Table("profile_badge").Filter(
+		rethink.Row.Field("guildid").Eq(guildID),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&entryBucket)
+
+	if err != nil {
+		if err != rethink.ErrEmptyResult {
+			helpers.Relax(err)
+		}
+	}
+
+	return entryBucket
+}
+
+func (l *Levels) GetServerBadges(guildID string) []DB_Badge {
+	var entryBucket []DB_Badge
+	var globalEntryBucket []DB_Badge
+	listCursor, err := rethink.Table("profile_badge").Filter(
+		rethink.Row.Field("guildid").Eq(guildID),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&entryBucket)
+	if err != nil {
+		panic(err)
+	}
+
+	listCursor, err = rethink.Table("profile_badge").Filter(
+		rethink.Row.Field("guildid").Eq("global"),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&globalEntryBucket)
+
+	for _, globalEntry := range globalEntryBucket {
+		entryBucket = append(entryBucket, globalEntry)
+	}
+
+	if err != nil {
+		if err != rethink.ErrEmptyResult {
+			helpers.Relax(err)
+		}
+	}
+
+	return entryBucket
+}
+
+func (l *Levels) GetCategoryBadges(category string, guildID string) []DB_Badge {
+	var entryBucket []DB_Badge
+	result := make([]DB_Badge, 0)
+	listCursor, err := rethink.Table("profile_badge").Filter(
+		rethink.Row.Field("category").Eq(category),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&entryBucket)
+
+	if err == rethink.ErrEmptyResult {
+		return result
+	} else if err != nil {
+		helpers.Relax(err)
+	}
+
+	for _, badge := range entryBucket {
+		if badge.GuildID == guildID || badge.GuildID == "global" {
+			result = append(result, badge)
+		}
+	}
+
+	return result
+}
+
+func (l *Levels) GetBadge(category string, name string, guildID string) DB_Badge {
+	var entryBucket []DB_Badge
+	var emptyBadge DB_Badge
+	listCursor, err := rethink.Table("profile_badge").Filter(
+		rethink.Row.Field("category").Eq(strings.ToLower(category)),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&entryBucket)
+
+	if err == rethink.ErrEmptyResult {
+		return emptyBadge
+	} else if err != nil {
+		helpers.Relax(err)
+	}
+
+	for _, badge := range entryBucket {
+		if strings.ToLower(badge.Name) == strings.ToLower(name) && (badge.GuildID == guildID || badge.GuildID == "global") {
+			return badge
+		}
+	}
+
+	return emptyBadge
+}
+
+func (l *Levels) GetBadgeByID(badgeID string) DB_Badge {
+	var badgeBucket DB_Badge
+	listCursor, err := rethink.Table("profile_badge").Filter(
+		rethink.Row.Field("id").Eq(badgeID),
+	).Run(helpers.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&badgeBucket)
+
+	if err == rethink.ErrEmptyResult {
+		return badgeBucket
+	} else if err != nil {
+		panic(err)
+	}
+
+	return badgeBucket
+}
+
+func (l *Levels) GetBadgesAvailable(user *discordgo.User, sourceServerID string) []DB_Badge {
+	guildsToCheck := make([]string, 0)
+	guildsToCheck = append(guildsToCheck, "global")
+
+	session := cache.GetSession()
+
+	for _, guild := range session.State.Guilds {
+		if helpers.GetIsInGuild(guild.ID, user.ID) {
+			guildsToCheck = append(guildsToCheck, guild.ID)
+		}
+	}
+
+	sourceServerAlreadyIn := false
+	for _, guild := range guildsToCheck {
+		if guild == sourceServerID {
+			sourceServerAlreadyIn = true
+		}
+	}
+	if sourceServerAlreadyIn == false {
+		guildsToCheck = append(guildsToCheck, sourceServerID)
+	}
+
+	var allBadges []DB_Badge
+	for _, guildToCheck := range guildsToCheck {
+		var entryBucket []DB_Badge
+		listCursor, err := rethink.Table("profile_badge").Filter(
+			rethink.Row.Field("guildid").Eq(guildToCheck),
+		).Run(helpers.GetDB())
+		defer listCursor.Close()
+		if err != nil {
+			continue
+		}
+		err = listCursor.All(&entryBucket)
+		if err != nil {
+			continue
+		}
+		for _, entryBadge := range entryBucket {
+			allBadges = append(allBadges, entryBadge)
+		}
+	}
+
+	levelCache := make(map[string]int, 0)
+
+	var availableBadges []DB_Badge
+	for _, foundBadge := range allBadges {
+		isAllowed := false
+
+		// Level Check
+		if foundBadge.LevelRequirement < 0 { // Available for no one?
+			isAllowed = false
+		} else if foundBadge.LevelRequirement == 0 { // Available for everyone?
+			isAllowed = true
+		} else if foundBadge.LevelRequirement > 0 { // Meets min level?
+			if _, ok := levelCache[foundBadge.GuildID]; ok {
+				if foundBadge.LevelRequirement <= levelCache[foundBadge.GuildID] {
+					isAllowed = true
+				} else {
+					isAllowed = false
+				}
+			} else {
+				levelCache[foundBadge.GuildID] = l.GetLevelForUser(user.ID, foundBadge.GuildID)
+				if foundBadge.LevelRequirement <= levelCache[foundBadge.GuildID] {
+					isAllowed = true
+				} else {
+					isAllowed = false
+				}
+			}
+		}
+
+		// User is in allowed user list?
+		for _, allowedUserID := range foundBadge.AllowedUserIDs {
+			if allowedUserID == user.ID {
+				isAllowed = true
+			}
+		}
+
+		// User meets the badge rules?
+		for _, ruleUserID := range foundBadge.RuleUserIDs {
+			if ruleUserID == user.ID {
+				isAllowed = true
+			}
+		}
+
+		// User is in denied user list?
+		for _, deniedUserID := range foundBadge.DeniedUserIDs {
+			if deniedUserID == user.ID {
+				isAllowed = false
+			}
+		}
+
+		if isAllowed == true {
+			availableBadges = append(availableBadges, foundBadge)
+		}
+	}
+
+	return availableBadges
+}
+
+func (l *Levels) GetBadgesAvailableServer(user *discordgo.User, serverID string) []DB_Badge {
+	guildsToCheck := make([]string, 0)
+	guildsToCheck = append(guildsToCheck, "global")
+
+	if _, err := helpers.GetGuildMember(serverID, user.ID); err == nil {
+		guildsToCheck = append(guildsToCheck, serverID)
+	}
+
+	var allBadges []DB_Badge
+	for _, guildToCheck := range guildsToCheck {
+		var entryBucket []DB_Badge
+		listCursor, err := rethink.Table("profile_badge").Filter(
+			rethink.Row.Field("guildid").Eq(guildToCheck),
+		).Run(helpers.GetDB())
+		defer listCursor.Close()
+		if err != nil {
+			continue
+		}
+		err = listCursor.All(&entryBucket)
+		if err != nil {
+			continue
+		}
+		for _, entryBadge := range entryBucket {
+			allBadges = append(allBadges, entryBadge)
+		}
+	}
+
+	var availableBadges []DB_Badge
+	for _, foundBadge := range allBadges {
+		isAllowed := false
+
+		// Level Check
+		if foundBadge.LevelRequirement < 0 { // Available for no one?
+			isAllowed = false
+		} else if foundBadge.LevelRequirement == 0 { // Available for everyone?
+			isAllowed = true
+		} else if foundBadge.LevelRequirement > 0 { // Meets min level=
+			if foundBadge.LevelRequirement <= l.GetLevelForUser(user.ID, foundBadge.GuildID) {
+				isAllowed = true
+			} else {
+				isAllowed = false
+			}
+		}
+
+		// User is in allowed user list?
+		for _, allowedUserID := range foundBadge.AllowedUserIDs {
+			if allowedUserID == user.ID {
+				isAllowed = true
+			}
+		}
+
+		// User meets the badge rules?
+		for _, ruleUserID := range foundBadge.RuleUserIDs {
+			if ruleUserID == user.ID {
+				isAllowed = true
+			}
+		}
+
+		// User is in denied user list?
+		for _, deniedUserID := range foundBadge.DeniedUserIDs {
+			if deniedUserID == user.ID {
+				isAllowed = false
+			}
+		}
+
+		if isAllowed == true {
+			availableBadges = append(availableBadges, foundBadge)
+		}
+	}
+
+	return availableBadges
+}
+
+func (l *Levels) GetBadgesAvailableQuick(user *discordgo.User, activeBadgeIDs []string) []DB_Badge {
+
+	activeBadges := make([]DB_Badge, 0)
+	for _, activeBadgeID := range activeBadgeIDs {
+		badge := l.GetBadgeByID(activeBadgeID)
+		if badge.ID != "" {
+			if badge.GuildID == "global" {
+				activeBadges = append(activeBadges, badge)
+			} else {
+				if helpers.GetIsInGuild(badge.GuildID, user.ID) {
+					activeBadges = append(activeBadges, badge)
+				}
+			}
+		}
+	}
+
+	var availableBadges []DB_Badge
+	for _, foundBadge := range activeBadges {
+		isAllowed := true
+
+		// User is in allowed user list?
+		for _, allowedUserID := range foundBadge.AllowedUserIDs {
+			if allowedUserID == user.ID {
+				isAllowed = true
+			}
+		}
+
+		// User is in denied user list?
+		for _, deniedUserID := range foundBadge.DeniedUserIDs {
+			if deniedUserID == user.ID {
+				isAllowed = false
+			}
+		}
+
+		if isAllowed == true {
+			availableBadges = append(availableBadges, foundBadge)
+		}
+	}
+
+	return availableBadges
+}
+
+func (l *Levels) GetLevelForUser(userID string, guildID string) int {
+	var levelsServersUser []DB_Levels_ServerUser
+	listCursor, err := rethink.Table("levels_serverusers").Filter(
+		rethink.Row.Field("userid").Eq(userID),
+	).Run(helpers.GetDB())
+	helpers.Relax(err)
+	defer listCursor.Close()
+	err = listCursor.All(&levelsServersUser)
+
+	if err == rethink.ErrEmptyResult {
+		return 0
+	} else if err != nil {
+		helpers.Relax(err)
+	}
+
+	if guildID == "global" {
+		totalExp := int64(0)
+		for _, levelsServerUser := range levelsServersUser {
+			totalExp += levelsServerUser.Exp
+		}
+		return l.getLevelFromExp("global", totalExp)
+	} else {
+		for _, levelsServerUser := range levelsServersUser {
+			if levelsServerUser.GuildID == guildID {
+				return l.getLevelFromExp(guildID, levelsServerUser.Exp)
+			}
+		}
+	}
+
+	return 0
+}
+
+func (l *Levels) InsertBadge(entry DB_Badge) {
+	insert := rethink.Table("profile_badge").Insert(entry)
+	_, err := insert.RunWrite(helpers.GetDB())
+	if err != nil {
+		helpers.Relax(err)
+	}
+	return
+}
+
+func (l *Levels) UpdateBadge(entry DB_Badge) {
+	if entry.ID != "" {
+		_, err := rethink.Table("profile_badge").Update(entry).Run(helpers.GetDB())
+		helpers.Relax(err)
+	}
+}
+
+func (l *Levels) DeleteBadge(badgeID string) {
+	_, err := rethink.Table("profile_badge").Filter(
+		rethink.Row.Field("id").Eq(badgeID),
+	).Delete().RunWrite(helpers.GetDB())
+	helpers.Relax(err)
+	return
+}
+
+// levelsBadgeRuleCheck evaluates badge rules, data needed by the rules is loaded once per check
+type levelsBadgeRuleCheck struct {
+	guildID         string
+	userID          string // only loads data for this user if set
+	starboardCounts map[string]int64
+	reps            map[string]int
+	donatorUserIDs  map[string]bool
+	seasons         map[string]models.LevelsSeasonEntry
+}
+
+func (c *levelsBadgeRuleCheck) load(rules []DB_Badge_Rule) (err error) {
+	for _, rule := range rules {
+		switch rule.Type {
+		case levelsBadgeRuleStarboard:
+			if c.starboardCounts != nil {
+				continue
+			}
+			query := rethink.Table("starboard_entries").GetAllByIndex("guild_id", c.guildID)
+			if c.userID != "" {
+				query = query.Filter(rethink.Row.Field("author_id").Eq(c.userID))
+			}
+			listCursor, err := query.Group("author_id").Count().Ungroup().Run(helpers.GetDB())
+			if err != nil {
+				return err
+			}
+			var results []struct {
+				UserID string `gorethink:"group"`
+				Count  int64  `gorethink:"reduction"`
+			}
+			err = listCursor.All(&results)
+			listCursor.Close()
+			if err != nil && err != rethink.ErrEmptyResult {
+				return err
+			}
+			c.starboardCounts = make(map[string]int64)
+			for _, result := range results {
+				c.starboardCounts[result.UserID] = result.Count
+			}
+		case levelsBadgeRuleRep:
+			if c.reps != nil {
+				continue
+			}
+			var query rethink.Term
+			if c.userID != "" {
+				query = rethink.Table("profile_userdata").GetAllByIndex("userid", c.userID)
+			} else {
+				query = rethink.Table("profile_userdata").Filter(rethink.Row.Field("rep").Gt(0))
+			}
+			listCursor, err := query.Pluck("userid", "rep").Run(helpers.GetDB())
+			if err != nil {
+				return err
+			}
+			var results []DB_Profile_Userdata
+			err = listCursor.All(&results)
+			listCursor.Close()
+			if err != nil && err != rethink.ErrEmptyResult {
+				return err
+			}
+			c.reps = make(map[string]int)
+			for _, result := range results {
+				c.reps[result.UserID] = result.Rep
+			}
+		case levelsBadgeRuleDonator:
+			if c.donatorUserIDs != nil {
+				continue
+			}
+			listCursor, err := rethink.Table(models.DonatorsTable).Filter(
+				rethink.Row.Field("user_id").Default("").Ne(""),
+			).Run(helpers.GetDB())
+			if err != nil {
+				return err
+			}
+			var results []models.DonatorEntry
+			err = listCursor.All(&results)
+			listCursor.Close()
+			if err != nil && err != rethink.ErrEmptyResult {
+				return err
+			}
+			c.donatorUserIDs = make(map[string]bool)
+			for _, result := range results {
+				c.donatorUserIDs[result.UserID] = true
+			}
+		case levelsBadgeRuleSeason:
+			if c.seasons == nil {
+				c.seasons = make(map[string]models.LevelsSeasonEntry)
+			}
+			if _, ok := c.seasons[rule.TargetID]; ok {
+				continue
+			}
+			season, err := (&Levels{}).getSeason(rule.TargetID)
+			if err != nil && err != rethink.ErrEmptyResult {
+				return err
+			}
+			c.seasons[rule.TargetID] = season
+		}
+	}
+	return nil
+}
+
+// matches returns true if the user meets all rules, member is only required for member and role rules
+func (c *levelsBadgeRuleCheck) matches(rules []DB_Badge_Rule, userID string, member *discordgo.Member) bool {
+	if len(rules) <= 0 {
+		return false
+	}
+	for _, rule := range rules {
+		if !c.matchesRule(rule, userID, member) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *levelsBadgeRuleCheck) matchesRule(rule DB_Badge_Rule, userID string, member *discordgo.Member) bool {
+	switch rule.Type {
+	case levelsBadgeRuleStarboard:
+		return c.starboardCounts[userID] >= rule.Value
+	case levelsBadgeRuleMember:
+		if member == nil {
+			return false
+		}
+		joinedAt, err := discordgo.Timestamp(member.JoinedAt).Parse()
+		if err != nil {
+			return false
+		}
+		return time.Since(joinedAt) >= time.Duration(rule.Value)*24*time.Hour
+	case levelsBadgeRuleDonator:
+		return c.donatorUserIDs[userID]
+	case levelsBadgeRuleRep:
+		return int64(c.reps[userID]) >= rule.Value
+	case levelsBadgeRuleSeason:
+		season := c.seasons[rule.TargetID]
+		if season.Active {
+			return false
+		}
+		for _, result := range season.Results {
+			if result.UserID == userID && int64(result.Ranking) <= rule.Value {
+				return true
+			}
+		}
+	case levelsBadgeRuleRole:
+		if member == nil {
+			return false
+		}
+		for _, roleID := range member.Roles {
+			if roleID == rule.TargetID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *Levels) badgeRulesLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "levels").Error("The badgeRulesLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.badgeRulesLoop()
+		}()
+	}()
+
+	for {
+		badges, err := m.getBadgesWithRules()
+		if err != nil {
+			helpers.RelaxLog(err)
+			time.Sleep(60 * time.Second)
+			continue
+		}
+
+		guildIDs := make(map[string]bool)
+		for _, badge := range badges {
+			guildIDs[badge.GuildID] = true
+		}
+		levelsBadgeRuleGuildIDsLock.Lock()
+		levelsBadgeRuleGuildIDs = guildIDs
+		levelsBadgeRuleGuildIDsLock.Unlock()
+
+		for _, badge := range badges {
+			err = m.updateBadgeRuleUsers(badge)
+			if err != nil {
+				log.WithField("module", "levels").Error(fmt.Sprintf("failed to check rules of badge %s: %s", badge.ID, err.Error()))
+			}
+		}
+
+		time.Sleep(levelsBadgeRulesInterval)
+	}
+}
+
+// getBadgesWithRules returns all badges with rules of the given guilds, or of all guilds if none are given
+func (m *Levels) getBadgesWithRules(guildIDs ...string) (badges []DB_Badge, err error) {
+	query := rethink.Table("profile_badge")
+	if len(guildIDs) > 0 {
+		keys := make([]interface{}, 0)
+		for _, guildID := range guildIDs {
+			keys = append(keys, guildID)
+		}
+		query = query.GetAllByIndex("guildid", keys...)
+	}
+	listCursor, err := query.Filter(
+		rethink.Row.Field("rules").Default([]interface{}{}).Count().Gt(0),
+	).Run(helpers.GetDB())
+	if err != nil {
+		return badges, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&badges)
+	if err == rethink.ErrEmptyResult {
+		err = nil
+	}
+	return badges, err
+}
+
+// updateBadgeRuleUsers evaluates the rules of the badge for every member of its guild,
+// global badges are checked for every user with rep or a linked donation
+func (m *Levels) updateBadgeRuleUsers(badge DB_Badge) (err error) {
+	check := &levelsBadgeRuleCheck{guildID: badge.GuildID}
+	err = check.load(badge.Rules)
+	if err != nil {
+		return err
+	}
+
+	ruleUserIDs := make([]string, 0)
+	if badge.GuildID == "global" {
+		candidates := make(map[string]bool)
+		for userID := range check.reps {
+			candidates[userID] = true
+		}
+		for userID := range check.donatorUserIDs {
+			candidates[userID] = true
+		}
+		for userID := range candidates {
+			if check.matches(badge.Rules, userID, nil) {
+				ruleUserIDs = append(ruleUserIDs, userID)
+			}
+		}
+	} else {
+		guild, err := helpers.GetGuild(badge.GuildID)
+		if err != nil {
+			return err
+		}
+		// members missing from the state would lose the badge, try again once all members have been received
+		if len(guild.Members) < guild.MemberCount {
+			cache.GetLogger().WithField("module", "levels").WithField("GuildID", guild.ID).Info(fmt.Sprintf(
+				"skipping badge rules for badge #%s because only %d of %d members are cached", badge.ID, len(guild.Members), guild.MemberCount))
+			return nil
+		}
+		for _, member := range guild.Members {
+			if member.User == nil || member.User.Bot {
+				continue
+			}
+			if check.matches(badge.Rules, member.User.ID, member) {
+				ruleUserIDs = append(ruleUserIDs, member.User.ID)
+			}
+		}
+	}
+
+	isRuleUserID := make(map[string]bool)
+	for _, ruleUserID := range ruleUserIDs {
+		isRuleUserID[ruleUserID] = true
+	}
+	hadBadge := make(map[string]bool)
+	revokedUserIDs := make([]string, 0)
+	for _, previousUserID := range badge.RuleUserIDs {
+		hadBadge[previousUserID] = true
+		if !isRuleUserID[previousUserID] {
+			revokedUserIDs = append(revokedUserIDs, previousUserID)
+		}
+	}
+	awardedUserIDs := make([]string, 0)
+	for _, ruleUserID := range ruleUserIDs {
+		if !hadBadge[ruleUserID] {
+			awardedUserIDs = append(awardedUserIDs, ruleUserID)
+		}
+	}
+	if len(awardedUserIDs) <= 0 && len(revokedUserIDs) <= 0 {
+		return nil
+	}
+
+	// apply the changes only, checkBadgeRulesForUser might have changed the users in the meantime
+	_, err = rethink.Table("profile_badge").Get(badge.ID).Update(map[string]interface{}{
+		"rule_userids": rethink.Row.Field("rule_userids").Default([]interface{}{}).SetUnion(awardedUserIDs).SetDifference(revokedUserIDs),
+	}).RunWrite(helpers.GetDB())
+	if err != nil {
+		return err
+	}
+
+	// don't spam announcements when a new rule awards the badge to a lot of members at once
+	if badge.AnnounceRules && len(awardedUserIDs) <= levelsBadgeRuleAnnounceLimit {
+		for _, awardedUserID := range awardedUserIDs {
+			go m.announceBadgeRuleAward(badge, awardedUserID)
+		}
+	}
+	return nil
+}
+
+// checkBadgeRulesForUser evaluates the rules of the guild badges and global badges for a single user
+func (m *Levels) checkBadgeRulesForUser(guildID string, userID string) {
+	defer helpers.Recover()
+
+	levelsBadgeRuleGuildIDsLock.Lock()
+	hasRuleBadges := levelsBadgeRuleGuildIDs[guildID] || levelsBadgeRuleGuildIDs["global"]
+	levelsBadgeRuleGuildIDsLock.Unlock()
+	if !hasRuleBadges {
+		return
+	}
+
+	badges, err := m.getBadgesWithRules(guildID, "global")
+	helpers.Relax(err)
+
+	member, err := helpers.GetGuildMemberWithoutApi(guildID, userID)
+	if err != nil {
+		member = nil
+	}
+
+	checks := make(map[string]*levelsBadgeRuleCheck)
+	for _, badge := range badges {
+		// without the member rules like role or member can't be evaluated, don't revoke the badge because of that
+		if badge.GuildID != "global" && member == nil {
+			continue
+		}
+
+		check, ok := checks[badge.GuildID]
+		if !ok {
+			check = &levelsBadgeRuleCheck{guildID: badge.GuildID, userID: userID}
+			checks[badge.GuildID] = check
+		}
+		err = check.load(badge.Rules)
+		helpers.Relax(err)
+
+		hasBadge := false
+		for _, ruleUserID := range badge.RuleUserIDs {
+			if ruleUserID == userID {
+				hasBadge = true
+				break
+			}
+		}
+
+		if check.matches(badge.Rules, userID, member) {
+			if hasBadge {
+				continue
+			}
+			_, err = rethink.Table("profile_badge").Get(badge.ID).Update(map[string]interface{}{
+				"rule_userids": rethink.Row.Field("rule_userids").Default([]interface{}{}).SetInsert(userID),
+			}).RunWrite(helpers.GetDB())
+			helpers.Relax(err)
+			if badge.AnnounceRules {
+				go m.announceBadgeRuleAward(badge, userID)
+			}
+		} else if hasBadge {
+			_, err = rethink.Table("profile_badge").Get(badge.ID).Update(map[string]interface{}{
+				"rule_userids": rethink.Row.Field("rule_userids").Default([]interface{}{}).SetDifference([]string{userID}),
+			}).RunWrite(helpers.GetDB())
+			helpers.Relax(err)
+		}
+	}
+}
+
+// announceBadgeRuleAward announces a badge in the level up announcement channel of the guild, or sends a DM
+func (m *Levels) announceBadgeRuleAward(badge DB_Badge, userID string) {
+	defer helpers.Recover()
+
+	user, err := helpers.GetUser(userID)
+	if err != nil || user == nil {
+		return
+	}
+	if m.GetUserUserdata(user).LevelUpOptOut {
+		return
+	}
+
+	var channelID string
+	text := helpers.GetTextF("plugins.levels.badge-rule-awarded-global", user.ID, badge.Name, badge.Category)
+	if badge.GuildID != "global" {
+		guild, err := helpers.GetGuild(badge.GuildID)
+		if err != nil {
+			return
+		}
+		text = helpers.GetTextF("plugins.levels.badge-rule-awarded", user.ID, badge.Name, badge.Category, guild.Name)
+
+		settings := helpers.GuildSettingsGetCached(badge.GuildID)
+		if settings.LevelsAnnouncementsDestination == levelsAnnouncementsDestinationChannel {
+			channelID = settings.LevelsAnnouncementsChannelID
+		}
+	}
+	if channelID == "" {
+		dmChannel, err := cache.GetSession().UserChannelCreate(user.ID)
+		if err != nil {
+			return
+		}
+		channelID = dmChannel.ID
+	}
+
+	_, err = helpers.SendMessage(channelID, text)
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok && errD.Message != nil {
+			switch errD.Message.Code {
+			case discordgo.ErrCodeUnknownChannel, discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions,
+				discordgo.ErrCodeCannotSendMessagesToThisUser:
+				return
+			}
+		}
+		helpers.RelaxLog(err)
+	}
+}
+
+// parseBadgeRule parses the arguments of a badge rule, global badges only support rules that don't depend on a server
+func (m *Levels) parseBadgeRule(guildID string, args []string) (rule DB_Badge_Rule, err error) {
+	if len(args) <= 0 {
+		return rule, errors.New("no rule type")
+	}
+	rule.Type = strings.ToLower(args[0])
+
+	if guildID == "global" && rule.Type != levelsBadgeRuleDonator && rule.Type != levelsBadgeRuleRep {
+		return rule, errors.New("rule not supported for global badges")
+	}
+
+	switch rule.Type {
+	case levelsBadgeRuleDonator:
+		return rule, nil
+	case levelsBadgeRuleStarboard, levelsBadgeRuleRep:
+		if len(args) < 2 {
+			return rule, errors.New("no value")
+		}
+		rule.Value, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil || rule.Value <= 0 {
+			return rule, errors.New("invalid value")
+		}
+		return rule, nil
+	case levelsBadgeRuleMember:
+		if len(args) < 2 {
+			return rule, errors.New("no value")
+		}
+		duration, err := helpers.ParseDuration(args[1])
+		if err != nil || duration < 24*time.Hour {
+			return rule, errors.New("invalid value")
+		}
+		rule.Value = int64(duration / (24 * time.Hour))
+		return rule, nil
+	case levelsBadgeRuleSeason:
+		if len(args) < 3 {
+			return rule, errors.New("no value")
+		}
+		season, err := m.getSeason(args[1])
+		if err != nil || season.GuildID != guildID {
+			return rule, errors.New("season not found")
+		}
+		rule.TargetID = season.ID
+		rule.Value, err = strconv.ParseInt(args[2], 10, 64)
+		if err != nil || rule.Value <= 0 {
+			return rule, errors.New("invalid value")
+		}
+		return rule, nil
+	case levelsBadgeRuleRole:
+		if len(args) < 2 {
+			return rule, errors.New("no role")
+		}
+		guild, err := helpers.GetGuild(guildID)
+		if err != nil {
+			return rule, err
+		}
+		roleNameToMatch := strings.Join(args[1:], " ")
+		for _, role := range guild.Roles {
+			if strings.ToLower(role.Name) == strings.ToLower(roleNameToMatch) || role.ID == roleNameToMatch {
+				rule.TargetID = role.ID
+				return rule, nil
+			}
+		}
+		return rule, errors.New("role not found")
+	}
+	return rule, errors.New("unknown rule type")
+}
+
+func (m *Levels) getBadgeRuleText(guildID string, rule DB_Badge_Rule) string {
+	switch rule.Type {
+	case levelsBadgeRuleStarboard:
+		return helpers.GetTextF("plugins.levels.badge-rule-starboard", rule.Value)
+	case levelsBadgeRuleMember:
+		return helpers.GetTextF("plugins.levels.badge-rule-member", rule.Value)
+	case levelsBadgeRuleDonator:
+		return helpers.GetText("plugins.levels.badge-rule-donator")
+	case levelsBadgeRuleRep:
+		return helpers.GetTextF("plugins.levels.badge-rule-rep", rule.Value)
+	case levelsBadgeRuleSeason:
+		seasonName := rule.TargetID
+		if season, err := m.getSeason(rule.TargetID); err == nil {
+			seasonName = season.Name
+		}
+		return helpers.GetTextF("plugins.levels.badge-rule-season", rule.Value, seasonName)
+	case levelsBadgeRuleRole:
+		roleName := "N/A"
+		if role, err := cache.GetSession().State.Role(guildID, rule.TargetID); err == nil {
+			roleName = role.Name
+		}
+		return helpers.GetTextF("plugins.levels.badge-rule-role", roleName)
+	}
+	return rule.Type
+}
+
+func (l *Levels) setUserUserdata(entry DB_Profile_Userdata) {
+	_, err := rethink.Table("profile_userdata").Update(entry).Run(helpers.GetDB())
+	helpers.Relax(err)
+}
+
+// levelsProfileData contains everything shown on a profile, it is used for the HTML template and the rendered card
+type levelsProfileData struct {
+	UserID             string
+	Username           string
+	Nickname           string
+	UserAndNick        string
+	AvatarURL          string
+	Title              string
+	Bio                string
+	ServerLevel        int
+	ServerRank         string
+	ServerLevelPercent int
+	GlobalLevel        int
+	GlobalRank         string
+	BackgroundURL      string
+	Rep                int
+	Badges             []levelsProfileBadge
+	BackgroundColor    string
+	BackgroundOpacity  string
+	AccentColor        string
+	DetailOpacity      string
+	TextColor          string
+	Time               string
+	Birthday           string
+}
+
+type levelsProfileBadge struct {
+	URL         string
+	BorderColor string
+}
+
+func (m *Levels) getProfileData(member *discordgo.Member, guild *discordgo.Guild, web bool) (data levelsProfileData, err error) {
+	var levelsServersUser []DB_Levels_ServerUser
+	listCursor, err := rethink.Table("levels_serverusers").Filter(
+		rethink.Row.Field("userid").Eq(member.User.ID),
+	).Run(helpers.GetDB())
+	if err != nil {
+		return data, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&levelsServersUser)
+	if err != nil {
+		return data, err
+	}
+
+	var levelThisServerUser DB_Levels_ServerUser
+	var totalExp int64
+	for _, levelsServerUser := range levelsServersUser {
+		if levelsServerUser.GuildID == guild.ID {
+			levelThisServerUser = levelsServerUser
+		}
+		totalExp += levelsServerUser.Exp
+	}
+
+	serverRank := "N/A"
+	globalRank := "N/A"
+	for _, serverCache := range topCache {
+		if serverCache.GuildID == "global" {
+			for i, pair := range serverCache.Levels {
+				if pair.Key == member.User.ID {
+					globalRank = strconv.Itoa(i + 1)
+				}
+			}
+		} else if serverCache.GuildID == guild.ID {
+			for i, pair := range serverCache.Levels {
+				if pair.Key == member.User.ID {
+					serverRank = strconv.Itoa(i + 1)
+				}
+			}
+		}
+	}
+
+	userData := m.GetUserUserdata(member.User)
+
+	avatarUrl := helpers.GetAvatarUrl(member.User)
+	avatarUrlGif := ""
+	if avatarUrl != "" {
+		avatarUrl = strings.Replace(avatarUrl, "size=1024", "size=128", -1)
+		if strings.Contains(avatarUrl, "gif") {
+			avatarUrlGif = avatarUrl
+		}
+		avatarUrl = strings.Replace(avatarUrl, "gif", "png", -1)
+		avatarUrl = strings.Replace(avatarUrl, "jpg", "png", -1)
+	}
+	if web == true && avatarUrlGif != "" {
+		avatarUrl = avatarUrlGif
+	}
+	if avatarUrl == "" {
+		avatarUrl = "http://i.imgur.com/osAqNL6.png"
+	}
+	userAndNick := member.User.Username
+	if member.Nick != "" {
+		userAndNick = fmt.Sprintf("%s (%s)", member.User.Username, member.Nick)
+	}
+	title := userData.Title
+	if title == "" {
+		title = "Robyul's friend"
+	}
+	bio := userData.Bio
+	if bio == "" {
+		bio = "Robyul would like to know more about me!"
+	}
+
+	badgesToDisplay := make([]levelsProfileBadge, 0)
+	availableBadges := m.GetBadgesAvailableQuick(member.User, userData.ActiveBadgeIDs)
+	for _, activeBadgeID := range userData.ActiveBadgeIDs {
+		for _, availableBadge := range availableBadges {
+			if activeBadgeID == availableBadge.ID {
+				badgesToDisplay = append(badgesToDisplay, levelsProfileBadge{
+					URL:         availableBadge.URL,
+					BorderColor: availableBadge.BorderColor,
+				})
+			}
+		}
+	}
+
+	userTimeText := ""
+	if userData.Timezone != "" && web == false { // privacy
+		userLocation, err := time.LoadLocation(userData.Timezone)
+		if err == nil {
+			userTimeText = time.Now().In(userLocation).Format(TimeAtUserFormat)
+		}
+	}
+
+	userBirthdayText := ""
+	if userData.Birthday != "" && web == false { // privacy
+		isBirthday := false
+		userLocation, err := time.LoadLocation("Etc/UTC")
+		if err == nil {
+			if userData.Timezone != "" {
+				userLocationUser, err := time.LoadLocation(userData.Timezone)
+				if err == nil {
+					userLocation = userLocationUser
+				}
+			}
+			birthdayTime, err := time.ParseInLocation(TimeBirthdayFormat, userData.Birthday, userLocation)
+			birthdayTime = birthdayTime.AddDate(time.Now().Year(), 0, 0)
+			if err == nil {
+				if time.Now().In(userLocation).Sub(birthdayTime).Hours() <= 23 && time.Now().In(userLocation).Sub(birthdayTime).Hours() > 0 {
+					isBirthday = true
+				}
+			}
+		}
+
+		userBirthdayText = userData.Birthday
+		if isBirthday {
+			userBirthdayText = "Today!"
+		}
+	}
+
+	return levelsProfileData{
+		UserID:             member.User.ID,
+		Username:           member.User.Username,
+		Nickname:           member.Nick,
+		UserAndNick:        userAndNick,
+		AvatarURL:          avatarUrl,
+		Title:              title,
+		Bio:                bio,
+		ServerLevel:        m.getLevelFromExp(guild.ID, levelThisServerUser.Exp),
+		ServerRank:         serverRank,
+		ServerLevelPercent: m.getProgressToNextLevelFromExp(guild.ID, levelThisServerUser.Exp),
+		GlobalLevel:        m.getLevelFromExp("global", totalExp),
+		GlobalRank:         globalRank,
+		BackgroundURL:      m.GetProfileBackgroundUrl(userData.Background),
+		Rep:                userData.Rep,
+		Badges:             badgesToDisplay,
+		BackgroundColor:    m.GetBackgroundColor(userData),
+		BackgroundOpacity:  m.GetBackgroundOpacity(userData),
+		AccentColor:        m.GetAccentColor(userData),
+		DetailOpacity:      m.GetDetailOpacity(userData),
+		TextColor:          m.GetTextColor(userData),
+		Time:               userTimeText,
+		Birthday:           userBirthdayText,
+	}, nil
+}
+
+func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild, web bool) (string, error) {
+	data, err := m.getProfileData(member, guild, web)
+	if err != nil {
+		return "", err
+	}
+
+	badgesHTML := ""
+	for _, badge := range data.Badges {
+		badgesHTML += fmt.Sprintf("<img src=\"%s\" style=\"border: 2px solid #%s;\">", badge.URL, badge.BorderColor)
+	}
+
+	backgroundColor, err := colorful.Hex("#" + data.BackgroundColor)
+	if err != nil {
+		backgroundColor, err = colorful.Hex("#000000")
+		if err != nil {
+			return "", err
+		}
+	}
+	backgroundColorString := fmt.Sprintf("rgba(%d, %d, %d, %s)",
+		int(backgroundColor.R*255), int(backgroundColor.G*255), int(backgroundColor.B*255),
+		data.BackgroundOpacity)
+	detailColorString := fmt.Sprintf("rgba(0, 0, 0, %s)",
+		data.DetailOpacity)
+
+	userTimeText := ""
+	if data.Time != "" {
+		userTimeText = "<i class=\"fa fa-clock-o\" aria-hidden=\"true\"></i> " + data.Time
+	}
+	userBirthdayText := ""
+	if data.Birthday != "" {
+		userBirthdayText = "<i class=\"fa fa-birthday-cake\" aria-hidden=\"true\"></i> " + data.Birthday
+	}
+
+	tempTemplateHtml := strings.Replace(htmlTemplateString, "{USER_USERNAME}", html.EscapeString(data.Username), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_NICKNAME}", html.EscapeString(data.Nickname), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_AND_NICKNAME}", html.EscapeString(data.UserAndNick), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_AVATAR_URL}", html.EscapeString(data.AvatarURL), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TITLE}", html.EscapeString(data.Title), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BIO}", html.EscapeString(data.Bio), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL}", strconv.Itoa(data.ServerLevel), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_RANK}", data.ServerRank, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL_PERCENT}", strconv.Itoa(data.ServerLevelPercent), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_LEVEL}", strconv.Itoa(data.GlobalLevel), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_RANK}", data.GlobalRank, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BACKGROUND_URL}", data.BackgroundURL, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_REP}", strconv.Itoa(data.Rep), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BADGES_HTML}", badgesHTML, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BACKGROUND_COLOR}", html.EscapeString(backgroundColorString), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_ACCENT_COLOR}", "#"+data.AccentColor, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_DETAIL_COLOR}", html.EscapeString(detailColorString), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TEXT_COLOR}", "#"+data.TextColor, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TIME}", userTimeText, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BIRTHDAY}", userBirthdayText, -1)
+
+	return tempTemplateHtml, nil
+}
+
+func (m *Levels) GetProfile(member *discordgo.Member, guild *discordgo.Guild, gifP bool) ([]byte, string, error) {
+	data, err := m.getProfileData(member, guild, false)
+	if err != nil {
+		return []byte{}, "", err
+	}
+
+	imageBytes, err := m.getProfileCard(data)
+	if err != nil {
+		return []byte{}, "", err
+	}
+
+	avatarUrlGif := helpers.GetAvatarUrl(member.User)
+	if avatarUrlGif != "" {
+		avatarUrlGif = strings.Replace(avatarUrlGif, "size=1024", "size=128", -1)
+		if !strings.Contains(avatarUrlGif, "gif") {
+			avatarUrlGif = ""
+		}
+	}
+
+	if avatarUrlGif != "" && gifP == true {
+		outGif := &gif.GIF{}
+
+		decodedFirstFrame, err := png.Decode(bytes.NewReader(imageBytes))
+		if err != nil {
+			raven.SetUserContext(&raven.User{
+				Username: member.User.Username + "#" + member.User.Discriminator,
+			})
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			goto ReturnImageBytes
+		}
+		buf := bytes.Buffer{}
+		err = gif.Encode(&buf, decodedFirstFrame, nil)
+		if err != nil {
+			raven.SetUserContext(&raven.User{
+				Username: member.User.Username + "#" + member.User.Discriminator,
+			})
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			goto ReturnImageBytes
+		}
+		firstFrame, err := gif.Decode(&buf)
+		if err != nil {
+			raven.SetUserContext(&raven.User{
+				Username: member.User.Username + "#" + member.User.Discriminator,
+			})
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			goto ReturnImageBytes
+		}
+
+		avatarGifBytes, err := helpers.NetGetUAWithError(avatarUrlGif, helpers.DEFAULT_UA)
+		if err != nil {
+			raven.SetUserContext(&raven.User{
+				Username: member.User.Username + "#" + member.User.Discriminator,
+			})
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			goto ReturnImageBytes
+		}
+
+		avatarGif, err := gif.DecodeAll(bytes.NewReader(avatarGifBytes))
+		if err != nil {
+			raven.SetUserContext(&raven.User{
+				Username: member.User.Username + "#" + member.User.Discriminator,
+			})
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			goto ReturnImageBytes
+		}
+
+		fullRect := image.Rect(0, 0, 400, 300)
+		pm := image.NewPaletted(fullRect, nil)
+		q := gogif.MedianCutQuantizer{NumColor: 256}
+		q.Quantize(pm, fullRect, decodedFirstFrame, image.ZP)
+		draw.FloydSteinberg.Draw(pm, fullRect, firstFrame, image.ZP)
 
-		if err == rethink.ErrEmptyResult {
-			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.level-no-stats"))
-			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			return
-		} else if err != nil {
-			helpers.Relax(err)
-		}
+		outGif.Image = append(outGif.Image, pm)
+		outGif.Delay = append(outGif.Delay, avatarGif.Delay[0])
 
-		var levelThisServerUser DB_Levels_ServerUser
-		var totalExp int64
-		for _, levelsServerUser := range levelsServersUser {
-			if levelsServerUser.GuildID == channel.GuildID {
-				levelThisServerUser = levelsServerUser
+		resizeRect := image.Rect(0, 0, 128, 128)
+		cutImage := image.NewRGBA(resizeRect)
+		resizedRect := image.Rect(4, 64, 4+80, 64+80)
+
+		for i, avatarGifFrame := range avatarGif.Image {
+			resizedImage := resize.Resize(80, 80, avatarGifFrame, resize.NearestNeighbor)
+			draw.DrawMask(
+				cutImage, resizedImage.Bounds(), resizedImage, image.ZP,
+				&circle{image.Pt(40, 40), 40}, image.ZP, draw.Over)
+			avatarGifFrame.Palette = append(avatarGifFrame.Palette, image.Transparent)
+			paletteHasTransparency := false
+			newPalette := make([]color.Color, 0)
+			for i, color := range avatarGifFrame.Palette {
+				if color == image.Transparent {
+					paletteHasTransparency = true
+				}
+				newPalette = append(newPalette, color)
+				if i == 254 && paletteHasTransparency == false {
+					newPalette = append(newPalette, image.Transparent)
+					break
+				}
 			}
-			totalExp += levelsServerUser.Exp
+			pm = image.NewPaletted(resizedRect, newPalette)
+			draw.FloydSteinberg.Draw(pm, resizedRect, cutImage, image.ZP)
+			outGif.Image = append(outGif.Image, pm)
+			outGif.Delay = append(outGif.Delay, avatarGif.Delay[i])
 		}
 
-		if totalExp <= 0 {
-			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.level-no-stats"))
-			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			return
-		}
+		outGif.LoopCount = -1
 
-		currentMember, _ := helpers.GetGuildMember(channel.GuildID, levelThisServerUser.UserID)
-		if currentMember == nil || currentMember.User == nil || currentMember.User.ID == "" {
-			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			return
+		buf = bytes.Buffer{}
+		err = gif.EncodeAll(&buf, outGif)
+		if err != nil {
+			raven.SetUserContext(&raven.User{
+				Username: member.User.Username + "#" + member.User.Discriminator,
+			})
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			goto ReturnImageBytes
 		}
+		return buf.Bytes(), "gif", nil
+	}
+ReturnImageBytes:
+	return imageBytes, "png", nil
+}
 
-		fullUsername := currentMember.User.Username
-		if currentMember.Nick != "" {
-			fullUsername += " ~ " + currentMember.Nick
+type circle struct {
+	p image.Point
+	r int
+}
+
+func (c *circle) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (c *circle) Bounds() image.Rectangle {
+	return image.Rect(c.p.X-c.r, c.p.Y-c.r, c.p.X+c.r, c.p.Y+c.r)
+}
+
+func (c *circle) At(x, y int) color.Color {
+	xx, yy, rr := float64(x-c.p.X)+0.5, float64(y-c.p.Y)+0.5, float64(c.r)
+	if xx*xx+yy*yy < rr*rr {
+		return color.Alpha{255}
+	}
+	return color.Alpha{0}
+}
+
+// getProfileCard returns the profile card as PNG, cards are cached by a hash of the profile data
+func (m *Levels) getProfileCard(data levelsProfileData) ([]byte, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("robyul2-discord:levels:profile-card:%x", sha256.Sum256(dataBytes))
+
+	cacheCodec := cache.GetRedisCacheCodec()
+	var imageBytes []byte
+	if err = cacheCodec.Get(key, &imageBytes); err == nil && len(imageBytes) > 0 {
+		return imageBytes, nil
+	}
+
+	start := time.Now()
+
+	card := m.renderProfileCard(data)
+	buf := bytes.Buffer{}
+	err = png.Encode(&buf, card)
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := time.Since(start)
+	cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("rendered profile card in %s", elapsed.String()))
+
+	metrics.LevelImagesGenerated.Add(1)
+
+	err = cacheCodec.Set(&redisCache.Item{
+		Key:        key,
+		Object:     buf.Bytes(),
+		Expiration: levelsProfileCardCacheExpiration,
+	})
+	helpers.RelaxLog(err)
+
+	return buf.Bytes(), nil
+}
+
+// getProfileImage downloads and decodes an image shown on profile cards, downloads are cached
+func (m *Levels) getProfileImage(imageUrl string) (image.Image, error) {
+	cacheCodec := cache.GetRedisCacheCodec()
+	key := fmt.Sprintf("robyul2-discord:levels:profile-image:%x", sha256.Sum256([]byte(imageUrl)))
+
+	var imageBytes []byte
+	err := cacheCodec.Get(key, &imageBytes)
+	if err != nil || len(imageBytes) <= 0 {
+		imageBytes, err = helpers.NetGetUAWithError(imageUrl, helpers.DEFAULT_UA)
+		if err != nil {
+			return nil, err
 		}
 
-		zeroWidthWhitespace, err := strconv.Unquote(`'\u200b'`)
-		helpers.Relax(err)
+		err = cacheCodec.Set(&redisCache.Item{
+			Key:        key,
+			Object:     imageBytes,
+			Expiration: levelsProfileImageCacheExpiration,
+		})
+		helpers.RelaxLog(err)
+	}
 
-		userLevelEmbed := &discordgo.MessageEmbed{
-			Color:       0x0FADED,
-			Title:       helpers.GetTextF("plugins.levels.user-embed-title", fullUsername),
-			Description: "View the leaderboard for this server [here](" + helpers.GetConfig().Path("website.ranking_base_url").Data().(string) + "/" + channel.GuildID + ").",
-			Footer: &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.levels.embed-footer",
-				len(session.State.Guilds),
-			)},
-			Fields: []*discordgo.MessageEmbedField{
-				{
-					Name:   "Level",
-					Value:  strconv.Itoa(m.getLevelFromExp(levelThisServerUser.Exp)),
-					Inline: true,
-				},
-				{
-					Name:   "Level Progress",
-					Value:  strconv.Itoa(m.getProgressToNextLevelFromExp(levelThisServerUser.Exp)) + " %",
-					Inline: true,
-				},
-				{
-					Name:   zeroWidthWhitespace,
-					Value:  zeroWidthWhitespace,
-					Inline: true,
-				},
-				{
-					Name:   "Global Level",
-					Value:  strconv.Itoa(m.getLevelFromExp(totalExp)),
-					Inline: true,
-				},
-				{
-					Name:   "Global Level Progress",
-					Value:  strconv.Itoa(m.getProgressToNextLevelFromExp(totalExp)) + " %",
-					Inline: true,
-				},
-				{
-					Name:   zeroWidthWhitespace,
-					Value:  zeroWidthWhitespace,
-					Inline: true,
-				},
-			},
+	decodedImage, _, err := image.Decode(bytes.NewReader(imageBytes))
+	return decodedImage, err
+}
+
+// renderProfileCard draws the profile card, the layout matches the HTML template
+func (m *Levels) renderProfileCard(data levelsProfileData) *image.RGBA {
+	card := image.NewRGBA(image.Rect(0, 0, levelsProfileCardWidth, levelsProfileCardHeight))
+	draw.Draw(card, card.Bounds(), image.White, image.ZP, draw.Src)
+
+	textColor := levelsProfileColor(data.TextColor, 1)
+	backgroundColor := levelsProfileColor(data.BackgroundColor, levelsProfileOpacity(data.BackgroundOpacity))
+	detailColor := levelsProfileColor("000000", levelsProfileOpacity(data.DetailOpacity))
+	accentColor := levelsProfileColor(data.AccentColor, 0.5)
+
+	var background image.Image
+	backgroundImage, err := m.getProfileImage(data.BackgroundURL)
+	if err == nil {
+		background = resize.Resize(levelsProfileCardWidth, levelsProfileCardHeight, backgroundImage, resize.Bilinear)
+		draw.Draw(card, card.Bounds(), background, background.Bounds().Min, draw.Over)
+	} else {
+		cache.GetLogger().WithField("module", "levels").Warnf("unable to get profile background %s: %s", data.BackgroundURL, err.Error())
+	}
+
+	container := image.Rect(5, 100, 395, 295)
+	levelsProfileFill(card, container, backgroundColor)
+	levelsProfileFill(card, image.Rect(5, 100, 395, 130), detailColor) // header
+	levelsProfileFill(card, image.Rect(5, 143, 85, 168), detailColor)  // rep
+
+	// the avatar overlaps the header, so the background is drawn again behind it
+	avatarCenter := image.Pt(44, 104)
+	if background != nil {
+		draw.DrawMask(card, image.Rect(0, 60, 88, 148), background, image.Pt(0, 60),
+			&circle{avatarCenter, 44}, image.Pt(0, 60), draw.Over)
+	}
+	draw.DrawMask(card, image.Rect(1, 61, 87, 147), image.NewUniform(detailColor), image.ZP,
+		&circle{avatarCenter, 43}, image.Pt(1, 61), draw.Over)
+	avatarImage, err := m.getProfileImage(data.AvatarURL)
+	if err == nil {
+		avatarImage = resize.Resize(80, 80, avatarImage, resize.Bilinear)
+		draw.DrawMask(card, image.Rect(4, 64, 84, 144), avatarImage, avatarImage.Bounds().Min,
+			&circle{avatarCenter, 40}, image.Pt(4, 64), draw.Over)
+	} else {
+		cache.GetLogger().WithField("module", "levels").Warnf("unable to get profile avatar %s: %s", data.AvatarURL, err.Error())
+	}
+
+	bold20 := newLevelsProfileText(20, true)
+	bold15 := newLevelsProfileText(15, true)
+	bold14 := newLevelsProfileText(14, true)
+	regular14 := newLevelsProfileText(14, false)
+	regular12 := newLevelsProfileText(12, false)
+	regular9 := newLevelsProfileText(9, false)
+
+	bold20.draw(card, image.Rect(95, 100, 395, 130), 95, 103, data.UserAndNick, textColor)
+	bold15.draw(card, image.Rect(90, 130, 395, 160), 90, 132, data.Title, textColor)
+
+	// level, rank and EXP bar
+	levelInfo := image.Rect(90, 156, 395, 180)
+	regular9.drawCentered(card, levelInfo, 93, 22, 158, "Level", textColor)
+	regular12.drawCentered(card, levelInfo, 93, 22, 167, strconv.Itoa(data.ServerLevel), textColor)
+	regular9.drawCentered(card, levelInfo, 120, 22, 158, "Rank", textColor)
+	regular12.drawCentered(card, levelInfo, 120, 22, 167, data.ServerRank, textColor)
+
+	expBar := image.Rect(146, 160, 283, 180)
+	levelsProfileFill(card, expBar, detailColor)
+	progress := data.ServerLevelPercent
+	if progress < 0 {
+		progress = 0
+	}
+	if progress > 100 {
+		progress = 100
+	}
+	levelsProfileFill(card, image.Rect(expBar.Min.X, expBar.Min.Y, expBar.Min.X+expBar.Dx()*progress/100, expBar.Max.Y), accentColor)
+	regular12.drawCentered(card, expBar, expBar.Min.X, expBar.Dx(), 163, "Server EXP Progress", textColor)
+
+	for i, globalBox := range []struct{ Title, Value string }{
+		{"Level", strconv.Itoa(data.GlobalLevel)},
+		{"Rank", data.GlobalRank},
+	} {
+		x := 290 + i*50
+		regular9.draw(card, levelInfo, x, 158, "Global", textColor)
+		regular9.draw(card, levelInfo, x, 168, globalBox.Title, textColor)
+		regular12.draw(card, levelInfo, x+30, 163, globalBox.Value, textColor)
+	}
+
+	bold15.drawCentered(card, container, 5, 80, 147, "+"+strconv.Itoa(data.Rep)+" REP", textColor)
+
+	// badges
+	bold14.drawCentered(card, container, 8, 80, 172, "BADGES", textColor)
+	for i, badge := range data.Badges {
+		x := 8 + (i%levelsProfileBadgesPerRow)*34 + 2
+		y := 188 + (i/levelsProfileBadgesPerRow)*34
+		if y+32 > container.Max.Y {
+			break
+		}
+		badgeCenter := image.Pt(x+16, y+16)
+		draw.DrawMask(card, image.Rect(x, y, x+32, y+32), image.NewUniform(levelsProfileColor(badge.BorderColor, 1)), image.ZP,
+			&circle{badgeCenter, 16}, image.Pt(x, y), draw.Over)
+		draw.DrawMask(card, image.Rect(x+2, y+2, x+30, y+30), image.NewUniform(color.Gray{128}), image.ZP,
+			&circle{badgeCenter, 14}, image.Pt(x+2, y+2), draw.Over)
+		badgeImage, err := m.getProfileImage(badge.URL)
+		if err != nil {
+			cache.GetLogger().WithField("module", "levels").Warnf("unable to get profile badge %s: %s", badge.URL, err.Error())
+			continue
 		}
+		badgeImage = resize.Resize(28, 28, badgeImage, resize.Bilinear)
+		draw.DrawMask(card, image.Rect(x+2, y+2, x+30, y+30), badgeImage, badgeImage.Bounds().Min,
+			&circle{badgeCenter, 14}, image.Pt(x+2, y+2), draw.Over)
+	}
 
-		_, err = helpers.SendEmbed(msg.ChannelID, userLevelEmbed)
-		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-		return
-	case "leaderboard", "leaderboards", "ranking", "rankings":
-		channel, err := helpers.GetChannel(msg.ChannelID)
-		helpers.Relax(err)
+	// bio
+	bio := image.Rect(149, 180, 387, 265)
+	for i, line := range regular12.wrap(data.Bio, bio.Dx()) {
+		top := bio.Min.Y + i*regular12.lineHeight()
+		if top >= bio.Max.Y {
+			break
+		}
+		regular12.draw(card, bio, bio.Min.X, top, line, textColor)
+	}
 
-		link := helpers.GetConfig().Path("website.ranking_base_url").Data().(string) + "/" + channel.GuildID
+	stats := make([]string, 0)
+	if data.Time != "" {
+		stats = append(stats, data.Time)
+	}
+	if data.Birthday != "" {
+		stats = append(stats, "Birthday: "+data.Birthday)
+	}
+	regular14.draw(card, image.Rect(149, 275, 387, 295), 149, 277, strings.Join(stats, "  "), textColor)
 
-		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.ranking-text", link))
-		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-		return
+	return card
+}
+
+func levelsProfileFill(dst draw.Image, rect image.Rectangle, fillColor color.Color) {
+	draw.Draw(dst, rect, image.NewUniform(fillColor), image.ZP, draw.Over)
+}
+
+// levelsProfileColor converts a hex color without # to a color, invalid colors are black
+func levelsProfileColor(hex string, opacity float64) color.NRGBA {
+	parsedColor, err := colorful.Hex("#" + hex)
+	if err != nil {
+		parsedColor = colorful.Color{}
+	}
+	return color.NRGBA{
+		R: uint8(parsedColor.R * 255),
+		G: uint8(parsedColor.G * 255),
+		B: uint8(parsedColor.B * 255),
+		A: uint8(opacity * 255),
 	}
 }
 
-func (l *Levels) DeleteMessages(channelID string, messages []string) {
-	for _, message := range messages {
-		cache.GetSession().ChannelMessageDelete(channelID, message)
+func levelsProfileOpacity(text string) float64 {
+	opacity, err := strconv.ParseFloat(text, 64)
+	if err != nil || opacity < 0 || opacity > 1 {
+		return 0.5
 	}
+	return opacity
 }
 
-func (l *Levels) BadgePickerPrintCategories(user *discordgo.User, channeID string, availableBadges []DB_Badge, activeBadgeIDs []string, allBadges []DB_Badge) []string {
-	categoriesCount := make(map[string]int, 0)
-	for _, badge := range availableBadges {
-		if _, ok := categoriesCount[badge.Category]; ok {
-			categoriesCount[badge.Category] += 1
-		} else {
-			categoriesCount[badge.Category] = 1
-		}
+func (m *Levels) loadProfileFont(fileName string) (*truetype.Font, error) {
+	fontBytes, err := ioutil.ReadFile(assetsPath + fileName)
+	if err != nil {
+		return nil, err
+	}
+	return truetype.Parse(fontBytes)
+}
+
+// levelsProfileText draws text in one size, runes missing in Roboto are drawn with the fallback font (CJK)
+type levelsProfileText struct {
+	fonts []*truetype.Font
+	faces []font.Face
+}
+
+func newLevelsProfileText(size float64, bold bool) *levelsProfileText {
+	text := &levelsProfileText{
+		fonts: []*truetype.Font{levelsProfileFontRegular, levelsProfileFontFallback},
 	}
+	if bold {
+		text.fonts[0] = levelsProfileFontBold
+	}
+	for _, textFont := range text.fonts {
+		text.faces = append(text.faces, truetype.NewFace(textFont, &truetype.Options{
+			Size:    size,
+			Hinting: font.HintingFull,
+		}))
+	}
+	return text
+}
 
-	sortedKeys := make([]string, len(categoriesCount))
-	i := 0
-	for k := range categoriesCount {
-		sortedKeys[i] = k
-		i++
+func (t *levelsProfileText) face(r rune) font.Face {
+	for i, textFont := range t.fonts {
+		if textFont.Index(r) != 0 {
+			return t.faces[i]
+		}
 	}
-	sort.Strings(sortedKeys)
+	return t.faces[0]
+}
 
-	resultText := l.BadgePickerActiveText(user.Username, activeBadgeIDs, allBadges)
-	resultText += "Choose a category name:\n"
-	for _, key := range sortedKeys {
-		resultText += fmt.Sprintf("__%s__ (%d badges)\n", key, categoriesCount[key])
+func (t *levelsProfileText) lineHeight() int {
+	return t.faces[0].Metrics().Height.Ceil()
+}
+
+func (t *levelsProfileText) width(text string) int {
+	var width fixed.Int26_6
+	var previousFace font.Face
+	previous := rune(-1)
+	for _, r := range text {
+		face := t.face(r)
+		if previous >= 0 && face == previousFace {
+			width += face.Kern(previous, r)
+		}
+		advance, _ := face.GlyphAdvance(r)
+		width += advance
+		previous, previousFace = r, face
 	}
-	resultText += l.BadgePickerHelpText()
+	return width.Ceil()
+}
 
-	messageIDs := make([]string, 0)
-	for _, page := range helpers.Pagify(resultText, "\n") {
-		messages, err := helpers.SendMessage(channeID, page)
-		helpers.RelaxMessage(err, channeID, "")
-		for _, message := range messages {
-			messageIDs = append(messageIDs, message.ID)
+// draw draws a single line of text starting at x with the top of the line at top, everything outside of clip is cut off
+func (t *levelsProfileText) draw(dst *image.RGBA, clip image.Rectangle, x, top int, text string, textColor color.Color) {
+	clipped, ok := dst.SubImage(clip).(*image.RGBA)
+	if !ok {
+		return
+	}
+	source := image.NewUniform(textColor)
+	dot := fixed.P(x, top+t.faces[0].Metrics().Ascent.Ceil())
+	var previousFace font.Face
+	previous := rune(-1)
+	for _, r := range text {
+		face := t.face(r)
+		if previous >= 0 && face == previousFace {
+			dot.X += face.Kern(previous, r)
+		}
+		glyphRect, mask, maskPoint, advance, ok := face.Glyph(dot, r)
+		if ok {
+			draw.DrawMask(clipped, glyphRect, source, image.ZP, mask, maskPoint, draw.Over)
 		}
+		dot.X += advance
+		previous, previousFace = r, face
 	}
-	return messageIDs
 }
 
-func (l *Levels) BadgePickerPrintBadges(user *discordgo.User, channeID string, availableBadges []DB_Badge, activeBadgeIDs []string, categoryName string, allBadges []DB_Badge) []string {
-	categoryName = strings.ToLower(categoryName)
+func (t *levelsProfileText) drawCentered(dst *image.RGBA, clip image.Rectangle, x, width, top int, text string, textColor color.Color) {
+	t.draw(dst, clip, x+(width-t.width(text))/2, top, text, textColor)
+}
 
-	resultText := l.BadgePickerActiveText(user.Username, activeBadgeIDs, allBadges)
-	resultText += "Choose a badge name:\n"
-	for _, badge := range availableBadges {
-		if badge.Category == categoryName {
-			isActive := false
-			for _, activeBadgeID := range activeBadgeIDs {
-				if activeBadgeID == badge.ID {
-					isActive = true
-				}
+// wrap splits text into lines fitting into width, lines are broken between words or between any two CJK characters
+func (t *levelsProfileText) wrap(text string, width int) (lines []string) {
+	text = strings.Replace(text, "\r", "", -1)
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, token := range levelsProfileTokenize(paragraph) {
+			if t.width(strings.TrimRight(line+token, " ")) <= width {
+				line += token
+				continue
 			}
-			activeText := ""
-			if isActive {
-				activeText = " _**in use**_"
+			if line != "" {
+				lines = append(lines, strings.TrimRight(line, " "))
+				line = ""
+			}
+			// tokens wider than a line are broken anywhere
+			for _, r := range token {
+				if line != "" && t.width(line+string(r)) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
 			}
-			resultText += fmt.Sprintf("__%s__%s\n", badge.Name, activeText)
-		}
-	}
-	resultText += l.BadgePickerHelpText()
-
-	messageIDs := make([]string, 0)
-	for _, page := range helpers.Pagify(resultText, "\n") {
-		messages, err := helpers.SendMessage(channeID, page)
-		helpers.RelaxMessage(err, channeID, "")
-		for _, message := range messages {
-			messageIDs = append(messageIDs, message.ID)
 		}
+		lines = append(lines, strings.TrimRight(line, " "))
 	}
-	return messageIDs
+	return lines
 }
 
-func (l *Levels) BadgePickerActiveText(username string, activeBadgeIDs []string, availableBadges []DB_Badge) string {
-	spaceLeft := BadgeLimt - len(activeBadgeIDs)
-	text := fmt.Sprintf("**@%s** You can pick %d more badge(s) to display on your profile.\nYou are currently displaying:", username, spaceLeft)
-	if len(activeBadgeIDs) > 0 {
-		for _, badgeID := range activeBadgeIDs {
-			for _, badge := range availableBadges {
-				if badge.ID == badgeID {
-					text += fmt.Sprintf(" `%s (%s)`", badge.Name, badge.Category)
-				}
+// levelsProfileTokenize splits text into words including their trailing whitespace, CJK characters are single tokens
+func levelsProfileTokenize(text string) (tokens []string) {
+	token := ""
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana):
+			if token != "" {
+				tokens = append(tokens, token)
 			}
+			tokens = append(tokens, string(r))
+			token = ""
+		case unicode.IsSpace(r):
+			tokens = append(tokens, token+string(r))
+			token = ""
+		default:
+			token += string(r)
 		}
-	} else {
-		text += " No badges"
 	}
-	text += ".\n\n"
-	return text
+	if token != "" {
+		tokens = append(tokens, token)
+	}
+	return tokens
 }
 
-func (l *Levels) BadgePickerHelpText() string {
-	return "\nSay `categories` to display all categories, `category name` to choose a category, `badge name` to choose a badge, `reset` to remove all badges displayed on your profile, `exit` to exit and save. To remove a badge from your Profile pick the badge again.\n"
+func (m *Levels) GetBackgroundColor(userUserdata DB_Profile_Userdata) string {
+	if userUserdata.BackgroundColor != "" {
+		return userUserdata.BackgroundColor
+	} else {
+		return "000000"
+	}
 }
 
-func (l *Levels) InsertNewProfileBackground(backgroundName string, backgroundUrl string, tags []string) error {
-	newEntry := new(DB_Profile_Background)
-	newEntry.Name = strings.ToLower(backgroundName)
-	newEntry.URL = backgroundUrl
-	newEntry.CreatedAt = time.Now()
-	newEntry.Tags = tags
-
-	insert := rethink.Table("profile_backgrounds").Insert(newEntry)
-	_, err := insert.RunWrite(helpers.GetDB())
-	return err
+func (m *Levels) GetAccentColor(userUserdata DB_Profile_Userdata) string {
+	if userUserdata.AccentColor != "" {
+		return userUserdata.AccentColor
+	} else {
+		return "46d42e"
+	}
 }
 
-func (l *Levels) DeleteProfileBackground(backgroundName string) error {
-	if backgroundName != "" {
-		_, err := rethink.Table("profile_backgrounds").Filter(
-			rethink.Row.Field("id").Eq(backgroundName),
-		).Delete().RunWrite(helpers.GetDB())
-		return err
+func (m *Levels) GetTextColor(userUserdata DB_Profile_Userdata) string {
+	if userUserdata.TextColor != "" {
+		return userUserdata.TextColor
+	} else {
+		return "ffffff"
 	}
-	return nil
 }
 
-func (l *Levels) ProfileBackgroundSearch(searchText string) []DB_Profile_Background {
-	var entryBucket []DB_Profile_Background
-	listCursor, err := rethink.Table("profile_backgrounds").Filter(func(profile rethink.Term) rethink.Term {
-		return profile.Field("id").Match(fmt.Sprintf("(?i)%s", searchText))
-	}).Limit(5).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+func (m *Levels) GetBackgroundOpacity(userUserdata DB_Profile_Userdata) string {
+	if userUserdata.BackgroundOpacity != "" {
+		return userUserdata.BackgroundOpacity
+	} else {
+		return "0.5"
 	}
-	defer listCursor.Close()
-	err = listCursor.All(&entryBucket)
+}
 
-	if err == rethink.ErrEmptyResult {
-		return entryBucket
-	} else if err != nil {
-		helpers.Relax(err)
+func (m *Levels) GetDetailOpacity(userUserdata DB_Profile_Userdata) string {
+	if userUserdata.DetailOpacity != "" {
+		return userUserdata.DetailOpacity
+	} else {
+		return "0.5"
 	}
+}
 
-	return entryBucket
+func (m *Levels) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+	go m.ProcessMessage(msg, session)
 }
 
-func (l *Levels) ProfileBackgroundNameExists(backgroundName string) bool {
-	var entryBucket DB_Profile_Background
-	listCursor, err := rethink.Table("profile_backgrounds").Filter(
-		rethink.Row.Field("id").Eq(strings.ToLower(backgroundName)),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+func (m *Levels) ProcessMessage(msg *discordgo.Message, session *discordgo.Session) {
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+	// ignore temporary ignored guilds
+	for _, temporaryIgnoredGuild := range temporaryIgnoredGuilds {
+		if temporaryIgnoredGuild == channel.GuildID {
+			return
+		}
+	}
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	// bot messages are part of the conversation for the anti farming heuristics
+	if settings.LevelsAntiFarming != "" {
+		m.recordAntiFarmingChannelMessage(msg)
+	}
+	// ignore bot messages
+	if msg.Author.Bot == true {
+		return
+	}
+	// ignore commands
+	prefix := helpers.GetPrefixForServer(channel.GuildID)
+	if prefix != "" {
+		if strings.HasPrefix(msg.Content, prefix) {
+			return
+		}
 	}
-	defer listCursor.Close()
-	err = listCursor.One(&entryBucket)
 
-	if err == rethink.ErrEmptyResult {
-		var entryBucket DB_Profile_Background
-		listCursor, err := rethink.Table("profile_backgrounds").Filter(func(profile rethink.Term) rethink.Term {
-			return profile.Field("id").Match(fmt.Sprintf("(?i)^%s$", backgroundName))
-		}).Run(helpers.GetDB())
-		if err != nil {
-			panic(err)
+	for _, ignoredChannelID := range settings.LevelsIgnoredChannelIDs {
+		if ignoredChannelID == msg.ChannelID {
+			return
 		}
-		defer listCursor.Close()
-		err = listCursor.One(&entryBucket)
+	}
+	for _, ignoredUserID := range settings.LevelsIgnoredUserIDs {
+		for ignoredUserID == msg.Author.ID {
+			return
+		}
+	}
 
-		if err == rethink.ErrEmptyResult {
-			return false
-		} else if err != nil {
-			helpers.Relax(err)
+	quality := float64(1)
+	if strictness, ok := levelsAntiFarmingStrictnesses[settings.LevelsAntiFarming]; ok {
+		var signals []string
+		quality, signals = m.scoreMessageQuality(channel.GuildID, msg, strictness)
+		m.recordAntiFarmingSignals(channel.GuildID, msg.Author.ID, signals)
+		if quality <= 0 {
+			return
 		}
+	}
 
-		return true
-	} else if err != nil {
-		helpers.Relax(err)
+	// check if bucket is empty
+	if !m.BucketHasKeys(channel.GuildID + msg.Author.ID) {
+		//m.BucketSet(channel.GuildID+msg.Author.ID, -1)
+		return
 	}
 
-	return true
+	err = m.BucketDrain(1, channel.GuildID+msg.Author.ID)
+	helpers.Relax(err)
+
+	expStack.Push(ProcessExpInfo{UserID: msg.Author.ID, GuildID: channel.GuildID, ChannelID: msg.ChannelID, Quality: quality})
 }
 
-func (l *Levels) GetProfileBackgroundUrl(backgroundName string) string {
-	if backgroundName == "" {
-		return "http://i.imgur.com/I9b74U9.jpg"
-	}
+// levelsAntiFarmingStrictness contains the thresholds of an anti farming strictness,
+// the EXP of a message gets multiplied with Penalty for every signal it triggers
+type levelsAntiFarmingStrictness struct {
+	MinLength      int
+	MinUniqueRatio float64
+	MaxSimilarity  float64
+	Penalty        float64
+	Commands       bool // messages that look like commands for other bots trigger a signal
+}
 
-	var entryBucket DB_Profile_Background
-	listCursor, err := rethink.Table("profile_backgrounds").Filter(
-		rethink.Row.Field("id").Eq(strings.ToLower(backgroundName)),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+var levelsAntiFarmingStrictnesses = map[string]levelsAntiFarmingStrictness{
+	levelsAntiFarmingLow:    {MinLength: 2, MinUniqueRatio: 0.2, MaxSimilarity: 0.95, Penalty: 0.5},
+	levelsAntiFarmingMedium: {MinLength: 4, MinUniqueRatio: 0.3, MaxSimilarity: 0.9, Penalty: 0.25},
+	levelsAntiFarmingHigh:   {MinLength: 6, MinUniqueRatio: 0.35, MaxSimilarity: 0.8, Penalty: 0, Commands: true},
+}
+
+type levelsAntiFarmingChannelMessage struct {
+	AuthorID string
+	SentAt   time.Time
+}
+
+type levelsAntiFarmingSuspect struct {
+	Since      time.Time
+	Messages   int
+	Flagged    int
+	Signals    map[string]int
+	ReportedAt time.Time
+}
+
+// scoreMessageQuality returns the factor the EXP of the message gets multiplied with, and the signals it triggered
+func (m *Levels) scoreMessageQuality(guildID string, msg *discordgo.Message, strictness levelsAntiFarmingStrictness) (quality float64, signals []string) {
+	text := levelsAntiFarmingCleanRegex.ReplaceAllString(msg.Content, " ")
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	if strictness.Commands && levelsAntiFarmingCommandRegex.MatchString(text) {
+		signals = append(signals, levelsAntiFarmingSignalCommand)
 	}
-	defer listCursor.Close()
-	err = listCursor.One(&entryBucket)
 
-	if err == rethink.ErrEmptyResult {
-		var entryBucket DB_Profile_Background
-		listCursor, err := rethink.Table("profile_backgrounds").Filter(func(profile rethink.Term) rethink.Term {
-			return profile.Field("id").Match(fmt.Sprintf("(?i)^%s$", backgroundName))
-		}).Run(helpers.GetDB())
-		if err != nil {
-			panic(err)
+	var length, runes int
+	uniqueRunes := make(map[rune]bool)
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
 		}
-		defer listCursor.Close()
-		err = listCursor.One(&entryBucket)
-
-		if err == rethink.ErrEmptyResult {
-			return "http://i.imgur.com/I9b74U9.jpg" // Default Robyul Background
-		} else if err != nil {
-			helpers.Relax(err)
+		runes++
+		uniqueRunes[r] = true
+		// a single CJK character carries about as much as a short word
+		if unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) {
+			length += 2
+		} else {
+			length++
 		}
+	}
+	if len(msg.Attachments) <= 0 {
+		if length < strictness.MinLength {
+			signals = append(signals, levelsAntiFarmingSignalShort)
+		} else if runes >= levelsAntiFarmingUniqueRatioMinLength &&
+			float64(len(uniqueRunes))/float64(runes) < strictness.MinUniqueRatio {
+			signals = append(signals, levelsAntiFarmingSignalRepetitive)
+		}
+	}
 
-		return entryBucket.URL
-	} else if err != nil {
-		helpers.Relax(err)
+	key := guildID + "-" + msg.Author.ID
+	levelsAntiFarmingLock.Lock()
+	if text != "" {
+		for _, previousText := range levelsAntiFarmingUserHistory[key] {
+			if levelsAntiFarmingSimilarity(text, previousText) >= strictness.MaxSimilarity {
+				signals = append(signals, levelsAntiFarmingSignalDuplicate)
+				break
+			}
+		}
+		history := append(levelsAntiFarmingUserHistory[key], text)
+		if len(history) > levelsAntiFarmingHistorySize {
+			history = history[len(history)-levelsAntiFarmingHistorySize:]
+		}
+		levelsAntiFarmingUserHistory[key] = history
+	}
+	// two members talking to each other alternate as well, only count that if the message is suspicious anyway
+	// or if they keep up a pace that looks more like farming than talking
+	channelHistory := levelsAntiFarmingChannelHistory[msg.ChannelID]
+	conversationless, alternating := levelsAntiFarmingIsConversationless(channelHistory, msg.Author.ID)
+	if conversationless && (!alternating || len(signals) > 0 || levelsAntiFarmingIsFastPaced(channelHistory)) {
+		signals = append(signals, levelsAntiFarmingSignalConversationless)
 	}
+	levelsAntiFarmingLock.Unlock()
 
-	return entryBucket.URL
+	quality = 1
+	for range signals {
+		quality *= strictness.Penalty
+	}
+	return quality, signals
 }
 
-func (l *Levels) GetUserUserdata(user *discordgo.User) DB_Profile_Userdata {
-	var entryBucket DB_Profile_Userdata
-	listCursor, err := rethink.Table("profile_userdata").Filter(
-		rethink.Row.Field("userid").Eq(user.ID),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+// levelsAntiFarmingSimilarity returns the dice coefficient of the character bigrams of both texts, 1 for identical texts
+func levelsAntiFarmingSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
 	}
-	defer listCursor.Close()
-	err = listCursor.One(&entryBucket)
-
-	if err == rethink.ErrEmptyResult {
-		entryBucket.UserID = user.ID
-		insert := rethink.Table("profile_userdata").Insert(entryBucket)
-		_, err := insert.RunWrite(helpers.GetDB())
-		if err != nil {
-			helpers.Relax(err)
+	bigrams := func(text string) map[string]int {
+		result := make(map[string]int)
+		runes := []rune(text)
+		if len(runes) > levelsAntiFarmingSimilarityMaxLength {
+			runes = runes[:levelsAntiFarmingSimilarityMaxLength]
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			result[string(runes[i:i+2])]++
+		}
+		return result
+	}
+	bigramsA, bigramsB := bigrams(a), bigrams(b)
+	var totalA, totalB, shared int
+	for bigram, count := range bigramsA {
+		totalA += count
+		if countB := bigramsB[bigram]; countB < count {
+			shared += countB
 		} else {
-			return l.GetUserUserdata(user)
+			shared += count
 		}
-		return entryBucket
-	} else if err != nil {
-		helpers.Relax(err)
+	}
+	for _, count := range bigramsB {
+		totalB += count
+	}
+	if totalA+totalB <= 0 {
+		return 0
+	}
+	return 2 * float64(shared) / float64(totalA+totalB)
+}
+
+// levelsAntiFarmingIsConversationless returns true if the recent messages of the channel
+// are only from the user, or alternate strictly between the user and one other author, alternating is set for the latter
+func levelsAntiFarmingIsConversationless(channelHistory []levelsAntiFarmingChannelMessage, userID string) (conversationless bool, alternating bool) {
+	recent := make([]levelsAntiFarmingChannelMessage, 0)
+	for _, channelMessage := range channelHistory {
+		if time.Since(channelMessage.SentAt) <= levelsAntiFarmingConversationWindow {
+			recent = append(recent, channelMessage)
+		}
+	}
+	if len(recent) < levelsAntiFarmingChannelHistorySize {
+		return false, false
 	}
 
-	return entryBucket
+	authors := make(map[string]bool)
+	for i, channelMessage := range recent {
+		authors[channelMessage.AuthorID] = true
+		if len(authors) > 2 {
+			return false, false
+		}
+		if len(authors) == 2 && i > 0 && recent[i-1].AuthorID == channelMessage.AuthorID {
+			return false, false
+		}
+	}
+	return authors[userID], authors[userID] && len(authors) == 2
 }
 
-func (l *Levels) GetServerOnlyBadges(guildID string) []DB_Badge {
-	var entryBucket []DB_Badge
-	listCursor, err := rethink.Table("profile_badge").Filter(
-		rethink.Row.Field("guildid").Eq(guildID),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+// levelsAntiFarmingIsFastPaced returns true if the last messages of the channel were all sent within the fast alternation window
+func levelsAntiFarmingIsFastPaced(channelHistory []levelsAntiFarmingChannelMessage) bool {
+	if len(channelHistory) < levelsAntiFarmingChannelHistorySize {
+		return false
 	}
-	defer listCursor.Close()
-	err = listCursor.All(&entryBucket)
+	return time.Since(channelHistory[len(channelHistory)-levelsAntiFarmingChannelHistorySize].SentAt) <= levelsAntiFarmingFastAlternationWindow
+}
 
-	if err != nil {
-		if err != rethink.ErrEmptyResult {
-			helpers.Relax(err)
-		}
-	}
+func (m *Levels) recordAntiFarmingChannelMessage(msg *discordgo.Message) {
+	levelsAntiFarmingLock.Lock()
+	defer levelsAntiFarmingLock.Unlock()
 
-	return entryBucket
+	history := append(levelsAntiFarmingChannelHistory[msg.ChannelID], levelsAntiFarmingChannelMessage{
+		AuthorID: msg.Author.ID,
+		SentAt:   time.Now(),
+	})
+	if len(history) > levelsAntiFarmingChannelHistorySize {
+		history = history[len(history)-levelsAntiFarmingChannelHistorySize:]
+	}
+	levelsAntiFarmingChannelHistory[msg.ChannelID] = history
 }
 
-func (l *Levels) GetServerBadges(guildID string) []DB_Badge {
-	var entryBucket []DB_Badge
-	var globalEntryBucket []DB_Badge
-	listCursor, err := rethink.Table("profile_badge").Filter(
-		rethink.Row.Field("guildid").Eq(guildID),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+// recordAntiFarmingSignals counts the scored messages of the user and reports them once they look like farming
+func (m *Levels) recordAntiFarmingSignals(guildID string, userID string, signals []string) {
+	levelsAntiFarmingLock.Lock()
+	if levelsAntiFarmingSuspects[guildID] == nil {
+		levelsAntiFarmingSuspects[guildID] = make(map[string]*levelsAntiFarmingSuspect)
 	}
-	defer listCursor.Close()
-	err = listCursor.All(&entryBucket)
-	if err != nil {
-		panic(err)
+	suspect, ok := levelsAntiFarmingSuspects[guildID][userID]
+	if !ok || time.Since(suspect.Since) > levelsAntiFarmingSuspectWindow {
+		suspect = &levelsAntiFarmingSuspect{
+			Since:   time.Now(),
+			Signals: make(map[string]int),
+		}
+		levelsAntiFarmingSuspects[guildID][userID] = suspect
+	}
+	suspect.Messages++
+	if len(signals) > 0 {
+		suspect.Flagged++
 	}
+	for _, signal := range signals {
+		suspect.Signals[signal]++
+	}
+	report := suspect.Flagged >= levelsAntiFarmingReportThreshold && suspect.ReportedAt.IsZero()
+	if report {
+		suspect.ReportedAt = time.Now()
+	}
+	reportedSuspect := *suspect
+	levelsAntiFarmingLock.Unlock()
 
-	listCursor, err = rethink.Table("profile_badge").Filter(
-		rethink.Row.Field("guildid").Eq("global"),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+	if report {
+		go m.reportAntiFarmingSuspect(guildID, userID, reportedSuspect)
 	}
-	defer listCursor.Close()
-	err = listCursor.All(&globalEntryBucket)
+}
 
-	for _, globalEntry := range globalEntryBucket {
-		entryBucket = append(entryBucket, globalEntry)
+func (m *Levels) reportAntiFarmingSuspect(guildID string, userID string, suspect levelsAntiFarmingSuspect) {
+	defer helpers.Recover()
+
+	settings := helpers.GuildSettingsGetCached(guildID)
+	if settings.LevelsAntiFarmingReportChannelID == "" {
+		return
 	}
 
+	_, err := helpers.SendMessage(settings.LevelsAntiFarmingReportChannelID, helpers.GetTextF("plugins.levels.anti-farming-report",
+		userID, userID, suspect.Flagged, suspect.Messages, levelsAntiFarmingSignalsText(suspect.Signals), helpers.GetPrefixForServer(guildID)))
 	if err != nil {
-		if err != rethink.ErrEmptyResult {
-			helpers.Relax(err)
+		if errD, ok := err.(*discordgo.RESTError); ok && errD.Message != nil {
+			switch errD.Message.Code {
+			case discordgo.ErrCodeUnknownChannel, discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions:
+				return
+			}
+		}
+		helpers.RelaxLog(err)
+	}
+}
+
+// getAntiFarmingSuspects returns the users of the guild with flagged messages, most flagged messages first
+func (m *Levels) getAntiFarmingSuspects(guildID string) (userIDs []string, suspects []levelsAntiFarmingSuspect) {
+	levelsAntiFarmingLock.Lock()
+	for userID, suspect := range levelsAntiFarmingSuspects[guildID] {
+		if suspect.Flagged <= 0 || time.Since(suspect.Since) > levelsAntiFarmingSuspectWindow {
+			continue
 		}
+		userIDs = append(userIDs, userID)
+		suspects = append(suspects, *suspect)
 	}
+	levelsAntiFarmingLock.Unlock()
 
-	return entryBucket
+	sort.Sort(levelsAntiFarmingSuspectsByFlagged{userIDs, suspects})
+	return userIDs, suspects
 }
 
-func (l *Levels) GetCategoryBadges(category string, guildID string) []DB_Badge {
-	var entryBucket []DB_Badge
-	result := make([]DB_Badge, 0)
-	listCursor, err := rethink.Table("profile_badge").Filter(
-		rethink.Row.Field("category").Eq(category),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+type levelsAntiFarmingSuspectsByFlagged struct {
+	userIDs  []string
+	suspects []levelsAntiFarmingSuspect
+}
+
+func (s levelsAntiFarmingSuspectsByFlagged) Len() int { return len(s.suspects) }
+func (s levelsAntiFarmingSuspectsByFlagged) Less(i, j int) bool {
+	return s.suspects[i].Flagged > s.suspects[j].Flagged
+}
+func (s levelsAntiFarmingSuspectsByFlagged) Swap(i, j int) {
+	s.userIDs[i], s.userIDs[j] = s.userIDs[j], s.userIDs[i]
+	s.suspects[i], s.suspects[j] = s.suspects[j], s.suspects[i]
+}
+
+func levelsAntiFarmingSignalsText(signals map[string]int) string {
+	keys := make([]string, 0)
+	for signal := range signals {
+		keys = append(keys, signal)
 	}
-	defer listCursor.Close()
-	err = listCursor.All(&entryBucket)
+	sort.Strings(keys)
 
-	if err == rethink.ErrEmptyResult {
-		return result
-	} else if err != nil {
-		helpers.Relax(err)
+	texts := make([]string, 0)
+	for _, signal := range keys {
+		texts = append(texts, fmt.Sprintf("%s: %d", helpers.GetText("plugins.levels.anti-farming-signal-"+signal), signals[signal]))
 	}
+	return strings.Join(texts, ", ")
+}
 
-	for _, badge := range entryBucket {
-		if badge.GuildID == guildID || badge.GuildID == "global" {
-			result = append(result, badge)
+// antiFarmingCleanupLoop forgets message histories and suspects that aren't relevant anymore
+func (m *Levels) antiFarmingCleanupLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "levels").Error("The antiFarmingCleanupLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.antiFarmingCleanupLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(30 * time.Minute)
+
+		levelsAntiFarmingLock.Lock()
+		for channelID, channelHistory := range levelsAntiFarmingChannelHistory {
+			if len(channelHistory) <= 0 || time.Since(channelHistory[len(channelHistory)-1].SentAt) > levelsAntiFarmingConversationWindow {
+				delete(levelsAntiFarmingChannelHistory, channelID)
+			}
+		}
+		for guildID, guildSuspects := range levelsAntiFarmingSuspects {
+			for userID, suspect := range guildSuspects {
+				if time.Since(suspect.Since) > levelsAntiFarmingSuspectWindow {
+					delete(guildSuspects, userID)
+					delete(levelsAntiFarmingUserHistory, guildID+"-"+userID)
+				}
+			}
+			if len(guildSuspects) <= 0 {
+				delete(levelsAntiFarmingSuspects, guildID)
+			}
 		}
+		levelsAntiFarmingLock.Unlock()
 	}
+}
+
+func (m *Levels) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+	go func() {
+		defer helpers.Recover()
+
+		err := m.applyLevelsRoles(member.GuildID, member.User.ID, m.GetLevelForUser(member.User.ID, member.GuildID))
+		if err != nil {
+			if errD, ok := err.(*discordgo.RESTError); !ok || errD.Message.Code != discordgo.ErrCodeUnknownMember {
+				helpers.RelaxLog(err)
+			}
+		}
+	}()
+
+	go m.checkBadgeRulesForUser(member.GuildID, member.User.ID)
 
-	return result
 }
 
-func (l *Levels) GetBadge(category string, name string, guildID string) DB_Badge {
-	var entryBucket []DB_Badge
-	var emptyBadge DB_Badge
-	listCursor, err := rethink.Table("profile_badge").Filter(
-		rethink.Row.Field("category").Eq(strings.ToLower(category)),
-	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
+func (m *Levels) OnGuildMemberUpdate(session *discordgo.Session, member *discordgo.GuildMemberUpdate) {
+	if member.Member == nil || member.User == nil || member.User.Bot {
+		return
 	}
-	defer listCursor.Close()
-	err = listCursor.All(&entryBucket)
 
-	if err == rethink.ErrEmptyResult {
-		return emptyBadge
-	} else if err != nil {
-		helpers.Relax(err)
-	}
+	go m.checkBadgeRulesForUser(member.GuildID, member.User.ID)
+}
 
-	for _, badge := range entryBucket {
-		if strings.ToLower(badge.Name) == strings.ToLower(name) && (badge.GuildID == guildID || badge.GuildID == "global") {
-			return badge
-		}
-	}
+func (m *Levels) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
 
-	return emptyBadge
 }
 
-func (l *Levels) GetBadgeByID(badgeID string) DB_Badge {
-	var badgeBucket DB_Badge
-	listCursor, err := rethink.Table("profile_badge").Filter(
-		rethink.Row.Field("id").Eq(badgeID),
+func (m *Levels) getLevelsServerUserOrCreateNew(guildid string, userid string) DB_Levels_ServerUser {
+	var levelsServerUser DB_Levels_ServerUser
+	listCursor, err := rethink.Table("levels_serverusers").GetAllByIndex(
+		"userid", userid,
+	).Filter(
+		rethink.Row.Field("guildid").Eq(guildid),
 	).Run(helpers.GetDB())
-	if err != nil {
-		panic(err)
-	}
+	helpers.Relax(err)
 	defer listCursor.Close()
-	err = listCursor.One(&badgeBucket)
+	err = listCursor.One(&levelsServerUser)
 
 	if err == rethink.ErrEmptyResult {
-		return badgeBucket
+		insert := rethink.Table("levels_serverusers").Insert(DB_Levels_ServerUser{GuildID: guildid, UserID: userid})
+		_, e := insert.RunWrite(helpers.GetDB())
+		if e != nil {
+			panic(e)
+		} else {
+			return m.getLevelsServerUserOrCreateNew(guildid, userid)
+		}
 	} else if err != nil {
 		panic(err)
 	}
 
-	return badgeBucket
+	return levelsServerUser
 }
 
-func (l *Levels) GetBadgesAvailable(user *discordgo.User, sourceServerID string) []DB_Badge {
-	guildsToCheck := make([]string, 0)
-	guildsToCheck = append(guildsToCheck, "global")
-
-	session := cache.GetSession()
-
-	for _, guild := range session.State.Guilds {
-		if helpers.GetIsInGuild(guild.ID, user.ID) {
-			guildsToCheck = append(guildsToCheck, guild.ID)
-		}
-	}
-
-	sourceServerAlreadyIn := false
-	for _, guild := range guildsToCheck {
-		if guild == sourceServerID {
-			sourceServerAlreadyIn = true
-		}
-	}
-	if sourceServerAlreadyIn == false {
-		guildsToCheck = append(guildsToCheck, sourceServerID)
+// getLevelsServerUserExp returns the EXP of the user on the guild without creating a new entry, 0 if there is none
+func (m *Levels) getLevelsServerUserExp(guildid string, userid string) int64 {
+	var levelsServerUser DB_Levels_ServerUser
+	listCursor, err := rethink.Table("levels_serverusers").GetAllByIndex(
+		"userid", userid,
+	).Filter(
+		rethink.Row.Field("guildid").Eq(guildid),
+	).Run(helpers.GetDB())
+	helpers.Relax(err)
+	defer listCursor.Close()
+	err = listCursor.One(&levelsServerUser)
+	if err != nil && err != rethink.ErrEmptyResult {
+		helpers.Relax(err)
 	}
+	return levelsServerUser.Exp
+}
 
-	var allBadges []DB_Badge
-	for _, guildToCheck := range guildsToCheck {
-		var entryBucket []DB_Badge
-		listCursor, err := rethink.Table("profile_badge").Filter(
-			rethink.Row.Field("guildid").Eq(guildToCheck),
-		).Run(helpers.GetDB())
-		defer listCursor.Close()
-		if err != nil {
-			continue
-		}
-		err = listCursor.All(&entryBucket)
-		if err != nil {
-			continue
-		}
-		for _, entryBadge := range entryBucket {
-			allBadges = append(allBadges, entryBadge)
-		}
-	}
+func (m *Levels) setLevelsServerUser(entry DB_Levels_ServerUser) {
+	_, err := rethink.Table("levels_serverusers").Get(entry.ID).Update(entry).Run(helpers.GetDB())
+	helpers.Relax(err)
+}
 
-	levelCache := make(map[string]int, 0)
+// getLevelFromExp returns the level for the EXP using the level curve of the guild, use "global" for the global level
+func (m *Levels) getLevelFromExp(guildID string, exp int64) int {
+	return m.getLevelsCurve(guildID).levelFromExp(exp)
+}
 
-	var availableBadges []DB_Badge
-	for _, foundBadge := range allBadges {
-		isAllowed := false
+// getExpForLevel returns the EXP required for the level using the level curve of the guild
+func (m *Levels) getExpForLevel(guildID string, level int) int64 {
+	return m.getLevelsCurve(guildID).expForLevel(level)
+}
 
-		// Level Check
-		if foundBadge.LevelRequirement < 0 { // Available for no one?
-			isAllowed = false
-		} else if foundBadge.LevelRequirement == 0 { // Available for everyone?
-			isAllowed = true
-		} else if foundBadge.LevelRequirement > 0 { // Meets min level?
-			if _, ok := levelCache[foundBadge.GuildID]; ok {
-				if foundBadge.LevelRequirement <= levelCache[foundBadge.GuildID] {
-					isAllowed = true
-				} else {
-					isAllowed = false
-				}
-			} else {
-				levelCache[foundBadge.GuildID] = l.GetLevelForUser(user.ID, foundBadge.GuildID)
-				if foundBadge.LevelRequirement <= levelCache[foundBadge.GuildID] {
-					isAllowed = true
-				} else {
-					isAllowed = false
-				}
-			}
-		}
+// getProgressToNextLevelFromExp returns the progress to the next level in percent using the level curve of the guild
+func (m *Levels) getProgressToNextLevelFromExp(guildID string, exp int64) int {
+	return m.getLevelsCurve(guildID).progressToNextLevel(exp)
+}
 
-		// User is in allowed user list?
-		for _, allowedUserID := range foundBadge.AllowedUserIDs {
-			if allowedUserID == user.ID {
-				isAllowed = true
-			}
-		}
+// getRandomExpForMessage returns a random amount of EXP inside of the EXP per message range of the guild
+func (m *Levels) getRandomExpForMessage(guildID string) int64 {
+	min, max := m.getExpPerMessageRange(guildID)
+	rand.Seed(time.Now().Unix())
+	return int64(rand.Intn(max-min+1) + min)
+}
 
-		// User is in denied user list?
-		for _, deniedUserID := range foundBadge.DeniedUserIDs {
-			if deniedUserID == user.ID {
-				isAllowed = false
-			}
-		}
+// getExpPerMessageRange returns the configured EXP per message range of the guild, or the default range
+func (m *Levels) getExpPerMessageRange(guildID string) (min, max int) {
+	settings := helpers.GuildSettingsGetCached(guildID)
+	if settings.LevelsExpPerMessageMin <= 0 || settings.LevelsExpPerMessageMax < settings.LevelsExpPerMessageMin {
+		return levelsExpPerMessageDefaultMin, levelsExpPerMessageDefaultMax
+	}
+	return settings.LevelsExpPerMessageMin, settings.LevelsExpPerMessageMax
+}
 
-		if isAllowed == true {
-			availableBadges = append(availableBadges, foundBadge)
-		}
+// getLevelsCurve returns the level curve of the guild, global levels always use the quadratic curve
+func (m *Levels) getLevelsCurve(guildID string) levelsCurve {
+	if guildID == "" || guildID == "global" {
+		return levelsCurve{Type: levelsCurveQuadratic}
 	}
 
-	return availableBadges
+	settings := helpers.GuildSettingsGetCached(guildID)
+	switch settings.LevelsCurve {
+	case levelsCurveLinear, levelsCurveExponential:
+		return levelsCurve{Type: settings.LevelsCurve}
+	case levelsCurveCustom:
+		if len(settings.LevelsCurveThresholds) > 0 {
+			return levelsCurve{Type: levelsCurveCustom, Thresholds: settings.LevelsCurveThresholds}
+		}
+	}
+	return levelsCurve{Type: levelsCurveQuadratic}
 }
 
-func (l *Levels) GetBadgesAvailableServer(user *discordgo.User, serverID string) []DB_Badge {
-	guildsToCheck := make([]string, 0)
-	guildsToCheck = append(guildsToCheck, "global")
-
-	if _, err := helpers.GetGuildMember(serverID, user.ID); err == nil {
-		guildsToCheck = append(guildsToCheck, serverID)
+// expForLevel returns the EXP needed for the level, levels that would need more than levelsExpMax EXP
+// return math.MaxInt64 so they can never be reached
+func (c levelsCurve) expForLevel(level int) int64 {
+	if level <= 0 {
+		return 0
 	}
 
-	var allBadges []DB_Badge
-	for _, guildToCheck := range guildsToCheck {
-		var entryBucket []DB_Badge
-		listCursor, err := rethink.Table("profile_badge").Filter(
-			rethink.Row.Field("guildid").Eq(guildToCheck),
-		).Run(helpers.GetDB())
-		defer listCursor.Close()
-		if err != nil {
-			continue
+	var calculatedExp float64
+	switch c.Type {
+	case levelsCurveLinear:
+		if int64(level) > levelsExpMax/levelsCurveLinearExpPerLevel {
+			return math.MaxInt64
 		}
-		err = listCursor.All(&entryBucket)
-		if err != nil {
-			continue
+		return int64(level) * levelsCurveLinearExpPerLevel
+	case levelsCurveExponential:
+		calculatedExp = levelsCurveExponentialFactor * (math.Pow(levelsCurveExponentialBase, float64(level)) - 1)
+	case levelsCurveCustom:
+		if level <= len(c.Thresholds) {
+			return c.Thresholds[level-1]
 		}
-		for _, entryBadge := range entryBucket {
-			allBadges = append(allBadges, entryBadge)
+		// continue after the last threshold with the step between the last two thresholds
+		last, step := c.customStep()
+		if int64(level-len(c.Thresholds)) > (levelsExpMax-last)/step {
+			return math.MaxInt64
 		}
+		return last + int64(level-len(c.Thresholds))*step
+	default:
+		calculatedExp = math.Pow(float64(level)/0.1, 2)
 	}
 
-	var availableBadges []DB_Badge
-	for _, foundBadge := range allBadges {
-		isAllowed := false
+	if calculatedExp > levelsExpMax {
+		return math.MaxInt64
+	}
+	return int64(calculatedExp)
+}
 
-		// Level Check
-		if foundBadge.LevelRequirement < 0 { // Available for no one?
-			isAllowed = false
-		} else if foundBadge.LevelRequirement == 0 { // Available for everyone?
-			isAllowed = true
-		} else if foundBadge.LevelRequirement > 0 { // Meets min level=
-			if foundBadge.LevelRequirement <= l.GetLevelForUser(user.ID, foundBadge.GuildID) {
-				isAllowed = true
-			} else {
-				isAllowed = false
-			}
-		}
+func (c levelsCurve) levelFromExp(exp int64) int {
+	if exp <= 0 {
+		return 0
+	}
+	if exp > levelsExpMax {
+		exp = levelsExpMax
+	}
 
-		// User is in allowed user list?
-		for _, allowedUserID := range foundBadge.AllowedUserIDs {
-			if allowedUserID == user.ID {
-				isAllowed = true
-			}
+	var level int
+	switch c.Type {
+	case levelsCurveLinear:
+		return int(exp / levelsCurveLinearExpPerLevel)
+	case levelsCurveExponential:
+		level = int(math.Floor(math.Log(float64(exp)/levelsCurveExponentialFactor+1) / math.Log(levelsCurveExponentialBase)))
+	case levelsCurveCustom:
+		level = sort.Search(len(c.Thresholds), func(i int) bool {
+			return c.Thresholds[i] > exp
+		})
+		if level < len(c.Thresholds) {
+			return level
 		}
+		last, step := c.customStep()
+		return level + int((exp-last)/step)
+	default:
+		level = int(math.Floor(0.1 * math.Sqrt(float64(exp))))
+	}
 
-		// User is in denied user list?
-		for _, deniedUserID := range foundBadge.DeniedUserIDs {
-			if deniedUserID == user.ID {
-				isAllowed = false
-			}
-		}
+	// correct floating point inaccuracies, terminates because exp is at most levelsExpMax
+	for level > 0 && c.expForLevel(level) > exp {
+		level--
+	}
+	for c.expForLevel(level+1) <= exp {
+		level++
+	}
+	return level
+}
 
-		if isAllowed == true {
-			availableBadges = append(availableBadges, foundBadge)
-		}
+func (c levelsCurve) progressToNextLevel(exp int64) int {
+	level := c.levelFromExp(exp)
+	expLevelCurrently := exp - c.expForLevel(level)
+	expLevelNext := c.expForLevel(level+1) - c.expForLevel(level)
+	if expLevelNext <= 0 {
+		return 0
 	}
+	return int(expLevelCurrently * 100 / expLevelNext)
+}
 
-	return availableBadges
+// levelsAddExp adds EXP without exceeding levelsExpMax
+func levelsAddExp(exp, add int64) int64 {
+	if add > levelsExpMax-exp {
+		return levelsExpMax
+	}
+	return exp + add
 }
 
-func (l *Levels) GetBadgesAvailableQuick(user *discordgo.User, activeBadgeIDs []string) []DB_Badge {
+func (c levelsCurve) customStep() (last int64, step int64) {
+	last = c.Thresholds[len(c.Thresholds)-1]
+	step = last
+	if len(c.Thresholds) >= 2 {
+		step = last - c.Thresholds[len(c.Thresholds)-2]
+	}
+	if step <= 0 {
+		step = 1
+	}
+	return last, step
+}
 
-	activeBadges := make([]DB_Badge, 0)
-	for _, activeBadgeID := range activeBadgeIDs {
-		badge := l.GetBadgeByID(activeBadgeID)
-		if badge.ID != "" {
-			if badge.GuildID == "global" {
-				activeBadges = append(activeBadges, badge)
-			} else {
-				if helpers.GetIsInGuild(badge.GuildID, user.ID) {
-					activeBadges = append(activeBadges, badge)
+// getExpMultiplier returns the EXP multiplier for a message of the user in the channel and a description of every active multiplier
+// channel multipliers stack, of the role and event multipliers only the highest one counts
+func (m *Levels) getExpMultiplier(guildID, channelID, userID string) (multiplier float64, sources []string) {
+	multiplier = 1
+	sources = make([]string, 0)
+
+	settings := helpers.GuildSettingsGetCached(guildID)
+
+	var member *discordgo.Member
+	var err error
+	roleMultiplier := -1.0
+	var roleID string
+	for _, levelsMultiplier := range settings.LevelsMultipliers {
+		switch levelsMultiplier.Type {
+		case models.LevelsMultiplierTypeChannel:
+			if levelsMultiplier.TargetID != channelID {
+				continue
+			}
+			multiplier *= levelsMultiplier.Multiplier
+			sources = append(sources, fmt.Sprintf("<#%s> %s", channelID, m.formatMultiplier(levelsMultiplier.Multiplier)))
+		case models.LevelsMultiplierTypeRole:
+			if member == nil {
+				member, err = helpers.GetGuildMember(guildID, userID)
+				if err != nil || member == nil {
+					member = new(discordgo.Member)
+				}
+			}
+			for _, memberRoleID := range member.Roles {
+				if memberRoleID == levelsMultiplier.TargetID && levelsMultiplier.Multiplier > roleMultiplier {
+					roleMultiplier = levelsMultiplier.Multiplier
+					roleID = memberRoleID
 				}
 			}
 		}
 	}
+	if roleMultiplier >= 0 {
+		multiplier *= roleMultiplier
+		sources = append(sources, fmt.Sprintf("<@&%s> %s", roleID, m.formatMultiplier(roleMultiplier)))
+	}
 
-	var availableBadges []DB_Badge
-	for _, foundBadge := range activeBadges {
-		isAllowed := true
-
-		// User is in allowed user list?
-		for _, allowedUserID := range foundBadge.AllowedUserIDs {
-			if allowedUserID == user.ID {
-				isAllowed = true
-			}
+	var activeEvent models.LevelsMultiplierEventEntry
+	levelsMultiplierEventsLock.RLock()
+	for _, event := range levelsMultiplierEvents {
+		if event.GuildID == guildID &&
+			!time.Now().Before(event.StartsAt) && time.Now().Before(event.EndsAt) &&
+			event.Multiplier > activeEvent.Multiplier {
+			activeEvent = event
 		}
+	}
+	levelsMultiplierEventsLock.RUnlock()
+	if activeEvent.ID != "" {
+		multiplier *= activeEvent.Multiplier
+		sources = append(sources, fmt.Sprintf("%s %s (ends %s)", activeEvent.Name, m.formatMultiplier(activeEvent.Multiplier), humanize.Time(activeEvent.EndsAt)))
+	}
 
-		// User is in denied user list?
-		for _, deniedUserID := range foundBadge.DeniedUserIDs {
-			if deniedUserID == user.ID {
-				isAllowed = false
-			}
-		}
+	return multiplier, sources
+}
 
-		if isAllowed == true {
-			availableBadges = append(availableBadges, foundBadge)
+func (m *Levels) formatMultiplier(multiplier float64) string {
+	return strconv.FormatFloat(multiplier, 'f', -1, 64) + "x"
+}
+
+// parseMultiplier parses a multiplier like 1.5 or 1.5x
+func (m *Levels) parseMultiplier(text string) (multiplier float64, err error) {
+	multiplier, err = strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(text), "x"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if multiplier < 0 || multiplier > levelsMultiplierLimit {
+		return 0, errors.New("multiplier out of range")
+	}
+	return multiplier, nil
+}
+
+// setLevelsMultiplier sets the multiplier for the role or channel, a negative multiplier removes it
+func (m *Levels) setLevelsMultiplier(guildID, multiplierType, targetID string, multiplier float64) (err error) {
+	settings := helpers.GuildSettingsGetCached(guildID)
+
+	newMultipliers := make([]models.LevelsMultiplier, 0)
+	for _, levelsMultiplier := range settings.LevelsMultipliers {
+		if levelsMultiplier.Type == multiplierType && levelsMultiplier.TargetID == targetID {
+			continue
 		}
+		newMultipliers = append(newMultipliers, levelsMultiplier)
+	}
+	if multiplier >= 0 {
+		newMultipliers = append(newMultipliers, models.LevelsMultiplier{
+			Type:       multiplierType,
+			TargetID:   targetID,
+			Multiplier: multiplier,
+		})
 	}
+	settings.LevelsMultipliers = newMultipliers
 
-	return availableBadges
+	return helpers.GuildSettingsSet(guildID, settings)
 }
 
-func (l *Levels) GetLevelForUser(userID string, guildID string) int {
-	var levelsServersUser []DB_Levels_ServerUser
-	listCursor, err := rethink.Table("levels_serverusers").Filter(
-		rethink.Row.Field("userid").Eq(userID),
+func (m *Levels) multiplierEventsLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "levels").Error("The multiplierEventsLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.multiplierEventsLoop()
+		}()
+	}()
+
+	for {
+		err := m.updateMultiplierEvents()
+		helpers.RelaxLog(err)
+
+		time.Sleep(1 * time.Minute)
+	}
+}
+
+// updateMultiplierEvents announces started and ended multiplier events and refreshes the cached events
+func (m *Levels) updateMultiplierEvents() (err error) {
+	levelsMultiplierEventsUpdateLock.Lock()
+	defer levelsMultiplierEventsUpdateLock.Unlock()
+
+	var entryBucket []models.LevelsMultiplierEventEntry
+	listCursor, err := rethink.Table(models.LevelsMultiplierEventsTable).Filter(
+		rethink.Row.Field("ended").Eq(false),
 	).Run(helpers.GetDB())
-	helpers.Relax(err)
+	if err != nil {
+		return err
+	}
 	defer listCursor.Close()
-	err = listCursor.All(&levelsServersUser)
-
-	if err == rethink.ErrEmptyResult {
-		return 0
-	} else if err != nil {
-		helpers.Relax(err)
+	err = listCursor.All(&entryBucket)
+	if err != nil && err != rethink.ErrEmptyResult {
+		return err
 	}
 
-	if guildID == "global" {
-		totalExp := int64(0)
-		for _, levelsServerUser := range levelsServersUser {
-			totalExp += levelsServerUser.Exp
-		}
-		return l.getLevelFromExp(totalExp)
-	} else {
-		for _, levelsServerUser := range levelsServersUser {
-			if levelsServerUser.GuildID == guildID {
-				return l.getLevelFromExp(levelsServerUser.Exp)
+	pendingEvents := make([]models.LevelsMultiplierEventEntry, 0)
+	for _, entry := range entryBucket {
+		if !time.Now().Before(entry.EndsAt) {
+			if entry.Started {
+				m.announceMultiplierEvent(entry, helpers.GetTextF("plugins.levels.multiplier-event-ended", entry.Name))
 			}
+			entry.Started = true
+			entry.Ended = true
+			err = m.setMultiplierEvent(entry)
+			helpers.RelaxLog(err)
+			continue
+		}
+
+		if !entry.Started && !time.Now().Before(entry.StartsAt) {
+			m.announceMultiplierEvent(entry, helpers.GetTextF("plugins.levels.multiplier-event-started",
+				entry.Name, m.formatMultiplier(entry.Multiplier), humanize.Time(entry.EndsAt)))
+			entry.Started = true
+			err = m.setMultiplierEvent(entry)
+			helpers.RelaxLog(err)
 		}
+
+		pendingEvents = append(pendingEvents, entry)
 	}
 
-	return 0
+	levelsMultiplierEventsLock.Lock()
+	levelsMultiplierEvents = pendingEvents
+	levelsMultiplierEventsLock.Unlock()
+
+	return nil
 }
 
-func (l *Levels) InsertBadge(entry DB_Badge) {
-	insert := rethink.Table("profile_badge").Insert(entry)
-	_, err := insert.RunWrite(helpers.GetDB())
-	if err != nil {
-		helpers.Relax(err)
+func (m *Levels) announceMultiplierEvent(entry models.LevelsMultiplierEventEntry, text string) {
+	if entry.ChannelID == "" {
+		return
 	}
-	return
-}
 
-func (l *Levels) UpdateBadge(entry DB_Badge) {
-	if entry.ID != "" {
-		_, err := rethink.Table("profile_badge").Update(entry).Run(helpers.GetDB())
-		helpers.Relax(err)
+	_, err := helpers.SendMessage(entry.ChannelID, text)
+	if err != nil {
+		cache.GetLogger().WithField("module", "levels").Warn(fmt.Sprintf("failed to announce multiplier event %s: %s", entry.ID, err.Error()))
 	}
 }
 
-func (l *Levels) DeleteBadge(badgeID string) {
-	_, err := rethink.Table("profile_badge").Filter(
-		rethink.Row.Field("id").Eq(badgeID),
-	).Delete().RunWrite(helpers.GetDB())
-	helpers.Relax(err)
-	return
+func (m *Levels) createMultiplierEvent(entry models.LevelsMultiplierEventEntry) (id string, err error) {
+	insert := rethink.Table(models.LevelsMultiplierEventsTable).Insert(entry)
+	inserted, err := insert.RunWrite(helpers.GetDB())
+	if err != nil {
+		return "", err
+	}
+	return inserted.GeneratedKeys[0], nil
 }
 
-func (l *Levels) setUserUserdata(entry DB_Profile_Userdata) {
-	_, err := rethink.Table("profile_userdata").Update(entry).Run(helpers.GetDB())
-	helpers.Relax(err)
+func (m *Levels) setMultiplierEvent(entry models.LevelsMultiplierEventEntry) (err error) {
+	if entry.ID == "" {
+		return errors.New("empty entry submitted")
+	}
+	_, err = rethink.Table(models.LevelsMultiplierEventsTable).Get(entry.ID).Update(entry).RunWrite(helpers.GetDB())
+	return err
 }
 
-func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild, web bool) (string, error) {
-	var levelsServersUser []DB_Levels_ServerUser
-	listCursor, err := rethink.Table("levels_serverusers").Filter(
-		rethink.Row.Field("userid").Eq(member.User.ID),
-	).Run(helpers.GetDB())
+func (m *Levels) getMultiplierEvents(guildID string) (entries []models.LevelsMultiplierEventEntry, err error) {
+	listCursor, err := rethink.Table(models.LevelsMultiplierEventsTable).GetAllByIndex(
+		"guild_id", guildID,
+	).Filter(
+		rethink.Row.Field("ended").Eq(false),
+	).OrderBy(rethink.Asc("starts_at")).Run(helpers.GetDB())
 	if err != nil {
-		return "", err
+		return entries, err
 	}
 	defer listCursor.Close()
-	err = listCursor.All(&levelsServersUser)
-	if err != nil {
-		return "", err
+	err = listCursor.All(&entries)
+	if err == rethink.ErrEmptyResult {
+		err = nil
 	}
+	return entries, err
+}
 
-	var levelThisServerUser DB_Levels_ServerUser
-	var totalExp int64
-	for _, levelsServerUser := range levelsServersUser {
-		if levelsServerUser.GuildID == guild.ID {
-			levelThisServerUser = levelsServerUser
-		}
-		totalExp += levelsServerUser.Exp
+func (m *Levels) deleteMultiplierEvent(id string) (err error) {
+	if id == "" {
+		return errors.New("empty id submitted")
 	}
+	_, err = rethink.Table(models.LevelsMultiplierEventsTable).Get(id).Delete().RunWrite(helpers.GetDB())
+	return err
+}
 
-	serverRank := "N/A"
-	globalRank := "N/A"
-	for _, serverCache := range topCache {
-		if serverCache.GuildID == "global" {
-			for i, pair := range serverCache.Levels {
-				if pair.Key == member.User.ID {
-					globalRank = strconv.Itoa(i + 1)
-				}
-			}
-		} else if serverCache.GuildID == guild.ID {
-			for i, pair := range serverCache.Levels {
-				if pair.Key == member.User.ID {
-					serverRank = strconv.Itoa(i + 1)
-				}
-			}
-		}
+// addExpHistory adds the EXP to the EXP the user gained on the guild today, used for periodic leaderboards
+func (m *Levels) addExpHistory(guildID, userID string, exp int64) (err error) {
+	day := time.Now().UTC().Format(levelsDayFormat)
+	id := guildID + "-" + userID + "-" + day
+
+	result, err := rethink.Table(models.LevelsExpHistoryTable).Get(id).Update(map[string]interface{}{
+		"exp": rethink.Row.Field("exp").Add(exp),
+	}).RunWrite(helpers.GetDB())
+	if err != nil {
+		return err
+	}
+	// no entry for today yet
+	if result.Skipped > 0 {
+		_, err = rethink.Table(models.LevelsExpHistoryTable).Insert(models.LevelsExpHistoryEntry{
+			ID:      id,
+			GuildID: guildID,
+			UserID:  userID,
+			Day:     day,
+			Exp:     exp,
+		}).RunWrite(helpers.GetDB())
+	}
+	return err
+}
+
+// levelsPeriodRankingsCacheEntry is a cached period leaderboard
+type levelsPeriodRankingsCacheEntry struct {
+	Rankings []Levels_Cache_Ranking_Item
+	CachedAt time.Time
+}
+
+// GetPeriodRankings returns the ranking of the guild (or global) for the EXP gained in the period (weekly, monthly or season),
+// rankings are cached for levelsPeriodCacheTime
+func (m *Levels) GetPeriodRankings(guildID, period string) (rankings []Levels_Cache_Ranking_Item, err error) {
+	cacheKey := guildID + ":" + period
+	levelsPeriodRankingsCacheLock.Lock()
+	cacheEntry, ok := levelsPeriodRankingsCache[cacheKey]
+	levelsPeriodRankingsCacheLock.Unlock()
+	if ok && time.Since(cacheEntry.CachedAt) < levelsPeriodCacheTime {
+		return cacheEntry.Rankings, nil
 	}
 
-	userData := m.GetUserUserdata(member.User)
+	rankings, err = m.getPeriodRankings(guildID, period)
+	if err != nil {
+		return nil, err
+	}
 
-	avatarUrl := helpers.GetAvatarUrl(member.User)
-	avatarUrlGif := ""
-	if avatarUrl != "" {
-		avatarUrl = strings.Replace(avatarUrl, "size=1024", "size=128", -1)
-		if strings.Contains(avatarUrl, "gif") {
-			avatarUrlGif = avatarUrl
+	levelsPeriodRankingsCacheLock.Lock()
+	levelsPeriodRankingsCache[cacheKey] = levelsPeriodRankingsCacheEntry{Rankings: rankings, CachedAt: time.Now()}
+	levelsPeriodRankingsCacheLock.Unlock()
+	return rankings, nil
+}
+
+// clearPeriodRankingsCache removes the cached period rankings of the guild, and expired rankings of all guilds
+func (m *Levels) clearPeriodRankingsCache(guildID string) {
+	levelsPeriodRankingsCacheLock.Lock()
+	defer levelsPeriodRankingsCacheLock.Unlock()
+	for cacheKey, cacheEntry := range levelsPeriodRankingsCache {
+		if strings.HasPrefix(cacheKey, guildID+":") || time.Since(cacheEntry.CachedAt) >= levelsPeriodCacheTime {
+			delete(levelsPeriodRankingsCache, cacheKey)
 		}
-		avatarUrl = strings.Replace(avatarUrl, "gif", "png", -1)
-		avatarUrl = strings.Replace(avatarUrl, "jpg", "png", -1)
 	}
-	if web == true && avatarUrlGif != "" {
-		avatarUrl = avatarUrlGif
+}
+
+func (m *Levels) getPeriodRankings(guildID, period string) (rankings []Levels_Cache_Ranking_Item, err error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var from time.Time
+	switch period {
+	case levelsPeriodWeekly:
+		from = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	case levelsPeriodMonthly:
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case levelsPeriodSeason:
+		if guildID == "global" {
+			return nil, ErrLevelsInvalidPeriod
+		}
+		season, err := m.getActiveSeason(guildID)
+		if err != nil {
+			return nil, err
+		}
+		return m.getRankingsBetween(guildID, season.StartedAt, now, season.FirstDayExp)
+	default:
+		return nil, ErrLevelsInvalidPeriod
 	}
-	if avatarUrl == "" {
-		avatarUrl = "http://i.imgur.com/osAqNL6.png"
+
+	return m.getRankingsBetween(guildID, from, now, nil)
+}
+
+// levelsDayStart returns the start of the UTC day of t
+func levelsDayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// getRankingsBetween ranks the users by the EXP gained between the UTC days of from and to,
+// EXP history is stored per UTC day so the EXP of the whole days of from and to counts,
+// firstDayOffsets is the EXP per user to leave out of the day of from (can be nil)
+func (m *Levels) getRankingsBetween(guildID string, from, to time.Time, firstDayOffsets map[string]int64) (rankings []Levels_Cache_Ranking_Item, err error) {
+	fromDay := from.UTC().Format(levelsDayFormat)
+	toDay := to.UTC().Format(levelsDayFormat)
+
+	var query rethink.Term
+	if guildID == "global" {
+		query = rethink.Table(models.LevelsExpHistoryTable).Between(
+			fromDay, toDay, rethink.BetweenOpts{Index: "day", RightBound: "closed"},
+		)
+	} else {
+		query = rethink.Table(models.LevelsExpHistoryTable).Between(
+			[]interface{}{guildID, fromDay}, []interface{}{guildID, toDay}, rethink.BetweenOpts{Index: "guild_id_day", RightBound: "closed"},
+		)
 	}
-	userAndNick := member.User.Username
-	if member.Nick != "" {
-		userAndNick = fmt.Sprintf("%s (%s)", member.User.Username, member.Nick)
+
+	listCursor, err := query.Group("user_id").Sum("exp").Ungroup().OrderBy(rethink.Desc("reduction")).Run(helpers.GetDB())
+	if err != nil {
+		return nil, err
 	}
-	title := userData.Title
-	if title == "" {
-		title = "Robyul's friend"
+	defer listCursor.Close()
+
+	var results []struct {
+		UserID string `gorethink:"group"`
+		Exp    int64  `gorethink:"reduction"`
 	}
-	bio := userData.Bio
-	if bio == "" {
-		bio = "Robyul would like to know more about me!"
+	err = listCursor.All(&results)
+	if err != nil && err != rethink.ErrEmptyResult {
+		return nil, err
 	}
 
-	badgesToDisplay := make([]DB_Badge, 0)
-	availableBadges := m.GetBadgesAvailableQuick(member.User, userData.ActiveBadgeIDs)
-	for _, activeBadgeID := range userData.ActiveBadgeIDs {
-		for _, availableBadge := range availableBadges {
-			if activeBadgeID == availableBadge.ID {
-				badgesToDisplay = append(badgesToDisplay, availableBadge)
+	// levels are always based on the lifetime EXP
+	lifetimeExp := make(map[string]int64)
+	for _, serverCache := range topCache {
+		if serverCache.GuildID == guildID {
+			for _, pair := range serverCache.Levels {
+				lifetimeExp[pair.Key] = pair.Value
 			}
 		}
 	}
-	badgesHTML := ""
-	for _, badge := range badgesToDisplay {
-		badgesHTML += fmt.Sprintf("<img src=\"%s\" style=\"border: 2px solid #%s;\">", badge.URL, badge.BorderColor)
-	}
 
-	backgroundColor, err := colorful.Hex("#" + m.GetBackgroundColor(userData))
-	if err != nil {
-		backgroundColor, err = colorful.Hex("#000000")
-		if err != nil {
-			return "", err
-		}
+	periodExp := make(map[string]int64)
+	for _, result := range results {
+		periodExp[result.UserID] = result.Exp - firstDayOffsets[result.UserID]
 	}
-	backgroundColorString := fmt.Sprintf("rgba(%d, %d, %d, %s)",
-		int(backgroundColor.R*255), int(backgroundColor.G*255), int(backgroundColor.B*255),
-		m.GetBackgroundOpacity(userData))
-	detailColorString := fmt.Sprintf("rgba(0, 0, 0, %s)",
-		m.GetDetailOpacity(userData))
 
-	userTimeText := ""
-	if userData.Timezone != "" {
-		userLocation, err := time.LoadLocation(userData.Timezone)
-		if err == nil {
-			userTimeText = "<i class=\"fa fa-clock-o\" aria-hidden=\"true\"></i> " + time.Now().In(userLocation).Format(TimeAtUserFormat)
+	rankings = make([]Levels_Cache_Ranking_Item, 0)
+	for _, pair := range m.rankMapByExp(periodExp) {
+		if pair.Value <= 0 {
+			continue
 		}
+		rankings = append(rankings, Levels_Cache_Ranking_Item{
+			UserID:  pair.Key,
+			EXP:     pair.Value,
+			Level:   m.getLevelFromExp(guildID, lifetimeExp[pair.Key]),
+			Ranking: len(rankings) + 1,
+		})
 	}
+	return rankings, nil
+}
 
-	userBirthdayText := ""
-	isBirthday := false
-	if userData.Birthday != "" {
-		userLocation, err := time.LoadLocation("Etc/UTC")
-		if err == nil {
-			if userData.Timezone != "" {
-				userLocationUser, err := time.LoadLocation(userData.Timezone)
-				if err == nil {
-					userLocation = userLocationUser
-				}
-			}
-			birthdayTime, err := time.ParseInLocation(TimeBirthdayFormat, userData.Birthday, userLocation)
-			birthdayTime = birthdayTime.AddDate(time.Now().Year(), 0, 0)
-			if err == nil {
-				if time.Now().In(userLocation).Sub(birthdayTime).Hours() <= 23 && time.Now().In(userLocation).Sub(birthdayTime).Hours() > 0 {
-					isBirthday = true
-				}
-			}
-		}
-
-		userBirthdayText = "<i class=\"fa fa-birthday-cake\" aria-hidden=\"true\"></i> " + userData.Birthday
-		if isBirthday {
-			userBirthdayText = "<i class=\"fa fa-birthday-cake\" aria-hidden=\"true\"></i> Today!"
-		}
-	}
-
-	tempTemplateHtml := strings.Replace(htmlTemplateString, "{USER_USERNAME}", html.EscapeString(member.User.Username), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_NICKNAME}", html.EscapeString(member.Nick), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_AND_NICKNAME}", html.EscapeString(userAndNick), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_AVATAR_URL}", html.EscapeString(avatarUrl), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TITLE}", html.EscapeString(title), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BIO}", html.EscapeString(bio), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL}", strconv.Itoa(m.getLevelFromExp(levelThisServerUser.Exp)), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_RANK}", serverRank, -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL_PERCENT}", strconv.Itoa(m.getProgressToNextLevelFromExp(levelThisServerUser.Exp)), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_LEVEL}", strconv.Itoa(m.getLevelFromExp(totalExp)), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_RANK}", globalRank, -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BACKGROUND_URL}", m.GetProfileBackgroundUrl(userData.Background), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_REP}", strconv.Itoa(userData.Rep), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BADGES_HTML}", badgesHTML, -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BACKGROUND_COLOR}", html.EscapeString(backgroundColorString), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_ACCENT_COLOR}", "#"+m.GetAccentColor(userData), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_DETAIL_COLOR}", html.EscapeString(detailColorString), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TEXT_COLOR}", "#"+m.GetTextColor(userData), -1)
+// getDayExp returns the EXP every user gained on the guild on the UTC day of t so far
+func (m *Levels) getDayExp(guildID string, t time.Time) (dayExp map[string]int64, err error) {
+	listCursor, err := rethink.Table(models.LevelsExpHistoryTable).GetAllByIndex(
+		"guild_id_day", []interface{}{guildID, t.UTC().Format(levelsDayFormat)},
+	).Run(helpers.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	defer listCursor.Close()
 
-	if web == false { // privacy
-		tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TIME}", userTimeText, -1)
-		tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BIRTHDAY}", userBirthdayText, -1)
-	} else {
-		tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TIME}", "", -1)
-		tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BIRTHDAY}", "", -1)
+	var entries []models.LevelsExpHistoryEntry
+	err = listCursor.All(&entries)
+	if err != nil && err != rethink.ErrEmptyResult {
+		return nil, err
 	}
 
-	return tempTemplateHtml, nil
+	dayExp = make(map[string]int64)
+	for _, entry := range entries {
+		dayExp[entry.UserID] = entry.Exp
+	}
+	return dayExp, nil
 }
 
-func (m *Levels) GetProfile(member *discordgo.Member, guild *discordgo.Guild, gifP bool) ([]byte, string, error) {
-	tempTemplateHtml, err := m.GetProfileHTML(member, guild, false)
-	if err != nil {
-		return []byte{}, "", err
+func (m *Levels) sendPeriodLeaderboard(msg *discordgo.Message, guild *discordgo.Guild, period string) {
+	guildID := "global"
+	if guild != nil {
+		guildID = guild.ID
 	}
 
-	tempTemplatePath := cachePath + strconv.FormatInt(time.Now().UnixNano(), 10) + member.User.ID + ".html"
-	err = ioutil.WriteFile(tempTemplatePath, []byte(tempTemplateHtml), 0644)
+	rankings, err := m.GetPeriodRankings(guildID, period)
 	if err != nil {
-		return []byte{}, "", err
+		switch err {
+		case ErrLevelsNoActiveSeason:
+			_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.season-none", helpers.GetPrefixForServer(guildID)))
+		case ErrLevelsInvalidPeriod:
+			_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		default:
+			helpers.Relax(err)
+		}
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
 	}
 
-	start := time.Now()
-
-	cmdArgs := []string{
-		tempTemplatePath,
-		"--window-size=400/300",
-		"--stream-type=png",
-		"--timeout=20000",
-		"--p:disk-cache=true",
-		"--p:disk-cache-path=" + cachePath,
-		"--p:proxy-type=none",
-		"--p:ignore-ssl-errors=false",
-		"--p:ssl-protocol=any",
-		"--p:web-security=false",
-		"--p:debug=true",
-	}
-	// fmt.Println(webshotBinary, strings.Join(cmdArgs, " "))
-	imgCmd := exec.Command(webshotBinary, cmdArgs...)
-	imgCmd.Env = levelsEnv
-	imageBytes, err := imgCmd.Output()
-	if err != nil {
-		return []byte{}, "", err
+	if len(rankings) <= 0 {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.top-period-no-stats"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
 	}
 
-	elapsed := time.Since(start)
-	cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("took screenshot of profile in %s", elapsed.String()))
+	var title string
+	switch {
+	case guild == nil:
+		title = helpers.GetTextF("plugins.levels.top-period-global-embed-title", strings.Title(period))
+	case period == levelsPeriodSeason:
+		season, err := m.getActiveSeason(guildID)
+		helpers.Relax(err)
+		title = helpers.GetTextF("plugins.levels.top-period-embed-title", season.Name, guild.Name)
+	default:
+		title = helpers.GetTextF("plugins.levels.top-period-embed-title", strings.Title(period), guild.Name)
+	}
 
-	err = os.Remove(tempTemplatePath)
-	if err != nil {
-		return []byte{}, "", err
+	topEmbed := &discordgo.MessageEmbed{
+		Color:  0x0FADED,
+		Title:  title,
+		Fields: []*discordgo.MessageEmbedField{},
 	}
 
-	metrics.LevelImagesGenerated.Add(1)
+	displayRanking := 1
+	authorRank := "N/A"
+	var authorExp int64
+	for _, rankingItem := range rankings {
+		if rankingItem.UserID == msg.Author.ID {
+			authorRank = strconv.Itoa(rankingItem.Ranking)
+			authorExp = rankingItem.EXP
+		}
+		if displayRanking > 10 {
+			continue
+		}
 
-	avatarUrlGif := helpers.GetAvatarUrl(member.User)
-	if avatarUrlGif != "" {
-		avatarUrlGif = strings.Replace(avatarUrlGif, "size=1024", "size=128", -1)
-		if !strings.Contains(avatarUrlGif, "gif") {
-			avatarUrlGif = ""
+		var fullUsername string
+		if guild == nil {
+			currentUser, err := helpers.GetUser(rankingItem.UserID)
+			if err != nil {
+				continue
+			}
+			fullUsername = currentUser.Username
+		} else {
+			currentMember, err := helpers.GetGuildMember(guildID, rankingItem.UserID)
+			if err != nil {
+				continue
+			}
+			fullUsername = currentMember.User.Username
+			if currentMember.Nick != "" {
+				fullUsername += " ~ " + currentMember.Nick
+			}
 		}
+
+		topEmbed.Fields = append(topEmbed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%d. %s", rankingItem.Ranking, fullUsername),
+			Value:  fmt.Sprintf("EXP: %s", humanize.Comma(rankingItem.EXP)),
+			Inline: false,
+		})
+		displayRanking++
 	}
 
-	if avatarUrlGif != "" && gifP == true {
-		outGif := &gif.GIF{}
+	topEmbed.Fields = append(topEmbed.Fields, &discordgo.MessageEmbedField{
+		Name:   "Your Rank: " + authorRank,
+		Value:  fmt.Sprintf("EXP: %s", humanize.Comma(authorExp)),
+		Inline: false,
+	})
 
-		decodedFirstFrame, err := png.Decode(bytes.NewReader(imageBytes))
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
-		}
-		buf := bytes.Buffer{}
-		err = gif.Encode(&buf, decodedFirstFrame, nil)
+	_, err = helpers.SendEmbed(msg.ChannelID, topEmbed)
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (m *Levels) seasonsLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "levels").Error("The seasonsLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.seasonsLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(5 * time.Minute)
+
+		var entryBucket []models.LevelsSeasonEntry
+		listCursor, err := rethink.Table(models.LevelsSeasonsTable).Filter(
+			rethink.Row.Field("active").Eq(true).Or(rethink.Row.Field("rewards_pending").Eq(true)),
+		).Run(helpers.GetDB())
 		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
+			helpers.RelaxLog(err)
+			continue
 		}
-		firstFrame, err := gif.Decode(&buf)
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
+		err = listCursor.All(&entryBucket)
+		listCursor.Close()
+		if err != nil && err != rethink.ErrEmptyResult {
+			helpers.RelaxLog(err)
+			continue
 		}
 
-		avatarGifBytes, err := helpers.NetGetUAWithError(avatarUrlGif, helpers.DEFAULT_UA)
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
+		for _, season := range entryBucket {
+			if !season.Active {
+				m.applyPendingSeasonRewards(season)
+				continue
+			}
+			if season.EndsAt.IsZero() || time.Now().Before(season.EndsAt) {
+				continue
+			}
+
+			season, err = m.endSeason(season)
+			if err != nil {
+				helpers.RelaxLog(err)
+				continue
+			}
+
+			settings := helpers.GuildSettingsGetCached(season.GuildID)
+			if settings.LevelsAnnouncementsDestination == levelsAnnouncementsDestinationChannel && settings.LevelsAnnouncementsChannelID != "" {
+				for _, page := range helpers.Pagify(m.getSeasonResultsText(season), "\n") {
+					_, err = helpers.SendMessage(settings.LevelsAnnouncementsChannelID, page)
+					helpers.RelaxLog(err)
+				}
+			}
 		}
+	}
+}
 
-		avatarGif, err := gif.DecodeAll(bytes.NewReader(avatarGifBytes))
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
+// endSeason archives the results of the season and gives the reward role to the top members
+func (m *Levels) endSeason(season models.LevelsSeasonEntry) (models.LevelsSeasonEntry, error) {
+	// seasons end at UTC midnight, don't count the day that just started
+	to := time.Now()
+	if !season.EndsAt.IsZero() && season.EndsAt.Before(to) {
+		to = season.EndsAt.Add(-time.Nanosecond)
+	}
+	rankings, err := m.getRankingsBetween(season.GuildID, season.StartedAt, to, season.FirstDayExp)
+	if err != nil {
+		return season, err
+	}
+
+	season.Results = make([]models.LevelsSeasonResult, 0)
+	for _, rankingItem := range rankings {
+		if len(season.Results) >= levelsSeasonArchiveSize {
+			break
 		}
+		season.Results = append(season.Results, models.LevelsSeasonResult{
+			UserID:  rankingItem.UserID,
+			Exp:     rankingItem.EXP,
+			Ranking: rankingItem.Ranking,
+		})
+	}
 
-		fullRect := image.Rect(0, 0, 400, 300)
-		pm := image.NewPaletted(fullRect, nil)
-		q := gogif.MedianCutQuantizer{NumColor: 256}
-		q.Quantize(pm, fullRect, decodedFirstFrame, image.ZP)
-		draw.FloydSteinberg.Draw(pm, fullRect, firstFrame, image.ZP)
+	settings := helpers.GuildSettingsGetCached(season.GuildID)
+	season.RewardRoleID = settings.LevelsSeasonRewardRoleID
+	season.RewardTopN = settings.LevelsSeasonRewardTopN
+	season.RewardsPending = true
+	season.Active = false
+	m.clearPeriodRankingsCache(season.GuildID)
+	season.EndedAt = time.Now()
 
-		outGif.Image = append(outGif.Image, pm)
-		outGif.Delay = append(outGif.Delay, avatarGif.Delay[0])
+	err = m.setSeason(season)
+	if err != nil {
+		return season, err
+	}
 
-		resizeRect := image.Rect(0, 0, 128, 128)
-		cutImage := image.NewRGBA(resizeRect)
-		resizedRect := image.Rect(4, 64, 4+80, 64+80)
+	season = m.applyPendingSeasonRewards(season)
 
-		for i, avatarGifFrame := range avatarGif.Image {
-			resizedImage := resize.Resize(80, 80, avatarGifFrame, resize.NearestNeighbor)
-			draw.DrawMask(
-				cutImage, resizedImage.Bounds(), resizedImage, image.ZP,
-				&circle{image.Pt(40, 40), 40}, image.ZP, draw.Over)
-			avatarGifFrame.Palette = append(avatarGifFrame.Palette, image.Transparent)
-			paletteHasTransparency := false
-			newPalette := make([]color.Color, 0)
-			for i, color := range avatarGifFrame.Palette {
-				if color == image.Transparent {
-					paletteHasTransparency = true
-				}
-				newPalette = append(newPalette, color)
-				if i == 254 && paletteHasTransparency == false {
-					newPalette = append(newPalette, image.Transparent)
+	go func() {
+		defer helpers.Recover()
+
+		badges, err := m.getBadgesWithRules(season.GuildID)
+		helpers.Relax(err)
+		for _, badge := range badges {
+			for _, rule := range badge.Rules {
+				if rule.Type == levelsBadgeRuleSeason && rule.TargetID == season.ID {
+					helpers.RelaxLog(m.updateBadgeRuleUsers(badge))
 					break
 				}
 			}
-			pm = image.NewPaletted(resizedRect, newPalette)
-			draw.FloydSteinberg.Draw(pm, resizedRect, cutImage, image.ZP)
-			outGif.Image = append(outGif.Image, pm)
-			outGif.Delay = append(outGif.Delay, avatarGif.Delay[i])
+		}
+	}()
+	return season, nil
+}
+
+// applyPendingSeasonRewards applies the rewards of the season and marks them as applied,
+// they stay pending if not all members of the guild are cached yet and get retried by the seasons loop
+func (m *Levels) applyPendingSeasonRewards(season models.LevelsSeasonEntry) models.LevelsSeasonEntry {
+	applied, err := m.applySeasonRewards(season)
+	if err != nil {
+		cache.GetLogger().WithField("module", "levels").Error(fmt.Sprintf("failed to apply season rewards for season %s: %s", season.ID, err.Error()))
+	}
+	if !applied {
+		return season
+	}
+
+	season.RewardsPending = false
+	err = m.setSeason(season)
+	helpers.RelaxLog(err)
+	return season
+}
+
+// applySeasonRewards gives the reward role to the top members of the season and removes it from everyone else
+func (m *Levels) applySeasonRewards(season models.LevelsSeasonEntry) (applied bool, err error) {
+	if season.RewardRoleID == "" || season.RewardTopN <= 0 {
+		return true, nil
+	}
+
+	guild, err := helpers.GetGuild(season.GuildID)
+	if err != nil {
+		// don't retry forever if the bot left the guild
+		return true, err
+	}
+	// members missing from the state would lose or never get the reward, try again once all members have been received
+	if len(guild.Members) < guild.MemberCount {
+		cache.GetLogger().WithField("module", "levels").WithField("GuildID", guild.ID).Info(fmt.Sprintf(
+			"deferring rewards for season #%s because only %d of %d members are cached", season.ID, len(guild.Members), guild.MemberCount))
+		return false, nil
+	}
+
+	winners := make(map[string]bool)
+	for _, result := range season.Results {
+		if result.Ranking <= season.RewardTopN {
+			winners[result.UserID] = true
+		}
+	}
+
+	session := cache.GetSession()
+	for _, member := range guild.Members {
+		hasRole := false
+		for _, roleID := range member.Roles {
+			if roleID == season.RewardRoleID {
+				hasRole = true
+			}
 		}
 
-		outGif.LoopCount = -1
+		var errRole error
+		if hasRole && !winners[member.User.ID] {
+			errRole = session.GuildMemberRoleRemove(guild.ID, member.User.ID, season.RewardRoleID)
+		} else if !hasRole && winners[member.User.ID] {
+			errRole = session.GuildMemberRoleAdd(guild.ID, member.User.ID, season.RewardRoleID)
+		}
+		if errRole != nil {
+			err = errRole
+		}
+	}
+	return true, err
+}
 
-		buf = bytes.Buffer{}
-		err = gif.EncodeAll(&buf, outGif)
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
+func (m *Levels) getSeasonResultsText(season models.LevelsSeasonEntry) (text string) {
+	text = helpers.GetTextF("plugins.levels.season-results-title", season.Name,
+		season.StartedAt.UTC().Format(time.ANSIC), season.EndedAt.UTC().Format(time.ANSIC)) + "\n"
+	if len(season.Results) <= 0 {
+		return text + helpers.GetText("plugins.levels.top-period-no-stats")
+	}
+
+	for _, result := range season.Results {
+		if result.Ranking > levelsSeasonResultsShown {
+			break
 		}
-		return buf.Bytes(), "gif", nil
+		username := "N/A"
+		user, err := helpers.GetUser(result.UserID)
+		if err == nil {
+			username = user.Username + "#" + user.Discriminator
+		}
+		rewardText := ""
+		if season.RewardRoleID != "" && result.Ranking <= season.RewardTopN {
+			rewardText = " :trophy:"
+		}
+		text += fmt.Sprintf("%d. %s %s EXP%s\n", result.Ranking, username, humanize.Comma(result.Exp), rewardText)
 	}
-ReturnImageBytes:
-	return imageBytes, "png", nil
+	return text
 }
 
-type circle struct {
-	p image.Point
-	r int
+func (m *Levels) getActiveSeason(guildID string) (season models.LevelsSeasonEntry, err error) {
+	listCursor, err := rethink.Table(models.LevelsSeasonsTable).GetAllByIndex(
+		"guild_id", guildID,
+	).Filter(
+		rethink.Row.Field("active").Eq(true),
+	).Run(helpers.GetDB())
+	if err != nil {
+		return season, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&season)
+	if err == rethink.ErrEmptyResult {
+		return season, ErrLevelsNoActiveSeason
+	}
+	return season, err
 }
 
-func (c *circle) ColorModel() color.Model {
-	return color.AlphaModel
+func (m *Levels) getSeasons(guildID string) (seasons []models.LevelsSeasonEntry, err error) {
+	listCursor, err := rethink.Table(models.LevelsSeasonsTable).GetAllByIndex(
+		"guild_id", guildID,
+	).OrderBy(rethink.Desc("started_at")).Run(helpers.GetDB())
+	if err != nil {
+		return seasons, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&seasons)
+	if err == rethink.ErrEmptyResult {
+		err = nil
+	}
+	return seasons, err
 }
 
-func (c *circle) Bounds() image.Rectangle {
-	return image.Rect(c.p.X-c.r, c.p.Y-c.r, c.p.X+c.r, c.p.Y+c.r)
+func (m *Levels) createSeason(season models.LevelsSeasonEntry) (id string, err error) {
+	inserted, err := rethink.Table(models.LevelsSeasonsTable).Insert(season).RunWrite(helpers.GetDB())
+	if err != nil {
+		return "", err
+	}
+	return inserted.GeneratedKeys[0], nil
 }
 
-func (c *circle) At(x, y int) color.Color {
-	xx, yy, rr := float64(x-c.p.X)+0.5, float64(y-c.p.Y)+0.5, float64(c.r)
-	if xx*xx+yy*yy < rr*rr {
-		return color.Alpha{255}
+func (m *Levels) getSeason(seasonID string) (season models.LevelsSeasonEntry, err error) {
+	listCursor, err := rethink.Table(models.LevelsSeasonsTable).Get(seasonID).Run(helpers.GetDB())
+	if err != nil {
+		return season, err
 	}
-	return color.Alpha{0}
+	defer listCursor.Close()
+	err = listCursor.One(&season)
+	return season, err
 }
 
-func (m *Levels) GetBackgroundColor(userUserdata DB_Profile_Userdata) string {
-	if userUserdata.BackgroundColor != "" {
-		return userUserdata.BackgroundColor
-	} else {
-		return "000000"
+func (m *Levels) setSeason(season models.LevelsSeasonEntry) (err error) {
+	if season.ID == "" {
+		return errors.New("empty season submitted")
 	}
+	_, err = rethink.Table(models.LevelsSeasonsTable).Get(season.ID).Update(season).RunWrite(helpers.GetDB())
+	return err
 }
 
-func (m *Levels) GetAccentColor(userUserdata DB_Profile_Userdata) string {
-	if userUserdata.AccentColor != "" {
-		return userUserdata.AccentColor
-	} else {
-		return "46d42e"
+// levelsRepRanking is the amount of rep a user received on a server
+type levelsRepRanking struct {
+	UserID string `gorethink:"group"`
+	Count  int    `gorethink:"reduction"`
+}
+
+// getRepCooldown returns the time users have to wait between giving rep on the given server, never below the default
+func (m *Levels) getRepCooldown(guildID string) time.Duration {
+	hours := helpers.GuildSettingsGetCached(guildID).LevelsRepCooldownHours
+	if hours < levelsRepCooldownDefaultHours {
+		hours = levelsRepCooldownDefaultHours
 	}
+	return time.Duration(hours) * time.Hour
 }
 
-func (m *Levels) GetTextColor(userUserdata DB_Profile_Userdata) string {
-	if userUserdata.TextColor != "" {
-		return userUserdata.TextColor
-	} else {
-		return "ffffff"
+// getNextRepAt returns when the user can give rep on the given server again,
+// the default cooldown applies to rep given on any server, the cooldown of the server to the last rep given on it
+func (m *Levels) getNextRepAt(guildID string, userData DB_Profile_Userdata) (nextRepAt time.Time, err error) {
+	nextRepAt = userData.LastRepped.Add(levelsRepCooldownDefaultHours * time.Hour)
+
+	lastEntries, err := m.getRepEntries("giver_user_id", userData.UserID, guildID, 1)
+	if err != nil {
+		return nextRepAt, err
 	}
+	if len(lastEntries) > 0 {
+		if guildNextRepAt := lastEntries[0].GivenAt.Add(m.getRepCooldown(guildID)); guildNextRepAt.After(nextRepAt) {
+			nextRepAt = guildNextRepAt
+		}
+	}
+	return nextRepAt, nil
 }
 
-func (m *Levels) GetBackgroundOpacity(userUserdata DB_Profile_Userdata) string {
-	if userUserdata.BackgroundOpacity != "" {
-		return userUserdata.BackgroundOpacity
-	} else {
-		return "0.5"
+// getRepRankings returns the users who received the most rep on the given server
+func (m *Levels) getRepRankings(guildID string) (rankings []levelsRepRanking, err error) {
+	listCursor, err := rethink.Table(models.LevelsRepTable).GetAllByIndex(
+		"guild_id", guildID,
+	).Filter(
+		rethink.Row.Field("revoked").Eq(false),
+	).Group("receiver_user_id").Count().Ungroup().OrderBy(
+		rethink.Desc("reduction"),
+	).Limit(levelsRepTopSize).Run(helpers.GetDB())
+	if err != nil {
+		return rankings, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&rankings)
+	if err == rethink.ErrEmptyResult {
+		err = nil
 	}
+	return rankings, err
 }
 
-func (m *Levels) GetDetailOpacity(userUserdata DB_Profile_Userdata) string {
-	if userUserdata.DetailOpacity != "" {
-		return userUserdata.DetailOpacity
-	} else {
-		return "0.5"
+// getRepEntries returns the newest rep entries matching the given index on the given server,
+// an empty guildID returns the entries of all servers, limit <= 0 returns all entries
+func (m *Levels) getRepEntries(index, userID, guildID string, limit int) (entries []models.LevelsRepEntry, err error) {
+	query := rethink.Table(models.LevelsRepTable).GetAllByIndex(index, userID)
+	if guildID != "" {
+		query = query.Filter(rethink.Row.Field("guild_id").Eq(guildID))
+	}
+	query = query.OrderBy(rethink.Desc("given_at"))
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	listCursor, err := query.Run(helpers.GetDB())
+	if err != nil {
+		return entries, err
 	}
+	defer listCursor.Close()
+	err = listCursor.All(&entries)
+	if err == rethink.ErrEmptyResult {
+		err = nil
+	}
+	return entries, err
 }
 
-func (m *Levels) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
-	go m.ProcessMessage(msg, session)
+func (m *Levels) getRepEntry(id string) (entry models.LevelsRepEntry, err error) {
+	listCursor, err := rethink.Table(models.LevelsRepTable).Get(id).Run(helpers.GetDB())
+	if err != nil {
+		return entry, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&entry)
+	return entry, err
 }
 
-func (m *Levels) ProcessMessage(msg *discordgo.Message, session *discordgo.Session) {
-	channel, err := helpers.GetChannel(msg.ChannelID)
-	helpers.Relax(err)
-	// ignore temporary ignored guilds
-	for _, temporaryIgnoredGuild := range temporaryIgnoredGuilds {
-		if temporaryIgnoredGuild == channel.GuildID {
-			return
-		}
+// revokeRepEntry marks the rep entry as revoked and removes the rep from the receiver
+func (m *Levels) revokeRepEntry(entry models.LevelsRepEntry, revokedByUserID string) (err error) {
+	if entry.ID == "" {
+		return errors.New("empty rep entry submitted")
 	}
-	// ignore bot messages
-	if msg.Author.Bot == true {
-		return
+
+	entry.Revoked = true
+	entry.RevokedByUserID = revokedByUserID
+	entry.RevokedAt = time.Now()
+	_, err = rethink.Table(models.LevelsRepTable).Get(entry.ID).Update(entry).RunWrite(helpers.GetDB())
+	if err != nil {
+		return err
 	}
-	// ignore commands
-	prefix := helpers.GetPrefixForServer(channel.GuildID)
-	if prefix != "" {
-		if strings.HasPrefix(msg.Content, prefix) {
-			return
+
+	receiverUserData := m.GetUserUserdata(&discordgo.User{ID: entry.ReceiverUserID})
+	if receiverUserData.Rep > 0 {
+		receiverUserData.Rep -= 1
+		m.setUserUserdata(receiverUserData)
+	}
+
+	go m.checkBadgeRulesForUser(entry.GuildID, entry.ReceiverUserID)
+	return nil
+}
+
+// isRepRing returns true if both users repeatedly gave each other rep recently
+func (m *Levels) isRepRing(giverUserID, receiverUserID string) (isRing bool, err error) {
+	countRep := func(fromUserID, toUserID string) (count int, err error) {
+		listCursor, err := rethink.Table(models.LevelsRepTable).GetAllByIndex(
+			"giver_user_id", fromUserID,
+		).Filter(
+			rethink.Row.Field("receiver_user_id").Eq(toUserID).And(
+				rethink.Row.Field("revoked").Eq(false)).And(
+				rethink.Row.Field("given_at").Gt(time.Now().Add(-levelsRepRingWindow))),
+		).Count().Run(helpers.GetDB())
+		if err != nil {
+			return 0, err
 		}
+		defer listCursor.Close()
+		err = listCursor.One(&count)
+		return count, err
 	}
 
-	settings := helpers.GuildSettingsGetCached(channel.GuildID)
-	for _, ignoredChannelID := range settings.LevelsIgnoredChannelIDs {
-		if ignoredChannelID == msg.ChannelID {
+	given, err := countRep(giverUserID, receiverUserID)
+	if err != nil || given < levelsRepRingLimit {
+		return false, err
+	}
+	received, err := countRep(receiverUserID, giverUserID)
+	if err != nil {
+		return false, err
+	}
+	return received >= levelsRepRingLimit, nil
+}
+
+// getRepReasonText prevents mentions in rep reasons from pinging anyone
+func (m *Levels) getRepReasonText(reason string) string {
+	return strings.Replace(reason, "@", "@\u200b", -1)
+}
+
+func (m *Levels) getRepEntryText(entry models.LevelsRepEntry, otherUserID string) (text string) {
+	username := "N/A"
+	if user, err := helpers.GetUser(otherUserID); err == nil {
+		username = user.Username + "#" + user.Discriminator
+	}
+	guildName := "N/A"
+	if guild, err := helpers.GetGuild(entry.GuildID); err == nil {
+		guildName = guild.Name
+	}
+
+	text = helpers.GetTextF("plugins.levels.rep-history-entry",
+		entry.ID, username, guildName, humanize.Time(entry.GivenAt))
+	if entry.Reason != "" {
+		text += ": " + m.getRepReasonText(entry.Reason)
+	}
+	if entry.Revoked {
+		text = "~~" + text + "~~"
+	}
+	return text
+}
+
+// levelsImportEntry is the EXP a user should get from an import
+type levelsImportEntry struct {
+	UserID string
+	Exp    int64
+}
+
+// parseLevelsImport parses a CSV or JSON export of another leveling bot
+// values without a column or field name are read as levels if valuesAreLevels is set, as EXP otherwise
+func (m *Levels) parseLevelsImport(guildID string, data []byte, valuesAreLevels bool) (entries []levelsImportEntry, err error) {
+	data = bytes.TrimSpace(data)
+	expByUser := make(map[string]int64)
+	order := make([]string, 0)
+	var tooHighUserID string
+	add := func(userID string, value int64, isLevel bool) {
+		if !levelsImportIDRegex.MatchString(userID) || value < 0 {
 			return
 		}
-	}
-	for _, ignoredUserID := range settings.LevelsIgnoredUserIDs {
-		for ignoredUserID == msg.Author.ID {
+		if isLevel {
+			if value > levelsImportMaxLevel {
+				return
+			}
+			value = m.getExpForLevel(guildID, int(value))
+		}
+		if value > levelsExpMax {
+			tooHighUserID = userID
 			return
 		}
+		if _, ok := expByUser[userID]; !ok {
+			order = append(order, userID)
+		}
+		expByUser[userID] = value
 	}
 
-	// check if bucket is empty
-	if !m.BucketHasKeys(channel.GuildID + msg.Author.ID) {
-		//m.BucketSet(channel.GuildID+msg.Author.ID, -1)
-		return
+	if len(data) > 0 && (data[0] == '[' || data[0] == '{') {
+		err = m.parseLevelsImportJSON(data, valuesAreLevels, add)
+	} else {
+		err = m.parseLevelsImportCSV(data, valuesAreLevels, add)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tooHighUserID != "" {
+		return nil, fmt.Errorf("the value for user #%s is higher than the maximum EXP of %d", tooHighUserID, int64(levelsExpMax))
 	}
 
-	err = m.BucketDrain(1, channel.GuildID+msg.Author.ID)
-	helpers.Relax(err)
-
-	expStack.Push(ProcessExpInfo{UserID: msg.Author.ID, GuildID: channel.GuildID})
+	entries = make([]levelsImportEntry, 0)
+	for _, userID := range order {
+		entries = append(entries, levelsImportEntry{UserID: userID, Exp: expByUser[userID]})
+	}
+	return entries, nil
 }
 
-func (m *Levels) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
-	go func() {
-		defer helpers.Recover()
+func (m *Levels) parseLevelsImportJSON(data []byte, valuesAreLevels bool, add func(userID string, value int64, isLevel bool)) (err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw interface{}
+	err = decoder.Decode(&raw)
+	if err != nil {
+		return err
+	}
 
-		err := m.applyLevelsRoles(member.GuildID, member.User.ID, m.GetLevelForUser(member.User.ID, member.GuildID))
-		if err != nil {
-			if errD, ok := err.(*discordgo.RESTError); !ok || errD.Message.Code != discordgo.ErrCodeUnknownMember {
-				helpers.RelaxLog(err)
+	var items []interface{}
+	switch rawData := raw.(type) {
+	case []interface{}:
+		items = rawData
+	case map[string]interface{}:
+		for _, key := range []string{"players", "users", "members", "leaderboard", "rankings", "data"} {
+			if list, ok := rawData[key].([]interface{}); ok {
+				items = list
+				break
 			}
 		}
-	}()
+		if items == nil {
+			// a map of user ids to values
+			for userID, value := range rawData {
+				if number, ok := m.levelsImportNumber(value); ok {
+					add(userID, number, valuesAreLevels)
+				}
+			}
+			return nil
+		}
+	}
 
-}
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		normalizedFields := make(map[string]interface{})
+		for key, value := range fields {
+			normalizedFields[m.levelsImportNormalizeKey(key)] = value
+		}
 
-func (m *Levels) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+		var userID string
+		for _, key := range levelsImportIDKeys {
+			if value, ok := normalizedFields[key]; ok {
+				// some bots nest the user object
+				if user, ok := value.(map[string]interface{}); ok {
+					value = user["id"]
+				}
+				userID = strings.TrimSpace(fmt.Sprint(value))
+				break
+			}
+		}
 
+		found := false
+		for _, key := range levelsImportExpKeys {
+			if number, ok := m.levelsImportNumber(normalizedFields[key]); ok {
+				add(userID, number, false)
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		for _, key := range levelsImportLevelKeys {
+			if number, ok := m.levelsImportNumber(normalizedFields[key]); ok {
+				add(userID, number, true)
+				break
+			}
+		}
+	}
+	return nil
 }
 
-func (m *Levels) getLevelsServerUserOrCreateNew(guildid string, userid string) DB_Levels_ServerUser {
-	var levelsServerUser DB_Levels_ServerUser
-	listCursor, err := rethink.Table("levels_serverusers").GetAllByIndex(
-		"userid", userid,
-	).Filter(
-		rethink.Row.Field("guildid").Eq(guildid),
-	).Run(helpers.GetDB())
-	helpers.Relax(err)
-	defer listCursor.Close()
-	err = listCursor.One(&levelsServerUser)
+func (m *Levels) parseLevelsImportCSV(data []byte, valuesAreLevels bool, add func(userID string, value int64, isLevel bool)) (err error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if bytes.Count(data, []byte(";")) > bytes.Count(data, []byte(",")) {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) <= 0 {
+		return nil
+	}
 
-	if err == rethink.ErrEmptyResult {
-		insert := rethink.Table("levels_serverusers").Insert(DB_Levels_ServerUser{GuildID: guildid, UserID: userid})
-		_, e := insert.RunWrite(helpers.GetDB())
-		if e != nil {
-			panic(e)
-		} else {
-			return m.getLevelsServerUserOrCreateNew(guildid, userid)
+	// without a header the first column is the user id and the second the value
+	idColumn, valueColumn := 0, 1
+	valueIsLevel := valuesAreLevels
+	if len(records[0]) > 0 && !levelsImportIDRegex.MatchString(strings.TrimSpace(records[0][0])) {
+		header := records[0]
+		records = records[1:]
+		idColumn = m.levelsImportFindColumn(header, levelsImportIDKeys)
+		valueColumn = m.levelsImportFindColumn(header, levelsImportExpKeys)
+		valueIsLevel = false
+		if valueColumn < 0 {
+			valueColumn = m.levelsImportFindColumn(header, levelsImportLevelKeys)
+			valueIsLevel = true
+		}
+		if idColumn < 0 || valueColumn < 0 {
+			return errors.New("unable to find the user id and exp or level columns")
 		}
-	} else if err != nil {
-		panic(err)
 	}
 
-	return levelsServerUser
+	for _, record := range records {
+		if len(record) <= idColumn || len(record) <= valueColumn {
+			continue
+		}
+		if number, ok := m.levelsImportNumber(record[valueColumn]); ok {
+			add(strings.TrimSpace(record[idColumn]), number, valueIsLevel)
+		}
+	}
+	return nil
 }
 
-func (m *Levels) setLevelsServerUser(entry DB_Levels_ServerUser) {
-	_, err := rethink.Table("levels_serverusers").Get(entry.ID).Update(entry).Run(helpers.GetDB())
-	helpers.Relax(err)
+func (m *Levels) levelsImportFindColumn(header []string, keys []string) int {
+	for _, key := range keys {
+		for i, column := range header {
+			if m.levelsImportNormalizeKey(column) == key {
+				return i
+			}
+		}
+	}
+	return -1
 }
 
-func (m *Levels) getLevelFromExp(exp int64) int {
-	calculatedLevel := 0.1 * math.Sqrt(float64(exp))
-
-	return int(math.Floor(calculatedLevel))
+func (m *Levels) levelsImportNormalizeKey(key string) string {
+	return strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(key)))
 }
 
-func (m *Levels) getExpForLevel(level int) int64 {
-	if level <= 0 {
-		return 0
+// levelsImportNumber reads a JSON number or a number in a string, ignoring thousands separators and decimals
+func (m *Levels) levelsImportNumber(value interface{}) (number int64, ok bool) {
+	var text string
+	switch typedValue := value.(type) {
+	case json.Number:
+		text = typedValue.String()
+	case string:
+		text = typedValue
+	default:
+		return 0, false
 	}
 
-	calculatedExp := math.Pow(float64(level)/0.1, 2)
-	return int64(calculatedExp)
-}
-
-func (m *Levels) getProgressToNextLevelFromExp(exp int64) int {
-	expLevelCurrently := exp - m.getExpForLevel(m.getLevelFromExp(exp))
-	expLevelNext := m.getExpForLevel(m.getLevelFromExp(exp)+1) - m.getExpForLevel(m.getLevelFromExp(exp))
-	return int(expLevelCurrently / (expLevelNext / 100))
-}
-
-func (m *Levels) getRandomExpForMessage() int64 {
-	min := 10
-	max := 15
-	rand.Seed(time.Now().Unix())
-	return int64(rand.Intn(max-min) + min)
+	text = strings.Replace(strings.TrimSpace(text), ",", "", -1)
+	if floatNumber, err := strconv.ParseFloat(text, 64); err == nil {
+		return int64(floatNumber), true
+	}
+	return 0, false
 }
 
 func (m *Levels) rankMapByExp(exp map[string]int64) PairList {
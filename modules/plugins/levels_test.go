@@ -0,0 +1,248 @@
+package plugins
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var levelsTestCurves = []levelsCurve{
+	{Type: levelsCurveQuadratic},
+	{Type: levelsCurveLinear},
+	{Type: levelsCurveExponential},
+	{Type: levelsCurveCustom, Thresholds: []int64{100, 250, 500}},
+	{Type: levelsCurveCustom, Thresholds: []int64{1000}},
+}
+
+func TestLevelsCurveRoundTrip(t *testing.T) {
+	for _, curve := range levelsTestCurves {
+		for level := 1; level <= 300; level++ {
+			exp := curve.expForLevel(level)
+			if exp > levelsExpMax {
+				break
+			}
+			if got := curve.levelFromExp(exp); got != level {
+				t.Fatalf("levelsCurve{%s}.levelFromExp(%d) = %d, want %d", curve.Type, exp, got, level)
+			}
+			if got := curve.levelFromExp(exp - 1); got != level-1 {
+				t.Fatalf("levelsCurve{%s}.levelFromExp(%d) = %d, want %d", curve.Type, exp-1, got, level-1)
+			}
+		}
+	}
+}
+
+func TestLevelsCurveExpForLevelIncreases(t *testing.T) {
+	for _, curve := range levelsTestCurves {
+		previous := curve.expForLevel(0)
+		for level := 1; level <= levelsImportMaxLevel; level++ {
+			exp := curve.expForLevel(level)
+			if exp < previous || (exp == previous && exp != math.MaxInt64) {
+				t.Fatalf("levelsCurve{%s}.expForLevel(%d) = %d, not above level %d with %d", curve.Type, level, exp, level-1, previous)
+			}
+			if exp > levelsExpMax && exp != math.MaxInt64 {
+				t.Fatalf("levelsCurve{%s}.expForLevel(%d) = %d, above levelsExpMax but reachable", curve.Type, level, exp)
+			}
+			previous = exp
+		}
+	}
+}
+
+func TestLevelsCurveMaxExp(t *testing.T) {
+	for _, curve := range levelsTestCurves {
+		for _, exp := range []int64{levelsExpMax - 1, levelsExpMax, levelsExpMax + 1, math.MaxInt64} {
+			level := curve.levelFromExp(exp)
+			if curve.expForLevel(level) > levelsExpMax {
+				t.Fatalf("levelsCurve{%s}.levelFromExp(%d) = %d, which needs more than levelsExpMax", curve.Type, exp, level)
+			}
+			if curve.expForLevel(level+1) <= levelsExpMax && curve.expForLevel(level+1) <= exp {
+				t.Fatalf("levelsCurve{%s}.levelFromExp(%d) = %d, but level %d is reachable", curve.Type, exp, level, level+1)
+			}
+		}
+	}
+}
+
+func TestLevelsAddExp(t *testing.T) {
+	tests := []struct {
+		exp  int64
+		add  int64
+		want int64
+	}{
+		{0, 10, 10},
+		{100, 0, 100},
+		{levelsExpMax - 10, 5, levelsExpMax - 5},
+		{levelsExpMax - 10, 10, levelsExpMax},
+		{levelsExpMax - 10, 11, levelsExpMax},
+		{levelsExpMax, math.MaxInt64, levelsExpMax},
+		{math.MaxInt64, 1, levelsExpMax},
+	}
+
+	for _, test := range tests {
+		if got := levelsAddExp(test.exp, test.add); got != test.want {
+			t.Errorf("levelsAddExp(%d, %d) = %d, want %d", test.exp, test.add, got, test.want)
+		}
+	}
+}
+
+func TestLevelsAntiFarmingSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"hello world", "hello world", 1, 1},
+		{"", "", 1, 1},
+		{"a", "b", 0, 0},
+		{"", "hello", 0, 0},
+		{"abc", "xyz", 0, 0},
+		{"hello world", "hello world!", 0.9, 0.99},
+		{"good morning everyone", "good morning everybody", 0.7, 0.9},
+		{"good morning everyone", "what are you playing right now", 0, 0.2},
+		{"hahahahaha", "hahahaha", 0.8, 0.95},
+		{"안녕하세요 여러분", "안녕하세요 여러분!", 0.85, 0.99},
+	}
+
+	for _, test := range tests {
+		got := levelsAntiFarmingSimilarity(test.a, test.b)
+		if got < test.min || got > test.max {
+			t.Errorf("levelsAntiFarmingSimilarity(%q, %q) = %f, want between %f and %f", test.a, test.b, got, test.min, test.max)
+		}
+		if reversed := levelsAntiFarmingSimilarity(test.b, test.a); reversed != got {
+			t.Errorf("levelsAntiFarmingSimilarity(%q, %q) = %f, but %f the other way around", test.a, test.b, got, reversed)
+		}
+	}
+
+	long := strings.Repeat("spam ", levelsAntiFarmingSimilarityMaxLength)
+	if got := levelsAntiFarmingSimilarity(long, long+"!"); got != 1 {
+		t.Errorf("levelsAntiFarmingSimilarity() = %f for texts only differing after the max length, want 1", got)
+	}
+}
+
+// levelsTestChannelHistory returns a channel history with one message per character of authors,
+// the last message is sent at sentAt and each message before it interval earlier
+func levelsTestChannelHistory(authors string, sentAt time.Time, interval time.Duration) (channelHistory []levelsAntiFarmingChannelMessage) {
+	for i, author := range authors {
+		channelHistory = append(channelHistory, levelsAntiFarmingChannelMessage{
+			AuthorID: string(author),
+			SentAt:   sentAt.Add(-time.Duration(len(authors)-1-i) * interval),
+		})
+	}
+	return channelHistory
+}
+
+func TestLevelsAntiFarmingIsConversationless(t *testing.T) {
+	tests := []struct {
+		authors              string
+		sentAt               time.Time
+		userID               string
+		wantConversationless bool
+		wantAlternating      bool
+	}{
+		{"AAAAAAA", time.Now(), "A", false, false},
+		{"AAAAAAAA", time.Now(), "A", true, false},
+		{"AAAAAAAA", time.Now(), "B", false, false},
+		{"ABABABAB", time.Now(), "A", true, true},
+		{"ABABABAB", time.Now(), "B", true, true},
+		{"ABABABAB", time.Now(), "C", false, false},
+		{"AABBABAB", time.Now(), "A", false, false},
+		{"ABABABBA", time.Now(), "A", false, false},
+		{"ABCABCAB", time.Now(), "A", false, false},
+		{"AAAAAAAA", time.Now().Add(-levelsAntiFarmingConversationWindow - time.Second), "A", false, false},
+	}
+
+	for _, test := range tests {
+		conversationless, alternating := levelsAntiFarmingIsConversationless(levelsTestChannelHistory(test.authors, test.sentAt, 0), test.userID)
+		if conversationless != test.wantConversationless || alternating != test.wantAlternating {
+			t.Errorf("levelsAntiFarmingIsConversationless(%s, %s) = %t, %t, want %t, %t", test.authors, test.userID,
+				conversationless, alternating, test.wantConversationless, test.wantAlternating)
+		}
+	}
+}
+
+func TestLevelsAntiFarmingIsFastPaced(t *testing.T) {
+	tests := []struct {
+		authors  string
+		interval time.Duration
+		want     bool
+	}{
+		{"ABABABA", 0, false},
+		{"ABABABAB", 5 * time.Second, true},
+		{"ABABABAB", 15 * time.Second, false},
+		{"ABABABABAB", 5 * time.Second, true},
+	}
+
+	for _, test := range tests {
+		if got := levelsAntiFarmingIsFastPaced(levelsTestChannelHistory(test.authors, time.Now(), test.interval)); got != test.want {
+			t.Errorf("levelsAntiFarmingIsFastPaced(%s, %s) = %t, want %t", test.authors, test.interval, got, test.want)
+		}
+	}
+}
+
+func TestScoreMessageQuality(t *testing.T) {
+	m := &Levels{}
+	tests := []struct {
+		strictness  string
+		history     string // authors of the recent messages in the channel, the author of the message is A
+		interval    time.Duration
+		content     string
+		attachment  bool
+		wantQuality float64
+		wantSignals []string
+	}{
+		{levelsAntiFarmingHigh, "", 0, "did anyone watch the stream yesterday?", false, 1, nil},
+		{levelsAntiFarmingLow, "", 0, "k", false, 0.5, []string{levelsAntiFarmingSignalShort}},
+		{levelsAntiFarmingMedium, "", 0, "k", false, 0.25, []string{levelsAntiFarmingSignalShort}},
+		{levelsAntiFarmingHigh, "", 0, "k", false, 0, []string{levelsAntiFarmingSignalShort}},
+		{levelsAntiFarmingMedium, "", 0, "k", true, 1, nil},
+		{levelsAntiFarmingMedium, "", 0, "<:blobwave:317048219098021888>", false, 0.25, []string{levelsAntiFarmingSignalShort}},
+		{levelsAntiFarmingMedium, "", 0, "aaaaaaaaaaaaaaaa", false, 0.25, []string{levelsAntiFarmingSignalRepetitive}},
+		{levelsAntiFarmingMedium, "", 0, "ㅋㅋ", false, 1, nil},
+		{levelsAntiFarmingLow, "", 0, "!rank", false, 1, nil},
+		{levelsAntiFarmingMedium, "", 0, "?what", false, 1, nil},
+		{levelsAntiFarmingHigh, "", 0, "!daily reward", false, 0, []string{levelsAntiFarmingSignalCommand}},
+		{levelsAntiFarmingHigh, "", 0, "> quoting what you said earlier", false, 1, nil},
+		{levelsAntiFarmingMedium, "AAAAAAAA", 0, "talking to myself again today", false, 0.25, []string{levelsAntiFarmingSignalConversationless}},
+		{levelsAntiFarmingHigh, "ABABABAB", 15 * time.Second, "sure, let's play after dinner", false, 1, nil},
+		{levelsAntiFarmingHigh, "ABABABAB", 5 * time.Second, "sure, let's play after dinner", false, 0, []string{levelsAntiFarmingSignalConversationless}},
+		{levelsAntiFarmingMedium, "ABABABAB", 15 * time.Second, "k", false, 0.0625, []string{levelsAntiFarmingSignalShort, levelsAntiFarmingSignalConversationless}},
+	}
+
+	for i, test := range tests {
+		// every test gets its own guild and channel so the message histories don't interfere
+		guildID := "test-guild-" + string(rune('a'+i))
+		channelID := "test-channel-" + string(rune('a'+i))
+		levelsAntiFarmingChannelHistory[channelID] = levelsTestChannelHistory(test.history, time.Now(), test.interval)
+
+		msg := &discordgo.Message{ChannelID: channelID, Content: test.content, Author: &discordgo.User{ID: "A"}}
+		if test.attachment {
+			msg.Attachments = []*discordgo.MessageAttachment{{URL: "https://example.com/picture.png"}}
+		}
+
+		quality, signals := m.scoreMessageQuality(guildID, msg, levelsAntiFarmingStrictnesses[test.strictness])
+		if quality != test.wantQuality || strings.Join(signals, ",") != strings.Join(test.wantSignals, ",") {
+			t.Errorf("scoreMessageQuality(%s, %q) = %f %v, want %f %v", test.strictness, test.content,
+				quality, signals, test.wantQuality, test.wantSignals)
+		}
+	}
+}
+
+func TestScoreMessageQualityDuplicate(t *testing.T) {
+	m := &Levels{}
+	strictness := levelsAntiFarmingStrictnesses[levelsAntiFarmingMedium]
+	send := func(content string) (quality float64, signals []string) {
+		return m.scoreMessageQuality("test-guild-duplicate", &discordgo.Message{
+			ChannelID: "test-channel-duplicate", Content: content, Author: &discordgo.User{ID: "A"},
+		}, strictness)
+	}
+
+	if quality, signals := send("buy my mixtape on soundcloud"); quality != 1 {
+		t.Fatalf("scoreMessageQuality() = %f %v for the first message, want 1", quality, signals)
+	}
+	if quality, signals := send("buy my mixtape on soundcloud!!"); quality != strictness.Penalty {
+		t.Fatalf("scoreMessageQuality() = %f %v for a copy of a previous message, want %f", quality, signals, strictness.Penalty)
+	}
+	if quality, signals := send("what's everyone doing tonight"); quality != 1 {
+		t.Fatalf("scoreMessageQuality() = %f %v for a new message, want 1", quality, signals)
+	}
+}
@@ -0,0 +1,359 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type massBanAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next massBanAction)
+
+type MassBan struct{}
+
+// massBanTarget is a user to ban, Name is empty if the user isn't known to us
+type massBanTarget struct {
+	ID   string
+	Name string
+}
+
+const (
+	massBanMaxTargets     = 1000
+	massBanSampleSize     = 10
+	massBanProgressPeriod = 5 * time.Second
+)
+
+var (
+	massBanIDRegex   = regexp.MustCompile(`\b[0-9]{16,20}\b`)
+	massBanDaysRegex = regexp.MustCompile(`^[0-9]$`)
+)
+
+func (mb *MassBan) Commands() []string {
+	return []string{
+		"massban",
+	}
+}
+
+func (mb *MassBan) Init(session *discordgo.Session) {
+
+}
+
+func (mb *MassBan) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := mb.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (mb *MassBan) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) massBanAction {
+	if !helpers.IsMod(in) {
+		*out = mb.newMsg("mod.no_permission")
+		return mb.actionFinish
+	}
+
+	userPermissions, err := cache.GetSession().State.UserChannelPermissions(in.Author.ID, in.ChannelID)
+	if err != nil || userPermissions&discordgo.PermissionBanMembers != discordgo.PermissionBanMembers {
+		*out = mb.newMsg("plugins.mod.disallowed")
+		return mb.actionFinish
+	}
+	botPermissions, err := cache.GetSession().State.UserChannelPermissions(cache.GetSession().State.User.ID, in.ChannelID)
+	if err != nil || botPermissions&discordgo.PermissionBanMembers != discordgo.PermissionBanMembers {
+		*out = mb.newMsg("plugins.mod.bot-disallowed")
+		return mb.actionFinish
+	}
+
+	if len(args) < 1 {
+		*out = mb.newMsg("bot.arguments.too-few")
+		return mb.actionFinish
+	}
+
+	switch args[0] {
+	case "ids", "id":
+		return mb.actionIDs
+	case "joined":
+		return mb.actionJoined
+	case "name", "names":
+		return mb.actionName
+	}
+
+	*out = mb.newMsg("bot.arguments.invalid")
+	return mb.actionFinish
+}
+
+// [p]massban ids <user id> [<user id> ...] [ | [<days>] <reason>], or with an uploaded .txt file of user IDs
+func (mb *MassBan) actionIDs(args []string, in *discordgo.Message, out **discordgo.MessageSend) massBanAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	idsText, _ := mb.splitReason(in.Content)
+	for _, attachment := range in.Attachments {
+		data, err := helpers.NetGetUAWithError(attachment.URL, helpers.DEFAULT_UA)
+		if err != nil {
+			mb.logger().Warn("unable to download id list: " + err.Error())
+			continue
+		}
+		idsText += "\n" + string(data)
+	}
+
+	targets := make([]massBanTarget, 0)
+	for _, userID := range massBanIDRegex.FindAllString(idsText, -1) {
+		target := massBanTarget{ID: userID}
+		if member, err := helpers.GetGuildMemberWithoutApi(channel.GuildID, userID); err == nil && member.User != nil {
+			target.Name = member.User.Username + "#" + member.User.Discriminator
+		}
+		targets = append(targets, target)
+	}
+
+	return mb.confirmAndBan(in, out, channel.GuildID, targets)
+}
+
+// [p]massban joined <since> [<until>] [ | [<days>] <reason>], for example 30m or 2h 1h
+func (mb *MassBan) actionJoined(args []string, in *discordgo.Message, out **discordgo.MessageSend) massBanAction {
+	windowText, _ := mb.splitReason(in.Content)
+	windowArgs := strings.Fields(windowText)
+	// windowArgs starts with the command and the subcommand
+	if len(windowArgs) < 3 {
+		*out = mb.newMsg("bot.arguments.too-few")
+		return mb.actionFinish
+	}
+
+	since, err := helpers.ParseDuration(windowArgs[2])
+	if err != nil || since <= 0 {
+		*out = mb.newMsg("bot.arguments.invalid")
+		return mb.actionFinish
+	}
+	var until time.Duration
+	if len(windowArgs) >= 4 {
+		until, err = helpers.ParseDuration(windowArgs[3])
+		if err != nil || until >= since {
+			*out = mb.newMsg("bot.arguments.invalid")
+			return mb.actionFinish
+		}
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var joins []DB_Mod_JoinLog
+	listCursor, err := rethink.Table("mod_joinlog").GetAllByIndex("guildid", channel.GuildID).Filter(
+		rethink.Row.Field("joinedat").Ge(time.Now().Add(-since)).And(
+			rethink.Row.Field("joinedat").Le(time.Now().Add(-until))),
+	).OrderBy(rethink.Asc("joinedat")).Run(helpers.GetDB())
+	helpers.Relax(err)
+	defer listCursor.Close()
+	err = listCursor.All(&joins)
+	helpers.Relax(err)
+
+	targets := make([]massBanTarget, 0)
+	for _, join := range joins {
+		target := massBanTarget{ID: join.UserID}
+		if member, err := helpers.GetGuildMemberWithoutApi(channel.GuildID, join.UserID); err == nil && member.User != nil {
+			target.Name = member.User.Username + "#" + member.User.Discriminator
+		}
+		targets = append(targets, target)
+	}
+
+	return mb.confirmAndBan(in, out, channel.GuildID, targets)
+}
+
+// [p]massban name <regex> [ | [<days>] <reason>]
+func (mb *MassBan) actionName(args []string, in *discordgo.Message, out **discordgo.MessageSend) massBanAction {
+	if len(args) < 2 {
+		*out = mb.newMsg("bot.arguments.too-few")
+		return mb.actionFinish
+	}
+
+	// the arguments are the last fields of the message, the fields before them are the prefix and the command
+	patternText, _ := mb.splitReason(in.Content)
+	patternText = helpers.TextAfterFields(patternText, len(strings.Fields(in.Content))-len(args)+1)
+	if patternText == "" {
+		*out = mb.newMsg("bot.arguments.too-few")
+		return mb.actionFinish
+	}
+	pattern, err := regexp.Compile(patternText)
+	if err != nil {
+		*out = mb.newMsg("plugins.massban.invalid-regex", err.Error())
+		return mb.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+	guild, err := helpers.GetGuild(channel.GuildID)
+	helpers.Relax(err)
+
+	targets := make([]massBanTarget, 0)
+	for _, member := range guild.Members {
+		if member.User == nil || !pattern.MatchString(member.User.Username) {
+			continue
+		}
+		targets = append(targets, massBanTarget{
+			ID:   member.User.ID,
+			Name: member.User.Username + "#" + member.User.Discriminator,
+		})
+	}
+
+	return mb.confirmAndBan(in, out, channel.GuildID, targets)
+}
+
+// confirmAndBan filters the targets, shows a preview, and bans the targets after confirmation
+func (mb *MassBan) confirmAndBan(in *discordgo.Message, out **discordgo.MessageSend, guildID string, targets []massBanTarget) massBanAction {
+	guild, err := helpers.GetGuild(guildID)
+	helpers.Relax(err)
+
+	// never ban ourselves, the issuer, the owner or staff members, and ban every user only once
+	seen := make(map[string]bool, 0)
+	filteredTargets := make([]massBanTarget, 0)
+	for _, target := range targets {
+		if seen[target.ID] ||
+			target.ID == cache.GetSession().State.User.ID ||
+			target.ID == in.Author.ID ||
+			target.ID == guild.OwnerID ||
+			helpers.IsModByID(guildID, target.ID) {
+			continue
+		}
+		seen[target.ID] = true
+		filteredTargets = append(filteredTargets, target)
+	}
+	targets = filteredTargets
+
+	if len(targets) <= 0 {
+		*out = mb.newMsg("plugins.massban.no-targets")
+		return mb.actionFinish
+	}
+	if len(targets) > massBanMaxTargets {
+		*out = mb.newMsg("plugins.massban.too-many-targets", len(targets), massBanMaxTargets)
+		return mb.actionFinish
+	}
+
+	_, reasonText := mb.splitReason(in.Content)
+	days := 0
+	reasonArgs := strings.Fields(reasonText)
+	if len(reasonArgs) > 0 && massBanDaysRegex.MatchString(reasonArgs[0]) {
+		days, err = strconv.Atoi(reasonArgs[0])
+		if err != nil || days > 7 {
+			*out = mb.newMsg("plugins.mod.user-banned-error-too-many-days")
+			return mb.actionFinish
+		}
+		reasonText = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(reasonText), reasonArgs[0]))
+	}
+	if reasonText == "" {
+		reasonText = "None given"
+	}
+
+	sample := make([]string, 0)
+	for i, target := range targets {
+		if i >= massBanSampleSize {
+			sample = append(sample, helpers.GetTextF("plugins.massban.confirm-and-more", len(targets)-massBanSampleSize))
+			break
+		}
+		sample = append(sample, mb.targetText(target))
+	}
+
+	if !helpers.ConfirmEmbed(in.ChannelID, in.Author,
+		helpers.GetTextF("plugins.massban.confirm", len(targets), days, reasonText, strings.Join(sample, "\n")), "✅", "🚫") {
+		return nil
+	}
+
+	progressMessages, err := helpers.SendMessage(in.ChannelID, helpers.GetTextF("plugins.massban.progress", 0, len(targets)))
+	helpers.Relax(err)
+
+	auditLogReason := fmt.Sprintf("Mass ban issued by: %s#%s (#%s) | Delete Days: %d | Reason: %s",
+		in.Author.Username, in.Author.Discriminator, in.Author.ID, days, reasonText)
+
+	var summary bytes.Buffer
+	var failedSummary bytes.Buffer
+	var banned, failed int
+	lastProgress := time.Now()
+	for i, target := range targets {
+		// send the appeal code like the ban command does, we can't DM the user after the ban
+		appealCode, appealCodeMessages := banAppealsSendCode(guild, &discordgo.User{ID: target.ID})
+		// discordgo waits for the rate limits on its own, this only limits how often we edit the progress message
+		err = cache.GetSession().GuildBanCreateWithReason(guildID, target.ID, auditLogReason, days)
+		if err != nil {
+			banAppealsRetractCode(appealCodeMessages)
+			failed++
+			failedSummary.WriteString(fmt.Sprintf("%s: %s\r\n", mb.targetText(target), err.Error()))
+		} else {
+			banned++
+			summary.WriteString(mb.targetText(target) + "\r\n")
+			err = banAppealsCreateCase(guildID, target.ID, in.Author.ID, auditLogReason, appealCode)
+			helpers.RelaxLog(err)
+		}
+
+		if len(progressMessages) > 0 && time.Since(lastProgress) > massBanProgressPeriod {
+			helpers.EditMessage(in.ChannelID, progressMessages[0].ID,
+				helpers.GetTextF("plugins.massban.progress", i+1, len(targets)))
+			lastProgress = time.Now()
+		}
+	}
+
+	mb.logger().WithField("GuildID", guildID).Info(fmt.Sprintf("Mass banned %d users (%d failed) by %s (#%s)",
+		banned, failed, in.Author.Username, in.Author.ID))
+
+	if len(progressMessages) > 0 {
+		cache.GetSession().ChannelMessageDelete(in.ChannelID, progressMessages[0].ID)
+	}
+
+	summaryText := fmt.Sprintf("Mass ban on %s (#%s) by %s#%s (#%s) at %s\r\nReason: %s\r\n\r\nBanned (%d):\r\n%s\r\nFailed (%d):\r\n%s",
+		guild.Name, guild.ID, in.Author.Username, in.Author.Discriminator, in.Author.ID, time.Now().UTC().Format(time.RFC1123),
+		reasonText, banned, summary.String(), failed, failedSummary.String())
+
+	*out = &discordgo.MessageSend{
+		Content: helpers.GetTextF("plugins.massban.done", banned, failed),
+		Files: []*discordgo.File{{
+			Name:   fmt.Sprintf("massban-%s-%s.txt", guildID, time.Now().UTC().Format("20060102-150405")),
+			Reader: strings.NewReader(summaryText),
+		}},
+	}
+	return mb.actionFinish
+}
+
+// splitReason splits the content at the first " | ", into the arguments and the optional days and reason,
+// the spaces are required so name patterns can contain alternations
+func (mb *MassBan) splitReason(content string) (arguments string, reason string) {
+	parts := strings.SplitN(content, " | ", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+func (mb *MassBan) targetText(target massBanTarget) string {
+	if target.Name == "" {
+		return "#" + target.ID
+	}
+	return fmt.Sprintf("%s (#%s)", target.Name, target.ID)
+}
+
+func (mb *MassBan) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) massBanAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (mb *MassBan) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (mb *MassBan) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "massban")
+}
@@ -0,0 +1,586 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+type messageLogAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next messageLogAction)
+
+type MessageLog struct{}
+
+type messageLogCacheItem struct {
+	ID                  string
+	GuildID             string
+	ChannelID           string
+	AuthorID            string
+	AuthorUsername      string
+	AuthorDiscriminator string
+	Content             string
+	Attachments         []string
+	CreatedAt           time.Time
+}
+
+const (
+	// messageLogCacheSize is the maximum number of messages kept in the local cache, older messages are dropped first
+	messageLogCacheSize  = 100000
+	messageLogFieldLimit = 1024
+)
+
+var (
+	messageLogCache      map[string]messageLogCacheItem
+	messageLogCacheOrder []string
+	messageLogCacheNext  int
+	messageLogCacheMutex sync.RWMutex
+)
+
+func (ml *MessageLog) Commands() []string {
+	return []string{
+		"message-log",
+	}
+}
+
+func (ml *MessageLog) Init(session *discordgo.Session) {
+	messageLogCacheMutex.Lock()
+	messageLogCache = make(map[string]messageLogCacheItem, 0)
+	messageLogCacheOrder = make([]string, messageLogCacheSize)
+	messageLogCacheNext = 0
+	messageLogCacheMutex.Unlock()
+
+	session.AddHandler(ml.OnMessageUpdate)
+	session.AddHandler(ml.OnMessageDeleteBulk)
+}
+
+func (ml *MessageLog) Uninit(session *discordgo.Session) {
+
+}
+
+func (ml *MessageLog) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := ml.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (ml *MessageLog) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) messageLogAction {
+	if !helpers.IsAdmin(in) {
+		*out = ml.newMsg("admin.no_permission")
+		return ml.actionFinish
+	}
+
+	if len(args) < 1 {
+		return ml.actionStatus
+	}
+
+	switch args[0] {
+	case "status":
+		return ml.actionStatus
+	case "set":
+		return ml.actionSet
+	case "disable":
+		return ml.actionDisable
+	case "ignore", "unignore":
+		return ml.actionIgnore
+	}
+
+	*out = ml.newMsg("bot.arguments.invalid")
+	return ml.actionFinish
+}
+
+// [p]message-log [status]
+func (ml *MessageLog) actionStatus(args []string, in *discordgo.Message, out **discordgo.MessageSend) messageLogAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if settings.MessageLogChannelID == "" {
+		*out = ml.newMsg("plugins.messagelog.status-disabled", helpers.GetPrefixForServer(channel.GuildID))
+		return ml.actionFinish
+	}
+
+	message := helpers.GetTextF("plugins.messagelog.status-enabled", settings.MessageLogChannelID) + "\n"
+	if settings.ChatlogDisabled {
+		message += helpers.GetTextF("plugins.messagelog.status-chatlog-disabled", helpers.GetPrefixForServer(channel.GuildID)) + "\n"
+	}
+	if len(settings.MessageLogIgnoredChannelIDs) > 0 {
+		message += "Ignored channels:"
+		for _, channelID := range settings.MessageLogIgnoredChannelIDs {
+			message += fmt.Sprintf(" <#%s>", channelID)
+		}
+		message += "\n"
+	}
+	if len(settings.MessageLogIgnoredUserIDs) > 0 {
+		message += "Ignored users:"
+		for _, userID := range settings.MessageLogIgnoredUserIDs {
+			message += fmt.Sprintf(" <@%s>", userID)
+		}
+		message += "\n"
+	}
+
+	*out = ml.newMsg(message)
+	return ml.actionFinish
+}
+
+// [p]message-log set <channel>
+func (ml *MessageLog) actionSet(args []string, in *discordgo.Message, out **discordgo.MessageSend) messageLogAction {
+	if len(args) < 2 {
+		*out = ml.newMsg("bot.arguments.too-few")
+		return ml.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+		*out = ml.newMsg("bot.arguments.invalid")
+		return ml.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.MessageLogChannelID = targetChannel.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.ChatlogDisabled {
+		*out = ml.newMsg("plugins.messagelog.set-success-chatlog-disabled", targetChannel.ID, helpers.GetPrefixForServer(channel.GuildID))
+		return ml.actionFinish
+	}
+
+	*out = ml.newMsg("plugins.messagelog.set-success", targetChannel.ID)
+	return ml.actionFinish
+}
+
+// [p]message-log disable
+func (ml *MessageLog) actionDisable(args []string, in *discordgo.Message, out **discordgo.MessageSend) messageLogAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.MessageLogChannelID = ""
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = ml.newMsg("plugins.messagelog.disable-success")
+	return ml.actionFinish
+}
+
+// [p]message-log ignore <channel or user>
+func (ml *MessageLog) actionIgnore(args []string, in *discordgo.Message, out **discordgo.MessageSend) messageLogAction {
+	if len(args) < 2 {
+		*out = ml.newMsg("bot.arguments.too-few")
+		return ml.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err == nil && targetChannel.ID != "" && targetChannel.GuildID == channel.GuildID {
+		var ignored bool
+		settings.MessageLogIgnoredChannelIDs, ignored = ml.toggleID(settings.MessageLogIgnoredChannelIDs, targetChannel.ID)
+		err = helpers.GuildSettingsSet(channel.GuildID, settings)
+		helpers.Relax(err)
+
+		if ignored {
+			*out = ml.newMsg("plugins.messagelog.ignore-channel-added", targetChannel.ID)
+		} else {
+			*out = ml.newMsg("plugins.messagelog.ignore-channel-removed", targetChannel.ID)
+		}
+		return ml.actionFinish
+	}
+
+	targetUser, err := helpers.GetUserFromMention(args[1])
+	if err != nil || targetUser == nil || targetUser.ID == "" {
+		*out = ml.newMsg("bot.arguments.invalid")
+		return ml.actionFinish
+	}
+
+	var ignored bool
+	settings.MessageLogIgnoredUserIDs, ignored = ml.toggleID(settings.MessageLogIgnoredUserIDs, targetUser.ID)
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if ignored {
+		*out = ml.newMsg("plugins.messagelog.ignore-user-added", targetUser.Username, targetUser.ID)
+	} else {
+		*out = ml.newMsg("plugins.messagelog.ignore-user-removed", targetUser.Username, targetUser.ID)
+	}
+	return ml.actionFinish
+}
+
+// toggleID adds the id to the list if it is not in it yet, or removes it otherwise, returns true if the id has been added
+func (ml *MessageLog) toggleID(ids []string, id string) (result []string, added bool) {
+	result = make([]string, 0)
+	for _, existingID := range ids {
+		if existingID != id {
+			result = append(result, existingID)
+		}
+	}
+	if len(result) == len(ids) {
+		result = append(result, id)
+		return result, true
+	}
+	return result, false
+}
+
+// isLogged returns true if messages of the user in the channel should be logged
+func (ml *MessageLog) isLogged(settings models.Config, channelID string, userID string) bool {
+	if settings.MessageLogChannelID == "" || settings.ChatlogDisabled {
+		return false
+	}
+	if channelID == settings.MessageLogChannelID {
+		return false
+	}
+	for _, ignoredChannelID := range settings.MessageLogIgnoredChannelIDs {
+		if ignoredChannelID == channelID {
+			return false
+		}
+	}
+	for _, ignoredUserID := range settings.MessageLogIgnoredUserIDs {
+		if ignoredUserID == userID {
+			return false
+		}
+	}
+	return true
+}
+
+func (ml *MessageLog) cacheMessage(message *discordgo.Message, guildID string) {
+	item := messageLogCacheItem{
+		ID:                  message.ID,
+		GuildID:             guildID,
+		ChannelID:           message.ChannelID,
+		AuthorID:            message.Author.ID,
+		AuthorUsername:      message.Author.Username,
+		AuthorDiscriminator: message.Author.Discriminator,
+		Content:             message.Content,
+		Attachments:         make([]string, 0),
+		CreatedAt:           helpers.GetTimeFromSnowflake(message.ID),
+	}
+	for _, attachment := range message.Attachments {
+		item.Attachments = append(item.Attachments, attachment.URL)
+	}
+
+	messageLogCacheMutex.Lock()
+	defer messageLogCacheMutex.Unlock()
+
+	if _, ok := messageLogCache[item.ID]; !ok {
+		// drop the oldest message to keep the cache bounded
+		if oldestID := messageLogCacheOrder[messageLogCacheNext]; oldestID != "" {
+			delete(messageLogCache, oldestID)
+		}
+		messageLogCacheOrder[messageLogCacheNext] = item.ID
+		messageLogCacheNext = (messageLogCacheNext + 1) % messageLogCacheSize
+	}
+	messageLogCache[item.ID] = item
+}
+
+func (ml *MessageLog) updateCachedContent(messageID string, content string) {
+	messageLogCacheMutex.Lock()
+	defer messageLogCacheMutex.Unlock()
+
+	if item, ok := messageLogCache[messageID]; ok {
+		item.Content = content
+		messageLogCache[messageID] = item
+	}
+}
+
+// getMessage returns the message from the local cache, or from the chatlog if it is available
+func (ml *MessageLog) getMessage(messageID string) (item messageLogCacheItem, found bool) {
+	messageLogCacheMutex.RLock()
+	item, found = messageLogCache[messageID]
+	messageLogCacheMutex.RUnlock()
+	if found {
+		return item, true
+	}
+
+	if !cache.HasElastic() {
+		return item, false
+	}
+
+	termQuery := elastic.NewQueryStringQuery("_type:" + models.ElasticTypeMessage + " AND MessageID:" + messageID)
+	searchResult, err := cache.GetElastic().Search().
+		Index(models.ElasticIndex).
+		Query(termQuery).
+		Size(1).
+		Do(context.Background())
+	if err != nil {
+		ml.logger().Warn("unable to search chatlog: " + err.Error())
+		return item, false
+	}
+
+	var ttyp models.ElasticMessage
+	for _, result := range searchResult.Each(reflect.TypeOf(ttyp)) {
+		message := result.(models.ElasticMessage)
+		item = messageLogCacheItem{
+			ID:             message.MessageID,
+			GuildID:        message.GuildID,
+			ChannelID:      message.ChannelID,
+			AuthorID:       message.UserID,
+			AuthorUsername: "N/A",
+			Content:        message.Content,
+			Attachments:    message.Attachments,
+			CreatedAt:      message.CreatedAt,
+		}
+		author, err := helpers.GetUser(message.UserID)
+		if err == nil && author != nil {
+			item.AuthorUsername = author.Username
+			item.AuthorDiscriminator = author.Discriminator
+		}
+		return item, true
+	}
+
+	return item, false
+}
+
+func (ml *MessageLog) removeFromCache(messageID string) {
+	messageLogCacheMutex.Lock()
+	defer messageLogCacheMutex.Unlock()
+
+	delete(messageLogCache, messageID)
+}
+
+func (ml *MessageLog) truncate(text string) string {
+	if text == "" {
+		return "_empty_"
+	}
+	if len([]rune(text)) > messageLogFieldLimit {
+		return string([]rune(text)[:messageLogFieldLimit-1]) + "…"
+	}
+	return text
+}
+
+func (ml *MessageLog) authorText(item messageLogCacheItem) string {
+	return fmt.Sprintf("%s#%s", item.AuthorUsername, item.AuthorDiscriminator)
+}
+
+func (ml *MessageLog) send(channelID string, data *discordgo.MessageSend) {
+	_, err := helpers.SendComplex(channelID, data)
+	if err != nil {
+		ml.logger().WithField("ChannelID", channelID).Warn("unable to post to message log: " + err.Error())
+	}
+}
+
+func (ml *MessageLog) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+	if msg.Author == nil {
+		return
+	}
+
+	channel, err := helpers.GetChannelWithoutApi(msg.ChannelID)
+	if err != nil || channel.GuildID == "" {
+		return
+	}
+
+	if !ml.isLogged(helpers.GuildSettingsGetCached(channel.GuildID), channel.ID, msg.Author.ID) {
+		return
+	}
+
+	ml.cacheMessage(msg, channel.GuildID)
+}
+
+func (ml *MessageLog) OnMessageUpdate(session *discordgo.Session, message *discordgo.MessageUpdate) {
+	// updates without content are embed updates
+	if message.Message == nil || message.Author == nil || message.Author.Bot || message.Content == "" {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		channel, err := helpers.GetChannel(message.ChannelID)
+		if err != nil || channel.GuildID == "" {
+			return
+		}
+
+		settings := helpers.GuildSettingsGetCached(channel.GuildID)
+		if !ml.isLogged(settings, channel.ID, message.Author.ID) {
+			return
+		}
+
+		before, found := ml.getMessage(message.ID)
+		if found && before.Content == message.Content {
+			return
+		}
+		ml.updateCachedContent(message.ID, message.Content)
+
+		beforeText := helpers.GetText("plugins.messagelog.content-unknown")
+		if found {
+			beforeText = ml.truncate(before.Content)
+		}
+
+		ml.send(settings.MessageLogChannelID, &discordgo.MessageSend{Embed: &discordgo.MessageEmbed{
+			Title: helpers.GetText("plugins.messagelog.edit-embed-title"),
+			Description: helpers.GetTextF("plugins.messagelog.edit-embed-description",
+				message.Author.ID, message.Author.Username, message.Author.Discriminator, channel.ID),
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Before", Value: beforeText, Inline: false},
+				{Name: "After", Value: ml.truncate(message.Content), Inline: false},
+			},
+			Footer:    &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("User ID: %s | Message ID: %s", message.Author.ID, message.ID)},
+			Timestamp: time.Now().Format(time.RFC3339),
+			Color:     0xF1C40F,
+		}})
+	}()
+}
+
+func (ml *MessageLog) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
+	go func() {
+		defer helpers.Recover()
+
+		channel, err := helpers.GetChannel(msg.ChannelID)
+		if err != nil || channel.GuildID == "" {
+			return
+		}
+
+		settings := helpers.GuildSettingsGetCached(channel.GuildID)
+		if settings.MessageLogChannelID == "" || settings.ChatlogDisabled {
+			return
+		}
+
+		item, found := ml.getMessage(msg.ID)
+		if !found || !ml.isLogged(settings, channel.ID, item.AuthorID) {
+			return
+		}
+		ml.removeFromCache(msg.ID)
+
+		deleteEmbed := &discordgo.MessageEmbed{
+			Title: helpers.GetText("plugins.messagelog.delete-embed-title"),
+			Description: helpers.GetTextF("plugins.messagelog.delete-embed-description",
+				item.AuthorID, ml.authorText(item), channel.ID, item.CreatedAt.UTC().Format(time.ANSIC)),
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Content", Value: ml.truncate(item.Content), Inline: false},
+			},
+			Footer:    &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("User ID: %s | Message ID: %s", item.AuthorID, item.ID)},
+			Timestamp: time.Now().Format(time.RFC3339),
+			Color:     0xE74C3C,
+		}
+		if len(item.Attachments) > 0 {
+			deleteEmbed.Fields = append(deleteEmbed.Fields, &discordgo.MessageEmbedField{
+				Name: "Attachments", Value: ml.truncate(strings.Join(item.Attachments, "\n")), Inline: false})
+		}
+
+		ml.send(settings.MessageLogChannelID, &discordgo.MessageSend{Embed: deleteEmbed})
+	}()
+}
+
+func (ml *MessageLog) OnMessageDeleteBulk(session *discordgo.Session, bulk *discordgo.MessageDeleteBulk) {
+	go func() {
+		defer helpers.Recover()
+
+		channel, err := helpers.GetChannel(bulk.ChannelID)
+		if err != nil || channel.GuildID == "" {
+			return
+		}
+
+		settings := helpers.GuildSettingsGetCached(channel.GuildID)
+		if !ml.isLogged(settings, channel.ID, "") {
+			return
+		}
+
+		items := make([]messageLogCacheItem, 0)
+		for _, messageID := range bulk.Messages {
+			item, found := ml.getMessage(messageID)
+			if !found {
+				continue
+			}
+			ml.removeFromCache(messageID)
+			if !ml.isLogged(settings, channel.ID, item.AuthorID) {
+				continue
+			}
+			items = append(items, item)
+		}
+
+		var transcript bytes.Buffer
+		transcript.WriteString(fmt.Sprintf("%d messages bulk deleted in #%s (#%s) at %s\r\n\r\n",
+			len(bulk.Messages), channel.Name, channel.ID, time.Now().UTC().Format(time.RFC1123)))
+		for i := len(items) - 1; i >= 0; i-- {
+			// the ids are ordered newest first
+			item := items[i]
+			transcript.WriteString(fmt.Sprintf("[%s] %s (#%s): %s\r\n",
+				item.CreatedAt.UTC().Format("2006-01-02 15:04:05"), ml.authorText(item), item.AuthorID, item.Content))
+			for _, attachment := range item.Attachments {
+				transcript.WriteString(fmt.Sprintf("\tAttachment: %s\r\n", attachment))
+			}
+		}
+
+		ml.send(settings.MessageLogChannelID, &discordgo.MessageSend{
+			Embed: &discordgo.MessageEmbed{
+				Title: helpers.GetText("plugins.messagelog.bulk-delete-embed-title"),
+				Description: helpers.GetTextF("plugins.messagelog.bulk-delete-embed-description",
+					len(bulk.Messages), channel.ID, len(items)),
+				Timestamp: time.Now().Format(time.RFC3339),
+				Color:     0xE74C3C,
+			},
+			Files: []*discordgo.File{
+				{
+					Name:   fmt.Sprintf("deleted-%s-%s.txt", channel.ID, time.Now().UTC().Format("20060102-150405")),
+					Reader: bytes.NewReader(transcript.Bytes()),
+				},
+			},
+		})
+	}()
+}
+
+func (ml *MessageLog) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) messageLogAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (ml *MessageLog) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (ml *MessageLog) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "messagelog")
+}
+
+func (ml *MessageLog) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (ml *MessageLog) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (ml *MessageLog) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+
+}
+
+func (ml *MessageLog) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+
+}
+
+func (ml *MessageLog) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
+
+}
+
+func (ml *MessageLog) OnGuildBanRemove(user *discordgo.GuildBanRemove, session *discordgo.Session) {
+
+}
@@ -3,11 +3,13 @@ package plugins
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"image/png"
@@ -16,6 +18,7 @@ import (
 	"github.com/Seklfreak/Robyul2/cache"
 	"github.com/Seklfreak/Robyul2/emojis"
 	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
 	"github.com/bradfitz/slice"
 	"github.com/bwmarrin/discordgo"
 	"github.com/dustin/go-humanize"
@@ -45,8 +48,8 @@ func (m *Mod) Commands() []string {
 		"inspect",
 		"inspect-extended",
 		"auto-inspects-channel",
+		"inspect-scoring",
 		"search-user",
-		"audit-log",
 		"invites",
 		"leave-server",
 		"say",
@@ -61,6 +64,9 @@ func (m *Mod) Commands() []string {
 		"pending-mutes",
 		"batch-roles",
 		"set-bot-dp",
+		"mod-log-channel",
+		"note",
+		"notes",
 	}
 }
 
@@ -80,10 +86,12 @@ type CacheInviteInformation struct {
 	Code            string
 	CreatedAt       time.Time
 	Uses            int
+	MaxUses         int
 }
 
 var (
-	invitesCache map[string][]CacheInviteInformation
+	invitesCache      map[string][]CacheInviteInformation
+	invitesCacheMutex sync.Mutex
 )
 
 func (m *Mod) Init(session *discordgo.Session) {
@@ -91,40 +99,27 @@ func (m *Mod) Init(session *discordgo.Session) {
 	m.parser.Add(en.All...)
 	m.parser.Add(common.All...)
 
+	invitesCacheMutex.Lock()
 	invitesCache = make(map[string][]CacheInviteInformation, 0)
+	invitesCacheMutex.Unlock()
 	go func() {
 		log := cache.GetLogger()
 
 		for _, guild := range session.State.Guilds {
-			invites, err := session.GuildInvites(guild.ID)
+			cacheInvites, err := m.getCacheInvites(guild.ID)
 			if err != nil {
 				log.WithField("module", "mod").Error(fmt.Sprintf("error getting invites from guild %s (#%s): %s",
 					guild.Name, guild.ID, err.Error()))
 				continue
 			}
 
-			cacheInvites := make([]CacheInviteInformation, 0)
-			for _, invite := range invites {
-				createdAt, err := invite.CreatedAt.Parse()
-				if err != nil {
-					continue
-				}
-				inviterID := ""
-				if invite.Inviter != nil {
-					inviterID = invite.Inviter.ID
-				}
-				cacheInvites = append(cacheInvites, CacheInviteInformation{
-					GuildID:         invite.Guild.ID,
-					CreatedByUserID: inviterID,
-					Code:            invite.Code,
-					CreatedAt:       createdAt,
-					Uses:            invite.Uses,
-				})
-			}
-
+			invitesCacheMutex.Lock()
 			invitesCache[guild.ID] = cacheInvites
+			invitesCacheMutex.Unlock()
 		}
+		invitesCacheMutex.Lock()
 		log.WithField("module", "mod").Info(fmt.Sprintf("got invite link cache of %d servers", len(invitesCache)))
+		invitesCacheMutex.Unlock()
 	}()
 	go m.cacheBans()
 	cache.GetLogger().WithField("module", "mod").Info("Started cacheBans")
@@ -177,213 +172,271 @@ func (m *Mod) Action(command string, content string, msg *discordgo.Message, ses
 		helpers.RequireMod(msg, func() {
 			args := strings.Fields(content)
 			if len(args) > 0 {
+				var messagesToCheck []*discordgo.Message
+				var filterArgs []string
 				switch args[0] {
-				case "after": // [p]cleanup after <after message id> [<until message id>]
-					if len(args) < 2 {
+				case "after", "between": // [p]cleanup after <after message id> [<until message id>] [<filters>], [p]cleanup between <message id> <message id> [<filters>]
+					if len(args) < 2 || (args[0] == "between" && len(args) < 3) {
 						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
 						return
-					} else {
-						afterMessageId := args[1]
-						untilMessageId := ""
-						if regexNumberOnly.MatchString(afterMessageId) == false {
+					}
+					afterMessageId := args[1]
+					untilMessageId := ""
+					if regexNumberOnly.MatchString(afterMessageId) == false {
+						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+						return
+					}
+					filterArgs = args[2:]
+					if len(args) >= 3 && (args[0] == "between" || regexNumberOnly.MatchString(args[2])) {
+						untilMessageId = args[2]
+						if regexNumberOnly.MatchString(untilMessageId) == false {
 							helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
 							return
 						}
-						if len(args) >= 3 {
-							untilMessageId = args[2]
-							if regexNumberOnly.MatchString(untilMessageId) == false {
-								helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
-								return
-							}
-						}
-
-						var messagesToDeleteIds []string
-
-						nextAfterID := afterMessageId
-					AllMessagesLoop:
-						for {
-							messagesToDelete, _ := session.ChannelMessages(msg.ChannelID, 100, "", nextAfterID, "")
-							slice.Sort(messagesToDelete, func(i, j int) bool {
-								return messagesToDelete[i].Timestamp < messagesToDelete[j].Timestamp
-							})
-							for _, messageToDelete := range messagesToDelete {
-								messagesToDeleteIds = append(messagesToDeleteIds, messageToDelete.ID)
-								nextAfterID = messageToDelete.ID
-								if messageToDelete.ID == untilMessageId {
-									break AllMessagesLoop
-								}
-							}
-							if len(messagesToDelete) <= 0 {
-								break AllMessagesLoop
-							}
+						filterArgs = args[3:]
+						// allow the message ids in any order
+						if helpers.GetTimeFromSnowflake(untilMessageId).Before(helpers.GetTimeFromSnowflake(afterMessageId)) {
+							afterMessageId, untilMessageId = untilMessageId, afterMessageId
 						}
+					}
 
-						msgAlreadyIn := false
-						for _, messageID := range messagesToDeleteIds {
-							if messageID == msg.ID {
-								msgAlreadyIn = true
-							}
-						}
-						if msgAlreadyIn == false {
-							messagesToDeleteIds = append(messagesToDeleteIds, msg.ID)
-						}
-
-						messagesToDeleteIds = m.removeDuplicates(messagesToDeleteIds)
-
-						if len(messagesToDeleteIds) <= 10 {
-							err := session.ChannelMessagesBulkDelete(msg.ChannelID, messagesToDeleteIds)
-							cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Deleted %d messages (command issued by %s (#%s))", len(messagesToDeleteIds), msg.Author.Username, msg.Author.ID))
-							if err != nil {
-								if errD, ok := err.(*discordgo.RESTError); ok {
-									if errD.Message.Code == 50034 {
-										_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-										helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-										return
-									} else if errD.Message.Code == 50013 {
-										_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-										helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-										return
-									} else {
-										helpers.Relax(errD)
-									}
-								} else {
-									helpers.Relax(err)
-								}
-								return
-							}
-						} else {
-							if helpers.ConfirmEmbed(msg.ChannelID, msg.Author, helpers.GetTextF("plugins.mod.deleting-message-bulkdelete-confirm", len(messagesToDeleteIds)), "✅", "🚫") == true {
-								for i := 0; i < len(messagesToDeleteIds); i += 100 {
-									batch := messagesToDeleteIds[i:m.Min(i+100, len(messagesToDeleteIds))]
-									err := session.ChannelMessagesBulkDelete(msg.ChannelID, batch)
-									cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Deleted %d messages (command issued by %s (#%s))", len(batch), msg.Author.Username, msg.Author.ID))
-									if err != nil {
-										if errD, ok := err.(*discordgo.RESTError); ok && errD.Message.Code == 50034 {
-											if errD.Message.Code == 50034 {
-												_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-												helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-												return
-											} else if errD.Message.Code == 50013 {
-												_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-												helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-												return
-											} else {
-												helpers.Relax(errD)
-											}
-										} else {
-											helpers.Relax(err)
-										}
-										return
-									}
-								}
-							} else {
-								session.ChannelMessageDelete(msg.ChannelID, msg.ID)
-							}
-							return
+					messagesToCheck = m.getMessagesAfter(msg.ChannelID, afterMessageId, untilMessageId)
+					if args[0] == "between" {
+						// between includes both messages
+						afterMessage, err := session.ChannelMessage(msg.ChannelID, afterMessageId)
+						if err == nil && afterMessage != nil {
+							messagesToCheck = append([]*discordgo.Message{afterMessage}, messagesToCheck...)
 						}
 					}
-				case "messages": // [p]cleanup messages <n>
+				case "messages": // [p]cleanup messages <n> [<filters>]
 					if len(args) < 2 {
 						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
 						return
-					} else {
-						if regexNumberOnly.MatchString(args[1]) == false {
-							helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
-							return
-						}
-						numOfMessagesToDelete, err := strconv.Atoi(args[1])
-						if err != nil {
-							helpers.SendMessage(msg.ChannelID, fmt.Sprintf(helpers.GetTextF("bot.errors.general"), err.Error()))
-							return
-						}
-						if numOfMessagesToDelete < 1 {
-							helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
-							return
-						}
+					}
+					if regexNumberOnly.MatchString(args[1]) == false {
+						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+						return
+					}
+					numOfMessagesToDelete, err := strconv.Atoi(args[1])
+					if err != nil {
+						helpers.SendMessage(msg.ChannelID, fmt.Sprintf(helpers.GetTextF("bot.errors.general"), err.Error()))
+						return
+					}
+					if numOfMessagesToDelete < 1 {
+						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+						return
+					}
+					filterArgs = args[2:]
 
-						var messagesToDeleteIds []string
+					messagesToCheck = m.getLastMessages(msg.ChannelID, msg.ID, numOfMessagesToDelete)
+				default:
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+					return
+				}
 
-						messagesLeft := numOfMessagesToDelete + 1
-						lastBeforeID := ""
-						for {
-							messagesToGet := messagesLeft
-							if messagesLeft > 100 {
-								messagesToGet = 100
-							}
-							messagesLeft -= messagesToGet
-
-							messagesToDelete, _ := session.ChannelMessages(msg.ChannelID, messagesToGet, lastBeforeID, "", "")
-							slice.Sort(messagesToDelete, func(i, j int) bool {
-								return messagesToDelete[i].Timestamp < messagesToDelete[j].Timestamp
-							})
-							for _, messageToDelete := range messagesToDelete {
-								messagesToDeleteIds = append(messagesToDeleteIds, messageToDelete.ID)
-								lastBeforeID = messageToDelete.ID
-							}
+				filter, err := m.parseCleanupFilter(msg, filterArgs)
+				if err != nil {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+					return
+				}
 
-							if messagesLeft <= 0 {
-								break
-							}
-						}
+				messagesToDelete := make([]*discordgo.Message, 0)
+				for _, messageToCheck := range messagesToCheck {
+					if messageToCheck.ID == msg.ID {
+						continue
+					}
+					if filter.Matches(messageToCheck) {
+						messagesToDelete = append(messagesToDelete, messageToCheck)
+					}
+				}
 
-						messagesToDeleteIds = m.removeDuplicates(messagesToDeleteIds)
+				if len(messagesToDelete) <= 0 {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.cleanup-no-messages-matched"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
 
-						if len(messagesToDeleteIds) <= 10 {
-							err := session.ChannelMessagesBulkDelete(msg.ChannelID, messagesToDeleteIds)
-							cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Deleted %d messages (command issued by %s (#%s))", len(messagesToDeleteIds), msg.Author.Username, msg.Author.ID))
-							if err != nil {
-								if errD, ok := err.(*discordgo.RESTError); ok {
-									if errD.Message.Code == 50034 {
-										_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-										helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-										return
-									} else if errD.Message.Code == 50013 {
-										_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-										helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-										return
-									} else {
-										helpers.Relax(errD)
-									}
-								} else {
-									helpers.Relax(err)
-								}
+				if len(messagesToDelete) > 10 || filter.Active() {
+					confirmText := helpers.GetTextF("plugins.mod.deleting-message-bulkdelete-confirm", len(messagesToDelete))
+					if filter.Active() {
+						confirmText = helpers.GetTextF("plugins.mod.deleting-message-bulkdelete-confirm-filtered",
+							len(messagesToDelete), len(messagesToCheck), filter.String())
+					}
+					if helpers.ConfirmEmbed(msg.ChannelID, msg.Author, confirmText, "✅", "🚫") == false {
+						session.ChannelMessageDelete(msg.ChannelID, msg.ID)
+						return
+					}
+				}
+
+				channel, err := helpers.GetChannel(msg.ChannelID)
+				helpers.Relax(err)
+				settings := helpers.GuildSettingsGetCached(channel.GuildID)
+				if settings.ModLogChannelID != "" {
+					err = m.archiveMessages(settings.ModLogChannelID, channel, msg.Author, messagesToDelete, filter)
+					if err != nil {
+						cache.GetLogger().WithField("module", "mod").Warn(fmt.Sprintf("archiving messages before cleanup failed: %s", err.Error()))
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.cleanup-archive-failed"))
+						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+						return
+					}
+				}
+
+				messagesToDeleteIds := []string{msg.ID}
+				for _, messageToDelete := range messagesToDelete {
+					messagesToDeleteIds = append(messagesToDeleteIds, messageToDelete.ID)
+				}
+				messagesToDeleteIds = m.removeDuplicates(messagesToDeleteIds)
+
+				for i := 0; i < len(messagesToDeleteIds); i += 100 {
+					batch := messagesToDeleteIds[i:m.Min(i+100, len(messagesToDeleteIds))]
+					err := session.ChannelMessagesBulkDelete(msg.ChannelID, batch)
+					cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Deleted %d messages (command issued by %s (#%s))", len(batch), msg.Author.Username, msg.Author.ID))
+					if err != nil {
+						if errD, ok := err.(*discordgo.RESTError); ok {
+							if errD.Message.Code == 50034 {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+								return
+							} else if errD.Message.Code == 50013 {
+								_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-no-permissions"))
+								helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 								return
-							}
-						} else {
-							if helpers.ConfirmEmbed(msg.ChannelID, msg.Author, helpers.GetTextF("plugins.mod.deleting-message-bulkdelete-confirm", len(messagesToDeleteIds)-1), "✅", "🚫") == true {
-								for i := 0; i < len(messagesToDeleteIds); i += 100 {
-									batch := messagesToDeleteIds[i:m.Min(i+100, len(messagesToDeleteIds))]
-									err := session.ChannelMessagesBulkDelete(msg.ChannelID, batch)
-									cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Deleted %d messages (command issued by %s (#%s))", len(batch), msg.Author.Username, msg.Author.ID))
-									if err != nil {
-										if errD, ok := err.(*discordgo.RESTError); ok {
-											if errD.Message.Code == 50034 {
-												_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-												helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-												return
-											} else if errD.Message.Code == 50013 {
-												_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.deleting-messages-failed-too-old"))
-												helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-												return
-											} else {
-												helpers.Relax(errD)
-											}
-										} else {
-											helpers.Relax(err)
-										}
-										return
-									}
-								}
 							} else {
-								session.ChannelMessageDelete(msg.ChannelID, msg.ID)
+								helpers.Relax(errD)
 							}
-							return
+						} else {
+							helpers.Relax(err)
 						}
+						return
 					}
 				}
 			}
 		})
 		return
+	case "mod-log-channel": // [p]mod-log-channel [<channel>]
+		helpers.RequireAdmin(msg, func() {
+			channel, err := helpers.GetChannel(msg.ChannelID)
+			helpers.Relax(err)
+			settings := helpers.GuildSettingsGetCached(channel.GuildID)
+			args := strings.Fields(content)
+
+			if len(args) < 1 {
+				settings.ModLogChannelID = ""
+				err = helpers.GuildSettingsSet(channel.GuildID, settings)
+				helpers.Relax(err)
+
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.mod-log-channel-disabled"))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			targetChannel, err := helpers.GetChannelFromMention(msg, args[0])
+			if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				return
+			}
+
+			settings.ModLogChannelID = targetChannel.ID
+			err = helpers.GuildSettingsSet(channel.GuildID, settings)
+			helpers.Relax(err)
+
+			_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.mod.mod-log-channel-set", targetChannel.ID))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		})
+		return
+	case "note", "notes": // [p]note add <user> <text>, [p]note list <user>, [p]note delete <user> <note #>
+		helpers.RequireMod(msg, func() {
+			args := strings.Fields(content)
+			if len(args) < 2 {
+				helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
+				return
+			}
+
+			channel, err := helpers.GetChannel(msg.ChannelID)
+			helpers.Relax(err)
+
+			targetUser, err := helpers.GetUserFromMention(args[1])
+			if err != nil || targetUser.ID == "" {
+				helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+				return
+			}
+
+			switch args[0] {
+			case "add":
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
+					return
+				}
+				noteText := strings.TrimSpace(strings.Replace(content, strings.Join(args[:2], " "), "", 1))
+
+				_, err = m.insertModNote(channel.GuildID, targetUser.ID, msg.Author.ID, noteText)
+				helpers.Relax(err)
+
+				cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Added note about User %s (#%s) on Guild #%s by %s (#%s)",
+					targetUser.Username, targetUser.ID, channel.GuildID, msg.Author.Username, msg.Author.ID))
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.mod.note-add-success", targetUser.Username, targetUser.ID))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			case "list":
+				notes, err := m.getModNotes(channel.GuildID, targetUser.ID)
+				helpers.Relax(err)
+
+				if len(notes) <= 0 {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.mod.note-list-none", targetUser.Username, targetUser.ID))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				noteListText := helpers.GetTextF("plugins.mod.note-list-title", targetUser.Username, targetUser.ID) + "\n"
+				for i, note := range notes {
+					author, err := helpers.GetUser(note.AuthorUserID)
+					if err != nil || author == nil {
+						author = new(discordgo.User)
+						author.ID = note.AuthorUserID
+						author.Username = "N/A"
+					}
+					noteListText += fmt.Sprintf("`#%d` %s by `%s (#%s)`: %s\n",
+						i+1, humanize.Time(note.CreatedAt), author.Username, author.ID, note.Text)
+				}
+
+				for _, page := range helpers.Pagify(noteListText, "\n") {
+					_, err = helpers.SendMessage(msg.ChannelID, page)
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				}
+				return
+			case "delete", "del", "remove":
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
+					return
+				}
+				noteNumber, err := strconv.Atoi(strings.TrimPrefix(args[2], "#"))
+				if err != nil {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+					return
+				}
+
+				notes, err := m.getModNotes(channel.GuildID, targetUser.ID)
+				helpers.Relax(err)
+
+				if noteNumber < 1 || noteNumber > len(notes) {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.note-delete-not-found"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				err = m.deleteModNote(notes[noteNumber-1])
+				helpers.Relax(err)
+
+				cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Deleted note about User %s (#%s) on Guild #%s by %s (#%s)",
+					targetUser.Username, targetUser.ID, channel.GuildID, msg.Author.Username, msg.Author.ID))
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.mod.note-delete-success", noteNumber, targetUser.Username, targetUser.ID))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.invalid"))
+		})
+		return
 	case "pending-unmutes", "pending-mutes": // [p]pending-unmutes
 		helpers.RequireMod(msg, func() {
 			session.ChannelTyping(msg.ChannelID)
@@ -613,9 +666,13 @@ func (m *Mod) Action(command string, content string, msg *discordgo.Message, ses
 				if strings.HasSuffix(reasonText, "Reason: ") {
 					reasonText += "None given"
 				}
+				// Send the appeal code, we can't DM the user after the ban
+				appealCode, appealCodeMessages := banAppealsSendCode(guild, targetUser)
 				// Ban user
 				err = session.GuildBanCreateWithReason(guild.ID, targetUser.ID, reasonText, days)
 				if err != nil {
+					// the user hasn't been banned, take back the appeal code
+					banAppealsRetractCode(appealCodeMessages)
 					if err, ok := err.(*discordgo.RESTError); ok && err.Message != nil {
 						if err.Message.Code == 0 {
 							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.mod.user-banned-failed-too-low"))
@@ -629,6 +686,8 @@ func (m *Mod) Action(command string, content string, msg *discordgo.Message, ses
 					}
 				}
 				cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Banned User %s (#%s) on Guild %s (#%s) by %s (#%s)", targetUser.Username, targetUser.ID, guild.Name, guild.ID, msg.Author.Username, msg.Author.ID))
+				err = banAppealsCreateCase(guild.ID, targetUser.ID, msg.Author.ID, reasonText, appealCode)
+				helpers.RelaxLog(err)
 				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.mod.user-banned-success", targetUser.Username, targetUser.ID))
 				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 			} else {
@@ -1104,13 +1163,8 @@ func (m *Mod) Action(command string, content string, msg *discordgo.Message, ses
 			joinedTimeText += fmt.Sprintf("⚠ User Account is less than one Day old.\n◾Joined at %s.\n", joinedTime.Format(time.ANSIC))
 		}
 
-		troublemakerReports := m.getTroublemakerReports(targetUser)
-		var troublemakerReportsText string
-		if len(troublemakerReports) <= 0 {
-			troublemakerReportsText = "✅ User never got reported\n"
-		} else {
-			troublemakerReportsText = fmt.Sprintf("⚠ User got reported %d time(s)\nUse `_troublemaker list %s` to view the details.\n", len(troublemakerReports), targetUser.ID)
-		}
+		troublemakerReportsText, _ := troublemakerInspectText(m.getTroublemakerReports(targetUser), targetUser.ID)
+		troublemakerReportsText += "\n"
 
 		joins, _ := m.GetJoins(targetUser.ID, channel.GuildID)
 		joinsText := ""
@@ -1163,6 +1217,12 @@ func (m *Mod) Action(command string, content string, msg *discordgo.Message, ses
 		resultText += commonGuildsText
 		resultText += joinedTimeText
 
+		if isMod {
+			modNotesText := m.inspectModNotesText(channel.GuildID, targetUser.ID)
+			resultEmbed.Fields = append(resultEmbed.Fields, &discordgo.MessageEmbedField{Name: "Mod Notes", Value: modNotesText, Inline: false})
+			resultText += modNotesText
+		}
+
 		for _, failedServer := range checkFailedServerList {
 			if failedServer.ID == channel.GuildID {
 				noAccessToBansText := "\n⚠ I wasn't able to gather the ban list for this server!\nPlease give Robyul the permission `Ban Members` to help other servers.\n"
@@ -1389,6 +1449,105 @@ func (m *Mod) Action(command string, content string, msg *discordgo.Message, ses
 			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 		})
 		return
+	case "inspect-scoring": // [p]inspect-scoring [enable|disable|weight <signal> <weight> [<days>]|action <threshold> <notify|mute|kick|remove>]
+		helpers.RequireAdmin(msg, func() {
+			channel, err := helpers.GetChannel(msg.ChannelID)
+			helpers.Relax(err)
+			settings := helpers.GuildSettingsGetCached(channel.GuildID)
+			args := strings.Fields(content)
+
+			if len(args) < 1 {
+				_, err = helpers.SendMessage(msg.ChannelID, m.inspectScoringStatusText(channel.GuildID, settings))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			var successMessage string
+			switch args[0] {
+			case "enable":
+				m.inspectScoringApplyDefaults(&settings)
+				settings.InspectScoring.Enabled = true
+				successMessage = helpers.GetText("plugins.mod.inspect-scoring-enabled")
+				if settings.InspectsChannel == "" {
+					successMessage += "\n" + helpers.GetTextF("plugins.mod.inspect-scoring-no-inspects-channel",
+						helpers.GetPrefixForServer(channel.GuildID))
+				}
+			case "disable":
+				settings.InspectScoring.Enabled = false
+				successMessage = helpers.GetText("plugins.mod.inspect-scoring-disabled")
+			case "weight":
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+				weight, err := strconv.Atoi(args[2])
+				if err != nil || weight < 0 || weight > 100 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					return
+				}
+				switch args[1] {
+				case "banned":
+					settings.InspectScoring.BannedOnOtherServers = weight
+				case "new-account":
+					settings.InspectScoring.NewlyCreatedAccount = weight
+					if len(args) >= 4 {
+						days, err := strconv.Atoi(args[3])
+						if err != nil || days < 1 {
+							helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							return
+						}
+						settings.InspectScoring.NewlyCreatedAccountDays = days
+					}
+				case "no-common-servers":
+					settings.InspectScoring.NoCommonServers = weight
+				case "reported":
+					settings.InspectScoring.Reported = weight
+				case "multiple-joins":
+					settings.InspectScoring.MultipleJoins = weight
+				default:
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.mod.inspect-scoring-invalid-signal"))
+					return
+				}
+				successMessage = helpers.GetTextF("plugins.mod.inspect-scoring-weight-set", args[1], weight)
+			case "action":
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+				threshold, err := strconv.Atoi(args[1])
+				if err != nil || threshold < 1 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					return
+				}
+				actions := make([]models.InspectScoreAction, 0)
+				for _, action := range settings.InspectScoring.Actions {
+					if action.Threshold != threshold {
+						actions = append(actions, action)
+					}
+				}
+				switch args[2] {
+				case models.InspectScoreActionNotify, models.InspectScoreActionMute, models.InspectScoreActionKick:
+					actions = append(actions, models.InspectScoreAction{Threshold: threshold, Action: args[2]})
+					successMessage = helpers.GetTextF("plugins.mod.inspect-scoring-action-set", args[2], threshold)
+				case "remove", "delete":
+					successMessage = helpers.GetTextF("plugins.mod.inspect-scoring-action-removed", threshold)
+				default:
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					return
+				}
+				sort.Slice(actions, func(i, j int) bool { return actions[i].Threshold < actions[j].Threshold })
+				settings.InspectScoring.Actions = actions
+			default:
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				return
+			}
+
+			err = helpers.GuildSettingsSet(channel.GuildID, settings)
+			helpers.Relax(err)
+			_, err = helpers.SendMessage(msg.ChannelID, successMessage)
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		})
+		return
 	case "search-user": // [p]search-user <name>
 		helpers.RequireMod(msg, func() {
 			searchText := strings.TrimSpace(content)
@@ -1453,52 +1612,49 @@ func (m *Mod) Action(command string, content string, msg *discordgo.Message, ses
 			}
 		})
 		return
-	case "audit-log":
-		helpers.RequireBotAdmin(msg, func() {
-			session.ChannelTyping(msg.ChannelID)
-			channel, err := helpers.GetChannel(msg.ChannelID)
-			helpers.Relax(err)
-			auditLogUrl := fmt.Sprintf(discordgo.EndpointAPI+"guilds/%s/audit-logs?limit=10", channel.GuildID)
-			resp, err := session.Request("GET", auditLogUrl, nil)
-			helpers.Relax(err)
-			parsedResult, err := gabs.ParseJSON(resp)
-			helpers.Relax(err)
+	case "invites": // [p]invites [<server id>], [p]invites top [<days>], [p]invites from <user>
+		args := strings.Fields(content)
+		if len(args) >= 1 && (args[0] == "top" || args[0] == "from") {
+			helpers.RequireMod(msg, func() {
+				session.ChannelTyping(msg.ChannelID)
 
-			logMessage := ""
+				channel, err := helpers.GetChannel(msg.ChannelID)
+				helpers.Relax(err)
 
-			var user *discordgo.Member
-			var target *discordgo.Member
+				var message string
+				if args[0] == "top" {
+					var since time.Time
+					if len(args) >= 2 {
+						days, err := strconv.Atoi(args[1])
+						if err != nil || days <= 0 {
+							helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+							return
+						}
+						since = time.Now().AddDate(0, 0, -days)
+					}
 
-			logEntries, err := parsedResult.Path("audit_log_entries").Children()
-			helpers.Relax(err)
-			for _, logEntry := range logEntries {
-				user, err = helpers.GetGuildMember(channel.GuildID, strings.Replace(logEntry.Path("user_id").String(), "\"", "", -1))
-				if err != nil {
-					user = new(discordgo.Member)
-					user.User = new(discordgo.User)
-					user.User.Username = "N/A"
-				}
-				target, err = helpers.GetGuildMember(channel.GuildID, strings.Replace(logEntry.Path("target_id").String(), "\"", "", -1))
-				if err != nil {
-					target = new(discordgo.Member)
-					target.User = new(discordgo.User)
-					target.User.Username = "N/A"
-				}
-				logMessage += "**Action** `" + logEntry.Path("action_type").String() + "` from `" + user.User.Username + "` for `" + target.User.Username + "`:\n"
-				logEntryChanges, err := logEntry.Path("changes").Children()
-				helpers.Relax(err)
-				for _, logEntryChange := range logEntryChanges {
-					logMessage += logEntryChange.Path("key").String() + ": " + logEntryChange.Path("new_value").String() + "\n"
+					message = m.invitesTopText(channel.GuildID, since)
+				} else {
+					if len(args) < 2 {
+						helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+						return
+					}
+					targetUser, err := helpers.GetUserFromMention(args[1])
+					if err != nil || targetUser == nil || targetUser.ID == "" {
+						helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						return
+					}
+
+					message = m.invitesFromText(channel.GuildID, targetUser)
 				}
-			}
 
-			for _, page := range helpers.Pagify(logMessage, "\n") {
-				_, err := helpers.SendMessage(msg.ChannelID, page)
-				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			}
-		})
-		return
-	case "invites":
+				for _, page := range helpers.Pagify(message, "\n") {
+					_, err := helpers.SendMessage(msg.ChannelID, page)
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				}
+			})
+			return
+		}
 		helpers.RequireBotAdmin(msg, func() {
 			session.ChannelTyping(msg.ChannelID)
 
@@ -1936,34 +2092,21 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 	go func() {
 		// Get invite link
 		var usedInvite CacheInviteInformation
-		invites, err := session.GuildInvites(member.GuildID)
+		newCacheInvites, err := m.getCacheInvites(member.GuildID)
 		if err != nil {
 			cache.GetLogger().WithField("module", "mod").Error(fmt.Sprintf("error getting invites from guild #%s: %s",
 				member.GuildID, err.Error()))
 		} else {
-			newCacheInvites := make([]CacheInviteInformation, 0)
-			for _, invite := range invites {
-				createdAt, err := invite.CreatedAt.Parse()
-				if err != nil {
-					continue
-				}
-				invitedByID := ""
-				if invite.Inviter != nil {
-					invitedByID = invite.Inviter.ID
-				}
-				newCacheInvites = append(newCacheInvites, CacheInviteInformation{
-					GuildID:         invite.Guild.ID,
-					CreatedByUserID: invitedByID,
-					Code:            invite.Code,
-					CreatedAt:       createdAt,
-					Uses:            invite.Uses,
-				})
-			}
+			invitesCacheMutex.Lock()
+			oldCacheInvites, ok := invitesCache[member.GuildID]
+			invitesCache[member.GuildID] = newCacheInvites
+			invitesCacheMutex.Unlock()
+
 			foundDiffsInInvites := make([]CacheInviteInformation, 0)
-			if _, ok := invitesCache[member.GuildID]; ok {
+			if ok {
 				for _, newInvite := range newCacheInvites {
 					seenInOldCache := false
-					for _, oldInvite := range invitesCache[member.GuildID] {
+					for _, oldInvite := range oldCacheInvites {
 						if oldInvite.Code == newInvite.Code {
 							seenInOldCache = true
 							if oldInvite.Uses != newInvite.Uses {
@@ -1975,8 +2118,25 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 						foundDiffsInInvites = append(foundDiffsInInvites, newInvite)
 					}
 				}
+				// invites with limited uses get deleted by discord when they have been used up
+				if len(foundDiffsInInvites) == 0 {
+					for _, oldInvite := range oldCacheInvites {
+						if oldInvite.MaxUses <= 0 || oldInvite.Uses+1 != oldInvite.MaxUses {
+							continue
+						}
+						seenInNewCache := false
+						for _, newInvite := range newCacheInvites {
+							if newInvite.Code == oldInvite.Code {
+								seenInNewCache = true
+							}
+						}
+						if !seenInNewCache {
+							oldInvite.Uses++
+							foundDiffsInInvites = append(foundDiffsInInvites, oldInvite)
+						}
+					}
+				}
 			}
-			invitesCache[member.GuildID] = newCacheInvites
 			if len(foundDiffsInInvites) == 1 {
 				usedInvite = foundDiffsInInvites[0]
 			}
@@ -2004,11 +2164,14 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 				return
 			}
 
+			scoringEnabled := helpers.GuildSettingsGetCached(member.GuildID).InspectScoring.Enabled
+
 			if helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserBannedOnOtherServers ||
 				helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserNoCommonServers ||
 				helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserNewlyCreatedAccount ||
 				helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserReported ||
-				helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserMultipleJoins {
+				helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserMultipleJoins ||
+				scoringEnabled {
 				guild, err := helpers.GetGuild(member.GuildID)
 				if err != nil {
 					raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
@@ -2016,11 +2179,11 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 				}
 
 				bannedOnServerList, checkFailedServerList := m.inspectUserBans(member.User, guild.ID)
-				troublemakerReports := m.getTroublemakerReports(member.User)
+				troublemakerReportsText, troublemakerReports := troublemakerInspectText(m.getTroublemakerReports(member.User), member.User.ID)
 				joins, _ := m.GetJoins(member.User.ID, member.GuildID)
 
 				cache.GetLogger().WithField("module", "mod").Info(fmt.Sprintf("Inspected user %s (%s) because he joined Guild %s (#%s): Banned On: %d, Banned Checks Failed: %d, Reports: %d, Joins: %d",
-					member.User.Username, member.User.ID, guild.Name, guild.ID, len(bannedOnServerList), len(checkFailedServerList), len(troublemakerReports), len(joins)))
+					member.User.Username, member.User.ID, guild.Name, guild.ID, len(bannedOnServerList), len(checkFailedServerList), troublemakerReports, len(joins)))
 
 				isOnServerList := m.inspectCommonServers(member.User)
 
@@ -2028,10 +2191,19 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 				oneDayAgo := time.Now().AddDate(0, 0, -1)
 				oneWeekAgo := time.Now().AddDate(0, 0, -7)
 
-				if !((helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserBannedOnOtherServers && len(bannedOnServerList) > 0) ||
+				var score int
+				var scoreBreakdown, scoreAction string
+				if scoringEnabled {
+					score, scoreBreakdown = m.inspectScore(helpers.GuildSettingsGetCached(member.GuildID),
+						len(bannedOnServerList), len(isOnServerList)-1, joinedTime, troublemakerReports, len(joins))
+					scoreAction = m.inspectScoreAction(helpers.GuildSettingsGetCached(member.GuildID), score)
+					if scoreAction == "" {
+						return
+					}
+				} else if !((helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserBannedOnOtherServers && len(bannedOnServerList) > 0) ||
 					(helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserNoCommonServers && (len(isOnServerList)-1) <= 0) ||
 					(helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserNewlyCreatedAccount && joinedTime.After(oneWeekAgo)) ||
-					(helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserReported && len(troublemakerReports) > 0) ||
+					(helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserReported && troublemakerReports > 0) ||
 					(helpers.GuildSettingsGetCached(member.GuildID).InspectTriggersEnabled.UserMultipleJoins && len(joins) > 1)) {
 					return
 				}
@@ -2068,13 +2240,6 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 					joinedTimeText += fmt.Sprintf("⚠ User Account is less than one Day old.\n◾Joined at %s.", joinedTime.Format(time.ANSIC))
 				}
 
-				var troublemakerReportsText string
-				if len(troublemakerReports) <= 0 {
-					troublemakerReportsText = "✅ User never got reported"
-				} else {
-					troublemakerReportsText = fmt.Sprintf("⚠ User got reported %d time(s)\nUse `_troublemaker list %s` to view the details.", len(troublemakerReports), member.User.ID)
-				}
-
 				joinsText := ""
 				if len(joins) == 0 {
 					joinsText = "✅ User never joined this server\n"
@@ -2119,6 +2284,17 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 					{Name: "Join History", Value: joinsText, Inline: false},
 					{Name: "Common Servers", Value: commonGuildsText, Inline: false},
 					{Name: "Account Age", Value: joinedTimeText, Inline: false},
+					{Name: "Mod Notes", Value: m.inspectModNotesText(member.GuildID, member.User.ID), Inline: false},
+				}
+
+				var scoreActionResult string
+				if scoringEnabled {
+					scoreActionResult = m.inspectRunScoreAction(member, scoreAction, score)
+					resultEmbed.Fields = append(resultEmbed.Fields, &discordgo.MessageEmbedField{
+						Name:   fmt.Sprintf("Score: %d", score),
+						Value:  scoreBreakdown + "\n" + scoreActionResult,
+						Inline: false,
+					})
 				}
 
 				for _, failedServer := range checkFailedServerList {
@@ -2130,6 +2306,9 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 
 				_, err = helpers.SendEmbed(helpers.GuildSettingsGetCached(member.GuildID).InspectsChannel, resultEmbed)
 				if err != nil {
+					if scoringEnabled {
+						m.inspectLogScoreAction(member, score, scoreActionResult)
+					}
 					cache.GetLogger().WithField("module", "mod").Error(fmt.Sprintf("Failed to send guild join inspect to channel #%s on guild #%s: %s",
 						helpers.GuildSettingsGetCached(member.GuildID).InspectsChannel, member.GuildID, err.Error()))
 					if errD, ok := err.(*discordgo.RESTError); ok {
@@ -2164,6 +2343,311 @@ func (m *Mod) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Sess
 	}()
 }
 
+// getCacheInvites returns the current invites of the guild for the invites cache
+func (m *Mod) getCacheInvites(guildID string) (cacheInvites []CacheInviteInformation, err error) {
+	invites, err := cache.GetSession().GuildInvites(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheInvites = make([]CacheInviteInformation, 0)
+	for _, invite := range invites {
+		createdAt, err := invite.CreatedAt.Parse()
+		if err != nil {
+			continue
+		}
+		inviterID := ""
+		if invite.Inviter != nil {
+			inviterID = invite.Inviter.ID
+		}
+		cacheInvites = append(cacheInvites, CacheInviteInformation{
+			GuildID:         guildID,
+			CreatedByUserID: inviterID,
+			Code:            invite.Code,
+			CreatedAt:       createdAt,
+			Uses:            invite.Uses,
+			MaxUses:         invite.MaxUses,
+		})
+	}
+	return cacheInvites, nil
+}
+
+// inspectScoringApplyDefaults sets the default weights and actions for signals which have never been configured
+func (m *Mod) inspectScoringApplyDefaults(settings *models.Config) {
+	if settings.InspectScoring.BannedOnOtherServers == 0 && settings.InspectScoring.NewlyCreatedAccount == 0 &&
+		settings.InspectScoring.NoCommonServers == 0 && settings.InspectScoring.Reported == 0 &&
+		settings.InspectScoring.MultipleJoins == 0 {
+		settings.InspectScoring.BannedOnOtherServers = 40
+		settings.InspectScoring.NewlyCreatedAccount = 30
+		settings.InspectScoring.NoCommonServers = 10
+		settings.InspectScoring.Reported = 30
+		settings.InspectScoring.MultipleJoins = 10
+	}
+	if settings.InspectScoring.NewlyCreatedAccountDays <= 0 {
+		settings.InspectScoring.NewlyCreatedAccountDays = 7
+	}
+	if len(settings.InspectScoring.Actions) <= 0 {
+		settings.InspectScoring.Actions = []models.InspectScoreAction{
+			{Threshold: 30, Action: models.InspectScoreActionNotify},
+		}
+	}
+}
+
+// inspectScore sums up the weights of all signals of a joining member, breakdown lists the signals that matched
+func (m *Mod) inspectScore(settings models.Config, bannedOn int, commonServers int, createdAt time.Time, reports int, joins int) (score int, breakdown string) {
+	addSignal := func(points int, text string) {
+		if points <= 0 {
+			return
+		}
+		score += points
+		breakdown += fmt.Sprintf("◾+%d %s\n", points, text)
+	}
+
+	addSignal(bannedOn*settings.InspectScoring.BannedOnOtherServers,
+		fmt.Sprintf("banned on %d other server(s)", bannedOn))
+	if createdAt.After(time.Now().AddDate(0, 0, -settings.InspectScoring.NewlyCreatedAccountDays)) {
+		addSignal(settings.InspectScoring.NewlyCreatedAccount,
+			fmt.Sprintf("account is less than %d day(s) old", settings.InspectScoring.NewlyCreatedAccountDays))
+	}
+	if commonServers <= 0 {
+		addSignal(settings.InspectScoring.NoCommonServers, "no other common servers")
+	}
+	addSignal(reports*settings.InspectScoring.Reported,
+		fmt.Sprintf("reported %d time(s)", reports))
+	if joins > 1 {
+		addSignal((joins-1)*settings.InspectScoring.MultipleJoins,
+			fmt.Sprintf("joined %d times", joins))
+	}
+
+	if breakdown == "" {
+		breakdown = "◾no signals\n"
+	}
+	return score, breakdown
+}
+
+// inspectScoreAction returns the action of the highest threshold the score reaches, or an empty string
+func (m *Mod) inspectScoreAction(settings models.Config, score int) (action string) {
+	var highestThreshold int
+	for _, scoreAction := range settings.InspectScoring.Actions {
+		if score >= scoreAction.Threshold && scoreAction.Threshold >= highestThreshold {
+			highestThreshold = scoreAction.Threshold
+			action = scoreAction.Action
+		}
+	}
+	return action
+}
+
+// inspectRunScoreAction runs the action for a joining member and returns a text describing the result
+func (m *Mod) inspectRunScoreAction(member *discordgo.Member, action string, score int) (result string) {
+	var err error
+	switch action {
+	case models.InspectScoreActionMute:
+		var muteRole *discordgo.Role
+		muteRole, err = helpers.GetMuteRole(member.GuildID)
+		if err == nil {
+			err = cache.GetSession().GuildMemberRoleAdd(member.GuildID, member.User.ID, muteRole.ID)
+		}
+		if err == nil {
+			// keeps the member muted if they leave and rejoin before their role update got cached
+			err = helpers.AddMutePersistency(member.GuildID, member.User.ID)
+		}
+		if err == nil {
+			return helpers.GetTextF("plugins.mod.inspect-scoring-result-muted", helpers.GetPrefixForServer(member.GuildID), member.User.ID)
+		}
+	case models.InspectScoreActionKick:
+		err = cache.GetSession().GuildMemberDeleteWithReason(member.GuildID, member.User.ID,
+			fmt.Sprintf("Inspect score of %d", score))
+		if err == nil {
+			return helpers.GetText("plugins.mod.inspect-scoring-result-kicked")
+		}
+	default:
+		return helpers.GetText("plugins.mod.inspect-scoring-result-notified")
+	}
+
+	cache.GetLogger().WithField("module", "mod").WithField("GuildID", member.GuildID).Warn(
+		fmt.Sprintf("unable to %s user #%s after inspect: %s", action, member.User.ID, err.Error()))
+	return helpers.GetTextF("plugins.mod.inspect-scoring-result-failed", action)
+}
+
+// inspectLogScoreAction posts the result of a score action if the inspect embed couldn't be sent,
+// falls back to the mod log channel if the inspects channel isn't available
+func (m *Mod) inspectLogScoreAction(member *discordgo.Member, score int, result string) {
+	settings := helpers.GuildSettingsGetCached(member.GuildID)
+	logText := helpers.GetTextF("plugins.mod.inspect-scoring-action-log",
+		member.User.Username, member.User.Discriminator, member.User.ID, score, result)
+
+	var err error
+	if settings.InspectsChannel != "" {
+		_, err = helpers.SendMessage(settings.InspectsChannel, logText)
+		if err == nil {
+			return
+		}
+	}
+	if settings.ModLogChannelID != "" {
+		_, err = helpers.SendMessage(settings.ModLogChannelID, logText)
+	}
+	if err != nil {
+		cache.GetLogger().WithField("module", "mod").WithField("GuildID", member.GuildID).Warn(
+			"unable to log inspect score action: " + err.Error())
+	}
+}
+
+func (m *Mod) inspectScoringStatusText(guildID string, settings models.Config) (text string) {
+	if !settings.InspectScoring.Enabled {
+		return helpers.GetTextF("plugins.mod.inspect-scoring-status-disabled", helpers.GetPrefixForServer(guildID))
+	}
+
+	text = helpers.GetTextF("plugins.mod.inspect-scoring-status-enabled",
+		settings.InspectScoring.BannedOnOtherServers,
+		settings.InspectScoring.NewlyCreatedAccount, settings.InspectScoring.NewlyCreatedAccountDays,
+		settings.InspectScoring.NoCommonServers,
+		settings.InspectScoring.Reported,
+		settings.InspectScoring.MultipleJoins)
+	if len(settings.InspectScoring.Actions) <= 0 {
+		text += "\n" + helpers.GetText("plugins.mod.inspect-scoring-status-no-actions")
+	}
+	for _, action := range settings.InspectScoring.Actions {
+		text += fmt.Sprintf("\n◾Score **%d** or more: `%s`", action.Threshold, action.Action)
+	}
+	if settings.InspectsChannel == "" {
+		text += "\n" + helpers.GetTextF("plugins.mod.inspect-scoring-no-inspects-channel", helpers.GetPrefixForServer(guildID))
+	}
+	return text
+}
+
+// getInviteJoins returns all joins of the guild with a known invite, joined after since
+func (m *Mod) getInviteJoins(guildID string, since time.Time) (joins []DB_Mod_JoinLog, err error) {
+	query := rethink.Table("mod_joinlog").GetAllByIndex("guildid", guildID).Filter(
+		rethink.Row.Field("invitecode").Ne(""),
+	)
+	if !since.IsZero() {
+		query = query.Filter(rethink.Row.Field("joinedat").Ge(since))
+	}
+	listCursor, err := query.Run(helpers.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	defer listCursor.Close()
+
+	err = listCursor.All(&joins)
+	return joins, err
+}
+
+// invitesTopText returns a ranking of the invites members joined with, since can be zero for all time
+func (m *Mod) invitesTopText(guildID string, since time.Time) string {
+	joins, err := m.getInviteJoins(guildID, since)
+	helpers.Relax(err)
+
+	if len(joins) <= 0 {
+		return helpers.GetText("plugins.mod.invites-top-none")
+	}
+
+	type inviteStats struct {
+		Code            string
+		CreatedByUserID string
+		Joins           int
+		Stayed          int
+	}
+	statsByCode := make(map[string]*inviteStats, 0)
+	for _, join := range joins {
+		if _, ok := statsByCode[join.InviteCodeUsed]; !ok {
+			statsByCode[join.InviteCodeUsed] = &inviteStats{
+				Code:            join.InviteCodeUsed,
+				CreatedByUserID: join.InviteCodeCreatedByUserID,
+			}
+		}
+		statsByCode[join.InviteCodeUsed].Joins++
+		if helpers.GetIsInGuild(guildID, join.UserID) {
+			statsByCode[join.InviteCodeUsed].Stayed++
+		}
+	}
+	stats := make([]*inviteStats, 0)
+	for _, inviteStat := range statsByCode {
+		stats = append(stats, inviteStat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Joins > stats[j].Joins })
+
+	invitesCacheMutex.Lock()
+	activeInvites := invitesCache[guildID]
+	invitesCacheMutex.Unlock()
+
+	var message string
+	if since.IsZero() {
+		message = helpers.GetTextF("plugins.mod.invites-top-title-alltime", len(joins)) + "\n"
+	} else {
+		message = helpers.GetTextF("plugins.mod.invites-top-title-since", len(joins), humanize.Time(since)) + "\n"
+	}
+	for i, inviteStat := range stats {
+		if i >= 25 {
+			break
+		}
+
+		createdByName := "N/A"
+		createdByUser, err := helpers.GetUser(inviteStat.CreatedByUserID)
+		if err == nil && createdByUser != nil {
+			createdByName = createdByUser.Username + "#" + createdByUser.Discriminator
+		}
+		status := "expired"
+		for _, activeInvite := range activeInvites {
+			if activeInvite.Code == inviteStat.Code {
+				status = "active"
+			}
+		}
+
+		message += fmt.Sprintf("#%d `%s` by `%s` (#%s): **%d** joins, %d still here (%s)\n",
+			i+1, inviteStat.Code, createdByName, inviteStat.CreatedByUserID, inviteStat.Joins, inviteStat.Stayed, status)
+	}
+
+	return message
+}
+
+// invitesFromText returns the invites created by the user, and the members who joined using them
+func (m *Mod) invitesFromText(guildID string, user *discordgo.User) string {
+	joins, err := m.getInviteJoins(guildID, time.Time{})
+	helpers.Relax(err)
+
+	joinsByCode := make(map[string][]DB_Mod_JoinLog, 0)
+	codes := make([]string, 0)
+	var totalJoins int
+	for _, join := range joins {
+		if join.InviteCodeCreatedByUserID != user.ID {
+			continue
+		}
+		if _, ok := joinsByCode[join.InviteCodeUsed]; !ok {
+			codes = append(codes, join.InviteCodeUsed)
+		}
+		joinsByCode[join.InviteCodeUsed] = append(joinsByCode[join.InviteCodeUsed], join)
+		totalJoins++
+	}
+
+	if totalJoins <= 0 {
+		return helpers.GetTextF("plugins.mod.invites-from-none", user.Username)
+	}
+	sort.Slice(codes, func(i, j int) bool { return len(joinsByCode[codes[i]]) > len(joinsByCode[codes[j]]) })
+
+	message := helpers.GetTextF("plugins.mod.invites-from-title", user.Username, user.ID, totalJoins, len(codes)) + "\n"
+	for _, code := range codes {
+		codeJoins := joinsByCode[code]
+		sort.Slice(codeJoins, func(i, j int) bool { return codeJoins[i].JoinedAt.After(codeJoins[j].JoinedAt) })
+
+		message += fmt.Sprintf("`%s`: **%d** joins, created %s\n",
+			code, len(codeJoins), humanize.Time(codeJoins[0].InviteCodeCreatedAt))
+		for i, join := range codeJoins {
+			if i >= 10 {
+				message += fmt.Sprintf("\tand %d more\n", len(codeJoins)-i)
+				break
+			}
+			stayed := ""
+			if !helpers.GetIsInGuild(guildID, join.UserID) {
+				stayed = ", left"
+			}
+			message += fmt.Sprintf("\t<@%s> joined %s%s\n", join.UserID, humanize.Time(join.JoinedAt), stayed)
+		}
+	}
+
+	return message
+}
+
 func (m *Mod) InsertJoinLog(entry DB_Mod_JoinLog) error {
 	if entry.UserID != "" {
 		insert := rethink.Table("mod_joinlog").Insert(entry)
@@ -2277,13 +2761,7 @@ func (m *Mod) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Sess
 						joinedTimeText += fmt.Sprintf("⚠ User Account is less than one Day old.\n◾Joined at %s.", joinedTime.Format(time.ANSIC))
 					}
 
-					troublemakerReports := m.getTroublemakerReports(user.User)
-					var troublemakerReportsText string
-					if len(troublemakerReports) <= 0 {
-						troublemakerReportsText = "✅ User never got reported"
-					} else {
-						troublemakerReportsText = fmt.Sprintf("⚠ User got reported %d time(s)\nUse `_troublemaker list %s` to view the details.", len(troublemakerReports), user.User.ID)
-					}
+					troublemakerReportsText, _ := troublemakerInspectText(m.getTroublemakerReports(user.User), user.User.ID)
 
 					joins, _ := m.GetJoins(user.User.ID, targetGuild.ID)
 					joinsText := ""
@@ -2388,3 +2866,277 @@ func (m *Mod) removeDuplicates(elements []string) []string {
 	}
 	return result
 }
+
+// getMessagesAfter returns all messages after the given message id, until (including) untilMessageID if set,
+// sorted from oldest to newest
+func (m *Mod) getMessagesAfter(channelID string, afterMessageID string, untilMessageID string) (messages []*discordgo.Message) {
+	messages = make([]*discordgo.Message, 0)
+
+	nextAfterID := afterMessageID
+AllMessagesLoop:
+	for {
+		messagesBatch, _ := cache.GetSession().ChannelMessages(channelID, 100, "", nextAfterID, "")
+		if len(messagesBatch) <= 0 {
+			break AllMessagesLoop
+		}
+		slice.Sort(messagesBatch, func(i, j int) bool {
+			return messagesBatch[i].Timestamp < messagesBatch[j].Timestamp
+		})
+		for _, message := range messagesBatch {
+			messages = append(messages, message)
+			nextAfterID = message.ID
+			if message.ID == untilMessageID {
+				break AllMessagesLoop
+			}
+		}
+	}
+
+	return messages
+}
+
+// getLastMessages returns the last n messages before the given message id, sorted from oldest to newest
+func (m *Mod) getLastMessages(channelID string, beforeMessageID string, n int) (messages []*discordgo.Message) {
+	messages = make([]*discordgo.Message, 0)
+
+	messagesLeft := n
+	lastBeforeID := beforeMessageID
+	for messagesLeft > 0 {
+		messagesToGet := messagesLeft
+		if messagesLeft > 100 {
+			messagesToGet = 100
+		}
+
+		messagesBatch, _ := cache.GetSession().ChannelMessages(channelID, messagesToGet, lastBeforeID, "", "")
+		if len(messagesBatch) <= 0 {
+			break
+		}
+		messagesLeft -= len(messagesBatch)
+
+		slice.Sort(messagesBatch, func(i, j int) bool {
+			return messagesBatch[i].Timestamp < messagesBatch[j].Timestamp
+		})
+		lastBeforeID = messagesBatch[0].ID
+		messages = append(messagesBatch, messages...)
+	}
+
+	return messages
+}
+
+var (
+	cleanupLinkRegex = regexp.MustCompile(`(?i)https?://\S+`)
+)
+
+type modCleanupFilter struct {
+	UserIDs     []string
+	Bots        bool
+	Attachments bool
+	Links       bool
+	Embeds      bool
+	Regex       *regexp.Regexp
+}
+
+// parseCleanupFilter parses cleanup filters, possible filters:
+// user <user>, bots, attachments, links, embeds, regex <pattern>
+func (m *Mod) parseCleanupFilter(msg *discordgo.Message, args []string) (filter modCleanupFilter, err error) {
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "user", "users":
+			if i+1 >= len(args) {
+				return filter, errors.New("user filter without user")
+			}
+			i++
+			targetUser, err := helpers.GetUserFromMention(args[i])
+			if err != nil || targetUser == nil || targetUser.ID == "" {
+				return filter, errors.New("user filter with invalid user")
+			}
+			filter.UserIDs = append(filter.UserIDs, targetUser.ID)
+		case "bots", "bot":
+			filter.Bots = true
+		case "attachments", "attachment", "files":
+			filter.Attachments = true
+		case "links", "link":
+			filter.Links = true
+		case "embeds", "embed":
+			filter.Embeds = true
+		case "regex":
+			if i+1 >= len(args) {
+				return filter, errors.New("regex filter without pattern")
+			}
+			i++
+			filter.Regex, err = regexp.Compile(args[i])
+			if err != nil {
+				return filter, err
+			}
+		default:
+			return filter, errors.New("unknown cleanup filter: " + args[i])
+		}
+	}
+	return filter, nil
+}
+
+// Active returns true if at least one filter is set
+func (f modCleanupFilter) Active() bool {
+	return len(f.UserIDs) > 0 || f.Bots || f.Attachments || f.Links || f.Embeds || f.Regex != nil
+}
+
+// Matches returns true if the message matches all set filters
+func (f modCleanupFilter) Matches(message *discordgo.Message) bool {
+	if len(f.UserIDs) > 0 {
+		if message.Author == nil {
+			return false
+		}
+		var userMatched bool
+		for _, userID := range f.UserIDs {
+			if message.Author.ID == userID {
+				userMatched = true
+				break
+			}
+		}
+		if !userMatched {
+			return false
+		}
+	}
+	if f.Bots && (message.Author == nil || !message.Author.Bot) {
+		return false
+	}
+	if f.Attachments && len(message.Attachments) <= 0 {
+		return false
+	}
+	if f.Links && !cleanupLinkRegex.MatchString(message.Content) {
+		return false
+	}
+	if f.Embeds && len(message.Embeds) <= 0 {
+		return false
+	}
+	if f.Regex != nil && !f.Regex.MatchString(message.Content) {
+		return false
+	}
+	return true
+}
+
+func (f modCleanupFilter) String() string {
+	filterTexts := make([]string, 0)
+	for _, userID := range f.UserIDs {
+		filterTexts = append(filterTexts, fmt.Sprintf("from <@%s>", userID))
+	}
+	if f.Bots {
+		filterTexts = append(filterTexts, "from bots")
+	}
+	if f.Attachments {
+		filterTexts = append(filterTexts, "with attachments")
+	}
+	if f.Links {
+		filterTexts = append(filterTexts, "with links")
+	}
+	if f.Embeds {
+		filterTexts = append(filterTexts, "with embeds")
+	}
+	if f.Regex != nil {
+		filterTexts = append(filterTexts, fmt.Sprintf("matching `%s`", f.Regex.String()))
+	}
+	if len(filterTexts) <= 0 {
+		return "none"
+	}
+	return strings.Join(filterTexts, ", ")
+}
+
+// archiveMessages posts a transcript of the given messages to the mod log channel
+func (m *Mod) archiveMessages(modLogChannelID string, channel *discordgo.Channel, author *discordgo.User, messages []*discordgo.Message, filter modCleanupFilter) (err error) {
+	var transcript bytes.Buffer
+	transcript.WriteString(fmt.Sprintf("Cleanup of %d messages in #%s (#%s) by %s#%s (#%s) at %s\r\n",
+		len(messages), channel.Name, channel.ID, author.Username, author.Discriminator, author.ID,
+		time.Now().UTC().Format(time.RFC1123)))
+	transcript.WriteString(fmt.Sprintf("Filters: %s\r\n\r\n", filter.String()))
+
+	for _, message := range messages {
+		createdAt, err := message.Timestamp.Parse()
+		if err != nil {
+			createdAt = helpers.GetTimeFromSnowflake(message.ID)
+		}
+		authorText := "N/A"
+		if message.Author != nil {
+			authorText = fmt.Sprintf("%s#%s (#%s)", message.Author.Username, message.Author.Discriminator, message.Author.ID)
+			if message.Author.Bot {
+				authorText += " [BOT]"
+			}
+		}
+		transcript.WriteString(fmt.Sprintf("[%s] %s: %s\r\n",
+			createdAt.UTC().Format("2006-01-02 15:04:05"), authorText, message.Content))
+		for _, attachment := range message.Attachments {
+			transcript.WriteString(fmt.Sprintf("\tAttachment: %s\r\n", attachment.URL))
+		}
+		if len(message.Embeds) > 0 {
+			transcript.WriteString(fmt.Sprintf("\tEmbeds: %d\r\n", len(message.Embeds)))
+		}
+	}
+
+	_, err = helpers.SendComplex(modLogChannelID, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Title: helpers.GetText("plugins.mod.cleanup-archive-embed-title"),
+			Description: helpers.GetTextF("plugins.mod.cleanup-archive-embed-description",
+				author.ID, len(messages), channel.ID, filter.String()),
+			Color: 0x0FADED,
+		},
+		Files: []*discordgo.File{
+			{
+				Name:   fmt.Sprintf("cleanup-%s-%s.txt", channel.ID, time.Now().UTC().Format("20060102-150405")),
+				Reader: bytes.NewReader(transcript.Bytes()),
+			},
+		},
+	})
+	return err
+}
+
+// getModNotes returns all notes about the user on the guild, sorted from oldest to newest
+func (m *Mod) getModNotes(guildID string, userID string) (notes []models.ModNoteEntry, err error) {
+	listCursor, err := rethink.Table(models.ModNotesTable).GetAllByIndex(
+		"user_id", userID,
+	).Filter(
+		rethink.Row.Field("guild_id").Eq(guildID),
+	).OrderBy(rethink.Asc("created_at")).Run(helpers.GetDB())
+	if err != nil {
+		return notes, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&notes)
+	return notes, err
+}
+
+func (m *Mod) insertModNote(guildID string, userID string, authorUserID string, text string) (note models.ModNoteEntry, err error) {
+	note = models.ModNoteEntry{
+		GuildID:      guildID,
+		UserID:       userID,
+		AuthorUserID: authorUserID,
+		CreatedAt:    time.Now(),
+		Text:         text,
+	}
+	insert := rethink.Table(models.ModNotesTable).Insert(note)
+	_, err = insert.RunWrite(helpers.GetDB())
+	return note, err
+}
+
+func (m *Mod) deleteModNote(note models.ModNoteEntry) (err error) {
+	if note.ID != "" {
+		_, err = rethink.Table(models.ModNotesTable).Get(note.ID).Delete().RunWrite(helpers.GetDB())
+		return err
+	}
+	return errors.New("empty modNoteEntry submitted")
+}
+
+func (m *Mod) inspectModNotesText(guildID string, userID string) string {
+	notes, err := m.getModNotes(guildID, userID)
+	if err != nil {
+		cache.GetLogger().WithField("module", "mod").Warn(fmt.Sprintf("getting mod notes failed: %s", err.Error()))
+		return "❓ Unable to get notes\n"
+	}
+	if len(notes) <= 0 {
+		return "✅ No notes about this user\n"
+	}
+
+	lastNote := notes[len(notes)-1]
+	if len([]rune(lastNote.Text)) > 200 {
+		lastNote.Text = string([]rune(lastNote.Text)[:200]) + "…"
+	}
+	return fmt.Sprintf("📝 %d note(s), latest %s: %s\nUse `%snote list %s` to view all notes.\n",
+		len(notes), humanize.Time(lastNote.CreatedAt), lastNote.Text, helpers.GetPrefixForServer(guildID), userID)
+}
@@ -0,0 +1,987 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type modmailAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next modmailAction)
+
+type Modmail struct{}
+
+// modmailPendingSelection contains the messages of a user who shares multiple servers with modmail with the bot,
+// until the user picked a server
+type modmailPendingSelection struct {
+	GuildIDs  []string
+	Messages  []*discordgo.Message
+	CreatedAt time.Time
+}
+
+const (
+	modmailColorUser  = 0x3498DB
+	modmailColorStaff = 0x2ECC71
+	modmailColorClose = 0x95A5A6
+	// pending selections older than this are dropped, the next DM starts over
+	modmailPendingSelectionTTL = 10 * time.Minute
+)
+
+var (
+	modmailPendingSelections map[string]modmailPendingSelection
+	// one lock for every user ID, so a user can't open multiple threads at once
+	modmailUserLocks map[string]*sync.Mutex
+	// modmailMutex protects modmailPendingSelections and modmailUserLocks
+	modmailMutex            sync.Mutex
+	modmailChannelNameRegex = regexp.MustCompile(`[^a-z0-9\-]+`)
+)
+
+func (mm *Modmail) Commands() []string {
+	return []string{
+		"modmail",
+	}
+}
+
+func (mm *Modmail) Init(session *discordgo.Session) {
+	modmailMutex.Lock()
+	modmailPendingSelections = make(map[string]modmailPendingSelection, 0)
+	modmailUserLocks = make(map[string]*sync.Mutex, 0)
+	modmailMutex.Unlock()
+}
+
+func (mm *Modmail) Uninit(session *discordgo.Session) {
+
+}
+
+func (mm *Modmail) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := mm.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (mm *Modmail) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	if !helpers.IsMod(in) {
+		*out = mm.newMsg("mod.no_permission")
+		return mm.actionFinish
+	}
+
+	if len(args) < 1 {
+		return mm.actionStatus
+	}
+
+	switch args[0] {
+	case "status":
+		return mm.actionStatus
+	case "setup", "disable", "anonymous":
+		if !helpers.IsAdmin(in) {
+			*out = mm.newMsg("admin.no_permission")
+			return mm.actionFinish
+		}
+		switch args[0] {
+		case "setup":
+			return mm.actionSetup
+		case "disable":
+			return mm.actionDisable
+		}
+		return mm.actionAnonymous
+	case "block", "unblock":
+		return mm.actionBlock
+	case "snippets", "snippet":
+		return mm.actionSnippets
+	case "reply", "areply":
+		return mm.actionReply
+	case "send":
+		return mm.actionSend
+	case "close":
+		return mm.actionClose
+	}
+
+	*out = mm.newMsg("bot.arguments.invalid")
+	return mm.actionFinish
+}
+
+// [p]modmail [status]
+func (mm *Modmail) actionStatus(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	if !settings.ModmailEnabled {
+		*out = mm.newMsg("plugins.modmail.status-disabled", helpers.GetPrefixForServer(channel.GuildID))
+		return mm.actionFinish
+	}
+
+	threads, err := mm.getOpenThreads(channel.GuildID)
+	helpers.Relax(err)
+
+	anonymousText := "with their names"
+	if settings.ModmailAnonymous {
+		anonymousText = "anonymously"
+	}
+
+	*out = mm.newMsg("plugins.modmail.status-enabled", settings.ModmailCategoryID, settings.ModmailLogChannelID,
+		anonymousText, len(threads), len(settings.ModmailBlockedUserIDs), len(settings.ModmailSnippets))
+	return mm.actionFinish
+}
+
+// [p]modmail setup <category id> <log channel>
+func (mm *Modmail) actionSetup(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	if len(args) < 3 {
+		*out = mm.newMsg("bot.arguments.too-few")
+		return mm.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	category, err := helpers.GetChannel(args[1])
+	if err != nil || category.GuildID != channel.GuildID || category.Type != discordgo.ChannelTypeGuildCategory {
+		*out = mm.newMsg("plugins.modmail.setup-invalid-category")
+		return mm.actionFinish
+	}
+
+	logChannel, err := helpers.GetChannelFromMention(in, args[2])
+	if err != nil || logChannel.GuildID != channel.GuildID {
+		*out = mm.newMsg("bot.arguments.invalid")
+		return mm.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.ModmailEnabled = true
+	settings.ModmailCategoryID = category.ID
+	settings.ModmailLogChannelID = logChannel.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = mm.newMsg("plugins.modmail.setup-success", category.Name, logChannel.ID)
+	return mm.actionFinish
+}
+
+// [p]modmail disable
+func (mm *Modmail) actionDisable(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.ModmailEnabled = false
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = mm.newMsg("plugins.modmail.disabled")
+	return mm.actionFinish
+}
+
+// [p]modmail anonymous
+func (mm *Modmail) actionAnonymous(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.ModmailAnonymous = !settings.ModmailAnonymous
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.ModmailAnonymous {
+		*out = mm.newMsg("plugins.modmail.anonymous-enabled")
+	} else {
+		*out = mm.newMsg("plugins.modmail.anonymous-disabled")
+	}
+	return mm.actionFinish
+}
+
+// [p]modmail block <user>, [p]modmail unblock <user>
+func (mm *Modmail) actionBlock(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	if len(args) < 2 {
+		*out = mm.newMsg("bot.arguments.too-few")
+		return mm.actionFinish
+	}
+
+	targetUser, err := helpers.GetUserFromMention(args[1])
+	if err != nil || targetUser == nil || targetUser.ID == "" {
+		*out = mm.newMsg("bot.arguments.invalid")
+		return mm.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	blockedUserIDs := make([]string, 0)
+	for _, blockedUserID := range settings.ModmailBlockedUserIDs {
+		if blockedUserID != targetUser.ID {
+			blockedUserIDs = append(blockedUserIDs, blockedUserID)
+		}
+	}
+	if args[0] == "block" {
+		blockedUserIDs = append(blockedUserIDs, targetUser.ID)
+	}
+	settings.ModmailBlockedUserIDs = blockedUserIDs
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if args[0] == "block" {
+		*out = mm.newMsg("plugins.modmail.blocked", targetUser.Username, targetUser.ID)
+	} else {
+		*out = mm.newMsg("plugins.modmail.unblocked", targetUser.Username, targetUser.ID)
+	}
+	return mm.actionFinish
+}
+
+// [p]modmail snippets, [p]modmail snippet add <name> <text>, [p]modmail snippet delete <name>
+func (mm *Modmail) actionSnippets(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if len(args) < 2 {
+		if len(settings.ModmailSnippets) <= 0 {
+			*out = mm.newMsg("plugins.modmail.snippets-none", helpers.GetPrefixForServer(channel.GuildID))
+			return mm.actionFinish
+		}
+
+		message := helpers.GetText("plugins.modmail.snippets-list") + "\n"
+		for _, snippet := range settings.ModmailSnippets {
+			message += fmt.Sprintf("`%s`: %s\n", snippet.Name, snippet.Text)
+		}
+		for _, page := range helpers.Pagify(message, "\n") {
+			_, err = helpers.SendMessage(in.ChannelID, page)
+			helpers.Relax(err)
+		}
+		return nil
+	}
+
+	if len(args) < 3 {
+		*out = mm.newMsg("bot.arguments.too-few")
+		return mm.actionFinish
+	}
+	name := strings.ToLower(args[2])
+
+	snippets := make([]models.ModmailSnippet, 0)
+	for _, snippet := range settings.ModmailSnippets {
+		if snippet.Name != name {
+			snippets = append(snippets, snippet)
+		}
+	}
+
+	switch args[1] {
+	case "add":
+		if len(args) < 4 {
+			*out = mm.newMsg("bot.arguments.too-few")
+			return mm.actionFinish
+		}
+		text := mm.textAfter(in, args, 3)
+		snippets = append(snippets, models.ModmailSnippet{Name: name, Text: text})
+		*out = mm.newMsg("plugins.modmail.snippet-added", name)
+	case "delete", "remove":
+		if len(snippets) == len(settings.ModmailSnippets) {
+			*out = mm.newMsg("plugins.modmail.snippet-not-found")
+			return mm.actionFinish
+		}
+		*out = mm.newMsg("plugins.modmail.snippet-deleted", name)
+	default:
+		*out = mm.newMsg("bot.arguments.invalid")
+		return mm.actionFinish
+	}
+
+	settings.ModmailSnippets = snippets
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	return mm.actionFinish
+}
+
+// [p]modmail reply <text>, [p]modmail areply <text>
+func (mm *Modmail) actionReply(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	if len(args) < 2 && len(in.Attachments) <= 0 {
+		*out = mm.newMsg("bot.arguments.too-few")
+		return mm.actionFinish
+	}
+
+	thread, err := mm.getThreadByChannelID(in.ChannelID)
+	if err != nil || !thread.Open {
+		*out = mm.newMsg("plugins.modmail.not-a-thread")
+		return mm.actionFinish
+	}
+
+	text := ""
+	if len(args) >= 2 {
+		text = mm.textAfter(in, args, 1)
+	}
+	anonymous := args[0] == "areply" || helpers.GuildSettingsGetCached(thread.GuildID).ModmailAnonymous
+
+	err = mm.relayToUser(thread, in, text, anonymous)
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok && errD.Message != nil && errD.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser {
+			*out = mm.newMsg("plugins.modmail.reply-cannot-dm")
+			return mm.actionFinish
+		}
+	}
+	helpers.Relax(err)
+
+	return nil
+}
+
+// [p]modmail send <snippet name>
+func (mm *Modmail) actionSend(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	if len(args) < 2 {
+		*out = mm.newMsg("bot.arguments.too-few")
+		return mm.actionFinish
+	}
+
+	thread, err := mm.getThreadByChannelID(in.ChannelID)
+	if err != nil || !thread.Open {
+		*out = mm.newMsg("plugins.modmail.not-a-thread")
+		return mm.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(thread.GuildID)
+	var text string
+	for _, snippet := range settings.ModmailSnippets {
+		if snippet.Name == strings.ToLower(args[1]) {
+			text = snippet.Text
+		}
+	}
+	if text == "" {
+		*out = mm.newMsg("plugins.modmail.snippet-not-found")
+		return mm.actionFinish
+	}
+
+	err = mm.relayToUser(thread, in, text, settings.ModmailAnonymous)
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok && errD.Message != nil && errD.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser {
+			*out = mm.newMsg("plugins.modmail.reply-cannot-dm")
+			return mm.actionFinish
+		}
+	}
+	helpers.Relax(err)
+
+	return nil
+}
+
+// [p]modmail close [<reason>]
+func (mm *Modmail) actionClose(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	thread, err := mm.getThreadByChannelID(in.ChannelID)
+	if err != nil || !thread.Open {
+		*out = mm.newMsg("plugins.modmail.not-a-thread")
+		return mm.actionFinish
+	}
+
+	var reason string
+	if len(args) >= 2 {
+		reason = mm.textAfter(in, args, 1)
+	}
+
+	err = mm.closeThread(thread, in.Author, reason)
+	helpers.Relax(err)
+
+	return nil
+}
+
+// textAfter returns the text of the message after the first n arguments, keeping line breaks
+func (mm *Modmail) textAfter(in *discordgo.Message, args []string, n int) string {
+	// the arguments are the last fields of the message, the fields before them are the prefix and the command
+	return helpers.TextAfterFields(in.Content, len(strings.Fields(in.Content))-len(args)+n)
+}
+
+func (mm *Modmail) getOpenThreads(guildID string) (threads []models.ModmailThreadEntry, err error) {
+	listCursor, err := rethink.Table(models.ModmailThreadsTable).GetAllByIndex("guild_id", guildID).Filter(
+		rethink.Row.Field("open").Eq(true),
+	).Run(helpers.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&threads)
+	return threads, err
+}
+
+func (mm *Modmail) getOpenThreadByUserID(userID string) (thread models.ModmailThreadEntry, err error) {
+	listCursor, err := rethink.Table(models.ModmailThreadsTable).GetAllByIndex("user_id", userID).Filter(
+		rethink.Row.Field("open").Eq(true),
+	).Run(helpers.GetDB())
+	if err != nil {
+		return thread, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&thread)
+	return thread, err
+}
+
+func (mm *Modmail) getThreadByChannelID(channelID string) (thread models.ModmailThreadEntry, err error) {
+	listCursor, err := rethink.Table(models.ModmailThreadsTable).GetAllByIndex("channel_id", channelID).Run(helpers.GetDB())
+	if err != nil {
+		return thread, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&thread)
+	return thread, err
+}
+
+func (mm *Modmail) countThreads(guildID string, userID string) (count int) {
+	listCursor, err := rethink.Table(models.ModmailThreadsTable).GetAllByIndex("user_id", userID).Filter(
+		rethink.Row.Field("guild_id").Eq(guildID),
+	).Count().Run(helpers.GetDB())
+	if err != nil {
+		return 0
+	}
+	defer listCursor.Close()
+	listCursor.One(&count)
+	return count
+}
+
+func (mm *Modmail) appendMessage(thread models.ModmailThreadEntry, message models.ModmailMessage) (err error) {
+	_, err = rethink.Table(models.ModmailThreadsTable).Get(thread.ID).Update(map[string]interface{}{
+		"messages": rethink.Row.Field("messages").Default([]interface{}{}).Append(message),
+	}).RunWrite(helpers.GetDB())
+	return err
+}
+
+// getFiles downloads the attachments of a message to upload them again, attachments that can't be downloaded are skipped
+func (mm *Modmail) getFiles(message *discordgo.Message) (files []*discordgo.File, urls []string) {
+	files = make([]*discordgo.File, 0)
+	urls = make([]string, 0)
+	for _, attachment := range message.Attachments {
+		urls = append(urls, attachment.URL)
+		data, err := helpers.NetGetUAWithError(attachment.URL, helpers.DEFAULT_UA)
+		if err != nil {
+			mm.logger().Warn("unable to download attachment: " + err.Error())
+			continue
+		}
+		files = append(files, &discordgo.File{Name: attachment.Filename, Reader: bytes.NewReader(data)})
+	}
+	return files, urls
+}
+
+func (mm *Modmail) getGuildsForUser(session *discordgo.Session, userID string) (guildIDs []string) {
+	guildIDs = make([]string, 0)
+	for _, guild := range session.State.Guilds {
+		if !mm.acceptsModmail(guild.ID, userID) {
+			continue
+		}
+		if !helpers.GetIsInGuild(guild.ID, userID) {
+			continue
+		}
+		guildIDs = append(guildIDs, guild.ID)
+	}
+	return guildIDs
+}
+
+// acceptsModmail returns true if modmail is enabled on the guild and the user isn't blocked
+func (mm *Modmail) acceptsModmail(guildID string, userID string) bool {
+	settings := helpers.GuildSettingsGetCached(guildID)
+	if !settings.ModmailEnabled || settings.ModmailCategoryID == "" {
+		return false
+	}
+	for _, blockedUserID := range settings.ModmailBlockedUserIDs {
+		if blockedUserID == userID {
+			return false
+		}
+	}
+	return true
+}
+
+// createThread creates the thread channel in the modmail category of the guild
+func (mm *Modmail) createThread(guildID string, user *discordgo.User) (thread models.ModmailThreadEntry, err error) {
+	settings := helpers.GuildSettingsGetCached(guildID)
+
+	category, err := helpers.GetChannel(settings.ModmailCategoryID)
+	if err != nil {
+		return thread, err
+	}
+
+	channelName := modmailChannelNameRegex.ReplaceAllString(strings.ToLower(user.Username), "")
+	if channelName == "" {
+		channelName = "modmail"
+	}
+	channelName += "-" + user.Discriminator
+
+	threadChannel, err := cache.GetSession().GuildChannelCreate(guildID, channelName, "text")
+	if err != nil {
+		return thread, err
+	}
+	threadChannelID := threadChannel.ID
+	threadChannel, err = cache.GetSession().ChannelEditComplex(threadChannelID, &discordgo.ChannelEdit{
+		Name:                 channelName,
+		Topic:                fmt.Sprintf("Modmail thread with %s#%s (#%s)", user.Username, user.Discriminator, user.ID),
+		Position:             threadChannel.Position,
+		PermissionOverwrites: category.PermissionOverwrites,
+		ParentID:             category.ID,
+	})
+	if err != nil {
+		// don't leave a channel with default permissions behind
+		_, errDelete := cache.GetSession().ChannelDelete(threadChannelID)
+		helpers.RelaxLog(errDelete)
+		return thread, err
+	}
+
+	thread = models.ModmailThreadEntry{
+		GuildID:   guildID,
+		UserID:    user.ID,
+		ChannelID: threadChannel.ID,
+		Open:      true,
+		CreatedAt: time.Now(),
+		Messages:  make([]models.ModmailMessage, 0),
+	}
+	previousThreads := mm.countThreads(guildID, user.ID)
+
+	insert, err := rethink.Table(models.ModmailThreadsTable).Insert(thread).RunWrite(helpers.GetDB())
+	if err != nil {
+		_, errDelete := cache.GetSession().ChannelDelete(threadChannel.ID)
+		helpers.RelaxLog(errDelete)
+		return thread, err
+	}
+	if len(insert.GeneratedKeys) > 0 {
+		thread.ID = insert.GeneratedKeys[0]
+	}
+
+	infoEmbed := &discordgo.MessageEmbed{
+		Title:       helpers.GetText("plugins.modmail.thread-embed-title"),
+		Description: helpers.GetTextF("plugins.modmail.thread-embed-description", user.ID, user.Username, user.Discriminator, user.ID),
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: helpers.GetAvatarUrl(user)},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Account created", Value: helpers.GetTimeFromSnowflake(user.ID).Format(time.ANSIC), Inline: true},
+			{Name: "Previous threads", Value: strconv.Itoa(previousThreads), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.modmail.thread-embed-footer",
+			helpers.GetPrefixForServer(guildID))},
+		Color: modmailColorUser,
+	}
+	member, err := helpers.GetGuildMember(guildID, user.ID)
+	if err == nil && member != nil {
+		joinedAt, err := discordgo.Timestamp(member.JoinedAt).Parse()
+		if err == nil {
+			infoEmbed.Fields = append(infoEmbed.Fields, &discordgo.MessageEmbedField{
+				Name: "Joined", Value: joinedAt.Format(time.ANSIC), Inline: true})
+		}
+		if len(member.Roles) > 0 {
+			infoEmbed.Fields = append(infoEmbed.Fields, &discordgo.MessageEmbedField{
+				Name: "Roles", Value: "<@&" + strings.Join(member.Roles, "> <@&") + ">", Inline: false})
+		}
+	}
+	_, err = helpers.SendEmbed(threadChannel.ID, infoEmbed)
+	return thread, err
+}
+
+// relayToThread posts a DM of the user into the thread channel
+func (mm *Modmail) relayToThread(thread models.ModmailThreadEntry, message *discordgo.Message) (err error) {
+	files, urls := mm.getFiles(message)
+
+	relayEmbed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    fmt.Sprintf("%s#%s", message.Author.Username, message.Author.Discriminator),
+			IconURL: helpers.GetAvatarUrl(message.Author),
+		},
+		Description: message.Content,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "User ID: " + message.Author.ID},
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Color:       modmailColorUser,
+	}
+	_, err = helpers.SendComplex(thread.ChannelID, &discordgo.MessageSend{Embed: relayEmbed, Files: files})
+	if err != nil {
+		return err
+	}
+
+	return mm.appendMessage(thread, models.ModmailMessage{
+		AuthorID:    message.Author.ID,
+		AuthorName:  message.Author.Username + "#" + message.Author.Discriminator,
+		Content:     message.Content,
+		Attachments: urls,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// relayToUser sends a staff reply to the user, and posts a copy into the thread channel
+func (mm *Modmail) relayToUser(thread models.ModmailThreadEntry, message *discordgo.Message, text string, anonymous bool) (err error) {
+	guild, err := helpers.GetGuild(thread.GuildID)
+	if err != nil {
+		return err
+	}
+
+	dmChannel, err := cache.GetSession().UserChannelCreate(thread.UserID)
+	if err != nil {
+		return err
+	}
+
+	authorName := fmt.Sprintf("%s#%s", message.Author.Username, message.Author.Discriminator)
+	displayName := authorName
+	if anonymous {
+		displayName = "Staff"
+	}
+
+	files, urls := mm.getFiles(message)
+	_, err = helpers.SendComplex(dmChannel.ID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("**%s** (%s): %s", displayName, guild.Name, text),
+		Files:   files,
+	})
+	if err != nil {
+		return err
+	}
+
+	// keep the thread clean, the copy below contains everything
+	cache.GetSession().ChannelMessageDelete(message.ChannelID, message.ID)
+
+	footerText := "Sent with name"
+	if anonymous {
+		footerText = "Sent anonymously"
+	}
+	copyText := text
+	for _, url := range urls {
+		copyText += "\n" + url
+	}
+	_, err = helpers.SendEmbed(thread.ChannelID, &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    authorName,
+			IconURL: helpers.GetAvatarUrl(message.Author),
+		},
+		Description: copyText,
+		Footer:      &discordgo.MessageEmbedFooter{Text: footerText},
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Color:       modmailColorStaff,
+	})
+	if err != nil {
+		return err
+	}
+
+	return mm.appendMessage(thread, models.ModmailMessage{
+		AuthorID:    message.Author.ID,
+		AuthorName:  authorName,
+		FromStaff:   true,
+		Anonymous:   anonymous,
+		Content:     text,
+		Attachments: urls,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// closeThread archives the transcript to the log channel, notifies the user and deletes the thread channel
+func (mm *Modmail) closeThread(thread models.ModmailThreadEntry, closedBy *discordgo.User, reason string) (err error) {
+	thread, err = mm.getThreadByChannelID(thread.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	thread.Open = false
+	thread.ClosedAt = time.Now()
+	if closedBy != nil {
+		thread.ClosedBy = closedBy.ID
+	}
+	_, err = rethink.Table(models.ModmailThreadsTable).Get(thread.ID).Update(thread).RunWrite(helpers.GetDB())
+	if err != nil {
+		return err
+	}
+
+	user, err := helpers.GetUser(thread.UserID)
+	if err != nil {
+		user = &discordgo.User{ID: thread.UserID, Username: "N/A", Discriminator: "0000"}
+	}
+	closedByText := "N/A"
+	if closedBy != nil {
+		closedByText = fmt.Sprintf("%s#%s", closedBy.Username, closedBy.Discriminator)
+	}
+
+	var transcript bytes.Buffer
+	transcript.WriteString(fmt.Sprintf("Modmail thread with %s#%s (#%s), opened at %s, closed at %s by %s\r\n",
+		user.Username, user.Discriminator, user.ID,
+		thread.CreatedAt.UTC().Format(time.RFC1123), thread.ClosedAt.UTC().Format(time.RFC1123), closedByText))
+	if reason != "" {
+		transcript.WriteString("Reason: " + reason + "\r\n")
+	}
+	transcript.WriteString("\r\n")
+	for _, message := range thread.Messages {
+		authorText := message.AuthorName
+		if message.FromStaff {
+			authorText = "[STAFF] " + authorText
+			if message.Anonymous {
+				authorText += " (anonymous)"
+			}
+		}
+		transcript.WriteString(fmt.Sprintf("[%s] %s: %s\r\n",
+			message.CreatedAt.UTC().Format("2006-01-02 15:04:05"), authorText, message.Content))
+		for _, attachment := range message.Attachments {
+			transcript.WriteString(fmt.Sprintf("\tAttachment: %s\r\n", attachment))
+		}
+	}
+
+	settings := helpers.GuildSettingsGetCached(thread.GuildID)
+	if settings.ModmailLogChannelID != "" {
+		logEmbed := &discordgo.MessageEmbed{
+			Title: helpers.GetText("plugins.modmail.log-embed-title"),
+			Description: helpers.GetTextF("plugins.modmail.log-embed-description",
+				user.ID, user.Username, user.Discriminator, len(thread.Messages), closedByText),
+			Timestamp: thread.ClosedAt.Format(time.RFC3339),
+			Color:     modmailColorClose,
+		}
+		if reason != "" {
+			logEmbed.Fields = []*discordgo.MessageEmbedField{{Name: "Reason", Value: reason, Inline: false}}
+		}
+		_, err = helpers.SendComplex(settings.ModmailLogChannelID, &discordgo.MessageSend{
+			Embed: logEmbed,
+			Files: []*discordgo.File{{
+				Name:   fmt.Sprintf("modmail-%s-%s.txt", user.ID, thread.ClosedAt.UTC().Format("20060102-150405")),
+				Reader: bytes.NewReader(transcript.Bytes()),
+			}},
+		})
+		if err != nil {
+			mm.logger().WithField("GuildID", thread.GuildID).Warn("unable to post modmail transcript: " + err.Error())
+		}
+	}
+
+	guild, err := helpers.GetGuild(thread.GuildID)
+	if err == nil {
+		dmChannel, err := cache.GetSession().UserChannelCreate(thread.UserID)
+		if err == nil {
+			helpers.SendMessage(dmChannel.ID, helpers.GetTextF("plugins.modmail.closed-dm", guild.Name))
+		}
+	}
+
+	_, err = cache.GetSession().ChannelDelete(thread.ChannelID)
+	if errD, ok := err.(*discordgo.RESTError); ok && errD.Message != nil && errD.Message.Code == discordgo.ErrCodeUnknownChannel {
+		return nil
+	}
+	return err
+}
+
+func (mm *Modmail) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) modmailAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (mm *Modmail) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (mm *Modmail) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "modmail")
+}
+
+func (mm *Modmail) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+	if msg.Author == nil || msg.Author.Bot {
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	if err != nil || channel.Type != discordgo.ChannelTypeDM {
+		return
+	}
+
+	if strings.HasPrefix(content, helpers.GetPrefixForServer("")) {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		mm.handleDM(session, content, msg)
+	}()
+}
+
+func (mm *Modmail) handleDM(session *discordgo.Session, content string, msg *discordgo.Message) {
+	// appeals are handled by the ban appeals plugin
+	if banAppealsIsAppeal(content) {
+		return
+	}
+
+	// captcha answers for the verification are handled by the verification plugin
+	if len(content) == verificationCodeLength && mm.hasPendingVerification(msg.Author.ID) {
+		return
+	}
+
+	mm.lockUser(msg.Author.ID)
+	defer mm.unlockUser(msg.Author.ID)
+
+	thread, err := mm.getOpenThreadByUserID(msg.Author.ID)
+	if err != nil && err != rethink.ErrEmptyResult {
+		helpers.Relax(err)
+	}
+	if err == nil && thread.ID != "" {
+		// the user got blocked or modmail got disabled while the thread was open
+		if !mm.acceptsModmail(thread.GuildID, msg.Author.ID) {
+			return
+		}
+		if _, err = helpers.GetChannel(thread.ChannelID); err == nil {
+			err = mm.relayToThread(thread, msg)
+			helpers.Relax(err)
+			return
+		}
+		// the thread channel has been deleted manually
+		err = mm.closeThread(thread, nil, "Thread channel deleted")
+		helpers.RelaxLog(err)
+	}
+
+	var guildID string
+	var messages []*discordgo.Message
+
+	if pending, ok := mm.getPendingSelection(msg.Author.ID); ok {
+		choice, err := strconv.Atoi(strings.TrimSpace(content))
+		if err != nil || choice < 1 || choice > len(pending.GuildIDs) {
+			pending.Messages = append(pending.Messages, msg)
+			mm.setPendingSelection(msg.Author.ID, pending)
+			_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.modmail.select-invalid"))
+			helpers.RelaxLog(err)
+			return
+		}
+		mm.deletePendingSelection(msg.Author.ID)
+		guildID = pending.GuildIDs[choice-1]
+		messages = pending.Messages
+	} else {
+		guildIDs := mm.getGuildsForUser(session, msg.Author.ID)
+		if len(guildIDs) <= 0 {
+			return
+		}
+		if len(guildIDs) > 1 {
+			mm.setPendingSelection(msg.Author.ID, modmailPendingSelection{
+				GuildIDs:  guildIDs,
+				Messages:  []*discordgo.Message{msg},
+				CreatedAt: time.Now(),
+			})
+
+			selectText := helpers.GetText("plugins.modmail.select-server") + "\n"
+			for i, guildIDToSelect := range guildIDs {
+				guild, err := helpers.GetGuild(guildIDToSelect)
+				if err != nil {
+					continue
+				}
+				selectText += fmt.Sprintf("`%d` %s\n", i+1, guild.Name)
+			}
+			_, err = helpers.SendMessage(msg.ChannelID, selectText)
+			helpers.RelaxLog(err)
+			return
+		}
+		guildID = guildIDs[0]
+		messages = []*discordgo.Message{msg}
+	}
+
+	thread, err = mm.createThread(guildID, msg.Author)
+	if err != nil {
+		mm.logger().WithField("GuildID", guildID).Error("unable to create modmail thread: " + err.Error())
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.modmail.create-failed"))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	for _, message := range messages {
+		err = mm.relayToThread(thread, message)
+		helpers.Relax(err)
+	}
+
+	guild, err := helpers.GetGuild(guildID)
+	helpers.Relax(err)
+	_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.modmail.created-dm", guild.Name))
+	helpers.RelaxLog(err)
+}
+
+func (mm *Modmail) lockUser(userID string) {
+	modmailMutex.Lock()
+	lock, ok := modmailUserLocks[userID]
+	if !ok {
+		lock = new(sync.Mutex)
+		modmailUserLocks[userID] = lock
+	}
+	modmailMutex.Unlock()
+
+	lock.Lock()
+}
+
+func (mm *Modmail) unlockUser(userID string) {
+	modmailMutex.Lock()
+	lock, ok := modmailUserLocks[userID]
+	modmailMutex.Unlock()
+
+	if ok {
+		lock.Unlock()
+	}
+}
+
+// getPendingSelection returns the pending server selection of the user, if it hasn't expired yet
+func (mm *Modmail) getPendingSelection(userID string) (pending modmailPendingSelection, ok bool) {
+	modmailMutex.Lock()
+	defer modmailMutex.Unlock()
+
+	// drop expired selections of all users, they would never be removed otherwise
+	for pendingUserID, pendingSelection := range modmailPendingSelections {
+		if time.Since(pendingSelection.CreatedAt) > modmailPendingSelectionTTL {
+			delete(modmailPendingSelections, pendingUserID)
+		}
+	}
+
+	pending, ok = modmailPendingSelections[userID]
+	return pending, ok
+}
+
+func (mm *Modmail) setPendingSelection(userID string, pending modmailPendingSelection) {
+	modmailMutex.Lock()
+	defer modmailMutex.Unlock()
+
+	modmailPendingSelections[userID] = pending
+}
+
+func (mm *Modmail) deletePendingSelection(userID string) {
+	modmailMutex.Lock()
+	defer modmailMutex.Unlock()
+
+	delete(modmailPendingSelections, userID)
+}
+
+func (mm *Modmail) hasPendingVerification(userID string) bool {
+	listCursor, err := rethink.Table(models.VerificationsTable).GetAllByIndex("user_id", userID).Count().Run(helpers.GetDB())
+	if err != nil {
+		return false
+	}
+	defer listCursor.Close()
+
+	var count int
+	err = listCursor.One(&count)
+	return err == nil && count > 0
+}
+
+func (mm *Modmail) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
+
+}
+
+func (mm *Modmail) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (mm *Modmail) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (mm *Modmail) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+
+}
+
+func (mm *Modmail) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+
+}
+
+func (mm *Modmail) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
+
+}
+
+func (mm *Modmail) OnGuildBanRemove(user *discordgo.GuildBanRemove, session *discordgo.Session) {
+
+}
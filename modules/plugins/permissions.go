@@ -0,0 +1,435 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/bwmarrin/discordgo"
+)
+
+type Permissions struct{}
+
+const (
+	permissionsSeverityLow = iota
+	permissionsSeverityMedium
+	permissionsSeverityHigh
+	permissionsSeverityCritical
+)
+
+const (
+	permissionsFindingsPerPage = 8
+	// keeps a full page of findings below the embed description limit of 2048 characters
+	permissionsFindingMaxLength = 230
+)
+
+type permissionsFinding struct {
+	Severity  int
+	Text      string
+	Continued bool // the rest of a finding that was too long for a single entry
+}
+
+// permissionsDangerous maps risky permissions to the severity of granting them by accident
+var permissionsDangerous = []struct {
+	Permission int
+	Severity   int
+}{
+	{discordgo.PermissionAdministrator, permissionsSeverityCritical},
+	{discordgo.PermissionManageServer, permissionsSeverityHigh},
+	{discordgo.PermissionManageRoles, permissionsSeverityHigh},
+	{discordgo.PermissionManageWebhooks, permissionsSeverityHigh},
+	{discordgo.PermissionManageChannels, permissionsSeverityHigh},
+	{discordgo.PermissionBanMembers, permissionsSeverityHigh},
+	{discordgo.PermissionKickMembers, permissionsSeverityMedium},
+	{discordgo.PermissionMentionEveryone, permissionsSeverityMedium},
+	{discordgo.PermissionManageMessages, permissionsSeverityMedium},
+	{discordgo.PermissionManageEmojis, permissionsSeverityMedium},
+	{discordgo.PermissionManageNicknames, permissionsSeverityMedium},
+	{discordgo.PermissionVoiceMoveMembers, permissionsSeverityLow},
+	{discordgo.PermissionVoiceMuteMembers, permissionsSeverityLow},
+	{discordgo.PermissionVoiceDeafenMembers, permissionsSeverityLow},
+}
+
+func (p *Permissions) Commands() []string {
+	return []string{
+		"permissions",
+	}
+}
+
+func (p *Permissions) Init(session *discordgo.Session) {
+
+}
+
+func (p *Permissions) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	args := strings.Fields(content)
+	if len(args) < 1 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	switch args[0] {
+	case "audit": // [p]permissions audit
+		helpers.RequireAdmin(msg, func() {
+			session.ChannelTyping(msg.ChannelID)
+
+			channel, err := helpers.GetChannel(msg.ChannelID)
+			helpers.Relax(err)
+
+			guild, err := helpers.GetGuild(channel.GuildID)
+			helpers.Relax(err)
+
+			findings := p.audit(guild)
+			if len(findings) <= 0 {
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.permissions.audit-none"))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			sort.SliceStable(findings, func(i, j int) bool { return findings[i].Severity > findings[j].Severity })
+			findings = p.splitFindings(findings)
+
+			numberOfPages := int(math.Ceil(float64(len(findings)) / float64(permissionsFindingsPerPage)))
+			currentPage := 1
+
+			auditEmbed := &discordgo.MessageEmbed{
+				Title: helpers.GetTextF("plugins.permissions.audit-embed-title", guild.Name),
+			}
+			p.setAuditPage(auditEmbed, findings, currentPage, numberOfPages)
+			auditEmbedMessages, err := helpers.SendEmbed(msg.ChannelID, auditEmbed)
+			helpers.RelaxEmbed(err, msg.ChannelID, msg.ID)
+
+			if len(auditEmbedMessages) <= 0 || numberOfPages <= 1 {
+				return
+			}
+			auditEmbedMessage := auditEmbedMessages[0]
+
+			err = session.MessageReactionAdd(msg.ChannelID, auditEmbedMessage.ID, "⬅")
+			helpers.Relax(err)
+			err = session.MessageReactionAdd(msg.ChannelID, auditEmbedMessage.ID, "➡")
+			helpers.Relax(err)
+
+			closeHandler := session.AddHandler(func(session *discordgo.Session, reaction *discordgo.MessageReactionAdd) {
+				defer helpers.Recover()
+
+				if reaction.MessageID != auditEmbedMessage.ID || reaction.UserID == session.State.User.ID {
+					return
+				}
+
+				if reaction.UserID == msg.Author.ID {
+					if reaction.Emoji.Name == "➡" && currentPage+1 <= numberOfPages {
+						currentPage += 1
+						p.setAuditPage(auditEmbed, findings, currentPage, numberOfPages)
+						_, err = helpers.EditEmbed(msg.ChannelID, auditEmbedMessage.ID, auditEmbed)
+						helpers.Relax(err)
+					} else if reaction.Emoji.Name == "⬅" && currentPage-1 >= 1 {
+						currentPage -= 1
+						p.setAuditPage(auditEmbed, findings, currentPage, numberOfPages)
+						_, err = helpers.EditEmbed(msg.ChannelID, auditEmbedMessage.ID, auditEmbed)
+						helpers.Relax(err)
+					}
+				}
+				err = session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, reaction.Emoji.Name, reaction.UserID)
+				if errD, ok := err.(*discordgo.RESTError); !ok || errD.Message.Code != discordgo.ErrCodeUnknownMessage {
+					helpers.RelaxLog(err)
+				}
+			})
+			time.Sleep(5 * time.Minute)
+			closeHandler()
+			err = session.MessageReactionRemove(msg.ChannelID, auditEmbedMessage.ID, "⬅", session.State.User.ID)
+			if errD, ok := err.(*discordgo.RESTError); !ok || errD.Message.Code != discordgo.ErrCodeUnknownMessage {
+				helpers.RelaxLog(err)
+			}
+			err = session.MessageReactionRemove(msg.ChannelID, auditEmbedMessage.ID, "➡", session.State.User.ID)
+			if errD, ok := err.(*discordgo.RESTError); !ok || errD.Message.Code != discordgo.ErrCodeUnknownMessage {
+				helpers.RelaxLog(err)
+			}
+		})
+		return
+	default:
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+	}
+}
+
+func (p *Permissions) setAuditPage(auditEmbed *discordgo.MessageEmbed, findings []permissionsFinding, currentPage int, numberOfPages int) {
+	counts := make(map[int]int, 0)
+	for _, finding := range findings {
+		if !finding.Continued {
+			counts[finding.Severity]++
+		}
+	}
+
+	auditEmbed.Description = ""
+	start := (currentPage - 1) * permissionsFindingsPerPage
+	for i := start; i < start+permissionsFindingsPerPage && i < len(findings); i++ {
+		auditEmbed.Description += fmt.Sprintf("%s %s\n\n", p.severityText(findings[i].Severity), findings[i].Text)
+	}
+
+	auditEmbed.Color = p.severityColor(findings[0].Severity)
+	auditEmbed.Footer = &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.permissions.audit-embed-footer",
+		currentPage, numberOfPages,
+		counts[permissionsSeverityCritical], counts[permissionsSeverityHigh],
+		counts[permissionsSeverityMedium], counts[permissionsSeverityLow])}
+}
+
+// splitFindings splits findings longer than permissionsFindingMaxLength into several entries,
+// lists are split between their items
+func (p *Permissions) splitFindings(findings []permissionsFinding) (result []permissionsFinding) {
+	result = make([]permissionsFinding, 0, len(findings))
+	for _, finding := range findings {
+		parts := make([]string, 0)
+		var part string
+		for _, item := range strings.SplitAfter(finding.Text, ", ") {
+			if part != "" && len([]rune(part+item)) > permissionsFindingMaxLength {
+				parts = append(parts, part+"…")
+				part = "…"
+			}
+			// a single item that doesn't fit into one entry
+			for len([]rune(part+item)) > permissionsFindingMaxLength {
+				cut := permissionsFindingMaxLength - len([]rune(part)) - 1
+				parts = append(parts, part+string([]rune(item)[:cut])+"…")
+				item = string([]rune(item)[cut:])
+				part = "…"
+			}
+			part += item
+		}
+		parts = append(parts, part)
+
+		for i, part := range parts {
+			result = append(result, permissionsFinding{Severity: finding.Severity, Text: part, Continued: i > 0})
+		}
+	}
+	return result
+}
+
+// audit checks the roles, channel overwrites and assignable roles of the guild for risky configurations
+func (p *Permissions) audit(guild *discordgo.Guild) (findings []permissionsFinding) {
+	findings = make([]permissionsFinding, 0)
+
+	var everyoneRole *discordgo.Role
+	rolesByID := make(map[string]*discordgo.Role, 0)
+	for _, role := range guild.Roles {
+		rolesByID[role.ID] = role
+		if role.ID == guild.ID {
+			everyoneRole = role
+		}
+	}
+	everyonePermissions := 0
+	if everyoneRole != nil {
+		everyonePermissions = everyoneRole.Permissions
+	}
+
+	// @everyone
+	if severity, names := p.dangerous(everyonePermissions); len(names) > 0 {
+		findings = append(findings, permissionsFinding{
+			Severity: severity,
+			Text:     fmt.Sprintf("`@everyone` has %s, every member of the server can use them.", p.namesText(names)),
+		})
+	}
+
+	// self assignable roles
+	for _, biasChannel := range biasChannels {
+		if biasChannel.ServerID != guild.ID {
+			continue
+		}
+		for _, category := range biasChannel.Categories {
+			for _, assignableRole := range category.Roles {
+				discordRole := (&Bias{}).GetDiscordRole(assignableRole, guild)
+				if discordRole == nil {
+					continue
+				}
+				severity, names := p.dangerous(discordRole.Permissions)
+				if len(names) <= 0 {
+					continue
+				}
+				if severity < permissionsSeverityCritical {
+					severity++
+				}
+				findings = append(findings, permissionsFinding{
+					Severity: severity,
+					Text: fmt.Sprintf("The self-assignable role `%s` in <#%s> grants %s, everyone can give it to themselves.",
+						discordRole.Name, biasChannel.ChannelID, p.namesText(names)),
+				})
+			}
+		}
+	}
+
+	// elevated roles
+	administratorRoles := make([]string, 0)
+	for _, role := range guild.Roles {
+		if role.ID != guild.ID && role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
+			administratorRoles = append(administratorRoles, "`"+role.Name+"`")
+		}
+	}
+	if len(administratorRoles) > 0 {
+		findings = append(findings, permissionsFinding{
+			Severity: permissionsSeverityLow,
+			Text: fmt.Sprintf("%d role(s) grant `Administrator`, which bypasses all channel overwrites: %s",
+				len(administratorRoles), strings.Join(administratorRoles, ", ")),
+		})
+	}
+	for _, role := range guild.Roles {
+		if role.ID == guild.ID || !role.Mentionable {
+			continue
+		}
+		if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator ||
+			role.Permissions&discordgo.PermissionBanMembers == discordgo.PermissionBanMembers {
+			findings = append(findings, permissionsFinding{
+				Severity: permissionsSeverityLow,
+				Text:     fmt.Sprintf("The staff role `%s` can be mentioned by everyone.", role.Name),
+			})
+		}
+	}
+
+	// roles the bot can't manage
+	botMember, err := helpers.GetGuildMember(guild.ID, cache.GetSession().State.User.ID)
+	if err == nil && botMember != nil {
+		highestPosition := 0
+		for _, roleID := range botMember.Roles {
+			if role, ok := rolesByID[roleID]; ok && role.Position > highestPosition {
+				highestPosition = role.Position
+			}
+		}
+		unmanageableRoles := make([]string, 0)
+		for _, role := range guild.Roles {
+			if role.ID != guild.ID && !role.Managed && role.Position > highestPosition {
+				unmanageableRoles = append(unmanageableRoles, "`"+role.Name+"`")
+			}
+		}
+		if len(unmanageableRoles) > 0 {
+			findings = append(findings, permissionsFinding{
+				Severity: permissionsSeverityLow,
+				Text: fmt.Sprintf("%d role(s) are above my highest role, I can't assign, remove or restore them: %s",
+					len(unmanageableRoles), strings.Join(unmanageableRoles, ", ")),
+			})
+		}
+	}
+
+	// channel overwrites
+	everyoneMentionChannels := make([]string, 0)
+	for _, channel := range guild.Channels {
+		for _, overwrite := range channel.PermissionOverwrites {
+			basePermissions := everyonePermissions
+			var targetText, baseText string
+			if overwrite.Type == "role" {
+				role, ok := rolesByID[overwrite.ID]
+				if !ok {
+					continue
+				}
+				basePermissions |= role.Permissions
+				targetText = "`@" + strings.TrimPrefix(role.Name, "@") + "`"
+				baseText = "its base role doesn't have"
+			} else {
+				member, err := helpers.GetGuildMember(guild.ID, overwrite.ID)
+				if err != nil || member == nil {
+					// the member left the server, the overwrite doesn't grant anything
+					continue
+				}
+				for _, roleID := range member.Roles {
+					if role, ok := rolesByID[roleID]; ok {
+						basePermissions |= role.Permissions
+					}
+				}
+				targetText = fmt.Sprintf("<@%s>", overwrite.ID)
+				baseText = "their roles don't grant"
+			}
+			if basePermissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
+				continue
+			}
+
+			severity, names := p.dangerous(overwrite.Allow &^ basePermissions)
+			if len(names) <= 0 {
+				continue
+			}
+			if overwrite.ID == guild.ID && severity < permissionsSeverityCritical {
+				severity++
+			}
+			findings = append(findings, permissionsFinding{
+				Severity: severity,
+				Text: fmt.Sprintf("An overwrite in %s grants %s %s, which %s.",
+					p.channelText(channel), targetText, p.namesText(names), baseText),
+			})
+		}
+
+		// @everyone mentions in channels everyone can write in
+		if channel.Type == discordgo.ChannelTypeGuildText &&
+			everyonePermissions&discordgo.PermissionMentionEveryone == discordgo.PermissionMentionEveryone &&
+			everyonePermissions&discordgo.PermissionSendMessages == discordgo.PermissionSendMessages {
+			denied := false
+			for _, overwrite := range channel.PermissionOverwrites {
+				if overwrite.ID == guild.ID &&
+					(overwrite.Deny&discordgo.PermissionMentionEveryone == discordgo.PermissionMentionEveryone ||
+						overwrite.Deny&discordgo.PermissionSendMessages == discordgo.PermissionSendMessages) {
+					denied = true
+				}
+			}
+			if !denied {
+				everyoneMentionChannels = append(everyoneMentionChannels, p.channelText(channel))
+			}
+		}
+	}
+	if len(everyoneMentionChannels) > 0 {
+		findings = append(findings, permissionsFinding{
+			Severity: permissionsSeverityHigh,
+			Text: fmt.Sprintf("Every member can mention `@everyone` in %d channel(s): %s",
+				len(everyoneMentionChannels), strings.Join(everyoneMentionChannels, ", ")),
+		})
+	}
+
+	return findings
+}
+
+// dangerous returns the names of the risky permissions in the bitfield, and the severity of the most risky one
+func (p *Permissions) dangerous(permissions int) (severity int, names []string) {
+	names = make([]string, 0)
+	for _, dangerous := range permissionsDangerous {
+		if permissions&dangerous.Permission != dangerous.Permission {
+			continue
+		}
+		names = append(names, helpers.GetPermissionNames(dangerous.Permission)...)
+		if dangerous.Severity > severity {
+			severity = dangerous.Severity
+		}
+	}
+	return severity, names
+}
+
+func (p *Permissions) namesText(names []string) string {
+	return "`" + strings.Join(names, "`, `") + "`"
+}
+
+func (p *Permissions) channelText(channel *discordgo.Channel) string {
+	if channel.Type == discordgo.ChannelTypeGuildText {
+		return "<#" + channel.ID + ">"
+	}
+	return "`" + channel.Name + "`"
+}
+
+func (p *Permissions) severityText(severity int) string {
+	switch severity {
+	case permissionsSeverityCritical:
+		return "🛑 **Critical**"
+	case permissionsSeverityHigh:
+		return "⚠ **High**"
+	case permissionsSeverityMedium:
+		return "🔸 **Medium**"
+	}
+	return "ℹ **Low**"
+}
+
+func (p *Permissions) severityColor(severity int) int {
+	switch severity {
+	case permissionsSeverityCritical:
+		return 0xE74C3C
+	case permissionsSeverityHigh:
+		return 0xE67E22
+	case permissionsSeverityMedium:
+		return 0xF1C40F
+	}
+	return 0x3498DB
+}
@@ -0,0 +1,845 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	"github.com/getsentry/raven-go"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type raidAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next raidAction)
+
+type Raid struct{}
+
+var (
+	raidJoins      map[string][]time.Time
+	raidLastAlerts map[string]time.Time
+	raidJoinsMutex sync.Mutex
+)
+
+const (
+	raidLockdownPermissions = discordgo.PermissionSendMessages + discordgo.PermissionAddReactions
+)
+
+func (r *Raid) Commands() []string {
+	return []string{
+		"raid",
+		"lockdown",
+		"unlock",
+	}
+}
+
+func (r *Raid) Init(session *discordgo.Session) {
+	raidJoinsMutex.Lock()
+	raidJoins = make(map[string][]time.Time, 0)
+	raidLastAlerts = make(map[string]time.Time, 0)
+	raidJoinsMutex.Unlock()
+}
+
+func (r *Raid) Uninit(session *discordgo.Session) {
+
+}
+
+func (r *Raid) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	var action raidAction
+	switch command {
+	case "lockdown":
+		action = r.actionLockdown
+	case "unlock":
+		action = r.actionUnlock
+	default:
+		action = r.actionStart
+	}
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (r *Raid) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsMod(in) {
+		*out = r.newMsg("mod.no_permission")
+		return r.actionFinish
+	}
+
+	if len(args) < 1 {
+		return r.actionStatus
+	}
+
+	switch args[0] {
+	case "status":
+		return r.actionStatus
+	case "on", "enable":
+		return r.actionOn
+	case "off", "disable":
+		return r.actionOff
+	case "detection":
+		return r.actionDetection
+	case "action":
+		return r.actionSetAction
+	case "auto":
+		return r.actionToggleAuto
+	case "verification":
+		return r.actionToggleVerification
+	case "lock-channels":
+		return r.actionToggleLockChannels
+	}
+
+	*out = r.newMsg("bot.arguments.invalid")
+	return r.actionFinish
+}
+
+// [p]raid status
+func (r *Raid) actionStatus(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	statusEmbed := &discordgo.MessageEmbed{
+		Title: helpers.GetText("plugins.raid.status-embed-title"),
+		Color: 0x0FADED,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Raid Mode", Value: r.boolText(settings.RaidModeEnabled), Inline: true},
+			{Name: "Automatic Raid Mode", Value: r.boolText(settings.RaidModeAutoEnable), Inline: true},
+			{Name: "Action on join", Value: r.actionText(settings.RaidModeAction), Inline: true},
+			{Name: "Raise Verification", Value: r.boolText(settings.RaidModeRaiseVerification), Inline: true},
+			{Name: "Lock Channels", Value: r.boolText(settings.RaidModeLockChannels), Inline: true},
+		},
+	}
+
+	if settings.RaidDetectionEnabled {
+		detectionText := helpers.GetTextF("plugins.raid.status-detection", settings.RaidDetectionJoins, settings.RaidDetectionSeconds)
+		if settings.RaidDetectionMaxAccountAge > 0 {
+			detectionText += "\n" + helpers.GetTextF("plugins.raid.status-detection-account-age", settings.RaidDetectionMaxAccountAge)
+		}
+		statusEmbed.Fields = append(statusEmbed.Fields, &discordgo.MessageEmbedField{Name: "Detection", Value: detectionText, Inline: false})
+	} else {
+		statusEmbed.Fields = append(statusEmbed.Fields, &discordgo.MessageEmbedField{Name: "Detection", Value: r.boolText(false), Inline: false})
+	}
+
+	if settings.InspectsChannel == "" {
+		statusEmbed.Description = helpers.GetTextF("plugins.raid.status-no-inspects-channel", helpers.GetPrefixForServer(channel.GuildID))
+	}
+
+	*out = &discordgo.MessageSend{Embed: statusEmbed}
+	return r.actionFinish
+}
+
+// [p]raid on
+func (r *Raid) actionOn(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	if helpers.GuildSettingsGetCached(channel.GuildID).RaidModeEnabled {
+		*out = r.newMsg("plugins.raid.on-error-already")
+		return r.actionFinish
+	}
+
+	err = r.enableRaidMode(channel.GuildID, in.Author.ID)
+	helpers.Relax(err)
+
+	*out = r.newMsg("plugins.raid.on-success")
+	return r.actionFinish
+}
+
+// [p]raid off
+func (r *Raid) actionOff(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	if !helpers.GuildSettingsGetCached(channel.GuildID).RaidModeEnabled {
+		*out = r.newMsg("plugins.raid.off-error-not-enabled")
+		return r.actionFinish
+	}
+
+	err = r.disableRaidMode(channel.GuildID, in.Author.ID)
+	helpers.Relax(err)
+
+	*out = r.newMsg("plugins.raid.off-success")
+	return r.actionFinish
+}
+
+// [p]raid detection <joins> <seconds> [<max account age in days>]
+// [p]raid detection off
+func (r *Raid) actionDetection(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsAdmin(in) {
+		*out = r.newMsg("admin.no_permission")
+		return r.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = r.newMsg("bot.arguments.too-few")
+		return r.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if args[1] == "off" || args[1] == "disable" {
+		settings.RaidDetectionEnabled = false
+		err = helpers.GuildSettingsSet(channel.GuildID, settings)
+		helpers.Relax(err)
+
+		*out = r.newMsg("plugins.raid.detection-disabled")
+		return r.actionFinish
+	}
+
+	if len(args) < 3 {
+		*out = r.newMsg("bot.arguments.too-few")
+		return r.actionFinish
+	}
+
+	joins, err := strconv.Atoi(args[1])
+	if err != nil || joins < 2 {
+		*out = r.newMsg("bot.arguments.invalid")
+		return r.actionFinish
+	}
+	seconds, err := strconv.Atoi(args[2])
+	if err != nil || seconds < 1 {
+		*out = r.newMsg("bot.arguments.invalid")
+		return r.actionFinish
+	}
+	var maxAccountAge int
+	if len(args) >= 4 {
+		maxAccountAge, err = strconv.Atoi(args[3])
+		if err != nil || maxAccountAge < 0 {
+			*out = r.newMsg("bot.arguments.invalid")
+			return r.actionFinish
+		}
+	}
+
+	settings.RaidDetectionEnabled = true
+	settings.RaidDetectionJoins = joins
+	settings.RaidDetectionSeconds = seconds
+	settings.RaidDetectionMaxAccountAge = maxAccountAge
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.InspectsChannel == "" {
+		*out = r.newMsg("plugins.raid.detection-enabled-no-inspects-channel", joins, seconds, helpers.GetPrefixForServer(channel.GuildID))
+		return r.actionFinish
+	}
+
+	*out = r.newMsg("plugins.raid.detection-enabled", joins, seconds)
+	return r.actionFinish
+}
+
+// [p]raid action <kick|mute|none>
+func (r *Raid) actionSetAction(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsAdmin(in) {
+		*out = r.newMsg("admin.no_permission")
+		return r.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = r.newMsg("bot.arguments.too-few")
+		return r.actionFinish
+	}
+
+	var newAction string
+	switch strings.ToLower(args[1]) {
+	case "kick":
+		newAction = "kick"
+	case "mute":
+		newAction = "mute"
+	case "none", "nothing":
+		newAction = ""
+	default:
+		*out = r.newMsg("bot.arguments.invalid")
+		return r.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.RaidModeAction = newAction
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if newAction == "mute" {
+		*out = r.newMsg("plugins.raid.action-set-mute", helpers.GetPrefixForServer(channel.GuildID))
+		return r.actionFinish
+	}
+
+	*out = r.newMsg("plugins.raid.action-set", r.actionText(newAction))
+	return r.actionFinish
+}
+
+// [p]raid auto
+func (r *Raid) actionToggleAuto(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsAdmin(in) {
+		*out = r.newMsg("admin.no_permission")
+		return r.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.RaidModeAutoEnable = !settings.RaidModeAutoEnable
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.RaidModeAutoEnable {
+		*out = r.newMsg("plugins.raid.auto-enabled")
+	} else {
+		*out = r.newMsg("plugins.raid.auto-disabled")
+	}
+	return r.actionFinish
+}
+
+// [p]raid verification
+func (r *Raid) actionToggleVerification(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsAdmin(in) {
+		*out = r.newMsg("admin.no_permission")
+		return r.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.RaidModeRaiseVerification = !settings.RaidModeRaiseVerification
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.RaidModeRaiseVerification {
+		*out = r.newMsg("plugins.raid.verification-enabled")
+	} else {
+		*out = r.newMsg("plugins.raid.verification-disabled")
+	}
+	return r.actionFinish
+}
+
+// [p]raid lock-channels
+func (r *Raid) actionToggleLockChannels(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsAdmin(in) {
+		*out = r.newMsg("admin.no_permission")
+		return r.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.RaidModeLockChannels = !settings.RaidModeLockChannels
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.RaidModeLockChannels {
+		*out = r.newMsg("plugins.raid.lock-channels-enabled")
+	} else {
+		*out = r.newMsg("plugins.raid.lock-channels-disabled")
+	}
+	return r.actionFinish
+}
+
+// [p]lockdown
+func (r *Raid) actionLockdown(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsMod(in) {
+		*out = r.newMsg("mod.no_permission")
+		return r.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	existingLockdown, _ := r.getLockdown(channel.GuildID)
+	if existingLockdown.ID != "" {
+		*out = r.newMsg("plugins.raid.lockdown-error-already", helpers.GetPrefixForServer(channel.GuildID))
+		return r.actionFinish
+	}
+
+	lockedChannels, failedChannels, err := r.lockdownGuild(channel.GuildID, in.Author.ID, false)
+	helpers.Relax(err)
+
+	*out = r.newMsg("plugins.raid.lockdown-success", lockedChannels, failedChannels, helpers.GetPrefixForServer(channel.GuildID))
+	return r.actionFinish
+}
+
+// [p]unlock
+func (r *Raid) actionUnlock(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	if !helpers.IsMod(in) {
+		*out = r.newMsg("mod.no_permission")
+		return r.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	lockdown, err := r.getLockdown(channel.GuildID)
+	if err != nil || lockdown.ID == "" {
+		*out = r.newMsg("plugins.raid.unlock-error-not-locked")
+		return r.actionFinish
+	}
+
+	restoredChannels, failedChannels, err := r.unlockGuild(lockdown)
+	helpers.Relax(err)
+
+	if failedChannels > 0 {
+		*out = r.newMsg("plugins.raid.unlock-partial", restoredChannels, failedChannels, helpers.GetPrefixForServer(channel.GuildID))
+		return r.actionFinish
+	}
+
+	*out = r.newMsg("plugins.raid.unlock-success", restoredChannels)
+	return r.actionFinish
+}
+
+// lockdownGuild snapshots the permission overwrites of all text channels and denies @everyone to send messages
+func (r *Raid) lockdownGuild(guildID string, userID string, raidMode bool) (locked int, failed int, err error) {
+	guild, err := helpers.GetGuild(guildID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lockdown := models.LockdownEntry{
+		GuildID:         guild.ID,
+		CreatedByUserID: userID,
+		CreatedAt:       time.Now(),
+		RaidMode:        raidMode,
+		Channels:        make([]models.LockdownChannel, 0),
+	}
+
+	for _, channel := range guild.Channels {
+		if channel.Type != discordgo.ChannelTypeGuildText {
+			continue
+		}
+
+		snapshot := models.LockdownChannel{
+			ChannelID:  channel.ID,
+			Overwrites: make([]models.LockdownOverwrite, 0),
+		}
+		for _, overwrite := range channel.PermissionOverwrites {
+			snapshot.Overwrites = append(snapshot.Overwrites, models.LockdownOverwrite{
+				ID:    overwrite.ID,
+				Type:  overwrite.Type,
+				Allow: overwrite.Allow,
+				Deny:  overwrite.Deny,
+			})
+		}
+		lockdown.Channels = append(lockdown.Channels, snapshot)
+	}
+
+	// store the snapshot before touching any channel, so a failed lockdown can still be undone
+	err = r.insertLockdown(lockdown)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, snapshot := range lockdown.Channels {
+		everyoneAllow := 0
+		everyoneDeny := 0
+		for _, overwrite := range snapshot.Overwrites {
+			if overwrite.ID == guild.ID {
+				everyoneAllow = overwrite.Allow
+				everyoneDeny = overwrite.Deny
+			}
+		}
+
+		err = cache.GetSession().ChannelPermissionSet(snapshot.ChannelID, guild.ID, "role",
+			everyoneAllow&^raidLockdownPermissions, everyoneDeny|raidLockdownPermissions)
+		if err != nil {
+			failed++
+			r.logger().WithField("GuildID", guild.ID).WithField("ChannelID", snapshot.ChannelID).Warn(
+				"unable to lock channel: " + err.Error())
+			continue
+		}
+		locked++
+	}
+
+	r.logger().WithField("GuildID", guild.ID).WithField("UserID", userID).Infof("locked down %d channels (%d failed)", locked, failed)
+	return locked, failed, nil
+}
+
+// unlockGuild restores the permission overwrites of the given lockdown snapshot exactly and removes the snapshot,
+// if any channel fails to restore only the snapshots of the failed channels are kept so unlocking again can retry them
+func (r *Raid) unlockGuild(lockdown models.LockdownEntry) (restored int, failed int, err error) {
+	failedChannels := make([]models.LockdownChannel, 0)
+	for _, snapshot := range lockdown.Channels {
+		channel, err := helpers.GetChannel(snapshot.ChannelID)
+		if err != nil || channel == nil || channel.ID == "" {
+			// channel got deleted during the lockdown
+			continue
+		}
+
+		channelFailed := false
+		for _, overwrite := range snapshot.Overwrites {
+			err = cache.GetSession().ChannelPermissionSet(channel.ID, overwrite.ID, overwrite.Type, overwrite.Allow, overwrite.Deny)
+			if err != nil {
+				channelFailed = true
+			}
+		}
+
+		// remove overwrites that have been created during the lockdown
+		for _, currentOverwrite := range channel.PermissionOverwrites {
+			inSnapshot := false
+			for _, overwrite := range snapshot.Overwrites {
+				if overwrite.ID == currentOverwrite.ID {
+					inSnapshot = true
+					break
+				}
+			}
+			if inSnapshot {
+				continue
+			}
+			err = cache.GetSession().ChannelPermissionDelete(channel.ID, currentOverwrite.ID)
+			if err != nil {
+				channelFailed = true
+			}
+		}
+
+		if channelFailed {
+			failedChannels = append(failedChannels, snapshot)
+			failed++
+			continue
+		}
+		restored++
+	}
+
+	if failed > 0 {
+		lockdown.Channels = failedChannels
+		err = r.updateLockdown(lockdown)
+	} else {
+		err = r.deleteLockdown(lockdown)
+	}
+	if err != nil {
+		return restored, failed, err
+	}
+
+	r.logger().WithField("GuildID", lockdown.GuildID).Infof("restored %d channels after lockdown (%d failed)", restored, failed)
+	return restored, failed, nil
+}
+
+func (r *Raid) enableRaidMode(guildID string, userID string) (err error) {
+	settings := helpers.GuildSettingsGetCached(guildID)
+	if settings.RaidModeEnabled {
+		return nil
+	}
+
+	settings.RaidModeEnabled = true
+	settings.RaidModeEnabledAt = time.Now()
+
+	if settings.RaidModeRaiseVerification {
+		guild, err := helpers.GetGuild(guildID)
+		if err == nil && guild.VerificationLevel < discordgo.VerificationLevelHigh {
+			newVerificationLevel := discordgo.VerificationLevelHigh
+			_, err = cache.GetSession().GuildEdit(guildID, discordgo.GuildParams{
+				VerificationLevel: &newVerificationLevel,
+			})
+			if err == nil {
+				settings.RaidModePreviousVerificationLevel = int(guild.VerificationLevel)
+				settings.RaidModeVerificationRaised = true
+			}
+		}
+		if err != nil {
+			r.logger().WithField("GuildID", guildID).Warn("unable to raise verification level: " + err.Error())
+		}
+	}
+
+	err = helpers.GuildSettingsSet(guildID, settings)
+	if err != nil {
+		return err
+	}
+
+	if settings.RaidModeLockChannels {
+		existingLockdown, _ := r.getLockdown(guildID)
+		if existingLockdown.ID == "" {
+			_, _, err = r.lockdownGuild(guildID, userID, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	r.logger().WithField("GuildID", guildID).WithField("UserID", userID).Info("enabled raid mode")
+	return nil
+}
+
+func (r *Raid) disableRaidMode(guildID string, userID string) (err error) {
+	settings := helpers.GuildSettingsGetCached(guildID)
+	if !settings.RaidModeEnabled {
+		return nil
+	}
+
+	// only restore the verification level if raid mode raised it, independent of the current setting
+	if settings.RaidModeVerificationRaised {
+		previousVerificationLevel := discordgo.VerificationLevel(settings.RaidModePreviousVerificationLevel)
+		_, err = cache.GetSession().GuildEdit(guildID, discordgo.GuildParams{
+			VerificationLevel: &previousVerificationLevel,
+		})
+		if err != nil {
+			r.logger().WithField("GuildID", guildID).Warn("unable to restore verification level: " + err.Error())
+		}
+		settings.RaidModeVerificationRaised = false
+	}
+
+	settings.RaidModeEnabled = false
+	err = helpers.GuildSettingsSet(guildID, settings)
+	if err != nil {
+		return err
+	}
+
+	// only lift lockdowns raid mode created itself, manual lockdowns have to be lifted using unlock
+	lockdown, _ := r.getLockdown(guildID)
+	if lockdown.ID != "" && lockdown.RaidMode {
+		_, _, err = r.unlockGuild(lockdown)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.logger().WithField("GuildID", guildID).WithField("UserID", userID).Info("disabled raid mode")
+	return nil
+}
+
+// trackJoin adds the join to the join window of the guild and returns the number of joins inside the window,
+// returns 0 if the guild has been alerted about during the current window already
+func (r *Raid) trackJoin(guildID string, window time.Duration) (joins int) {
+	raidJoinsMutex.Lock()
+	defer raidJoinsMutex.Unlock()
+
+	now := time.Now()
+	joinsInWindow := make([]time.Time, 0)
+	for _, joinedAt := range raidJoins[guildID] {
+		if now.Sub(joinedAt) <= window {
+			joinsInWindow = append(joinsInWindow, joinedAt)
+		}
+	}
+	joinsInWindow = append(joinsInWindow, now)
+	raidJoins[guildID] = joinsInWindow
+
+	if lastAlert, ok := raidLastAlerts[guildID]; ok && now.Sub(lastAlert) <= window {
+		return 0
+	}
+
+	return len(joinsInWindow)
+}
+
+func (r *Raid) markAlerted(guildID string) {
+	raidJoinsMutex.Lock()
+	defer raidJoinsMutex.Unlock()
+
+	raidLastAlerts[guildID] = time.Now()
+}
+
+func (r *Raid) alert(guildID string, joins int, raidModeEnabled bool) {
+	settings := helpers.GuildSettingsGetCached(guildID)
+	if settings.InspectsChannel == "" {
+		return
+	}
+
+	alertEmbed := &discordgo.MessageEmbed{
+		Title:       helpers.GetText("plugins.raid.alert-embed-title"),
+		Description: helpers.GetTextF("plugins.raid.alert-embed-description", joins, settings.RaidDetectionSeconds),
+		Color:       0xE74C3C,
+	}
+	if settings.RaidDetectionMaxAccountAge > 0 {
+		alertEmbed.Description += "\n" + helpers.GetTextF("plugins.raid.alert-embed-description-account-age", settings.RaidDetectionMaxAccountAge)
+	}
+	if raidModeEnabled {
+		alertEmbed.Description += "\n" + helpers.GetTextF("plugins.raid.alert-embed-raid-mode-enabled", helpers.GetPrefixForServer(guildID))
+	} else {
+		alertEmbed.Description += "\n" + helpers.GetTextF("plugins.raid.alert-embed-raid-mode-hint", helpers.GetPrefixForServer(guildID), helpers.GetPrefixForServer(guildID))
+	}
+
+	_, err := helpers.SendEmbed(settings.InspectsChannel, alertEmbed)
+	if err != nil {
+		r.logger().WithField("GuildID", guildID).Error("failed to send raid alert: " + err.Error())
+		if errD, ok := err.(*discordgo.RESTError); ok {
+			if errD.Message.Code != discordgo.ErrCodeMissingAccess && errD.Message.Code != discordgo.ErrCodeMissingPermissions {
+				raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			}
+		} else {
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+		}
+	}
+}
+
+// applyRaidModeAction kicks or mutes a new member while raid mode is enabled,
+// mutes stay after raid mode has been disabled and are persisted like the mute command, use unmute to lift them
+func (r *Raid) applyRaidModeAction(member *discordgo.Member, action string) (err error) {
+	switch action {
+	case "kick":
+		return cache.GetSession().GuildMemberDeleteWithReason(member.GuildID, member.User.ID, "Raid Mode is enabled")
+	case "mute":
+		muteRole, err := helpers.GetMuteRole(member.GuildID)
+		if err != nil {
+			return err
+		}
+		err = cache.GetSession().GuildMemberRoleAdd(member.GuildID, member.User.ID, muteRole.ID)
+		if err != nil {
+			return err
+		}
+		return helpers.AddMutePersistency(member.GuildID, member.User.ID)
+	}
+	return nil
+}
+
+func (r *Raid) getLockdown(guildID string) (entryBucket models.LockdownEntry, err error) {
+	listCursor, err := rethink.Table(models.LockdownsTable).GetAllByIndex(
+		"guild_id", guildID,
+	).Run(helpers.GetDB())
+	if err != nil {
+		return entryBucket, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&entryBucket)
+	return entryBucket, err
+}
+
+func (r *Raid) insertLockdown(entry models.LockdownEntry) (err error) {
+	insert := rethink.Table(models.LockdownsTable).Insert(entry)
+	_, err = insert.RunWrite(helpers.GetDB())
+	return err
+}
+
+func (r *Raid) updateLockdown(entry models.LockdownEntry) (err error) {
+	if entry.ID != "" {
+		_, err = rethink.Table(models.LockdownsTable).Get(entry.ID).Update(entry).RunWrite(helpers.GetDB())
+		return err
+	}
+	return errors.New("empty lockdownEntry submitted")
+}
+
+func (r *Raid) deleteLockdown(entry models.LockdownEntry) (err error) {
+	if entry.ID != "" {
+		_, err = rethink.Table(models.LockdownsTable).Get(entry.ID).Delete().RunWrite(helpers.GetDB())
+		return err
+	}
+	return errors.New("empty lockdownEntry submitted")
+}
+
+func (r *Raid) boolText(enabled bool) string {
+	if enabled {
+		return "✔ Enabled"
+	}
+	return "🔲 Disabled"
+}
+
+func (r *Raid) actionText(action string) string {
+	switch action {
+	case "kick":
+		return "Kick"
+	case "mute":
+		return "Mute"
+	}
+	return "None"
+}
+
+func (r *Raid) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) raidAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (r *Raid) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (r *Raid) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "raid")
+}
+
+func (r *Raid) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+	go func() {
+		defer helpers.Recover()
+
+		if member.User == nil || member.User.ID == session.State.User.ID {
+			return
+		}
+
+		settings := helpers.GuildSettingsGetCached(member.GuildID)
+
+		if settings.RaidModeEnabled && !member.User.Bot {
+			err := r.applyRaidModeAction(member, settings.RaidModeAction)
+			if err != nil {
+				r.logger().WithField("GuildID", member.GuildID).WithField("UserID", member.User.ID).Warn(
+					"unable to apply raid mode action: " + err.Error())
+			}
+		}
+
+		if !settings.RaidDetectionEnabled || settings.RaidDetectionJoins <= 0 || settings.RaidDetectionSeconds <= 0 {
+			return
+		}
+
+		if settings.RaidDetectionMaxAccountAge > 0 {
+			createdAt := helpers.GetTimeFromSnowflake(member.User.ID)
+			if createdAt.Before(time.Now().AddDate(0, 0, -settings.RaidDetectionMaxAccountAge)) {
+				return
+			}
+		}
+
+		joins := r.trackJoin(member.GuildID, time.Duration(settings.RaidDetectionSeconds)*time.Second)
+		if joins < settings.RaidDetectionJoins {
+			return
+		}
+		r.markAlerted(member.GuildID)
+
+		r.logger().WithField("GuildID", member.GuildID).Warnf("detected possible raid: %d joins in %d seconds",
+			joins, settings.RaidDetectionSeconds)
+
+		raidModeEnabled := settings.RaidModeEnabled
+		if settings.RaidModeAutoEnable && !settings.RaidModeEnabled {
+			err := r.enableRaidMode(member.GuildID, session.State.User.ID)
+			if err != nil {
+				raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			} else {
+				raidModeEnabled = true
+			}
+		}
+
+		r.alert(member.GuildID, joins, raidModeEnabled)
+	}()
+}
+
+func (r *Raid) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+
+}
+
+func (r *Raid) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
+
+}
+
+func (r *Raid) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (r *Raid) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+
+}
+
+func (r *Raid) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+
+}
+
+func (r *Raid) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
+
+}
+
+func (r *Raid) OnGuildBanRemove(user *discordgo.GuildBanRemove, session *discordgo.Session) {
+
+}
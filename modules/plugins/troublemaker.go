@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,6 +14,24 @@ import (
 
 type Troublemaker struct{}
 
+const (
+	// TroublemakerReviewChannelIDKey is the channel new reports are posted to for review, empty disables the review queue
+	TroublemakerReviewChannelIDKey = "troublemaker:review:channel-id"
+
+	troublemakerStatusPending   = "pending"
+	troublemakerStatusBroadcast = "broadcast"
+	troublemakerStatusRejected  = "rejected"
+	troublemakerStatusRetracted = "retracted"
+	troublemakerStatusCleared   = "cleared"
+
+	troublemakerEvidenceFieldLength = 1024 // embed field value limit
+	troublemakerEvidenceMaxFields   = 3
+)
+
+var (
+	troublemakerMessageLinkRegex = regexp.MustCompile(`https?://(?:(?:canary|ptb)\.)?discordapp\.com/channels/[0-9]+/[0-9]+/[0-9]+`)
+)
+
 func (t *Troublemaker) Commands() []string {
 	return []string{
 		"troublemaker",
@@ -28,6 +47,19 @@ type DB_Troublemaker_Entry struct {
 	CreatedAt         time.Time `gorethink:"createdat"`
 	ReportedByGuildID string    `gorethink:"reportedby_guildid"`
 	ReportedByUserID  string    `gorethink:"reportedby_userid"`
+	Evidence          []string  `gorethink:"evidence"` // attachment URLs and message links
+	// Status is empty for reports made before the review queue existed, they count as broadcast
+	Status           string                         `gorethink:"status"`
+	ReviewedByUserID string                         `gorethink:"reviewedby_userid"`
+	UpdatedAt        time.Time                      `gorethink:"updatedat"`
+	Notifications    []DB_Troublemaker_Notification `gorethink:"notifications"`
+}
+
+// DB_Troublemaker_Notification is a report message posted to a participating guild, or to the review channel
+type DB_Troublemaker_Notification struct {
+	GuildID   string `gorethink:"guildid"`
+	ChannelID string `gorethink:"channelid"`
+	MessageID string `gorethink:"messageid"`
 }
 
 func (t *Troublemaker) Init(session *discordgo.Session) {
@@ -117,10 +149,14 @@ func (t *Troublemaker) Action(command string, content string, msg *discordgo.Mes
 							reportedByGuild.ID = troublemakerReport.ReportedByGuildID
 							reportedByGuild.Name = "N/A"
 						}
-						troublemakerText += fmt.Sprintf("At: `%s`, Reason: `%s`, Reported By: `%s` (`#%s`) On: `%s` (`#%s`)\n",
+						troublemakerText += fmt.Sprintf("At: `%s`, Reason: `%s`, Reported By: `%s` (`#%s`) On: `%s` (`#%s`), Status: `%s`, ID: `%s`\n",
 							troublemakerReport.CreatedAt.Format(time.ANSIC), troublemakerReport.Reason,
 							reportedByUser.Username, reportedByUser.ID, reportedByGuild.Name, reportedByGuild.ID,
+							t.statusText(troublemakerReport), troublemakerReport.ID,
 						)
+						for _, evidence := range troublemakerReport.Evidence {
+							troublemakerText += fmt.Sprintf("◾Evidence: <%s>\n", evidence)
+						}
 					}
 
 					for _, page := range helpers.Pagify(troublemakerText, "\n") {
@@ -129,6 +165,166 @@ func (t *Troublemaker) Action(command string, content string, msg *discordgo.Mes
 					}
 				}
 			})
+		case "update", "retract": // [p]troublemaker update <report id> <reason>, [p]troublemaker retract <report id>
+			helpers.RequireMod(msg, func() {
+				session.ChannelTyping(msg.ChannelID)
+
+				if len(args) < 2 || (args[0] == "update" && len(args) < 3) {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+
+				channel, err := helpers.GetChannel(msg.ChannelID)
+				helpers.Relax(err)
+
+				report, err := t.getReport(args[1])
+				if err != nil || report.ReportedByGuildID != channel.GuildID {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.troublemaker.report-not-found"))
+					return
+				}
+				if !t.isOpen(report) {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.troublemaker.report-closed", report.Status))
+					return
+				}
+
+				if args[0] == "retract" {
+					report.Status = troublemakerStatusRetracted
+				} else {
+					reasonText := strings.TrimSpace(strings.SplitN(content, args[1], 2)[1])
+					report.Reason = reasonText
+					report.Evidence = append(report.Evidence, t.getEvidence(msg, reasonText)...)
+				}
+				report.UpdatedAt = time.Now()
+				t.setEntry(report)
+
+				go func() {
+					defer helpers.Recover()
+					t.updateNotifications(report)
+				}()
+
+				if args[0] == "retract" {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.troublemaker.report-retracted"))
+				} else {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.troublemaker.report-updated"))
+				}
+				helpers.Relax(err)
+			})
+		case "review-channel": // [p]troublemaker review-channel [<#channel>]
+			helpers.RequireRobyulMod(msg, func() {
+				var err error
+				if len(args) >= 2 {
+					var targetChannel *discordgo.Channel
+					targetChannel, err = helpers.GetChannelFromMention(msg, args[1])
+					if err != nil || targetChannel.ID == "" {
+						helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						return
+					}
+					err = helpers.SetBotConfigString(TroublemakerReviewChannelIDKey, targetChannel.ID)
+					helpers.Relax(err)
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.troublemaker.review-enabled", targetChannel.ID))
+				} else {
+					err = helpers.SetBotConfigString(TroublemakerReviewChannelIDKey, "")
+					helpers.Relax(err)
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.troublemaker.review-disabled"))
+				}
+				helpers.Relax(err)
+			})
+		case "review": // [p]troublemaker review
+			helpers.RequireRobyulMod(msg, func() {
+				session.ChannelTyping(msg.ChannelID)
+
+				var pendingReports []DB_Troublemaker_Entry
+				listCursor, err := rethink.Table("troublemakerlog").Filter(
+					rethink.Row.Field("status").Eq(troublemakerStatusPending),
+				).Run(helpers.GetDB())
+				helpers.Relax(err)
+				defer listCursor.Close()
+				listCursor.All(&pendingReports)
+
+				if len(pendingReports) <= 0 {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.troublemaker.review-none"))
+					helpers.Relax(err)
+					return
+				}
+
+				reviewText := fmt.Sprintf("%d report(s) are waiting for review:\n", len(pendingReports))
+				for _, pendingReport := range pendingReports {
+					reviewText += fmt.Sprintf("ID: `%s`, User: `#%s`, At: `%s`, Reason: `%s`, Evidence: %d\n",
+						pendingReport.ID, pendingReport.UserID, pendingReport.CreatedAt.Format(time.ANSIC),
+						pendingReport.Reason, len(pendingReport.Evidence))
+				}
+				for _, page := range helpers.Pagify(reviewText, "\n") {
+					_, err := helpers.SendMessage(msg.ChannelID, page)
+					helpers.Relax(err)
+				}
+			})
+		case "approve", "reject": // [p]troublemaker approve <report id>, [p]troublemaker reject <report id>
+			helpers.RequireRobyulMod(msg, func() {
+				session.ChannelTyping(msg.ChannelID)
+
+				if len(args) < 2 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+
+				report, err := t.getReport(args[1])
+				if err != nil || report.Status != troublemakerStatusPending {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.troublemaker.report-not-found"))
+					return
+				}
+
+				report.ReviewedByUserID = msg.Author.ID
+				report.UpdatedAt = time.Now()
+				if args[0] == "reject" {
+					report.Status = troublemakerStatusRejected
+					t.setEntry(report)
+					t.updateNotifications(report)
+
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.troublemaker.report-rejected"))
+					helpers.Relax(err)
+					return
+				}
+
+				report.Status = troublemakerStatusBroadcast
+				t.setEntry(report)
+				t.updateNotifications(report)
+
+				guildsNotified := t.broadcast(report)
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.troublemaker.report-approved", guildsNotified))
+				helpers.Relax(err)
+			})
+		case "clear": // [p]troublemaker clear <user>
+			helpers.RequireRobyulMod(msg, func() {
+				session.ChannelTyping(msg.ChannelID)
+
+				if len(args) < 2 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+
+				targetUser, err := helpers.GetUserFromMention(args[1])
+				if err != nil || targetUser.ID == "" {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					return
+				}
+
+				var cleared int
+				for _, report := range t.getTroublemakerReports(targetUser) {
+					if !t.isOpen(report) {
+						continue
+					}
+					report.Status = troublemakerStatusCleared
+					report.ReviewedByUserID = msg.Author.ID
+					report.UpdatedAt = time.Now()
+					t.setEntry(report)
+					t.updateNotifications(report)
+					cleared++
+				}
+
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.troublemaker.user-cleared",
+					cleared, targetUser.Username, targetUser.Discriminator))
+				helpers.Relax(err)
+			})
 		default:
 			helpers.RequireMod(msg, func() {
 				session.ChannelTyping(msg.ChannelID)
@@ -150,19 +346,49 @@ func (t *Troublemaker) Action(command string, content string, msg *discordgo.Mes
 				}
 
 				reasonText := strings.TrimSpace(strings.Replace(content, strings.Join(args[:1], " "), "", 1))
+				evidence := t.getEvidence(msg, reasonText)
 
 				if helpers.ConfirmEmbed(msg.ChannelID, msg.Author, helpers.GetTextF("plugins.troublemaker.report-confirm",
-					targetUser.Username, targetUser.Discriminator, targetUser.ID, targetUser.ID, reasonText,
+					targetUser.Username, targetUser.Discriminator, targetUser.ID, targetUser.ID, reasonText, len(evidence),
 				), "✅", "🚫") == true {
+					reviewChannelID, _ := helpers.GetBotConfigString(TroublemakerReviewChannelIDKey)
+
 					// Save to log DB
 					troublemakerLogEntry := t.getEntryByOrCreateEmpty("id", "")
 					troublemakerLogEntry.UserID = targetUser.ID
 					troublemakerLogEntry.Reason = reasonText
+					troublemakerLogEntry.Evidence = evidence
 					troublemakerLogEntry.CreatedAt = time.Now()
+					troublemakerLogEntry.UpdatedAt = time.Now()
 					troublemakerLogEntry.ReportedByGuildID = guild.ID
 					troublemakerLogEntry.ReportedByUserID = msg.Author.ID
+					troublemakerLogEntry.Status = troublemakerStatusBroadcast
+					if reviewChannelID != "" {
+						troublemakerLogEntry.Status = troublemakerStatusPending
+					}
 					t.setEntry(troublemakerLogEntry)
 
+					if troublemakerLogEntry.Status == troublemakerStatusPending {
+						cache.GetLogger().WithField("module", "troublemaker").Info(fmt.Sprintf("queued troublemaker report %s (#%s) by %s (#%s) on %s (#%s) for review",
+							targetUser.Username, targetUser.ID,
+							msg.Author.Username, msg.Author.ID,
+							guild.Name, guild.ID,
+						))
+
+						reviewMessages, err := helpers.SendEmbed(reviewChannelID, t.getReportEmbed(troublemakerLogEntry, targetUser, ""))
+						if err == nil && len(reviewMessages) > 0 {
+							troublemakerLogEntry.Notifications = append(troublemakerLogEntry.Notifications, DB_Troublemaker_Notification{
+								ChannelID: reviewMessages[0].ChannelID,
+								MessageID: reviewMessages[0].ID,
+							})
+							t.setEntry(troublemakerLogEntry)
+						}
+
+						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.troublemaker.report-queued", troublemakerLogEntry.ID))
+						helpers.Relax(err)
+						return
+					}
+
 					cache.GetLogger().WithField("module", "troublemaker").Info(fmt.Sprintf("will notify about troublemaker %s (#%s) by %s (#%s) on %s (#%s) reason %s",
 						targetUser.Username, targetUser.ID,
 						msg.Author.Username, msg.Author.ID,
@@ -170,65 +396,14 @@ func (t *Troublemaker) Action(command string, content string, msg *discordgo.Mes
 						reasonText,
 					))
 
-					guildsToNotify := make([]*discordgo.Guild, 0)
-
-					for _, guildToNotify := range session.State.Guilds {
-						if guildToNotify.ID != guild.ID {
-							guildToNotifySettings := helpers.GuildSettingsGetCached(guildToNotify.ID)
-							if guildToNotifySettings.TroublemakerIsParticipating == true && guildToNotifySettings.TroublemakerLogChannel != "" {
-								guildsToNotify = append(guildsToNotify, guildToNotify)
-							}
-						}
-					}
-
-					successMessages, _ := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.troublemaker.report-successful", len(guildsToNotify)))
+					successMessages, _ := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.troublemaker.report-successful",
+						len(t.getGuildsToNotify(guild.ID)), troublemakerLogEntry.ID))
 
 					// Send notifications out
 					go func() {
+						defer helpers.Recover()
 
-						for _, guildToNotify := range guildsToNotify {
-							guildToNotifySettings := helpers.GuildSettingsGetCached(guildToNotify.ID)
-							if guildToNotifySettings.TroublemakerIsParticipating == true && guildToNotifySettings.TroublemakerLogChannel != "" {
-								targetUserIsOnServer := false
-								_, err := helpers.GetGuildMember(guildToNotify.ID, targetUser.ID)
-								if err == nil {
-									targetUserIsOnServer = true
-								}
-
-								reportEmbed := &discordgo.MessageEmbed{
-									Title:       helpers.GetTextF("plugins.troublemaker.report-embed-title", targetUser.Username, targetUser.Discriminator),
-									Description: helpers.GetTextF("plugins.troublemaker.report-embed-description", targetUser.ID, targetUser.ID),
-									URL:         helpers.GetAvatarUrl(targetUser),
-									Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: helpers.GetAvatarUrl(targetUser)},
-									Footer:      &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.troublemaker.report-embed-footer", len(guildsToNotify))},
-									Color:       0x0FADED,
-									Fields: []*discordgo.MessageEmbedField{
-										{Name: "Reason stated", Value: reasonText, Inline: false},
-									},
-								}
-
-								if targetUserIsOnServer == true {
-									reportEmbed.Fields = append(reportEmbed.Fields, &discordgo.MessageEmbedField{
-										Name: "Member status", Value: "⚠ User is on this server", Inline: false,
-									})
-								} else {
-									reportEmbed.Fields = append(reportEmbed.Fields, &discordgo.MessageEmbedField{
-										Name: "Member status", Value: "✅ User is not on this server", Inline: false,
-									})
-								}
-
-								reportEmbed.Fields = append(reportEmbed.Fields, &discordgo.MessageEmbedField{
-									Name: "Reported by", Value: fmt.Sprintf("**%s** (#%s) <@%s>\non **%s** (#%s)",
-										msg.Author.Username, msg.Author.ID, msg.Author.ID, guild.Name, guild.ID,
-									), Inline: false})
-
-								_, err = helpers.SendEmbed(guildToNotifySettings.TroublemakerLogChannel, reportEmbed)
-								if err != nil {
-									cache.GetLogger().WithField("module", "troublemaker").Error(fmt.Sprintf("Failed to send troublemaker report to channel #%s on guild #%s: %s"+
-										guildToNotifySettings.TroublemakerLogChannel, guildToNotifySettings.Guild, err.Error()))
-								}
-							}
-						}
+						t.broadcast(troublemakerLogEntry)
 
 						if len(successMessages) > 0 {
 							session.MessageReactionAdd(msg.ChannelID, successMessages[0].ID, "👌")
@@ -244,6 +419,215 @@ func (t *Troublemaker) Action(command string, content string, msg *discordgo.Mes
 	}
 }
 
+// getEvidence collects the attachments of the message and the message links in the text
+func (t *Troublemaker) getEvidence(msg *discordgo.Message, text string) (evidence []string) {
+	evidence = make([]string, 0)
+	for _, attachment := range msg.Attachments {
+		evidence = append(evidence, attachment.URL)
+	}
+	evidence = append(evidence, troublemakerMessageLinkRegex.FindAllString(text, -1)...)
+	return evidence
+}
+
+// getEvidenceFields splits the evidence across embed fields to stay below the field length limit,
+// evidence that doesn't fit into troublemakerEvidenceMaxFields fields is counted at the end
+func (t *Troublemaker) getEvidenceFields(evidence []string) (fields []*discordgo.MessageEmbedField) {
+	fields = make([]*discordgo.MessageEmbedField, 0)
+	var value string
+	for i, item := range evidence {
+		if len(item) > troublemakerEvidenceFieldLength {
+			item = item[:troublemakerEvidenceFieldLength-3] + "..."
+		}
+		if value != "" && len(value)+len("\n")+len(item) > troublemakerEvidenceFieldLength {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: "Evidence", Value: value, Inline: false})
+			value = ""
+			if len(fields) >= troublemakerEvidenceMaxFields {
+				fields[len(fields)-1].Name = fmt.Sprintf("Evidence (%d more not shown)", len(evidence)-i)
+				return fields
+			}
+		}
+		if value != "" {
+			value += "\n"
+		}
+		value += item
+	}
+	if value != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Evidence", Value: value, Inline: false})
+	}
+	return fields
+}
+
+func (t *Troublemaker) getGuildsToNotify(reportedByGuildID string) (guildsToNotify []*discordgo.Guild) {
+	guildsToNotify = make([]*discordgo.Guild, 0)
+	for _, guildToNotify := range cache.GetSession().State.Guilds {
+		if guildToNotify.ID != reportedByGuildID {
+			guildToNotifySettings := helpers.GuildSettingsGetCached(guildToNotify.ID)
+			if guildToNotifySettings.TroublemakerIsParticipating == true && guildToNotifySettings.TroublemakerLogChannel != "" {
+				guildsToNotify = append(guildsToNotify, guildToNotify)
+			}
+		}
+	}
+	return guildsToNotify
+}
+
+// broadcast posts the report to all participating guilds, and returns the number of guilds notified
+func (t *Troublemaker) broadcast(report DB_Troublemaker_Entry) (notified int) {
+	targetUser, err := helpers.GetUser(report.UserID)
+	if err != nil {
+		targetUser = &discordgo.User{ID: report.UserID, Username: "N/A", Discriminator: "0000"}
+	}
+
+	guildsToNotify := t.getGuildsToNotify(report.ReportedByGuildID)
+	for _, guildToNotify := range guildsToNotify {
+		guildToNotifySettings := helpers.GuildSettingsGetCached(guildToNotify.ID)
+
+		reportEmbed := t.getReportEmbed(report, targetUser, guildToNotify.ID)
+		reportEmbed.Footer = &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.troublemaker.report-embed-footer", len(guildsToNotify))}
+
+		messages, err := helpers.SendEmbed(guildToNotifySettings.TroublemakerLogChannel, reportEmbed)
+		if err != nil {
+			cache.GetLogger().WithField("module", "troublemaker").Error(fmt.Sprintf("Failed to send troublemaker report to channel #%s on guild #%s: %s",
+				guildToNotifySettings.TroublemakerLogChannel, guildToNotifySettings.Guild, err.Error()))
+			continue
+		}
+		if len(messages) > 0 {
+			report.Notifications = append(report.Notifications, DB_Troublemaker_Notification{
+				GuildID:   guildToNotify.ID,
+				ChannelID: messages[0].ChannelID,
+				MessageID: messages[0].ID,
+			})
+		}
+		notified++
+	}
+
+	t.setEntry(report)
+	return notified
+}
+
+// updateNotifications edits all posted messages of the report to reflect its current reason, evidence and status
+func (t *Troublemaker) updateNotifications(report DB_Troublemaker_Entry) {
+	targetUser, err := helpers.GetUser(report.UserID)
+	if err != nil {
+		targetUser = &discordgo.User{ID: report.UserID, Username: "N/A", Discriminator: "0000"}
+	}
+
+	for _, notification := range report.Notifications {
+		message, err := helpers.GetMessage(notification.ChannelID, notification.MessageID)
+		if err != nil || len(message.Embeds) <= 0 {
+			continue
+		}
+
+		reportEmbed := t.getReportEmbed(report, targetUser, notification.GuildID)
+		if notification.GuildID != "" {
+			reportEmbed.Footer = message.Embeds[0].Footer
+		}
+		_, err = helpers.EditEmbed(notification.ChannelID, notification.MessageID, reportEmbed)
+		if err != nil {
+			cache.GetLogger().WithField("module", "troublemaker").Warn(fmt.Sprintf("Failed to update troublemaker report in channel #%s: %s",
+				notification.ChannelID, err.Error()))
+		}
+	}
+}
+
+// getReportEmbed builds the embed for a report, guildID is the guild the embed will be posted to, or empty for the review channel
+func (t *Troublemaker) getReportEmbed(report DB_Troublemaker_Entry, targetUser *discordgo.User, guildID string) (reportEmbed *discordgo.MessageEmbed) {
+	reportEmbed = &discordgo.MessageEmbed{
+		Title:       helpers.GetTextF("plugins.troublemaker.report-embed-title", targetUser.Username, targetUser.Discriminator),
+		Description: helpers.GetTextF("plugins.troublemaker.report-embed-description", targetUser.ID, targetUser.ID),
+		URL:         helpers.GetAvatarUrl(targetUser),
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: helpers.GetAvatarUrl(targetUser)},
+		Color:       0x0FADED,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Reason stated", Value: report.Reason, Inline: false},
+		},
+	}
+
+	reportEmbed.Fields = append(reportEmbed.Fields, t.getEvidenceFields(report.Evidence)...)
+
+	if guildID != "" {
+		if _, err := helpers.GetGuildMember(guildID, targetUser.ID); err == nil {
+			reportEmbed.Fields = append(reportEmbed.Fields, &discordgo.MessageEmbedField{
+				Name: "Member status", Value: "⚠ User is on this server", Inline: false,
+			})
+		} else {
+			reportEmbed.Fields = append(reportEmbed.Fields, &discordgo.MessageEmbedField{
+				Name: "Member status", Value: "✅ User is not on this server", Inline: false,
+			})
+		}
+	}
+
+	reportedByUser, err := helpers.GetUser(report.ReportedByUserID)
+	if err != nil {
+		reportedByUser = &discordgo.User{ID: report.ReportedByUserID, Username: "N/A"}
+	}
+	reportedByGuild, err := helpers.GetGuild(report.ReportedByGuildID)
+	if err != nil {
+		reportedByGuild = &discordgo.Guild{ID: report.ReportedByGuildID, Name: "N/A"}
+	}
+	reportEmbed.Fields = append(reportEmbed.Fields, &discordgo.MessageEmbedField{
+		Name: "Reported by", Value: fmt.Sprintf("**%s** (#%s) <@%s>\non **%s** (#%s)",
+			reportedByUser.Username, reportedByUser.ID, reportedByUser.ID, reportedByGuild.Name, reportedByGuild.ID,
+		), Inline: false})
+
+	if guildID == "" && report.Status == troublemakerStatusPending {
+		reportEmbed.Footer = &discordgo.MessageEmbedFooter{Text: helpers.GetTextF("plugins.troublemaker.review-embed-footer", report.ID)}
+	}
+
+	// participating guilds only see the status of closed reports, the review channel also sees approvals
+	if !t.isOpen(report) || (guildID == "" && report.Status == troublemakerStatusBroadcast) {
+		if !t.isOpen(report) {
+			reportEmbed.Color = 0x95A5A6
+		}
+		reportEmbed.Fields = append([]*discordgo.MessageEmbedField{{
+			Name: "Status", Value: helpers.GetTextF("plugins.troublemaker.report-embed-status", t.statusText(report)), Inline: false,
+		}}, reportEmbed.Fields...)
+	}
+	return reportEmbed
+}
+
+// isOpen returns true if the report hasn't been rejected, retracted or cleared
+func (t *Troublemaker) isOpen(report DB_Troublemaker_Entry) bool {
+	return report.Status == "" || report.Status == troublemakerStatusBroadcast || report.Status == troublemakerStatusPending
+}
+
+func (t *Troublemaker) statusText(report DB_Troublemaker_Entry) string {
+	if report.Status == "" {
+		return troublemakerStatusBroadcast
+	}
+	return report.Status
+}
+
+// troublemakerInspectText summarises the reports of a user for inspects, active counts the broadcast reports
+func troublemakerInspectText(reports []DB_Troublemaker_Entry, userID string) (text string, active int) {
+	var pending, closed int
+	for _, report := range reports {
+		switch report.Status {
+		case "", troublemakerStatusBroadcast:
+			active++
+		case troublemakerStatusPending:
+			pending++
+		default:
+			closed++
+		}
+	}
+
+	if active <= 0 {
+		text = "✅ User never got reported"
+	} else {
+		text = fmt.Sprintf("⚠ User got reported %d time(s)", active)
+	}
+	if pending > 0 {
+		text += fmt.Sprintf("\n◾%d report(s) waiting for review", pending)
+	}
+	if closed > 0 {
+		text += fmt.Sprintf("\n◾%d report(s) rejected, retracted or cleared", closed)
+	}
+	if active+pending+closed > 0 {
+		text += fmt.Sprintf("\nUse `_troublemaker list %s` to view the details.", userID)
+	}
+	return text, active
+}
+
 func (t *Troublemaker) getTroublemakerReports(user *discordgo.User) []DB_Troublemaker_Entry {
 	var entryBucket []DB_Troublemaker_Entry
 	listCursor, err := rethink.Table("troublemakerlog").Filter(
@@ -256,6 +640,16 @@ func (t *Troublemaker) getTroublemakerReports(user *discordgo.User) []DB_Trouble
 	return entryBucket
 }
 
+func (t *Troublemaker) getReport(id string) (entryBucket DB_Troublemaker_Entry, err error) {
+	listCursor, err := rethink.Table("troublemakerlog").Get(id).Run(helpers.GetDB())
+	if err != nil {
+		return entryBucket, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&entryBucket)
+	return entryBucket, err
+}
+
 func (t *Troublemaker) getEntryByOrCreateEmpty(key string, id string) DB_Troublemaker_Entry {
 	var entryBucket DB_Troublemaker_Entry
 	listCursor, err := rethink.Table("troublemakerlog").Filter(
@@ -285,6 +679,6 @@ func (t *Troublemaker) getEntryByOrCreateEmpty(key string, id string) DB_Trouble
 }
 
 func (t *Troublemaker) setEntry(entry DB_Troublemaker_Entry) {
-	_, err := rethink.Table("troublemakerlog").Update(entry).Run(helpers.GetDB())
+	_, err := rethink.Table("troublemakerlog").Get(entry.ID).Update(entry).Run(helpers.GetDB())
 	helpers.Relax(err)
 }
@@ -0,0 +1,951 @@
+package plugins
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Sirupsen/logrus"
+	"github.com/bwmarrin/discordgo"
+	rethink "github.com/gorethink/gorethink"
+)
+
+type verificationAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next verificationAction)
+
+type Verification struct{}
+
+const (
+	verificationModeReaction = "reaction"
+	verificationModeCaptcha  = "captcha"
+	verificationModeCode     = "code"
+
+	verificationDefaultEmoji = "✅"
+	verificationCodeLength   = 6
+)
+
+var (
+	verificationRand      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	verificationRandMutex sync.Mutex
+)
+
+func (v *Verification) Commands() []string {
+	return []string{
+		"verification",
+	}
+}
+
+func (v *Verification) Init(session *discordgo.Session) {
+
+}
+
+func (v *Verification) Uninit(session *discordgo.Session) {
+
+}
+
+func (v *Verification) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+
+	session.ChannelTyping(msg.ChannelID)
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := v.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (v *Verification) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if len(args) < 1 {
+		return v.actionStatus
+	}
+
+	switch args[0] {
+	case "status":
+		return v.actionStatus
+	case "enable":
+		return v.actionEnable
+	case "disable":
+		return v.actionDisable
+	case "mode":
+		return v.actionMode
+	case "role":
+		return v.actionRole
+	case "channel":
+		return v.actionChannel
+	case "message":
+		return v.actionMessage
+	case "timeout":
+		return v.actionTimeout
+	case "approve":
+		return v.actionApprove
+	}
+
+	*out = v.newMsg("bot.arguments.invalid")
+	return v.actionFinish
+}
+
+// [p]verification [status]
+func (v *Verification) actionStatus(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsMod(in) {
+		*out = v.newMsg("mod.no_permission")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	enabledText := "🔲 Disabled"
+	if settings.VerificationEnabled {
+		enabledText = "✔ Enabled"
+	}
+	modeText := settings.VerificationMode
+	if modeText == "" {
+		modeText = "N/A"
+	}
+	roleText := "N/A"
+	if settings.VerificationRoleID != "" {
+		roleText = fmt.Sprintf("<@&%s>", settings.VerificationRoleID)
+	}
+	channelText := "N/A"
+	if settings.VerificationChannelID != "" {
+		channelText = fmt.Sprintf("<#%s>", settings.VerificationChannelID)
+	}
+	messageText := "N/A"
+	if settings.VerificationMessageID != "" {
+		messageText = fmt.Sprintf("`#%s` %s", settings.VerificationMessageID, v.getEmoji(settings))
+	}
+	timeoutText := "Never"
+	if settings.VerificationKickAfter > 0 {
+		timeoutText = fmt.Sprintf("after %s", settings.VerificationKickAfter.String())
+	}
+
+	pendingVerifications, err := v.getPendingVerifications(channel.GuildID)
+	helpers.Relax(err)
+
+	*out = &discordgo.MessageSend{Embed: &discordgo.MessageEmbed{
+		Title: helpers.GetText("plugins.verification.status-embed-title"),
+		Color: 0x0FADED,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: enabledText, Inline: true},
+			{Name: "Mode", Value: modeText, Inline: true},
+			{Name: "Restricted Role", Value: roleText, Inline: true},
+			{Name: "Verify Channel", Value: channelText, Inline: true},
+			{Name: "Rules Message", Value: messageText, Inline: true},
+			{Name: "Kick unverified members", Value: timeoutText, Inline: true},
+			{Name: "Pending", Value: strconv.Itoa(len(pendingVerifications)), Inline: true},
+		},
+	}}
+	return v.actionFinish
+}
+
+// [p]verification enable
+func (v *Verification) actionEnable(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsAdmin(in) {
+		*out = v.newMsg("admin.no_permission")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if settings.VerificationRoleID == "" {
+		*out = v.newMsg("plugins.verification.enable-error-no-role", helpers.GetPrefixForServer(channel.GuildID))
+		return v.actionFinish
+	}
+	switch settings.VerificationMode {
+	case verificationModeReaction:
+		if settings.VerificationChannelID == "" || settings.VerificationMessageID == "" {
+			*out = v.newMsg("plugins.verification.enable-error-no-message", helpers.GetPrefixForServer(channel.GuildID))
+			return v.actionFinish
+		}
+	case verificationModeCode:
+		if settings.VerificationChannelID == "" {
+			*out = v.newMsg("plugins.verification.enable-error-no-channel", helpers.GetPrefixForServer(channel.GuildID))
+			return v.actionFinish
+		}
+	case verificationModeCaptcha:
+	default:
+		*out = v.newMsg("plugins.verification.enable-error-no-mode", helpers.GetPrefixForServer(channel.GuildID))
+		return v.actionFinish
+	}
+
+	settings.VerificationEnabled = true
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = v.newMsg("plugins.verification.enable-success")
+	return v.actionFinish
+}
+
+// [p]verification disable
+func (v *Verification) actionDisable(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsAdmin(in) {
+		*out = v.newMsg("admin.no_permission")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.VerificationEnabled = false
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = v.newMsg("plugins.verification.disable-success")
+	return v.actionFinish
+}
+
+// [p]verification mode <reaction|captcha|code>
+func (v *Verification) actionMode(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsAdmin(in) {
+		*out = v.newMsg("admin.no_permission")
+		return v.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = v.newMsg("bot.arguments.too-few")
+		return v.actionFinish
+	}
+
+	var newMode string
+	switch strings.ToLower(args[1]) {
+	case verificationModeReaction, "react":
+		newMode = verificationModeReaction
+	case verificationModeCaptcha:
+		newMode = verificationModeCaptcha
+	case verificationModeCode:
+		newMode = verificationModeCode
+	default:
+		*out = v.newMsg("bot.arguments.invalid")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.VerificationMode = newMode
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = v.newMsg("plugins.verification.mode-set", newMode)
+	return v.actionFinish
+}
+
+// [p]verification role <role name or id>
+func (v *Verification) actionRole(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsAdmin(in) {
+		*out = v.newMsg("admin.no_permission")
+		return v.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = v.newMsg("bot.arguments.too-few")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	roleNameToMatch := strings.TrimSpace(strings.Replace(strings.Join(args, " "), args[0], "", 1))
+
+	serverRoles, err := cache.GetSession().GuildRoles(channel.GuildID)
+	helpers.Relax(err)
+
+	var targetRole *discordgo.Role
+	for _, role := range serverRoles {
+		if strings.ToLower(role.Name) == strings.ToLower(roleNameToMatch) || role.ID == roleNameToMatch {
+			targetRole = role
+		}
+	}
+	if targetRole == nil || targetRole.ID == "" {
+		*out = v.newMsg("bot.arguments.invalid")
+		return v.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.VerificationRoleID = targetRole.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = v.newMsg("plugins.verification.role-set", targetRole.Name)
+	return v.actionFinish
+}
+
+// [p]verification channel <channel>
+func (v *Verification) actionChannel(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsAdmin(in) {
+		*out = v.newMsg("admin.no_permission")
+		return v.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = v.newMsg("bot.arguments.too-few")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+		*out = v.newMsg("bot.arguments.invalid")
+		return v.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.VerificationChannelID = targetChannel.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = v.newMsg("plugins.verification.channel-set", targetChannel.ID)
+	return v.actionFinish
+}
+
+// [p]verification message <channel> <message id> [<emoji>]
+func (v *Verification) actionMessage(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsAdmin(in) {
+		*out = v.newMsg("admin.no_permission")
+		return v.actionFinish
+	}
+
+	if len(args) < 3 {
+		*out = v.newMsg("bot.arguments.too-few")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+		*out = v.newMsg("bot.arguments.invalid")
+		return v.actionFinish
+	}
+
+	targetMessage, err := cache.GetSession().ChannelMessage(targetChannel.ID, args[2])
+	if err != nil || targetMessage == nil || targetMessage.ID == "" {
+		*out = v.newMsg("plugins.verification.message-error-not-found")
+		return v.actionFinish
+	}
+
+	emoji := verificationDefaultEmoji
+	if len(args) >= 4 {
+		// custom emoji are in the format <:name:id>, the API expects name:id
+		emoji = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(args[3], "<a:"), "<:"), ">")
+	}
+
+	err = cache.GetSession().MessageReactionAdd(targetChannel.ID, targetMessage.ID, emoji)
+	if err != nil {
+		*out = v.newMsg("plugins.verification.message-error-reaction")
+		return v.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.VerificationChannelID = targetChannel.ID
+	settings.VerificationMessageID = targetMessage.ID
+	settings.VerificationEmoji = emoji
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = v.newMsg("plugins.verification.message-set", targetChannel.ID)
+	return v.actionFinish
+}
+
+// [p]verification timeout <minutes>
+func (v *Verification) actionTimeout(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsAdmin(in) {
+		*out = v.newMsg("admin.no_permission")
+		return v.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = v.newMsg("bot.arguments.too-few")
+		return v.actionFinish
+	}
+
+	var minutes int
+	var err error
+	if args[1] != "off" && args[1] != "never" {
+		minutes, err = strconv.Atoi(args[1])
+		if err != nil || minutes < 0 {
+			*out = v.newMsg("bot.arguments.invalid")
+			return v.actionFinish
+		}
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.VerificationKickAfter = time.Duration(minutes) * time.Minute
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if settings.VerificationKickAfter <= 0 {
+		*out = v.newMsg("plugins.verification.timeout-disabled")
+		return v.actionFinish
+	}
+
+	*out = v.newMsg("plugins.verification.timeout-set", settings.VerificationKickAfter.String())
+	return v.actionFinish
+}
+
+// [p]verification approve <user>
+func (v *Verification) actionApprove(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	if !helpers.IsMod(in) {
+		*out = v.newMsg("mod.no_permission")
+		return v.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = v.newMsg("bot.arguments.too-few")
+		return v.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetUser, err := helpers.GetUserFromMention(args[1])
+	if err != nil || targetUser == nil || targetUser.ID == "" {
+		*out = v.newMsg("bot.arguments.invalid")
+		return v.actionFinish
+	}
+
+	verification, err := v.getPendingVerification(channel.GuildID, targetUser.ID)
+	if err != nil || verification.ID == "" {
+		*out = v.newMsg("plugins.verification.approve-error-not-pending")
+		return v.actionFinish
+	}
+
+	err = v.pass(verification)
+	helpers.Relax(err)
+
+	v.logger().WithField("GuildID", channel.GuildID).WithField("UserID", in.Author.ID).Infof(
+		"manually verified %s (#%s)", targetUser.Username, targetUser.ID)
+
+	*out = v.newMsg("plugins.verification.approve-success", targetUser.Username, targetUser.ID)
+	return v.actionFinish
+}
+
+// start restricts the new member and sends the challenge for the configured mode
+func (v *Verification) start(member *discordgo.Member, settings models.Config) (err error) {
+	err = cache.GetSession().GuildMemberRoleAdd(member.GuildID, member.User.ID, settings.VerificationRoleID)
+	if err != nil {
+		return err
+	}
+
+	// the member could never pass without a pending verification, so lift the restriction again if starting fails
+	var inserted bool
+	defer func() {
+		if err != nil && !inserted {
+			errRemove := cache.GetSession().GuildMemberRoleRemove(member.GuildID, member.User.ID, settings.VerificationRoleID)
+			helpers.RelaxLog(errRemove)
+		}
+	}()
+
+	verification := models.VerificationEntry{
+		GuildID:   member.GuildID,
+		UserID:    member.User.ID,
+		Mode:      settings.VerificationMode,
+		CreatedAt: time.Now(),
+	}
+
+	switch settings.VerificationMode {
+	case verificationModeCaptcha:
+		verification.Code = v.generateCode("0123456789")
+
+		captcha, err := v.generateCaptchaImage(verification.Code)
+		if err != nil {
+			return err
+		}
+
+		guild, err := helpers.GetGuild(member.GuildID)
+		if err != nil {
+			return err
+		}
+
+		dmChannel, err := cache.GetSession().UserChannelCreate(member.User.ID)
+		if err != nil {
+			return err
+		}
+		verification.PromptChannelID = dmChannel.ID
+
+		_, err = helpers.SendComplex(dmChannel.ID, &discordgo.MessageSend{
+			Content: helpers.GetTextF("plugins.verification.captcha-dm", guild.Name),
+			Files: []*discordgo.File{
+				{
+					Name:   "captcha.png",
+					Reader: bytes.NewReader(captcha),
+				},
+			},
+		})
+		if err != nil {
+			v.logger().WithField("GuildID", member.GuildID).WithField("UserID", member.User.ID).Warn(
+				"unable to send captcha: " + err.Error())
+			if settings.VerificationChannelID != "" {
+				_, err = helpers.SendMessage(settings.VerificationChannelID,
+					helpers.GetTextF("plugins.verification.captcha-dm-failed", member.User.ID))
+				helpers.RelaxLog(err)
+			}
+		}
+	case verificationModeCode:
+		verification.Code = v.generateCode("ABCDEFGHJKLMNPQRSTUVWXYZ23456789")
+
+		prompts, err := helpers.SendMessage(settings.VerificationChannelID,
+			helpers.GetTextF("plugins.verification.code-prompt", member.User.ID, verification.Code))
+		if err != nil {
+			return err
+		}
+		if len(prompts) > 0 {
+			verification.PromptChannelID = prompts[0].ChannelID
+			verification.PromptMessageID = prompts[0].ID
+		}
+	}
+
+	err = v.insertVerification(verification)
+	if err != nil {
+		return err
+	}
+	inserted = true
+
+	if settings.VerificationKickAfter > 0 {
+		signature := VerificationKickSignature(member.GuildID, member.User.ID)
+		kickAt := time.Now().Add(settings.VerificationKickAfter)
+		signature.ETA = &kickAt
+
+		_, err = cache.GetMachineryServer().SendTask(signature)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pass lifts the restriction, applies the autoroles and removes the pending verification
+func (v *Verification) pass(verification models.VerificationEntry) (err error) {
+	settings := helpers.GuildSettingsGetCached(verification.GuildID)
+
+	err = v.deleteVerification(verification)
+	if err != nil {
+		return err
+	}
+
+	if verification.PromptMessageID != "" {
+		cache.GetSession().ChannelMessageDelete(verification.PromptChannelID, verification.PromptMessageID)
+	}
+
+	if settings.VerificationRoleID != "" {
+		err = cache.GetSession().GuildMemberRoleRemove(verification.GuildID, verification.UserID, settings.VerificationRoleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return AutoroleApplyAll(verification.GuildID, verification.UserID)
+}
+
+// VerificationKick kicks the user if the verification is still pending and kicking is still enabled, is called by machinery
+func VerificationKick(guildID string, userID string) (err error) {
+	v := &Verification{}
+
+	settings := helpers.GuildSettingsGetCached(guildID)
+	if !settings.VerificationEnabled || settings.VerificationKickAfter <= 0 {
+		// verification or kicking got disabled after the user joined
+		return nil
+	}
+
+	verification, err := v.getPendingVerification(guildID, userID)
+	if err != nil || verification.ID == "" {
+		// user passed the verification already or left the server
+		return nil
+	}
+
+	err = v.deleteVerification(verification)
+	if err != nil {
+		return err
+	}
+
+	if verification.PromptMessageID != "" {
+		cache.GetSession().ChannelMessageDelete(verification.PromptChannelID, verification.PromptMessageID)
+	}
+
+	err = cache.GetSession().GuildMemberDeleteWithReason(guildID, userID, "Did not pass the verification in time")
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok {
+			if errD.Message.Code == discordgo.ErrCodeUnknownMember ||
+				errD.Message.Code == discordgo.ErrCodeMissingPermissions ||
+				errD.Message.Code == discordgo.ErrCodeMissingAccess {
+				return nil
+			}
+		}
+		return err
+	}
+
+	v.logger().WithField("GuildID", guildID).WithField("UserID", userID).Info("kicked unverified member")
+	return nil
+}
+
+func VerificationKickSignature(guildID string, userID string) (signature *tasks.Signature) {
+	signature = &tasks.Signature{
+		Name: "verification_kick",
+		Args: []tasks.Arg{
+			{
+				Type:  "string",
+				Value: guildID,
+			},
+			{
+				Type:  "string",
+				Value: userID,
+			},
+		},
+	}
+	signature.RetryCount = 3
+	signature.OnError = []*tasks.Signature{{Name: "log_error"}}
+	return signature
+}
+
+func (v *Verification) generateCode(alphabet string) (code string) {
+	verificationRandMutex.Lock()
+	defer verificationRandMutex.Unlock()
+
+	for i := 0; i < verificationCodeLength; i++ {
+		code += string(alphabet[verificationRand.Intn(len(alphabet))])
+	}
+	return code
+}
+
+// verificationCaptchaGlyphs is a 5x7 bitmap font for the captcha digits
+var verificationCaptchaGlyphs = map[rune][7]string{
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+}
+
+// generateCaptchaImage draws the code with jittered glyphs and noise into a PNG
+func (v *Verification) generateCaptchaImage(code string) (data []byte, err error) {
+	verificationRandMutex.Lock()
+	defer verificationRandMutex.Unlock()
+
+	const scale = 7
+	const padding = 20
+	glyphWidth := 5 * scale
+	glyphHeight := 7 * scale
+	width := padding*2 + len(code)*(glyphWidth+scale*2)
+	height := padding*2 + glyphHeight
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			shade := uint8(220 + verificationRand.Intn(36))
+			img.Set(x, y, color.RGBA{shade, shade, shade, 255})
+		}
+	}
+
+	for i, char := range code {
+		glyph, ok := verificationCaptchaGlyphs[char]
+		if !ok {
+			return nil, errors.New("unsupported captcha character: " + string(char))
+		}
+		glyphColor := color.RGBA{
+			uint8(verificationRand.Intn(120)), uint8(verificationRand.Intn(120)), uint8(verificationRand.Intn(120)), 255}
+		offsetX := padding + i*(glyphWidth+scale*2) + verificationRand.Intn(scale)
+		offsetY := padding + verificationRand.Intn(padding) - padding/2
+		slant := verificationRand.Intn(5) - 2
+		for row, line := range glyph {
+			for column, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				for dx := 0; dx < scale; dx++ {
+					for dy := 0; dy < scale; dy++ {
+						x := offsetX + column*scale + dx + slant*(7-row)
+						y := offsetY + row*scale + dy
+						img.Set(x, y, glyphColor)
+					}
+				}
+			}
+		}
+	}
+
+	// strike through lines to make the text harder to read for OCR
+	for i := 0; i < 6; i++ {
+		lineColor := color.RGBA{
+			uint8(verificationRand.Intn(160)), uint8(verificationRand.Intn(160)), uint8(verificationRand.Intn(160)), 255}
+		startY := float64(verificationRand.Intn(height))
+		endY := float64(verificationRand.Intn(height))
+		for x := 0; x < width; x++ {
+			y := int(startY + (endY-startY)*float64(x)/float64(width))
+			img.Set(x, y, lineColor)
+			img.Set(x, y+1, lineColor)
+		}
+	}
+
+	var buffer bytes.Buffer
+	err = png.Encode(&buffer, img)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (v *Verification) getEmoji(settings models.Config) string {
+	if settings.VerificationEmoji == "" {
+		return verificationDefaultEmoji
+	}
+	return settings.VerificationEmoji
+}
+
+func (v *Verification) getPendingVerification(guildID string, userID string) (entryBucket models.VerificationEntry, err error) {
+	listCursor, err := rethink.Table(models.VerificationsTable).GetAllByIndex(
+		"user_id", userID,
+	).Filter(
+		rethink.Row.Field("guild_id").Eq(guildID),
+	).Run(helpers.GetDB())
+	if err != nil {
+		return entryBucket, err
+	}
+	defer listCursor.Close()
+	err = listCursor.One(&entryBucket)
+	return entryBucket, err
+}
+
+func (v *Verification) getPendingVerifications(guildID string) (entryBucket []models.VerificationEntry, err error) {
+	listCursor, err := rethink.Table(models.VerificationsTable).GetAllByIndex(
+		"guild_id", guildID,
+	).Run(helpers.GetDB())
+	if err != nil {
+		return entryBucket, err
+	}
+	defer listCursor.Close()
+	err = listCursor.All(&entryBucket)
+	return entryBucket, err
+}
+
+func (v *Verification) insertVerification(entry models.VerificationEntry) (err error) {
+	insert := rethink.Table(models.VerificationsTable).Insert(entry)
+	_, err = insert.RunWrite(helpers.GetDB())
+	return err
+}
+
+func (v *Verification) deleteVerification(entry models.VerificationEntry) (err error) {
+	if entry.ID != "" {
+		_, err = rethink.Table(models.VerificationsTable).Get(entry.ID).Delete().RunWrite(helpers.GetDB())
+		return err
+	}
+	return errors.New("empty verificationEntry submitted")
+}
+
+func (v *Verification) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) verificationAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.Relax(err)
+
+	return nil
+}
+
+func (v *Verification) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (v *Verification) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "verification")
+}
+
+func (v *Verification) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+	if msg.Author == nil || msg.Author.Bot {
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	if err != nil {
+		return
+	}
+
+	// captcha answers are sent in DMs, codes in the verify channel of the guild
+	var guildIDs []string
+	if channel.Type == discordgo.ChannelTypeDM {
+		if len(content) != verificationCodeLength {
+			return
+		}
+		for _, guild := range session.State.Guilds {
+			settings := helpers.GuildSettingsGetCached(guild.ID)
+			if settings.VerificationEnabled && settings.VerificationMode == verificationModeCaptcha {
+				guildIDs = append(guildIDs, guild.ID)
+			}
+		}
+	} else {
+		settings := helpers.GuildSettingsGetCached(channel.GuildID)
+		if !settings.VerificationEnabled || settings.VerificationMode != verificationModeCode ||
+			settings.VerificationChannelID != channel.ID {
+			return
+		}
+		guildIDs = append(guildIDs, channel.GuildID)
+	}
+	if len(guildIDs) <= 0 {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		for _, guildID := range guildIDs {
+			verification, err := v.getPendingVerification(guildID, msg.Author.ID)
+			if err != nil || verification.ID == "" {
+				continue
+			}
+
+			if verification.Mode == verificationModeCode {
+				// keep the verify channel clean
+				session.ChannelMessageDelete(msg.ChannelID, msg.ID)
+			}
+
+			if strings.ToUpper(strings.TrimSpace(content)) != verification.Code {
+				if verification.Mode == verificationModeCaptcha {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.verification.captcha-wrong"))
+					helpers.RelaxLog(err)
+				}
+				continue
+			}
+
+			err = v.pass(verification)
+			if err != nil {
+				v.logger().WithField("GuildID", guildID).WithField("UserID", msg.Author.ID).Error(
+					"unable to verify member: " + err.Error())
+				continue
+			}
+
+			if verification.Mode == verificationModeCaptcha {
+				guild, err := helpers.GetGuild(guildID)
+				if err == nil {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.verification.captcha-success", guild.Name))
+					helpers.RelaxLog(err)
+				}
+			}
+		}
+	}()
+}
+
+func (v *Verification) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
+
+}
+
+func (v *Verification) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+	go func() {
+		defer helpers.Recover()
+
+		if member.User == nil || member.User.Bot {
+			return
+		}
+
+		settings := helpers.GuildSettingsGetCached(member.GuildID)
+		if !settings.VerificationEnabled || settings.VerificationRoleID == "" {
+			return
+		}
+
+		err := v.start(member, settings)
+		if err != nil {
+			v.logger().WithField("GuildID", member.GuildID).WithField("UserID", member.User.ID).Error(
+				"unable to start verification: " + err.Error())
+		}
+	}()
+}
+
+func (v *Verification) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+	go func() {
+		defer helpers.Recover()
+
+		if member.User == nil {
+			return
+		}
+
+		verification, err := v.getPendingVerification(member.GuildID, member.User.ID)
+		if err != nil || verification.ID == "" {
+			return
+		}
+
+		err = v.deleteVerification(verification)
+		helpers.RelaxLog(err)
+
+		if verification.PromptMessageID != "" {
+			session.ChannelMessageDelete(verification.PromptChannelID, verification.PromptMessageID)
+		}
+	}()
+}
+
+func (v *Verification) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+	if reaction.UserID == session.State.User.ID {
+		return
+	}
+
+	channel, err := helpers.GetChannel(reaction.ChannelID)
+	if err != nil || channel.GuildID == "" {
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	if !settings.VerificationEnabled || settings.VerificationMode != verificationModeReaction ||
+		settings.VerificationMessageID != reaction.MessageID {
+		return
+	}
+
+	if reaction.Emoji.APIName() != v.getEmoji(settings) {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		verification, err := v.getPendingVerification(channel.GuildID, reaction.UserID)
+		if err != nil || verification.ID == "" {
+			return
+		}
+
+		err = v.pass(verification)
+		if err != nil {
+			v.logger().WithField("GuildID", channel.GuildID).WithField("UserID", reaction.UserID).Error(
+				"unable to verify member: " + err.Error())
+		}
+	}()
+}
+
+func (v *Verification) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+
+}
+
+func (v *Verification) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
+
+}
+
+func (v *Verification) OnGuildBanRemove(user *discordgo.GuildBanRemove, session *discordgo.Session) {
+
+}
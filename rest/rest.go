@@ -20,6 +20,7 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/emicklei/go-restful"
 	"github.com/getsentry/raven-go"
+	rethink "github.com/gorethink/gorethink"
 	"github.com/pkg/errors"
 	"github.com/vmihailenco/msgpack"
 	"gopkg.in/olivere/elastic.v5"
@@ -116,6 +117,25 @@ func NewRestServices() []*restful.WebService {
 
 	service.Route(service.GET("/{guild-id}/{channel-id}/around/{message-id}").Filter(sessionAndWebkeyAuthenticate).To(GetChatlogAroundMessageID))
 	services = append(services, service)
+
+	service = new(restful.WebService)
+	service.
+		Path("/modnotes").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+
+	service.Route(service.GET("/{guild-id}").Filter(webkeyAuthenticate).To(GetModNotes))
+	service.Route(service.GET("/{guild-id}/{user-id}").Filter(webkeyAuthenticate).To(GetModNotes))
+	services = append(services, service)
+
+	service = new(restful.WebService)
+	service.
+		Path("/auditlog").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+
+	service.Route(service.GET("/{guild-id}").Filter(webkeyAuthenticate).To(GetAuditLog))
+	services = append(services, service)
 	return services
 }
 
@@ -466,6 +486,8 @@ func GetProfile(request *restful.Request, response *restful.Response) {
 	}
 }
 
+// GetRankings returns the top 100 of the guild or global leaderboard
+// optional query parameter: period (weekly, monthly or season, default is all time)
 func GetRankings(request *restful.Request, response *restful.Response) {
 	guildID := request.PathParameter("guild-id")
 
@@ -477,6 +499,29 @@ func GetRankings(request *restful.Request, response *restful.Response) {
 		}
 	}
 
+	if period := request.QueryParameter("period"); period != "" && period != "all" {
+		rankings, ok := getPeriodRankings(response, guildID, period)
+		if !ok {
+			return
+		}
+
+		result := new(models.Rest_Ranking)
+		result.Ranks = make([]models.Rest_Ranking_Rank_Item, 0)
+		result.Count = len(rankings)
+		for _, rankingItem := range rankings {
+			if rankingItem.Ranking > 100 {
+				break
+			}
+			rankItem, ok := getRestRankingItem(guildID, rankingItem)
+			if ok {
+				result.Ranks = append(result.Ranks, rankItem)
+			}
+		}
+
+		response.WriteEntity(result)
+		return
+	}
+
 	var err error
 	var rankingsCount int
 	rankingsCountKey := fmt.Sprintf("robyul2-discord:levels:ranking:%s:by-rank:count", guildID)
@@ -495,8 +540,6 @@ func GetRankings(request *restful.Request, response *restful.Response) {
 	i := 1
 	var keyByRank string
 	var rankingItem plugins.Levels_Cache_Ranking_Item
-	var userItem models.Rest_User
-	var isMember bool
 	for {
 		if i > rankingsCount {
 			break
@@ -505,43 +548,10 @@ func GetRankings(request *restful.Request, response *restful.Response) {
 		if err = cacheCodec.Get(keyByRank, &rankingItem); err != nil {
 			break
 		}
-		var user *discordgo.User
-		if guildID != "global" {
-			member, _ := helpers.GetGuildMemberWithoutApi(guildID, rankingItem.UserID)
-			if member != nil && member.User != nil && member.User.ID != "" {
-				user = member.User
-			} else {
-				user, _ = helpers.GetUser(rankingItem.UserID)
-			}
-		} else {
-			user, _ = helpers.GetUser(rankingItem.UserID)
-		}
-		if user != nil && user.ID != "" {
-			userItem = models.Rest_User{
-				ID:            user.ID,
-				Username:      user.Username,
-				AvatarHash:    user.Avatar,
-				Discriminator: user.Discriminator,
-				Bot:           user.Bot,
-			}
-
-			if guildID == "global" {
-				isMember = true
-			} else {
-				isMember = true
-				if !helpers.GetIsInGuild(guildID, user.ID) {
-					isMember = false
-				}
-			}
-
-			result.Ranks = append(result.Ranks, models.Rest_Ranking_Rank_Item{
-				User:     userItem,
-				EXP:      rankingItem.EXP,
-				Level:    rankingItem.Level,
-				Ranking:  i,
-				IsMember: isMember,
-				GuildID:  guildID,
-			})
+		rankingItem.Ranking = i
+		rankItem, ok := getRestRankingItem(guildID, rankingItem)
+		if ok {
+			result.Ranks = append(result.Ranks, rankItem)
 		}
 		i += 1
 		if i > 100 {
@@ -552,6 +562,62 @@ func GetRankings(request *restful.Request, response *restful.Response) {
 	response.WriteEntity(result)
 }
 
+// getPeriodRankings gets the leaderboard of the period and writes an error to the response if it fails
+func getPeriodRankings(response *restful.Response, guildID string, period string) (rankings []plugins.Levels_Cache_Ranking_Item, ok bool) {
+	rankings, err := generator.GetProfileGenerator().GetPeriodRankings(guildID, period)
+	switch err {
+	case nil:
+		return rankings, true
+	case plugins.ErrLevelsInvalidPeriod:
+		response.WriteErrorString(http.StatusBadRequest, "invalid period")
+	case plugins.ErrLevelsNoActiveSeason:
+		response.WriteError(http.StatusNotFound, errors.New("No active season"))
+	default:
+		response.WriteError(http.StatusInternalServerError, err)
+	}
+	return nil, false
+}
+
+// getRestRankingItem turns a ranking item into a rest ranking item, returns false if the user can't be found
+func getRestRankingItem(guildID string, rankingItem plugins.Levels_Cache_Ranking_Item) (item models.Rest_Ranking_Rank_Item, ok bool) {
+	var user *discordgo.User
+	if guildID != "global" {
+		member, _ := helpers.GetGuildMemberWithoutApi(guildID, rankingItem.UserID)
+		if member != nil && member.User != nil && member.User.ID != "" {
+			user = member.User
+		} else {
+			user, _ = helpers.GetUser(rankingItem.UserID)
+		}
+	} else {
+		user, _ = helpers.GetUser(rankingItem.UserID)
+	}
+	if user == nil || user.ID == "" {
+		return item, false
+	}
+
+	isMember := true
+	if guildID != "global" && !helpers.GetIsInGuild(guildID, user.ID) {
+		isMember = false
+	}
+
+	return models.Rest_Ranking_Rank_Item{
+		User: models.Rest_User{
+			ID:            user.ID,
+			Username:      user.Username,
+			AvatarHash:    user.Avatar,
+			Discriminator: user.Discriminator,
+			Bot:           user.Bot,
+		},
+		EXP:      rankingItem.EXP,
+		Level:    rankingItem.Level,
+		Ranking:  rankingItem.Ranking,
+		IsMember: isMember,
+		GuildID:  guildID,
+	}, true
+}
+
+// GetUserRanking returns the ranking of the user on the guild or global leaderboard
+// optional query parameter: period (weekly, monthly or season, default is all time)
 func GetUserRanking(request *restful.Request, response *restful.Response) {
 	userID := request.PathParameter("user-id")
 	guildID := request.PathParameter("guild-id")
@@ -566,12 +632,28 @@ func GetUserRanking(request *restful.Request, response *restful.Response) {
 
 	var err error
 	var rankingItem plugins.Levels_Cache_Ranking_Item
-	rankingsKey := fmt.Sprintf("robyul2-discord:levels:ranking:%s:by-user:%s", guildID, userID)
-	cacheCodec := cache.GetRedisCacheCodec()
+	if period := request.QueryParameter("period"); period != "" && period != "all" {
+		rankings, ok := getPeriodRankings(response, guildID, period)
+		if !ok {
+			return
+		}
+		for _, periodRankingItem := range rankings {
+			if periodRankingItem.UserID == userID {
+				rankingItem = periodRankingItem
+			}
+		}
+		if rankingItem.UserID == "" {
+			response.WriteError(http.StatusNotFound, errors.New("Member not found."))
+			return
+		}
+	} else {
+		rankingsKey := fmt.Sprintf("robyul2-discord:levels:ranking:%s:by-user:%s", guildID, userID)
+		cacheCodec := cache.GetRedisCacheCodec()
 
-	if err = cacheCodec.Get(rankingsKey, &rankingItem); err != nil {
-		response.WriteError(http.StatusNotFound, errors.New("Member not found."))
-		return
+		if err = cacheCodec.Get(rankingsKey, &rankingItem); err != nil {
+			response.WriteError(http.StatusNotFound, errors.New("Member not found."))
+			return
+		}
 	}
 
 	user, _ := helpers.GetUser(userID)
@@ -609,6 +691,8 @@ func GetUserRanking(request *restful.Request, response *restful.Response) {
 	response.WriteEntity(result)
 }
 
+// GetAllUserRanking returns the rankings of the user on all shared guilds and the global leaderboard
+// optional query parameter: period (weekly, monthly or season, default is all time)
 func GetAllUserRanking(request *restful.Request, response *restful.Response) {
 	userID := request.PathParameter("user-id")
 
@@ -632,14 +716,36 @@ func GetAllUserRanking(request *restful.Request, response *restful.Response) {
 
 	result := make([]models.Rest_Ranking_Rank_Item, 0)
 
+	period := request.QueryParameter("period")
+	if period == "all" {
+		period = ""
+	}
+
 	for _, guild := range append(cache.GetSession().State.Guilds, &discordgo.Guild{ID: "global", Name: "global"}) {
 		if guild.ID != "global" && !helpers.GetIsInGuild(guild.ID, userID) {
 			continue
 		}
 
-		rankingsKey := fmt.Sprintf("robyul2-discord:levels:ranking:%s:by-user:%s", guild.ID, userID)
-		if err = cacheCodec.Get(rankingsKey, &rankingItem); err != nil {
-			continue
+		if period != "" {
+			rankings, err := generator.GetProfileGenerator().GetPeriodRankings(guild.ID, period)
+			if err == plugins.ErrLevelsInvalidPeriod && guild.ID != "global" {
+				response.WriteErrorString(http.StatusBadRequest, "invalid period")
+				return
+			}
+			rankingItem = plugins.Levels_Cache_Ranking_Item{}
+			for _, periodRankingItem := range rankings {
+				if periodRankingItem.UserID == userID {
+					rankingItem = periodRankingItem
+				}
+			}
+			if rankingItem.UserID == "" {
+				continue
+			}
+		} else {
+			rankingsKey := fmt.Sprintf("robyul2-discord:levels:ranking:%s:by-user:%s", guild.ID, userID)
+			if err = cacheCodec.Get(rankingsKey, &rankingItem); err != nil {
+				continue
+			}
 		}
 
 		result = append(result, models.Rest_Ranking_Rank_Item{
@@ -1059,3 +1165,125 @@ func GetChatlogAroundMessageID(request *restful.Request, response *restful.Respo
 
 	response.WriteEntity(result)
 }
+
+func GetModNotes(request *restful.Request, response *restful.Response) {
+	guildID := request.PathParameter("guild-id")
+	userID := request.PathParameter("user-id")
+
+	query := rethink.Table(models.ModNotesTable).GetAllByIndex("guild_id", guildID)
+	if userID != "" {
+		query = query.Filter(rethink.Row.Field("user_id").Eq(userID))
+	}
+	listCursor, err := query.OrderBy(rethink.Desc("created_at")).Run(helpers.GetDB())
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	defer listCursor.Close()
+
+	var notes []models.ModNoteEntry
+	err = listCursor.All(&notes)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]models.Rest_ModNote, 0)
+	for _, note := range notes {
+		result = append(result, models.Rest_ModNote{
+			ID:           note.ID,
+			GuildID:      note.GuildID,
+			UserID:       note.UserID,
+			AuthorUserID: note.AuthorUserID,
+			CreatedAt:    note.CreatedAt,
+			Text:         note.Text,
+		})
+	}
+
+	response.WriteEntity(result)
+}
+
+// GetAuditLog returns the stored audit log entries of a guild, newest first
+// optional query parameters: user (user id, matches the user and the target), action (action type or name),
+// since and until (unix timestamps), limit (default 100)
+func GetAuditLog(request *restful.Request, response *restful.Response) {
+	guildID := request.PathParameter("guild-id")
+
+	query := rethink.Table(models.AuditLogTable).GetAllByIndex("guild_id", guildID)
+
+	if userID := request.QueryParameter("user"); userID != "" {
+		query = query.Filter(rethink.Row.Field("user_id").Eq(userID).Or(rethink.Row.Field("target_id").Eq(userID)))
+	}
+	if action := request.QueryParameter("action"); action != "" {
+		actionType, err := strconv.Atoi(action)
+		if err != nil {
+			actionType = -1
+			for key, name := range models.AuditLogActionTypes {
+				if name == action {
+					actionType = key
+				}
+			}
+			if actionType < 0 {
+				response.WriteErrorString(http.StatusBadRequest, "invalid action")
+				return
+			}
+		}
+		query = query.Filter(rethink.Row.Field("action_type").Eq(actionType))
+	}
+	for _, parameter := range []string{"since", "until"} {
+		value := request.QueryParameter(parameter)
+		if value == "" {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			response.WriteErrorString(http.StatusBadRequest, "invalid "+parameter)
+			return
+		}
+		if parameter == "since" {
+			query = query.Filter(rethink.Row.Field("created_at").Ge(time.Unix(timestamp, 0)))
+		} else {
+			query = query.Filter(rethink.Row.Field("created_at").Le(time.Unix(timestamp, 0)))
+		}
+	}
+	limit := 100
+	if value := request.QueryParameter("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			response.WriteErrorString(http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	listCursor, err := query.OrderBy(rethink.Desc("created_at")).Limit(limit).Run(helpers.GetDB())
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	defer listCursor.Close()
+
+	var entries []models.AuditLogEntry
+	err = listCursor.All(&entries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]models.Rest_AuditLogEntry, 0)
+	for _, entry := range entries {
+		result = append(result, models.Rest_AuditLogEntry{
+			ID:         entry.ID,
+			GuildID:    entry.GuildID,
+			UserID:     entry.UserID,
+			TargetID:   entry.TargetID,
+			ActionType: entry.ActionType,
+			Action:     models.AuditLogActionTypes[entry.ActionType],
+			Reason:     entry.Reason,
+			Text:       entry.Text,
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+
+	response.WriteEntity(result)
+}